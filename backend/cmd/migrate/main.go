@@ -16,7 +16,7 @@ import (
 
 func main() {
 	var action string
-	flag.StringVar(&action, "action", "migrate", "Action to perform: migrate, drop, seed")
+	flag.StringVar(&action, "action", "migrate", "Action to perform: migrate, drop, seed, encrypt")
 	flag.Parse()
 
 	// 加载配置
@@ -26,6 +26,11 @@ func main() {
 	cfg := config.GetConfig()
 	logger.InitLogger(cfg.Log.Level, cfg.Log.Filename, cfg.Log.MaxSize, cfg.Log.MaxAge, cfg.Log.MaxBackups)
 
+	// 初始化字段加密密钥
+	if err := utils.InitFieldCrypto(); err != nil {
+		log.Fatalf("Failed to init field encryption: %v", err)
+	}
+
 	// 初始化数据库
 	database.InitDB()
 	defer database.CloseDB()
@@ -53,10 +58,17 @@ func main() {
 			log.Fatalf("Seeding failed: %v", err)
 		}
 		fmt.Println("Database seeded successfully!")
+
+	case "encrypt":
+		fmt.Println("Encrypting personal data with active key...")
+		if err := encryptData(db); err != nil {
+			log.Fatalf("Encryption failed: %v", err)
+		}
+		fmt.Println("Personal data encrypted successfully!")
 		
 	default:
 		fmt.Printf("Unknown action: %s\n", action)
-		fmt.Println("Available actions: migrate, drop, seed")
+		fmt.Println("Available actions: migrate, drop, seed, encrypt")
 	}
 }
 
@@ -75,8 +87,27 @@ func runMigrations(db *gorm.DB) error {
 		&model.Order{},
 		&model.OrderItem{},
 		&model.OrderPayment{},
+		&model.OrderRefund{},
 		&model.CartItem{},
 		&model.Admin{},
+		&model.LoginLog{},
+		&model.UserSession{},
+		&model.AdminRecoveryCode{},
+		&model.TwoFactorPolicy{},
+		&model.AccountMergeLog{},
+		&model.PrivacyRequest{},
+		&model.AdminAuditLog{},
+		&model.MemberLevel{},
+		&model.UserPoints{},
+		&model.PointsLedger{},
+		&model.ProductFavorite{},
+		&model.UserFootprint{},
+		&model.Notification{},
+		&model.ProductSpec{},
+		&model.ProductSpecValue{},
+		&model.InventoryLedger{},
+		&model.PriceSchedule{},
+		&model.PriceHistory{},
 	)
 }
 
@@ -84,7 +115,26 @@ func runMigrations(db *gorm.DB) error {
 func dropTables(db *gorm.DB) error {
 	// 按照依赖关系的逆序删除表
 	tables := []interface{}{
+		&model.PriceHistory{},
+		&model.PriceSchedule{},
+		&model.InventoryLedger{},
+		&model.ProductSpecValue{},
+		&model.ProductSpec{},
+		&model.Notification{},
+		&model.UserFootprint{},
+		&model.ProductFavorite{},
+		&model.PointsLedger{},
+		&model.UserPoints{},
+		&model.MemberLevel{},
+		&model.AdminAuditLog{},
+		&model.PrivacyRequest{},
+		&model.AccountMergeLog{},
+		&model.TwoFactorPolicy{},
+		&model.AdminRecoveryCode{},
+		&model.UserSession{},
+		&model.LoginLog{},
 		&model.CartItem{},
+		&model.OrderRefund{},
 		&model.OrderPayment{},
 		&model.OrderItem{},
 		&model.Order{},
@@ -108,6 +158,101 @@ func dropTables(db *gorm.DB) error {
 	return nil
 }
 
+// encryptData 使用当前版本密钥重新加密个人信息字段并重建盲索引
+// 用于明文历史数据加密以及密钥轮换后的数据迁移，可重复执行
+func encryptData(db *gorm.DB) error {
+	var users []model.User
+	err := db.Unscoped().FindInBatches(&users, 200, func(tx *gorm.DB, batch int) error {
+		for i := range users {
+			if err := db.Unscoped().Model(&users[i]).Select("phone", "phone_hash").Updates(&users[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	var addresses []model.UserAddress
+	err = db.Unscoped().FindInBatches(&addresses, 200, func(tx *gorm.DB, batch int) error {
+		for i := range addresses {
+			if err := db.Unscoped().Model(&addresses[i]).Select("name", "phone", "address").Updates(&addresses[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	var orders []model.Order
+	err = db.Unscoped().FindInBatches(&orders, 200, func(tx *gorm.DB, batch int) error {
+		for i := range orders {
+			if err := db.Unscoped().Model(&orders[i]).Select("receiver_phone", "receiver_phone_hash", "receiver_address").Updates(&orders[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	var auths []model.UserAuth
+	err = db.Unscoped().FindInBatches(&auths, 200, func(tx *gorm.DB, batch int) error {
+		for i := range auths {
+			if err := db.Unscoped().Model(&auths[i]).Select("auth_key", "auth_key_hash").Updates(&auths[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	var loginLogs []model.LoginLog
+	err = db.Unscoped().FindInBatches(&loginLogs, 200, func(tx *gorm.DB, batch int) error {
+		for i := range loginLogs {
+			if err := db.Unscoped().Model(&loginLogs[i]).Select("account", "account_hash").Updates(&loginLogs[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	var mergeLogs []model.AccountMergeLog
+	err = db.Unscoped().FindInBatches(&mergeLogs, 200, func(tx *gorm.DB, batch int) error {
+		for i := range mergeLogs {
+			if err := db.Unscoped().Model(&mergeLogs[i]).Select("phone").Updates(&mergeLogs[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	var admins []model.Admin
+	return db.Unscoped().FindInBatches(&admins, 200, func(tx *gorm.DB, batch int) error {
+		for i := range admins {
+			if admins[i].TOTPSecret == "" {
+				continue
+			}
+			if err := db.Unscoped().Model(&admins[i]).Select("totp_secret").Updates(&admins[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+}
+
 // seedData 播种初始数据
 func seedData(db *gorm.DB) error {
 	// 创建默认管理员
@@ -125,9 +270,33 @@ func seedData(db *gorm.DB) error {
 		return err
 	}
 
+	// 创建默认会员等级
+	if err := seedMemberLevels(db); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// seedMemberLevels 创建默认会员等级
+func seedMemberLevels(db *gorm.DB) error {
+	var count int64
+	db.Model(&model.MemberLevel{}).Count(&count)
+	if count > 0 {
+		fmt.Println("Member levels already exist, skipping...")
+		return nil
+	}
+
+	levels := []model.MemberLevel{
+		{Name: "普通会员", Level: 1, MinSpend: 0, PointsRatio: 1, Status: 1},
+		{Name: "银卡会员", Level: 2, MinSpend: 1000, PointsRatio: 1.2, Status: 1},
+		{Name: "金卡会员", Level: 3, MinSpend: 5000, PointsRatio: 1.5, Status: 1},
+		{Name: "钻石会员", Level: 4, MinSpend: 20000, PointsRatio: 2, Status: 1},
+	}
+
+	return db.Create(&levels).Error
+}
+
 // seedAdmins 创建默认管理员
 func seedAdmins(db *gorm.DB) error {
 	// 检查是否已存在管理员
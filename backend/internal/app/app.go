@@ -6,10 +6,12 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 
 	"mall/internal/handler"
 	"mall/internal/repository"
@@ -19,36 +21,59 @@ import (
 	"mall/pkg/config"
 	"mall/pkg/database"
 	"mall/pkg/logger"
+	"mall/pkg/scheduler"
+	"mall/pkg/search"
+	"mall/pkg/sms"
+	"mall/pkg/storage"
+	"mall/pkg/utils"
 )
 
 // App 应用结构体
 type App struct {
-	config   *config.Config
-	server   *http.Server
-	handlers *Handlers
+	config    *config.Config
+	server    *http.Server
+	handlers  *Handlers
+	scheduler *scheduler.Scheduler
+
+	footprintService service.FootprintService
 }
 
 // Handlers 处理器容器
 type Handlers struct {
-	AuthHandler     *handler.AuthHandler
-	CategoryHandler *handler.CategoryHandler
-	ProductHandler  *handler.ProductHandler
-	CartHandler     *handler.CartHandler
-	OrderHandler    *handler.OrderHandler
-	PaymentHandler  *handler.PaymentHandler
+	AuthHandler         *handler.AuthHandler
+	CategoryHandler     *handler.CategoryHandler
+	ProductHandler      *handler.ProductHandler
+	CartHandler         *handler.CartHandler
+	OrderHandler        *handler.OrderHandler
+	PaymentHandler      *handler.PaymentHandler
+	AdminAuthHandler    *handler.AdminAuthHandler
+	PrivacyHandler      *handler.PrivacyHandler
+	AuditHandler        *handler.AuditHandler
+	AddressHandler      *handler.AddressHandler
+	LoyaltyHandler      *handler.LoyaltyHandler
+	FavoriteHandler     *handler.FavoriteHandler
+	FootprintHandler    *handler.FootprintHandler
+	NotificationHandler *handler.NotificationHandler
+	UploadHandler       *handler.UploadHandler
+	InventoryHandler    *handler.InventoryHandler
+	PriceHandler        *handler.PriceHandler
 }
 
 // New 创建新的应用实例
 func New() *App {
 	app := &App{
-		config: config.LoadConfig(),
+		config:    config.LoadConfig(),
+		scheduler: scheduler.New(),
 	}
-	
+
 	app.initLogger()
+	app.initFieldCrypto()
 	app.initDatabase()
 	app.initRedis()
+	app.initJWTKeys()
 	app.initDependencies()
-	
+	app.initScheduler()
+
 	return app
 }
 
@@ -63,6 +88,13 @@ func (a *App) initLogger() {
 	)
 }
 
+// initFieldCrypto 初始化个人信息字段加密密钥
+func (a *App) initFieldCrypto() {
+	if err := utils.InitFieldCrypto(); err != nil {
+		logger.Fatal("Failed to init field encryption", zap.Error(err))
+	}
+}
+
 // initDatabase 初始化数据库
 func (a *App) initDatabase() {
 	database.InitDB()
@@ -73,37 +105,209 @@ func (a *App) initRedis() {
 	cache.InitRedis()
 }
 
+// initJWTKeys 初始化JWT签名密钥
+func (a *App) initJWTKeys() {
+	if err := utils.InitJWTKeys(); err != nil {
+		logger.Fatal("Failed to init jwt keys", zap.Error(err))
+	}
+}
+
+// initScheduler 注册基础设施定时任务，业务任务在initDependencies中随服务注册
+func (a *App) initScheduler() {
+	// JWT签名密钥轮换
+	a.scheduler.Every("jwt_key_rotation", time.Hour, func(ctx context.Context) {
+		if err := utils.RotateJWTKeysIfDue(); err != nil {
+			logger.Error("Failed to rotate jwt keys", zap.Error(err))
+		}
+	})
+}
+
 // initDependencies 初始化依赖
 func (a *App) initDependencies() {
 	db := database.GetDB()
-	
+
 	// 初始化仓储
 	userRepo := repository.NewUserRepository(db)
 	userAuthRepo := repository.NewUserAuthRepository(db)
 	categoryRepo := repository.NewCategoryRepository(db)
 	productRepo := repository.NewProductRepository(db)
 	productSKURepo := repository.NewProductSKURepository(db)
+	productImageRepo := repository.NewProductImageRepository(db)
+	productSpecRepo := repository.NewProductSpecRepository(db)
 	cartRepo := repository.NewCartRepository(db)
 	orderRepo := repository.NewOrderRepository(db)
 	orderItemRepo := repository.NewOrderItemRepository(db)
 	paymentRepo := repository.NewOrderPaymentRepository(db)
+	loginLogRepo := repository.NewLoginLogRepository(db)
+	sessionRepo := repository.NewUserSessionRepository(db)
+	adminRepo := repository.NewAdminRepository(db)
+	adminRecoveryCodeRepo := repository.NewAdminRecoveryCodeRepository(db)
+	twoFactorPolicyRepo := repository.NewTwoFactorPolicyRepository(db)
+	accountMergeRepo := repository.NewAccountMergeRepository(db)
+	privacyRequestRepo := repository.NewPrivacyRequestRepository(db)
+	auditLogRepo := repository.NewAdminAuditLogRepository(db)
+	addressRepo := repository.NewUserAddressRepository(db)
+	memberLevelRepo := repository.NewMemberLevelRepository(db)
+	pointsRepo := repository.NewPointsRepository(db)
+	favoriteRepo := repository.NewProductFavoriteRepository(db)
+	footprintRepo := repository.NewFootprintRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	inventoryRepo := repository.NewInventoryRepository(db)
+	priceRepo := repository.NewPriceRepository(db)
 
 	// 初始化服务
-	authService := service.NewAuthService(userRepo, userAuthRepo)
+	smsSender, err := sms.NewSender()
+	if err != nil {
+		logger.Fatal("Failed to create sms sender", zap.Error(err))
+	}
+	smsService := service.NewSMSService(smsSender)
+	sessionService := service.NewSessionService(sessionRepo)
+	notificationService := service.NewNotificationService(notificationRepo)
+	uploadService := service.NewUploadService(storage.NewStorage())
+	footprintService := service.NewFootprintService(footprintRepo, productRepo)
+	a.footprintService = footprintService
+	authService := service.NewAuthService(userRepo, userAuthRepo, loginLogRepo, accountMergeRepo, smsService, sessionService, notificationService, footprintService)
 	categoryService := service.NewCategoryService(categoryRepo)
-	productService := service.NewProductService(productRepo, categoryRepo, productSKURepo)
+	inventoryService := service.NewInventoryService(inventoryRepo, orderItemRepo)
+	// 搜索客户端创建失败时商品照常读写，仅跳过索引更新
+	var searchService service.SearchService
+	if esClient, err := search.NewClient(); err != nil {
+		logger.Error("Failed to create elasticsearch client, product indexing disabled", zap.Error(err))
+	} else {
+		searchService = service.NewSearchService(esClient, productRepo)
+	}
+	priceService := service.NewPriceService(priceRepo, productRepo, productSKURepo, searchService)
+	productService := service.NewProductService(productRepo, categoryRepo, productSKURepo, favoriteRepo, productImageRepo, productSpecRepo, inventoryService, priceService, searchService)
 	cartService := service.NewCartService(cartRepo, productRepo, productSKURepo)
-	orderService := service.NewOrderService(orderRepo, orderItemRepo, productRepo, productSKURepo, cartRepo, userRepo)
-	paymentService := service.NewPaymentService(paymentRepo, orderRepo)
+	loyaltyService := service.NewLoyaltyService(pointsRepo, memberLevelRepo)
+	orderService := service.NewOrderService(orderRepo, orderItemRepo, productRepo, productSKURepo, cartRepo, userRepo, addressRepo, loyaltyService, notificationService, inventoryService)
+	paymentService := service.NewPaymentService(paymentRepo, orderRepo, orderItemRepo, loyaltyService, notificationService, inventoryService)
+	adminAuthService := service.NewAdminAuthService(adminRepo, adminRecoveryCodeRepo, twoFactorPolicyRepo)
+	privacyService := service.NewPrivacyService(privacyRequestRepo, userRepo, smsService, sessionService)
+	auditService := service.NewAuditService(auditLogRepo)
+	addressService := service.NewAddressService(addressRepo)
+	favoriteService := service.NewFavoriteService(favoriteRepo, productRepo)
+
+	// 注册审计快照
+	auditService.RegisterSnapshot("category", func(id string) (interface{}, error) {
+		categoryID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return categoryRepo.GetByID(categoryID)
+	})
+	auditService.RegisterSnapshot("product", func(id string) (interface{}, error) {
+		productID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return productRepo.GetByID(productID)
+	})
+	auditService.RegisterSnapshot("order", func(id string) (interface{}, error) {
+		orderID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return orderRepo.GetByID(orderID)
+	})
+	auditService.RegisterSnapshot("payment", func(paymentNo string) (interface{}, error) {
+		return paymentRepo.GetByPaymentNo(paymentNo)
+	})
+	auditService.RegisterSnapshot("member_level", func(id string) (interface{}, error) {
+		levelID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return memberLevelRepo.GetByID(levelID)
+	})
+	auditService.RegisterSnapshot("user", func(id string) (interface{}, error) {
+		userID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return userRepo.GetByID(userID)
+	})
+	auditService.RegisterSnapshot("admin", func(id string) (interface{}, error) {
+		adminID, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return adminRepo.GetByID(adminID)
+	})
+
+	// 注册后台任务
+	a.scheduler.Every("privacy_requests", time.Minute, func(ctx context.Context) {
+		if err := privacyService.ProcessExports(); err != nil {
+			logger.Error("Failed to process data exports", zap.Error(err))
+		}
+		if err := privacyService.ProcessDeletions(); err != nil {
+			logger.Error("Failed to process account deletions", zap.Error(err))
+		}
+		if err := privacyService.CleanupExpiredExports(); err != nil {
+			logger.Error("Failed to cleanup data exports", zap.Error(err))
+		}
+	})
+
+	a.scheduler.Every("order_auto_cancel", time.Minute, func(ctx context.Context) {
+		if err := orderService.CancelExpiredOrders(); err != nil {
+			logger.Error("Failed to cancel expired orders", zap.Error(err))
+		}
+	})
+	a.scheduler.Every("product_listing", time.Minute, func(ctx context.Context) {
+		if err := productService.ProcessListingSchedules(); err != nil {
+			logger.Error("Failed to process listing schedules", zap.Error(err))
+		}
+	})
+	a.scheduler.Every("price_schedules", time.Minute, func(ctx context.Context) {
+		if err := priceService.ProcessSchedules(); err != nil {
+			logger.Error("Failed to process price schedules", zap.Error(err))
+		}
+	})
+	a.scheduler.Every("points_expiry", time.Hour, func(ctx context.Context) {
+		if err := loyaltyService.ProcessExpirations(); err != nil {
+			logger.Error("Failed to expire points", zap.Error(err))
+		}
+	})
+	a.scheduler.Every("points_reconcile", 10*time.Minute, func(ctx context.Context) {
+		if err := loyaltyService.ReconcileOrderPoints(); err != nil {
+			logger.Error("Failed to reconcile order points", zap.Error(err))
+		}
+	})
+
+	persistInterval := time.Duration(a.config.Footprint.PersistIntervalSeconds) * time.Second
+	if persistInterval <= 0 {
+		persistInterval = time.Minute
+	}
+	a.scheduler.Every("footprint_persist", persistInterval, func(ctx context.Context) {
+		if err := footprintService.PersistPending(); err != nil {
+			logger.Error("Failed to persist footprints", zap.Error(err))
+		}
+	})
+	a.scheduler.Every("footprint_cleanup", time.Hour, func(ctx context.Context) {
+		if err := footprintService.CleanupExpired(); err != nil {
+			logger.Error("Failed to cleanup footprints", zap.Error(err))
+		}
+	})
 
 	// 初始化处理器
 	a.handlers = &Handlers{
-		AuthHandler:     handler.NewAuthHandler(authService),
-		CategoryHandler: handler.NewCategoryHandler(categoryService),
-		ProductHandler:  handler.NewProductHandler(productService),
-		CartHandler:     handler.NewCartHandler(cartService),
-		OrderHandler:    handler.NewOrderHandler(orderService),
-		PaymentHandler:  handler.NewPaymentHandler(paymentService),
+		AuthHandler:         handler.NewAuthHandler(authService, sessionService),
+		CategoryHandler:     handler.NewCategoryHandler(categoryService),
+		ProductHandler:      handler.NewProductHandler(productService, footprintService),
+		CartHandler:         handler.NewCartHandler(cartService),
+		OrderHandler:        handler.NewOrderHandler(orderService),
+		PaymentHandler:      handler.NewPaymentHandler(paymentService),
+		AdminAuthHandler:    handler.NewAdminAuthHandler(adminAuthService),
+		PrivacyHandler:      handler.NewPrivacyHandler(privacyService),
+		AuditHandler:        handler.NewAuditHandler(auditService),
+		AddressHandler:      handler.NewAddressHandler(addressService),
+		LoyaltyHandler:      handler.NewLoyaltyHandler(loyaltyService),
+		FavoriteHandler:     handler.NewFavoriteHandler(favoriteService),
+		FootprintHandler:    handler.NewFootprintHandler(footprintService),
+		NotificationHandler: handler.NewNotificationHandler(notificationService),
+		UploadHandler:       handler.NewUploadHandler(uploadService),
+		InventoryHandler:    handler.NewInventoryHandler(inventoryService),
+		PriceHandler:        handler.NewPriceHandler(priceService),
 	}
 }
 
@@ -113,7 +317,7 @@ func (a *App) Run() error {
 	gin.SetMode(a.config.Server.Mode)
 
 	// 创建路由
-	router := routes.SetupRouter(a.handlers.AuthHandler, a.handlers.CategoryHandler, a.handlers.ProductHandler, a.handlers.CartHandler, a.handlers.OrderHandler, a.handlers.PaymentHandler)
+	router := routes.SetupRouter(a.handlers.AuthHandler, a.handlers.CategoryHandler, a.handlers.ProductHandler, a.handlers.CartHandler, a.handlers.OrderHandler, a.handlers.PaymentHandler, a.handlers.AdminAuthHandler, a.handlers.PrivacyHandler, a.handlers.AuditHandler, a.handlers.AddressHandler, a.handlers.LoyaltyHandler, a.handlers.FavoriteHandler, a.handlers.FootprintHandler, a.handlers.NotificationHandler, a.handlers.UploadHandler, a.handlers.InventoryHandler, a.handlers.PriceHandler)
 
 	// 创建HTTP服务器
 	a.server = &http.Server{
@@ -124,6 +328,9 @@ func (a *App) Run() error {
 		MaxHeaderBytes: 1 << 20,
 	}
 
+	// 启动后台任务
+	a.scheduler.Start()
+
 	// 启动服务器
 	go func() {
 		logger.Info(fmt.Sprintf("Server starting on %s:%d", a.config.Server.Host, a.config.Server.Port))
@@ -147,6 +354,9 @@ func (a *App) Run() error {
 		logger.Fatal("Server forced to shutdown")
 	}
 
+	a.scheduler.Stop()
+	a.footprintService.Close()
+
 	logger.Info("Server exited")
 	return nil
 }
@@ -155,4 +365,4 @@ func (a *App) Run() error {
 func (a *App) Close() {
 	database.CloseDB()
 	cache.CloseRedis()
-}
\ No newline at end of file
+}
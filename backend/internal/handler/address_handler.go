@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"mall/internal/service"
+	"mall/pkg/utils"
+)
+
+// AddressHandler 收货地址处理器
+type AddressHandler struct {
+	addressService service.AddressService
+}
+
+// NewAddressHandler 创建收货地址处理器
+func NewAddressHandler(addressService service.AddressService) *AddressHandler {
+	return &AddressHandler{
+		addressService: addressService,
+	}
+}
+
+// ListAddresses 获取地址列表
+func (h *AddressHandler) ListAddresses(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	addresses, err := h.addressService.ListAddresses(uint64(userID))
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, addresses)
+}
+
+// GetAddress 获取地址详情
+func (h *AddressHandler) GetAddress(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid address ID")
+		return
+	}
+
+	address, err := h.addressService.GetAddress(uint64(userID), id)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, address)
+}
+
+// CreateAddress 新增地址
+func (h *AddressHandler) CreateAddress(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	var req service.AddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	address, err := h.addressService.CreateAddress(uint64(userID), &req)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, address)
+}
+
+// UpdateAddress 修改地址
+func (h *AddressHandler) UpdateAddress(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid address ID")
+		return
+	}
+
+	var req service.AddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	address, err := h.addressService.UpdateAddress(uint64(userID), id, &req)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, address)
+}
+
+// DeleteAddress 删除地址
+func (h *AddressHandler) DeleteAddress(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid address ID")
+		return
+	}
+
+	if err := h.addressService.DeleteAddress(uint64(userID), id); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Address deleted successfully", nil)
+}
+
+// SetDefaultAddress 设置默认地址
+func (h *AddressHandler) SetDefaultAddress(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid address ID")
+		return
+	}
+
+	if err := h.addressService.SetDefault(uint64(userID), id); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Default address updated successfully", nil)
+}
@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"mall/internal/service"
+	"mall/pkg/utils"
+)
+
+// AdminAuthHandler 管理员认证处理器
+type AdminAuthHandler struct {
+	adminAuthService service.AdminAuthService
+}
+
+// NewAdminAuthHandler 创建管理员认证处理器
+func NewAdminAuthHandler(adminAuthService service.AdminAuthService) *AdminAuthHandler {
+	return &AdminAuthHandler{
+		adminAuthService: adminAuthService,
+	}
+}
+
+// Login 管理员登录
+func (h *AdminAuthHandler) Login(c *gin.Context) {
+	var req service.AdminLoginRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	response, err := h.adminAuthService.Login(&req, clientInfo(c))
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, response)
+}
+
+// SetupTwoFactorByChallenge 登录时按策略绑定两步验证
+func (h *AdminAuthHandler) SetupTwoFactorByChallenge(c *gin.Context) {
+	var req struct {
+		ChallengeToken string `json:"challenge_token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	response, err := h.adminAuthService.SetupTwoFactorByChallenge(req.ChallengeToken)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, response)
+}
+
+// VerifyTwoFactor 登录两步验证
+func (h *AdminAuthHandler) VerifyTwoFactor(c *gin.Context) {
+	var req service.TwoFactorVerifyRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	response, err := h.adminAuthService.VerifyTwoFactor(&req)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, response)
+}
+
+// GetTwoFactorStatus 获取两步验证状态
+func (h *AdminAuthHandler) GetTwoFactorStatus(c *gin.Context) {
+	adminID := c.GetInt64("user_id")
+	if adminID == 0 {
+		utils.Unauthorized(c, "Invalid admin")
+		return
+	}
+
+	response, err := h.adminAuthService.GetTwoFactorStatus(uint64(adminID))
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, response)
+}
+
+// SetupTwoFactor 生成两步验证密钥
+func (h *AdminAuthHandler) SetupTwoFactor(c *gin.Context) {
+	adminID := c.GetInt64("user_id")
+	if adminID == 0 {
+		utils.Unauthorized(c, "Invalid admin")
+		return
+	}
+
+	response, err := h.adminAuthService.SetupTwoFactor(uint64(adminID))
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, response)
+}
+
+// EnableTwoFactor 启用两步验证
+func (h *AdminAuthHandler) EnableTwoFactor(c *gin.Context) {
+	adminID := c.GetInt64("user_id")
+	if adminID == 0 {
+		utils.Unauthorized(c, "Invalid admin")
+		return
+	}
+
+	var req service.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	response, err := h.adminAuthService.EnableTwoFactor(uint64(adminID), req.Code)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, response)
+}
+
+// DisableTwoFactor 关闭两步验证
+func (h *AdminAuthHandler) DisableTwoFactor(c *gin.Context) {
+	adminID := c.GetInt64("user_id")
+	if adminID == 0 {
+		utils.Unauthorized(c, "Invalid admin")
+		return
+	}
+
+	var req service.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	if err := h.adminAuthService.DisableTwoFactor(uint64(adminID), req.Code); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Two-factor authentication disabled successfully", nil)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+func (h *AdminAuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	adminID := c.GetInt64("user_id")
+	if adminID == 0 {
+		utils.Unauthorized(c, "Invalid admin")
+		return
+	}
+
+	var req service.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	response, err := h.adminAuthService.RegenerateRecoveryCodes(uint64(adminID), req.Code)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, response)
+}
+
+// ListTwoFactorPolicies 获取两步验证角色策略
+func (h *AdminAuthHandler) ListTwoFactorPolicies(c *gin.Context) {
+	policies, err := h.adminAuthService.ListTwoFactorPolicies()
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, policies)
+}
+
+// UpdateTwoFactorPolicy 更新两步验证角色策略
+func (h *AdminAuthHandler) UpdateTwoFactorPolicy(c *gin.Context) {
+	adminID := c.GetInt64("user_id")
+	if adminID == 0 {
+		utils.Unauthorized(c, "Invalid admin")
+		return
+	}
+
+	var req service.UpdateTwoFactorPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	if err := h.adminAuthService.UpdateTwoFactorPolicy(uint64(adminID), &req); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Two-factor policy updated successfully", nil)
+}
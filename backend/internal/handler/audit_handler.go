@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"mall/internal/middleware"
+	"mall/internal/service"
+	"mall/pkg/utils"
+)
+
+// AuditHandler 管理员审计日志处理器
+type AuditHandler struct {
+	auditService service.AuditService
+}
+
+// NewAuditHandler 创建管理员审计日志处理器
+func NewAuditHandler(auditService service.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+// Audit 返回记录管理员写操作的兜底中间件
+func (h *AuditHandler) Audit() gin.HandlerFunc {
+	return middleware.AdminAudit(h.auditService)
+}
+
+// Action 返回在路由上声明审计动作的中间件
+func (h *AuditHandler) Action(action, targetType, idParam string) gin.HandlerFunc {
+	return middleware.AuditAction(h.auditService, action, targetType, idParam)
+}
+
+// ListAuditLogs 查询审计日志
+func (h *AuditHandler) ListAuditLogs(c *gin.Context) {
+	var req service.AuditLogListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	response, err := h.auditService.ListLogs(&req)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, response)
+}
+
+// GetAuditLog 获取审计日志详情
+func (h *AuditHandler) GetAuditLog(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid audit log ID")
+		return
+	}
+
+	response, err := h.auditService.GetLog(id)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, response)
+}
@@ -1,36 +1,77 @@
 package handler
 
 import (
+	"strconv"
+
 	"github.com/gin-gonic/gin"
 
 	"mall/internal/service"
+	"mall/pkg/config"
 	"mall/pkg/utils"
 )
 
 // AuthHandler 认证处理器
 type AuthHandler struct {
-	authService service.AuthService
+	authService    service.AuthService
+	sessionService service.SessionService
 }
 
 // NewAuthHandler 创建认证处理器
-func NewAuthHandler(authService service.AuthService) *AuthHandler {
+func NewAuthHandler(authService service.AuthService, sessionService service.SessionService) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
+		authService:    authService,
+		sessionService: sessionService,
 	}
 }
 
-// SendSMSCode 发送短信验证码
+// SendSMSCode 发送登录验证码
 func (h *AuthHandler) SendSMSCode(c *gin.Context) {
-	var req struct {
-		Phone string `json:"phone" binding:"required"`
+	var req service.SendSMSCodeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
 	}
 
+	if err := h.authService.SendSMSCode(&req, c.ClientIP()); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Verification code sent successfully", nil)
+}
+
+// SendRegisterCode 发送注册验证码
+func (h *AuthHandler) SendRegisterCode(c *gin.Context) {
+	var req service.SendSMSCodeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.InvalidParams(c, err.Error())
 		return
 	}
 
-	if err := h.authService.SendSMSCode(req.Phone); err != nil {
+	if err := h.authService.SendRegisterCode(&req, c.ClientIP()); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Verification code sent successfully", nil)
+}
+
+// SendBindCode 发送绑定手机号验证码
+func (h *AuthHandler) SendBindCode(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	var req service.SendSMSCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	if err := h.authService.SendBindCode(uint64(userID), &req, c.ClientIP()); err != nil {
 		utils.Error(c, utils.ERROR, err.Error())
 		return
 	}
@@ -50,7 +91,7 @@ func (h *AuthHandler) LoginByPhone(c *gin.Context) {
 		return
 	}
 
-	response, err := h.authService.LoginByPhone(req.Phone, req.Code)
+	response, err := h.authService.LoginByPhone(req.Phone, req.Code, clientInfo(c))
 	if err != nil {
 		utils.Error(c, utils.ERROR, err.Error())
 		return
@@ -70,7 +111,7 @@ func (h *AuthHandler) LoginByWechat(c *gin.Context) {
 		return
 	}
 
-	response, err := h.authService.LoginByWechat(req.Code)
+	response, err := h.authService.LoginByWechat(req.Code, clientInfo(c))
 	if err != nil {
 		utils.Error(c, utils.ERROR, err.Error())
 		return
@@ -108,7 +149,7 @@ func (h *AuthHandler) LoginByPassword(c *gin.Context) {
 		return
 	}
 
-	response, err := h.authService.LoginByPassword(req.Username, req.Password)
+	response, err := h.authService.LoginByPassword(req.Username, req.Password, clientInfo(c))
 	if err != nil {
 		utils.Error(c, utils.ERROR, err.Error())
 		return
@@ -147,7 +188,7 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		return
 	}
 
-	if err := h.authService.Logout(uint64(userID)); err != nil {
+	if err := h.authService.Logout(uint64(userID), c.GetString("session_id")); err != nil {
 		utils.Error(c, utils.ERROR, err.Error())
 		return
 	}
@@ -247,10 +288,294 @@ func (h *AuthHandler) BindPhone(c *gin.Context) {
 		return
 	}
 
-	if err := h.authService.BindPhone(uint64(userID), &req); err != nil {
+	response, err := h.authService.BindPhone(uint64(userID), &req)
+	if err != nil {
 		utils.Error(c, utils.ERROR, err.Error())
 		return
 	}
 
-	utils.SuccessWithMessage(c, "Phone number bound successfully", nil)
-}
\ No newline at end of file
+	utils.Success(c, response)
+}
+
+// MergeAccount 合并账号
+func (h *AuthHandler) MergeAccount(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	var req service.MergeAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	response, err := h.authService.MergeAccount(uint64(userID), &req, c.GetString("platform"), clientInfo(c))
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, response)
+}
+
+// ForgotPassword 发送找回密码验证码
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req struct {
+		Phone string `json:"phone" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	if err := h.authService.SendPasswordResetCode(req.Phone, c.ClientIP()); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Verification code sent successfully", nil)
+}
+
+// VerifyPasswordReset 校验找回密码验证码
+func (h *AuthHandler) VerifyPasswordReset(c *gin.Context) {
+	var req service.VerifyPasswordResetRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	response, err := h.authService.VerifyPasswordResetCode(req.Phone, req.Code)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, response)
+}
+
+// ResetPassword 重置密码
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req service.ResetPasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	if err := h.authService.ResetPassword(&req); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Password reset successfully", nil)
+}
+
+// GetLoginLogs 获取当前用户最近登录记录
+func (h *AuthHandler) GetLoginLogs(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	response, err := h.authService.GetLoginLogs(uint64(userID), page, pageSize)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, response)
+}
+
+// ListLoginLogs 查询登录日志（管理员）
+func (h *AuthHandler) ListLoginLogs(c *gin.Context) {
+	var req service.LoginLogListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	req.Reveal = canRevealPII(c)
+
+	response, err := h.authService.ListLoginLogs(&req)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, response)
+}
+
+// UnlockAccount 解除账号锁定（管理员）
+func (h *AuthHandler) UnlockAccount(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid user ID")
+		return
+	}
+
+	if err := h.authService.UnlockAccount(id); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Account unlocked successfully", nil)
+}
+
+// ListUsers 查询用户列表（管理员）
+func (h *AuthHandler) ListUsers(c *gin.Context) {
+	var req service.AdminUserListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+	req.Reveal = canRevealPII(c)
+
+	response, err := h.authService.ListUsers(&req)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, response)
+}
+
+// GetUserDetail 获取用户详情（管理员）
+func (h *AuthHandler) GetUserDetail(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid user ID")
+		return
+	}
+
+	response, err := h.authService.GetUserDetail(id, canRevealPII(c))
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, response)
+}
+
+// UpdateUserStatus 启用或禁用用户（管理员）
+func (h *AuthHandler) UpdateUserStatus(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid user ID")
+		return
+	}
+
+	var req service.UpdateUserStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	if err := h.authService.UpdateUserStatus(id, *req.Status); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "User status updated successfully", nil)
+}
+
+// ResetUserPassword 重置用户密码（管理员）
+func (h *AuthHandler) ResetUserPassword(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid user ID")
+		return
+	}
+
+	var req service.AdminResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	if err := h.authService.AdminResetPassword(id, req.Password); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Password reset successfully", nil)
+}
+
+// GetMergeLogs 获取用户账号合并记录（管理员）
+func (h *AuthHandler) GetMergeLogs(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid user ID")
+		return
+	}
+
+	logs, err := h.authService.GetMergeLogs(id)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, logs)
+}
+
+// GetSessions 获取当前用户的登录设备列表
+func (h *AuthHandler) GetSessions(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	sessions, err := h.sessionService.ListSessions(uint64(userID), c.GetString("session_id"))
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, sessions)
+}
+
+// RevokeSession 下线指定登录设备
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid session ID")
+		return
+	}
+
+	if err := h.sessionService.RevokeSession(uint64(userID), id); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Session revoked successfully", nil)
+}
+
+// canRevealPII 当前管理员角色是否可查看完整手机号、地址
+func canRevealPII(c *gin.Context) bool {
+	return utils.StringInSlice(c.GetString("role"), config.GetConfig().Privacy.RevealRoles)
+}
+
+// clientInfo 提取客户端信息
+func clientInfo(c *gin.Context) *service.ClientInfo {
+	return &service.ClientInfo{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Device:    c.GetHeader("X-Device-Name"),
+		Platform:  c.GetHeader("X-Platform"),
+	}
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"mall/internal/service"
+	"mall/pkg/utils"
+)
+
+// FavoriteHandler 商品收藏处理器
+type FavoriteHandler struct {
+	favoriteService service.FavoriteService
+}
+
+// NewFavoriteHandler 创建商品收藏处理器
+func NewFavoriteHandler(favoriteService service.FavoriteService) *FavoriteHandler {
+	return &FavoriteHandler{
+		favoriteService: favoriteService,
+	}
+}
+
+// ListFavorites 获取收藏列表
+func (h *FavoriteHandler) ListFavorites(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	var req service.FavoriteListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	response, err := h.favoriteService.ListFavorites(uint64(userID), &req)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, response)
+}
+
+// AddFavorite 收藏商品
+func (h *FavoriteHandler) AddFavorite(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	var req service.FavoriteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	if err := h.favoriteService.AddFavorite(uint64(userID), req.ProductID); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Favorite added successfully", nil)
+}
+
+// RemoveFavorite 取消收藏
+func (h *FavoriteHandler) RemoveFavorite(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	productID, err := strconv.ParseUint(c.Param("productId"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid product ID")
+		return
+	}
+
+	if err := h.favoriteService.RemoveFavorite(uint64(userID), productID); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Favorite removed successfully", nil)
+}
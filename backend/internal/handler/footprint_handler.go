@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"mall/internal/service"
+	"mall/pkg/utils"
+)
+
+// FootprintHandler 浏览足迹处理器
+type FootprintHandler struct {
+	footprintService service.FootprintService
+}
+
+// NewFootprintHandler 创建浏览足迹处理器
+func NewFootprintHandler(footprintService service.FootprintService) *FootprintHandler {
+	return &FootprintHandler{
+		footprintService: footprintService,
+	}
+}
+
+// ListFootprints 获取浏览足迹
+func (h *FootprintHandler) ListFootprints(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	var req service.FootprintListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	response, err := h.footprintService.ListFootprints(uint64(userID), &req)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, response)
+}
+
+// DeleteFootprints 批量删除浏览足迹
+func (h *FootprintHandler) DeleteFootprints(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	var req service.DeleteFootprintsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	if err := h.footprintService.DeleteFootprints(uint64(userID), &req); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Footprints deleted successfully", nil)
+}
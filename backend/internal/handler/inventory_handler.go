@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"mall/internal/service"
+	"mall/pkg/utils"
+)
+
+// InventoryHandler 库存处理器
+type InventoryHandler struct {
+	inventoryService service.InventoryService
+}
+
+// NewInventoryHandler 创建库存处理器
+func NewInventoryHandler(inventoryService service.InventoryService) *InventoryHandler {
+	return &InventoryHandler{
+		inventoryService: inventoryService,
+	}
+}
+
+// GetLedger 查询库存流水
+func (h *InventoryHandler) GetLedger(c *gin.Context) {
+	var req service.InventoryLedgerRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	response, err := h.inventoryService.GetLedger(&req)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, response)
+}
+
+// ImportStocks 批量导入库存
+func (h *InventoryHandler) ImportStocks(c *gin.Context) {
+	var req service.ImportStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	if err := h.inventoryService.ImportStocks(&req, uint64(c.GetInt64("user_id"))); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Stock imported successfully", nil)
+}
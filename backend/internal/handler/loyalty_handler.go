@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"mall/internal/service"
+	"mall/pkg/utils"
+)
+
+// LoyaltyHandler 会员积分处理器
+type LoyaltyHandler struct {
+	loyaltyService service.LoyaltyService
+}
+
+// NewLoyaltyHandler 创建会员积分处理器
+func NewLoyaltyHandler(loyaltyService service.LoyaltyService) *LoyaltyHandler {
+	return &LoyaltyHandler{
+		loyaltyService: loyaltyService,
+	}
+}
+
+// GetPoints 获取积分与会员等级概览
+func (h *LoyaltyHandler) GetPoints(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	summary, err := h.loyaltyService.GetSummary(uint64(userID))
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, summary)
+}
+
+// GetPointsLedger 获取积分流水
+func (h *LoyaltyHandler) GetPointsLedger(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	var req service.PointsLedgerRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	response, err := h.loyaltyService.GetLedger(uint64(userID), &req)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, response)
+}
+
+// ListMemberLevels 获取会员等级列表
+func (h *LoyaltyHandler) ListMemberLevels(c *gin.Context) {
+	levels, err := h.loyaltyService.ListLevels()
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, levels)
+}
+
+// CreateMemberLevel 创建会员等级
+func (h *LoyaltyHandler) CreateMemberLevel(c *gin.Context) {
+	var req service.MemberLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	level, err := h.loyaltyService.CreateLevel(&req)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, level)
+}
+
+// UpdateMemberLevel 更新会员等级
+func (h *LoyaltyHandler) UpdateMemberLevel(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid member level ID")
+		return
+	}
+
+	var req service.MemberLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	level, err := h.loyaltyService.UpdateLevel(id, &req)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, level)
+}
+
+// DeleteMemberLevel 删除会员等级
+func (h *LoyaltyHandler) DeleteMemberLevel(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid member level ID")
+		return
+	}
+
+	if err := h.loyaltyService.DeleteLevel(id); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Member level deleted successfully", nil)
+}
@@ -160,6 +160,7 @@ func (h *OrderHandler) GetOrders(c *gin.Context) {
 	if req.PageSize <= 0 {
 		req.PageSize = 20
 	}
+	req.Reveal = canRevealPII(c)
 
 	response, err := h.orderService.GetOrders(&req)
 	if err != nil {
@@ -218,7 +219,7 @@ func (h *OrderHandler) SearchOrders(c *gin.Context) {
 		}
 	}
 
-	response, err := h.orderService.SearchOrders(keyword, page, pageSize)
+	response, err := h.orderService.SearchOrders(keyword, page, pageSize, canRevealPII(c))
 	if err != nil {
 		utils.Error(c, utils.ERROR, err.Error())
 		return
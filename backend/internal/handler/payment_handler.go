@@ -115,21 +115,29 @@ func (h *PaymentHandler) CancelPayment(c *gin.Context) {
 
 // RefundPayment 退款
 func (h *PaymentHandler) RefundPayment(c *gin.Context) {
+	adminID := c.GetInt64("user_id")
+	if adminID == 0 {
+		utils.Unauthorized(c, "Invalid admin")
+		return
+	}
+
 	paymentNo := c.Param("paymentNo")
 	if paymentNo == "" {
 		utils.InvalidParams(c, "Payment number is required")
 		return
 	}
 
-	var req struct {
-		RefundAmount float64 `json:"refund_amount" binding:"required,gt=0"`
-		RefundReason string  `json:"refund_reason" binding:"required"`
-	}
+	var req service.RefundPaymentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.InvalidParams(c, err.Error())
 		return
 	}
 
-	// TODO: 实现退款逻辑
-	utils.SuccessWithMessage(c, "Refund request submitted successfully", nil)
+	response, err := h.paymentService.RefundPayment(paymentNo, &req, uint64(adminID))
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Refund processed successfully", response)
 }
\ No newline at end of file
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"mall/internal/service"
+	"mall/pkg/utils"
+)
+
+// PriceHandler 价格处理器
+type PriceHandler struct {
+	priceService service.PriceService
+}
+
+// NewPriceHandler 创建价格处理器
+func NewPriceHandler(priceService service.PriceService) *PriceHandler {
+	return &PriceHandler{
+		priceService: priceService,
+	}
+}
+
+// ListPriceSchedules 获取商品的调价计划
+func (h *PriceHandler) ListPriceSchedules(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid product ID")
+		return
+	}
+
+	schedules, err := h.priceService.ListSchedules(id)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, schedules)
+}
+
+// CreatePriceSchedule 创建调价计划
+func (h *PriceHandler) CreatePriceSchedule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid product ID")
+		return
+	}
+
+	var req service.CreatePriceScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	schedule, err := h.priceService.CreateSchedule(id, &req, uint64(c.GetInt64("user_id")))
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Price schedule created successfully", schedule)
+}
+
+// CancelPriceSchedule 取消调价计划
+func (h *PriceHandler) CancelPriceSchedule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid product ID")
+		return
+	}
+	scheduleID, err := strconv.ParseUint(c.Param("scheduleId"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid schedule ID")
+		return
+	}
+
+	if err := h.priceService.CancelSchedule(id, scheduleID, uint64(c.GetInt64("user_id"))); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Price schedule cancelled successfully", nil)
+}
+
+// GetPriceHistory 查询商品价格历史
+func (h *PriceHandler) GetPriceHistory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid product ID")
+		return
+	}
+
+	var req service.PriceHistoryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	response, err := h.priceService.GetPriceHistory(id, &req)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, response)
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"mall/internal/service"
+	"mall/pkg/utils"
+)
+
+// PrivacyHandler 用户隐私处理器
+type PrivacyHandler struct {
+	privacyService service.PrivacyService
+}
+
+// NewPrivacyHandler 创建用户隐私处理器
+func NewPrivacyHandler(privacyService service.PrivacyService) *PrivacyHandler {
+	return &PrivacyHandler{
+		privacyService: privacyService,
+	}
+}
+
+// GetRequests 获取隐私请求记录
+func (h *PrivacyHandler) GetRequests(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	requests, err := h.privacyService.GetRequests(uint64(userID))
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, requests)
+}
+
+// RequestExport 申请导出个人数据
+func (h *PrivacyHandler) RequestExport(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	response, err := h.privacyService.RequestExport(uint64(userID))
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, response)
+}
+
+// DownloadExport 下载个人数据导出文件
+func (h *PrivacyHandler) DownloadExport(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid request ID")
+		return
+	}
+
+	filePath, err := h.privacyService.GetExportFile(uint64(userID), id)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	c.FileAttachment(filePath, fmt.Sprintf("mall-data-export-%d.zip", id))
+}
+
+// SendDeletionCode 发送注销账号验证码
+func (h *PrivacyHandler) SendDeletionCode(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	if err := h.privacyService.SendDeletionCode(uint64(userID), c.ClientIP()); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Verification code sent successfully", nil)
+}
+
+// RequestDeletion 申请注销账号
+func (h *PrivacyHandler) RequestDeletion(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	var req service.DeletionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	response, err := h.privacyService.RequestDeletion(uint64(userID), &req)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, response)
+}
+
+// CancelDeletion 撤销注销申请
+func (h *PrivacyHandler) CancelDeletion(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if userID == 0 {
+		utils.Unauthorized(c, "Invalid user")
+		return
+	}
+
+	if err := h.privacyService.CancelDeletion(uint64(userID)); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Deletion request cancelled successfully", nil)
+}
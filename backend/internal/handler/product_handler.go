@@ -11,13 +11,15 @@ import (
 
 // ProductHandler 商品处理器
 type ProductHandler struct {
-	productService service.ProductService
+	productService   service.ProductService
+	footprintService service.FootprintService
 }
 
 // NewProductHandler 创建商品处理器
-func NewProductHandler(productService service.ProductService) *ProductHandler {
+func NewProductHandler(productService service.ProductService, footprintService service.FootprintService) *ProductHandler {
 	return &ProductHandler{
-		productService: productService,
+		productService:   productService,
+		footprintService: footprintService,
 	}
 }
 
@@ -29,7 +31,7 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		return
 	}
 
-	if err := h.productService.CreateProduct(&req); err != nil {
+	if err := h.productService.CreateProduct(&req, uint64(c.GetInt64("user_id"))); err != nil {
 		utils.Error(c, utils.ERROR, err.Error())
 		return
 	}
@@ -52,7 +54,7 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		return
 	}
 
-	if err := h.productService.UpdateProduct(id, &req); err != nil {
+	if err := h.productService.UpdateProduct(id, &req, uint64(c.GetInt64("user_id"))); err != nil {
 		utils.Error(c, utils.ERROR, err.Error())
 		return
 	}
@@ -104,12 +106,19 @@ func (h *ProductHandler) GetProductDetail(c *gin.Context) {
 		return
 	}
 
-	product, err := h.productService.GetProductDetail(id)
+	// 未登录时user_id为0
+	userID := uint64(c.GetInt64("user_id"))
+	product, err := h.productService.GetProductDetail(id, userID)
 	if err != nil {
 		utils.Error(c, utils.ERROR, err.Error())
 		return
 	}
 
+	// 登录用户异步记录浏览足迹
+	if userID > 0 {
+		h.footprintService.Record(userID, id)
+	}
+
 	utils.Success(c, product)
 }
 
@@ -205,7 +214,7 @@ func (h *ProductHandler) UpdateProductStock(c *gin.Context) {
 		return
 	}
 
-	if err := h.productService.UpdateProductStock(id, req.Stock); err != nil {
+	if err := h.productService.UpdateProductStock(id, req.Stock, uint64(c.GetInt64("user_id"))); err != nil {
 		utils.Error(c, utils.ERROR, err.Error())
 		return
 	}
@@ -213,6 +222,212 @@ func (h *ProductHandler) UpdateProductStock(c *gin.Context) {
 	utils.SuccessWithMessage(c, "Product stock updated successfully", nil)
 }
 
+// ListProductImages 获取商品图片
+func (h *ProductHandler) ListProductImages(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid product ID")
+		return
+	}
+
+	images, err := h.productService.ListProductImages(id)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, images)
+}
+
+// AddProductImage 添加商品图片
+func (h *ProductHandler) AddProductImage(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid product ID")
+		return
+	}
+
+	var req service.ProductImageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	image, err := h.productService.AddProductImage(id, &req)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Product image added successfully", image)
+}
+
+// RemoveProductImage 删除商品图片
+func (h *ProductHandler) RemoveProductImage(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid product ID")
+		return
+	}
+	imageID, err := strconv.ParseUint(c.Param("imageId"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid image ID")
+		return
+	}
+
+	if err := h.productService.RemoveProductImage(id, imageID); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Product image removed successfully", nil)
+}
+
+// ReorderProductImages 调整商品图片顺序
+func (h *ProductHandler) ReorderProductImages(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid product ID")
+		return
+	}
+
+	var req service.ReorderProductImagesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	if err := h.productService.ReorderProductImages(id, &req); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Product images reordered successfully", nil)
+}
+
+// SetMainProductImage 设置商品主图
+func (h *ProductHandler) SetMainProductImage(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid product ID")
+		return
+	}
+	imageID, err := strconv.ParseUint(c.Param("imageId"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid image ID")
+		return
+	}
+
+	if err := h.productService.SetMainProductImage(id, imageID); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Main image updated successfully", nil)
+}
+
+// GetProductSpecs 获取商品规格
+func (h *ProductHandler) GetProductSpecs(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid product ID")
+		return
+	}
+
+	specs, err := h.productService.GetProductSpecs(id)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, specs)
+}
+
+// SaveProductSpecs 保存商品规格
+func (h *ProductHandler) SaveProductSpecs(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid product ID")
+		return
+	}
+
+	var req service.SaveProductSpecsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	specs, err := h.productService.SaveProductSpecs(id, &req)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Product specs saved successfully", specs)
+}
+
+// GenerateSKUs 按规格生成SKU矩阵
+func (h *ProductHandler) GenerateSKUs(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid product ID")
+		return
+	}
+
+	var req service.GenerateSKUsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	skus, err := h.productService.GenerateSKUs(id, &req, uint64(c.GetInt64("user_id")))
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "SKUs generated successfully", skus)
+}
+
+// SetListingSchedule 设置商品定时上下架时间
+func (h *ProductHandler) SetListingSchedule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.InvalidParams(c, "Invalid product ID")
+		return
+	}
+
+	var req service.ListingScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	if err := h.productService.SetListingSchedule(id, &req); err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "Listing schedule updated successfully", nil)
+}
+
+// GetUpcomingLaunches 获取待定时上架的商品
+func (h *ProductHandler) GetUpcomingLaunches(c *gin.Context) {
+	var req service.UpcomingLaunchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.InvalidParams(c, err.Error())
+		return
+	}
+
+	response, err := h.productService.GetUpcomingLaunches(&req)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.Success(c, response)
+}
+
 // GetProductsByCategory 根据分类获取商品
 func (h *ProductHandler) GetProductsByCategory(c *gin.Context) {
 	categoryIDStr := c.Param("categoryId")
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"mall/internal/service"
+	"mall/pkg/utils"
+)
+
+// UploadHandler 文件上传处理器
+type UploadHandler struct {
+	uploadService service.UploadService
+}
+
+// NewUploadHandler 创建文件上传处理器
+func NewUploadHandler(uploadService service.UploadService) *UploadHandler {
+	return &UploadHandler{
+		uploadService: uploadService,
+	}
+}
+
+// UploadAvatar 上传用户头像，返回的地址可用于更新个人资料
+func (h *UploadHandler) UploadAvatar(c *gin.Context) {
+	if c.GetInt64("user_id") == 0 {
+		utils.Unauthorized(c, "Invalid user")
+		return
+	}
+	h.upload(c, service.UploadSceneAvatar)
+}
+
+// UploadProductImage 上传商品图片
+func (h *UploadHandler) UploadProductImage(c *gin.Context) {
+	h.upload(c, service.UploadSceneProduct)
+}
+
+// upload 读取表单中的file字段并上传
+func (h *UploadHandler) upload(c *gin.Context, scene string) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		utils.InvalidParams(c, "File is required")
+		return
+	}
+
+	response, err := h.uploadService.UploadImage(scene, file)
+	if err != nil {
+		utils.Error(c, utils.ERROR, err.Error())
+		return
+	}
+
+	utils.SuccessWithMessage(c, "File uploaded successfully", response)
+}
@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"mall/internal/service"
+)
+
+// auditRecordedKey 路由已声明审计动作并完成记录的上下文标记
+const auditRecordedKey = "audit_recorded"
+
+// 请求参数中需要脱敏的字段，按字段名精确匹配
+var auditSensitiveKeys = map[string]bool{
+	"password":         true,
+	"old_password":     true,
+	"new_password":     true,
+	"secret":           true,
+	"code":             true,
+	"recovery_code":    true,
+	"token":            true,
+	"challenge_token":  true,
+	"phone":            true,
+	"receiver_phone":   true,
+	"address":          true,
+	"receiver_address": true,
+	"full_address":     true,
+}
+
+// 审计记录的请求参数最大长度
+const auditMaxParamsSize = 4096
+
+// auditResponseWriter 记录响应体以便获取新建对象ID
+type auditResponseWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+// Write 写入响应并保留副本
+func (w *auditResponseWriter) Write(data []byte) (int, error) {
+	if w.body.Len() < 64*1024 {
+		w.body.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// AdminAudit 管理员操作审计兜底中间件，需放在AdminAuth之后；
+// 未通过AuditAction声明审计动作的写操作按方法加路由记录，不含前后快照
+func AdminAudit(auditService service.AuditService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		method := c.Request.Method
+		if method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions {
+			c.Next()
+			return
+		}
+
+		params := readAuditParams(c)
+		c.Next()
+		if c.GetBool(auditRecordedKey) {
+			return
+		}
+
+		routePath := c.FullPath()
+		if idx := strings.Index(routePath, "/admin"); idx >= 0 {
+			routePath = routePath[idx+len("/admin"):]
+		}
+		auditService.Record(&service.AuditEntry{
+			AdminID:    uint64(c.GetInt64("user_id")),
+			AdminName:  c.GetString("username"),
+			Action:     strings.ToLower(method) + " " + routePath,
+			Params:     params,
+			Method:     method,
+			Path:       c.Request.URL.Path,
+			StatusCode: c.Writer.Status(),
+			IP:         c.ClientIP(),
+			RequestID:  c.GetString("request_id"),
+		})
+	}
+}
+
+// AuditAction 在注册路由时声明审计动作，记录操作对象的前后快照；
+// idParam为空时新建对象的ID从响应中解析，targetType为admin时对象为当前管理员
+func AuditAction(auditService service.AuditService, action, targetType, idParam string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		method := c.Request.Method
+		adminID := uint64(c.GetInt64("user_id"))
+		targetID := ""
+		if idParam != "" {
+			targetID = c.Param(idParam)
+		} else if targetType == "admin" {
+			targetID = fmt.Sprintf("%d", adminID)
+		}
+
+		params := readAuditParams(c)
+		before := auditService.Snapshot(targetType, targetID)
+
+		writer := &auditResponseWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = writer
+
+		c.Next()
+
+		status := c.Writer.Status()
+		var after interface{}
+		if status < http.StatusBadRequest {
+			if targetID == "" {
+				targetID = responseDataID(writer.body.Bytes())
+			}
+			if method != http.MethodDelete {
+				after = auditService.Snapshot(targetType, targetID)
+			}
+		}
+
+		auditService.Record(&service.AuditEntry{
+			AdminID:    adminID,
+			AdminName:  c.GetString("username"),
+			Action:     action,
+			TargetType: targetType,
+			TargetID:   targetID,
+			Before:     before,
+			After:      after,
+			Params:     params,
+			Method:     method,
+			Path:       c.Request.URL.Path,
+			StatusCode: status,
+			IP:         c.ClientIP(),
+			RequestID:  c.GetString("request_id"),
+		})
+		c.Set(auditRecordedKey, true)
+	}
+}
+
+// readAuditParams 读取请求体并脱敏，读取后恢复请求体供后续处理
+func readAuditParams(c *gin.Context) string {
+	if c.Request.Body == nil {
+		return c.Request.URL.RawQuery
+	}
+	// 上传文件不记录文件内容
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		return ""
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return ""
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	var params map[string]interface{}
+	if err := json.Unmarshal(body, &params); err != nil {
+		return truncateAuditParams(string(body))
+	}
+	for key := range params {
+		if auditSensitiveKeys[strings.ToLower(key)] {
+			params[key] = "***"
+		}
+	}
+	data, _ := json.Marshal(params)
+	return truncateAuditParams(string(data))
+}
+
+// truncateAuditParams 截断过长的请求参数
+func truncateAuditParams(params string) string {
+	if len(params) > auditMaxParamsSize {
+		return params[:auditMaxParamsSize]
+	}
+	return params
+}
+
+// responseDataID 从统一响应结构中解析data.id
+func responseDataID(body []byte) string {
+	var resp struct {
+		Data struct {
+			ID json.Number `json:"id"`
+		} `json:"data"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&resp); err != nil {
+		return ""
+	}
+	return resp.Data.ID.String()
+}
@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"mall/internal/service"
+)
+
+// fakeAuditService 记录审计条目，快照返回当前版本号
+type fakeAuditService struct {
+	service.AuditService
+	entries []*service.AuditEntry
+	version int
+}
+
+func (f *fakeAuditService) Snapshot(targetType, targetID string) interface{} {
+	if targetID == "" {
+		return nil
+	}
+	return map[string]interface{}{"type": targetType, "id": targetID, "version": f.version}
+}
+
+func (f *fakeAuditService) Record(entry *service.AuditEntry) error {
+	f.entries = append(f.entries, entry)
+	return nil
+}
+
+func newAuditTestRouter(audit *fakeAuditService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	admin := r.Group("/api/v1/admin", func(c *gin.Context) {
+		c.Set("user_id", int64(9))
+		c.Set("username", "root")
+		c.Set("request_id", "req-1")
+	}, AdminAudit(audit))
+
+	admin.POST("/products", AuditAction(audit, "product.create", "product", ""), func(c *gin.Context) {
+		audit.version++
+		c.JSON(http.StatusOK, gin.H{"code": 0, "data": gin.H{"id": 42}})
+	})
+	admin.PUT("/products/:id", AuditAction(audit, "product.update", "product", "id"), func(c *gin.Context) {
+		audit.version++
+		c.JSON(http.StatusOK, gin.H{"code": 0})
+	})
+	admin.DELETE("/products/:id", AuditAction(audit, "product.delete", "product", "id"), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"code": 0})
+	})
+	admin.PUT("/orders/:id/remark", func(c *gin.Context) {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 400})
+	})
+	admin.GET("/products", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"code": 0})
+	})
+	return r
+}
+
+func TestAdminAudit(t *testing.T) {
+	cases := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantAction string
+		wantTarget string
+		wantBefore bool
+		wantAfter  bool
+		wantStatus int
+	}{
+		{"create parses id from response", http.MethodPost, "/api/v1/admin/products", `{"name":"p"}`, "product.create", "42", false, true, http.StatusOK},
+		{"update snapshots before and after", http.MethodPut, "/api/v1/admin/products/7", `{"name":"p"}`, "product.update", "7", true, true, http.StatusOK},
+		{"delete has no after snapshot", http.MethodDelete, "/api/v1/admin/products/7", "", "product.delete", "7", true, false, http.StatusOK},
+		{"undeclared route falls back to method and path", http.MethodPut, "/api/v1/admin/orders/3/remark", `{}`, "put /orders/:id/remark", "", false, false, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			audit := &fakeAuditService{}
+			r := newAuditTestRouter(audit)
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			if len(audit.entries) != 1 {
+				t.Fatalf("recorded %d entries, want 1", len(audit.entries))
+			}
+			entry := audit.entries[0]
+			if entry.Action != tc.wantAction || entry.TargetID != tc.wantTarget || entry.StatusCode != tc.wantStatus {
+				t.Errorf("entry = %+v", entry)
+			}
+			if (entry.Before != nil) != tc.wantBefore || (entry.After != nil) != tc.wantAfter {
+				t.Errorf("before = %v, after = %v", entry.Before, entry.After)
+			}
+			if entry.AdminID != 9 || entry.AdminName != "root" || entry.RequestID != "req-1" || entry.Path != tc.path {
+				t.Errorf("request context not recorded: %+v", entry)
+			}
+		})
+	}
+
+	audit := &fakeAuditService{}
+	newAuditTestRouter(audit).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/admin/products", nil))
+	if len(audit.entries) != 0 {
+		t.Errorf("read requests should not be audited: %+v", audit.entries)
+	}
+}
+
+func TestReadAuditParams(t *testing.T) {
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{"sensitive fields masked", "application/json", `{"name":"p","password":"secret","Phone":"13800001234"}`, `{"Phone":"***","name":"p","password":"***"}`},
+		{"non json kept", "text/plain", "plain", "plain"},
+		{"multipart skipped", "multipart/form-data; boundary=x", "--x--", ""},
+		{"long body truncated", "text/plain", strings.Repeat("a", auditMaxParamsSize+10), strings.Repeat("a", auditMaxParamsSize)},
+	}
+	for _, tc := range cases {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+		c.Request.Header.Set("Content-Type", tc.contentType)
+
+		if got := readAuditParams(c); got != tc.want {
+			t.Errorf("%s: params = %s, want %s", tc.name, got, tc.want)
+		}
+		// 请求体读取后需恢复，供后续处理函数绑定
+		rest, _ := io.ReadAll(c.Request.Body)
+		if tc.want != "" && string(rest) != tc.body {
+			t.Errorf("%s: body not restored", tc.name)
+		}
+	}
+}
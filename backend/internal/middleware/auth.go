@@ -11,53 +11,72 @@ import (
 // JWT认证中间件
 func JWT() gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
-		// 获取Authorization头
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			utils.Unauthorized(c, "Missing authorization header")
-			c.Abort()
-			return
-		}
-
-		// 检查Bearer token格式
-		if !strings.HasPrefix(authHeader, "Bearer ") {
-			utils.Unauthorized(c, "Invalid authorization header format")
-			c.Abort()
-			return
-		}
-
-		// 提取token
-		tokenString := authHeader[7:] // 移除"Bearer "前缀
-		if tokenString == "" {
-			utils.Unauthorized(c, "Missing token")
-			c.Abort()
-			return
-		}
-
-		// 解析token
-		claims, err := utils.ParseToken(tokenString)
-		if err != nil {
-			utils.Unauthorized(c, "Invalid token")
-			c.Abort()
+		if !authenticate(c) {
 			return
 		}
-
-		// 将用户信息设置到上下文中
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
-		c.Set("role", claims.Role)
-		c.Set("platform", claims.Platform)
-
 		c.Next()
 	})
 }
 
+// authenticate 校验请求携带的token并将用户信息写入上下文，失败时中止请求；不调用c.Next
+func authenticate(c *gin.Context) bool {
+	// 获取Authorization头
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		utils.Unauthorized(c, "Missing authorization header")
+		c.Abort()
+		return false
+	}
+
+	// 检查Bearer token格式
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		utils.Unauthorized(c, "Invalid authorization header format")
+		c.Abort()
+		return false
+	}
+
+	// 提取token
+	tokenString := authHeader[7:] // 移除"Bearer "前缀
+	if tokenString == "" {
+		utils.Unauthorized(c, "Missing token")
+		c.Abort()
+		return false
+	}
+
+	// 解析token
+	claims, err := utils.ParseToken(tokenString)
+	if err != nil {
+		utils.Unauthorized(c, "Invalid token")
+		c.Abort()
+		return false
+	}
+
+	// 校验会话是否已被注销
+	if !utils.ValidateSession(claims) {
+		utils.Unauthorized(c, "Session expired or revoked")
+		c.Abort()
+		return false
+	}
+
+	// 将用户信息设置到上下文中
+	setClaims(c, claims)
+	return true
+}
+
+// setClaims 将token中的用户信息设置到上下文中
+func setClaims(c *gin.Context, claims *utils.Claims) {
+	c.Set("user_id", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Set("role", claims.Role)
+	c.Set("platform", claims.Platform)
+	c.Set("session_id", claims.ID)
+}
+
 // AdminAuth 管理员认证中间件
 func AdminAuth() gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
-		// 先执行JWT中间件
-		JWT()(c)
-		if c.IsAborted() {
+		// 先完成token认证
+		if !authenticate(c) {
 			return
 		}
 
@@ -84,9 +103,8 @@ func AdminAuth() gin.HandlerFunc {
 // SuperAdminAuth 超级管理员认证中间件
 func SuperAdminAuth() gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
-		// 先执行JWT中间件
-		JWT()(c)
-		if c.IsAborted() {
+		// 先完成token认证
+		if !authenticate(c) {
 			return
 		}
 
@@ -113,9 +131,8 @@ func SuperAdminAuth() gin.HandlerFunc {
 // UserAuth 用户认证中间件
 func UserAuth() gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
-		// 先执行JWT中间件
-		JWT()(c)
-		if c.IsAborted() {
+		// 先完成token认证
+		if !authenticate(c) {
 			return
 		}
 
@@ -139,4 +156,28 @@ func UserAuth() gin.HandlerFunc {
 
 		c.Next()
 	})
-}
\ No newline at end of file
+}
+
+// OptionalUserAuth 可选用户认证中间件，携带有效用户token时设置用户信息，否则按未登录继续
+func OptionalUserAuth() gin.HandlerFunc {
+	return gin.HandlerFunc(func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if !strings.HasPrefix(authHeader, "Bearer ") || len(authHeader) <= 7 {
+			c.Next()
+			return
+		}
+
+		claims, err := utils.ParseToken(authHeader[7:])
+		if err != nil || !utils.ValidateSession(claims) {
+			c.Next()
+			return
+		}
+		if claims.Platform != "web" && claims.Platform != "miniprogram" {
+			c.Next()
+			return
+		}
+
+		setClaims(c, claims)
+		c.Next()
+	})
+}
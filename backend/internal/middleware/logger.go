@@ -10,12 +10,7 @@ import (
 	"mall/pkg/utils"
 )
 
-// Logger 日志中间件
-func Logger() gin.HandlerFunc {
-	return gin.LoggerWithWriter(gin.DefaultWriter, "/health")
-}
-
-// RequestLogger 请求日志中间件
+// RequestLogger 请求日志中间件，经zap写入，路径与查询参数中的手机号会被脱敏
 func RequestLogger() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
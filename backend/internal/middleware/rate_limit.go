@@ -1,108 +1,145 @@
 package middleware
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"math"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"go.uber.org/zap"
 
 	"mall/pkg/cache"
+	"mall/pkg/config"
+	"mall/pkg/logger"
 	"mall/pkg/utils"
 )
 
-// RateLimiter 限流中间件
-func RateLimiter(maxRequests int, windowDuration time.Duration) gin.HandlerFunc {
+// 限流维度
+const (
+	RateLimitKeyByIP    = "ip"
+	RateLimitKeyByUser  = "user"
+	RateLimitKeyByPhone = "phone"
+)
+
+// defaultRateLimitPolicies 配置文件未定义时使用的默认策略
+var defaultRateLimitPolicies = map[string]config.RateLimitPolicy{
+	"api":   {Limit: 100, WindowSeconds: 60, KeyBy: RateLimitKeyByIP},
+	"login": {Limit: 5, WindowSeconds: 60, KeyBy: RateLimitKeyByIP},
+	"sms":   {Limit: 1, WindowSeconds: 60, KeyBy: RateLimitKeyByPhone},
+}
+
+// RateLimiter 按策略名限流的中间件，策略在配置文件rate_limit.policies中定义
+func RateLimiter(policyName string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 获取客户端IP
-		clientIP := c.ClientIP()
-		
-		// 生成限流key
-		key := fmt.Sprintf("rate_limit:%s", clientIP)
-		
-		ctx := context.Background()
-		
-		// 获取当前请求次数
-		countStr, err := cache.Get(ctx, key)
-		if err != nil {
-			// 如果key不存在，初始化为1
-			cache.Set(ctx, key, "1", windowDuration)
+		cfg := config.GetConfig().RateLimit
+		if !cfg.Enabled {
+			c.Next()
+			return
+		}
+
+		policy, ok := cfg.Policies[policyName]
+		if !ok {
+			policy, ok = defaultRateLimitPolicies[policyName]
+		}
+		if !ok || policy.Limit <= 0 || policy.WindowSeconds <= 0 {
 			c.Next()
 			return
 		}
-		
-		count, err := strconv.Atoi(countStr)
+
+		subject := rateLimitSubject(c, policy.KeyBy)
+		if subject == "" {
+			utils.InvalidParams(c, "Phone number is required")
+			c.Abort()
+			return
+		}
+
+		// 每个策略独立计数
+		key := fmt.Sprintf("rate_limit:%s:%s:%s", policyName, policy.KeyBy, subject)
+		window := time.Duration(policy.WindowSeconds) * time.Second
+
+		result, err := cache.SlidingWindowAllow(context.Background(), key, policy.Limit, window, utils.GenerateRandomString(8))
 		if err != nil {
-			// 解析错误，重新初始化
-			cache.Set(ctx, key, "1", windowDuration)
+			// Redis异常时放行，避免限流故障导致整站不可用
+			logger.Warn("Rate limit check failed",
+				zap.String("policy", policyName),
+				zap.String("request_id", c.GetString("request_id")),
+				zap.Error(err),
+			)
 			c.Next()
 			return
 		}
-		
-		// 检查是否超过限制
-		if count >= maxRequests {
+
+		resetSeconds := int64(math.Ceil(result.ResetIn.Seconds()))
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(time.Now().Unix()+resetSeconds, 10))
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.FormatInt(resetSeconds, 10))
 			utils.Error(c, utils.TOO_MANY_REQUESTS, "Rate limit exceeded")
 			c.Abort()
 			return
 		}
-		
-		// 增加计数
-		cache.Set(ctx, key, strconv.Itoa(count+1), windowDuration)
-		
+
 		c.Next()
 	}
 }
 
 // APIRateLimiter API限流中间件
 func APIRateLimiter() gin.HandlerFunc {
-	// 默认每分钟100次请求
-	return RateLimiter(100, time.Minute)
+	return RateLimiter("api")
 }
 
 // LoginRateLimiter 登录限流中间件
 func LoginRateLimiter() gin.HandlerFunc {
-	// 登录接口每分钟5次请求
-	return RateLimiter(5, time.Minute)
+	return RateLimiter("login")
 }
 
 // SMSRateLimiter 短信限流中间件
 func SMSRateLimiter() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// 获取手机号
-		phone := c.PostForm("phone")
-		if phone == "" {
-			phone = c.Query("phone")
-		}
-		
-		if phone == "" {
-			utils.InvalidParams(c, "Phone number is required")
-			c.Abort()
-			return
-		}
-		
-		// 生成限流key
-		key := fmt.Sprintf("sms_rate_limit:%s", phone)
-		
-		ctx := context.Background()
-		
-		// 检查是否在限制时间内
-		exists, err := cache.Exists(ctx, key)
-		if err != nil {
-			utils.InternalServerError(c, "Rate limit check failed")
-			c.Abort()
-			return
+	return RateLimiter("sms")
+}
+
+// rateLimitSubject 获取限流对象标识，按用户限流时未登录请求退化为按IP限流
+func rateLimitSubject(c *gin.Context, keyBy string) string {
+	switch keyBy {
+	case RateLimitKeyByUser:
+		if userID := c.GetInt64("user_id"); userID > 0 {
+			return strconv.FormatInt(userID, 10)
 		}
-		
-		if exists > 0 {
-			utils.Error(c, utils.TOO_MANY_REQUESTS, "SMS request too frequent, please try again later")
-			c.Abort()
-			return
+		return "ip:" + c.ClientIP()
+	case RateLimitKeyByPhone:
+		return phoneFromRequest(c)
+	default:
+		return c.ClientIP()
+	}
+}
+
+// phoneFromRequest 从JSON请求体或表单/查询参数中读取手机号
+func phoneFromRequest(c *gin.Context) string {
+	if c.ContentType() == binding.MIMEJSON && c.Request.Body != nil {
+		body, err := io.ReadAll(c.Request.Body)
+		// 读取后回填请求体，供后续处理器绑定
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		if err == nil {
+			var req struct {
+				Phone string `json:"phone"`
+			}
+			if json.Unmarshal(body, &req) == nil && req.Phone != "" {
+				return req.Phone
+			}
 		}
-		
-		// 设置限制（1分钟内不能重复发送）
-		cache.Set(ctx, key, "1", time.Minute)
-		
-		c.Next()
 	}
-}
\ No newline at end of file
+
+	phone := c.PostForm("phone")
+	if phone == "" {
+		phone = c.Query("phone")
+	}
+	return phone
+}
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	"mall/pkg/utils"
+)
+
+func init() {
+	schema.RegisterSerializer("encrypt", EncryptSerializer{})
+}
+
+// EncryptSerializer 字段加密序列化器，写入时加密、读取时解密
+type EncryptSerializer struct{}
+
+// Scan 读取时解密
+func (EncryptSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var plain string
+	if dbValue != nil {
+		var value string
+		switch v := dbValue.(type) {
+		case []byte:
+			value = string(v)
+		case string:
+			value = v
+		default:
+			return fmt.Errorf("failed to decrypt field %s: unsupported value %#v", field.Name, dbValue)
+		}
+
+		decrypted, err := utils.DecryptField(value)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt field %s: %w", field.Name, err)
+		}
+		plain = decrypted
+	}
+
+	field.ReflectValueOf(ctx, dst).SetString(plain)
+	return nil
+}
+
+// Value 写入时加密
+func (EncryptSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	plain, ok := fieldValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("failed to encrypt field %s: unsupported type %T", field.Name, fieldValue)
+	}
+	return utils.EncryptField(plain)
+}
+
+// BeforeSave 同步手机号盲索引
+func (u *User) BeforeSave(tx *gorm.DB) (err error) {
+	u.PhoneHash, err = utils.BlindIndex(u.Phone)
+	return err
+}
+
+// BeforeSave 同步收货手机号盲索引
+func (o *Order) BeforeSave(tx *gorm.DB) (err error) {
+	o.ReceiverPhoneHash, err = utils.BlindIndex(o.ReceiverPhone)
+	return err
+}
+
+// BeforeSave 同步认证key盲索引
+func (a *UserAuth) BeforeSave(tx *gorm.DB) (err error) {
+	a.AuthKeyHash, err = utils.BlindIndex(a.AuthKey)
+	return err
+}
+
+// BeforeSave 同步登录账号盲索引
+func (l *LoginLog) BeforeSave(tx *gorm.DB) (err error) {
+	l.AccountHash, err = utils.BlindIndex(l.Account)
+	return err
+}
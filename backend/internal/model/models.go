@@ -18,7 +18,8 @@ type BaseModel struct {
 type User struct {
 	BaseModel
 	Username     string `json:"username" gorm:"size:50;uniqueIndex"`
-	Phone        string `json:"phone" gorm:"size:20;uniqueIndex"`
+	Phone        string `json:"phone" gorm:"size:255;serializer:encrypt;comment:加密存储"`
+	PhoneHash    string `json:"-" gorm:"size:64;uniqueIndex;comment:手机号盲索引"`
 	Email        string `json:"email" gorm:"size:100"`
 	WechatOpenID string `json:"wechat_openid" gorm:"column:wechat_openid;size:100;uniqueIndex"`
 	Status       int8   `json:"status" gorm:"default:1;comment:1正常 0禁用"`
@@ -48,12 +49,12 @@ type UserProfile struct {
 type UserAddress struct {
 	BaseModel
 	UserID    uint64 `json:"user_id" gorm:"not null;index"`
-	Name      string `json:"name" gorm:"size:50;not null"`
-	Phone     string `json:"phone" gorm:"size:20;not null"`
+	Name      string `json:"name" gorm:"size:255;not null;serializer:encrypt"`
+	Phone     string `json:"phone" gorm:"size:255;not null;serializer:encrypt"`
 	Province  string `json:"province" gorm:"size:50;not null"`
 	City      string `json:"city" gorm:"size:50;not null"`
 	District  string `json:"district" gorm:"size:50;not null"`
-	Address   string `json:"address" gorm:"size:200;not null"`
+	Address   string `json:"address" gorm:"size:1024;not null;serializer:encrypt"`
 	IsDefault int8   `json:"is_default" gorm:"default:0;comment:0否 1是"`
 
 	// 关联
@@ -65,7 +66,8 @@ type UserAuth struct {
 	BaseModel
 	UserID       uint64 `json:"user_id" gorm:"not null;uniqueIndex:uk_user_auth"`
 	AuthType     string `json:"auth_type" gorm:"size:20;not null;uniqueIndex:uk_user_auth;comment:password,wechat"`
-	AuthKey      string `json:"auth_key" gorm:"size:100;not null;comment:手机号或openid"`
+	AuthKey      string `json:"auth_key" gorm:"size:255;not null;serializer:encrypt;comment:手机号或openid，加密存储"`
+	AuthKeyHash  string `json:"-" gorm:"size:64;index;comment:认证key盲索引"`
 	PasswordHash string `json:"password_hash" gorm:"size:255"`
 	Salt         string `json:"salt" gorm:"size:32"`
 
@@ -103,6 +105,11 @@ type Product struct {
 	Sales         int     `json:"sales" gorm:"default:0"`
 	Status        int8    `json:"status" gorm:"default:1;comment:1上架 0下架"`
 	SortOrder     int     `json:"sort_order" gorm:"default:0"`
+	FavoriteCount int     `json:"favorite_count" gorm:"default:0;comment:收藏数"`
+
+	// 定时上下架，到期切换状态后清空
+	PublishAt   *time.Time `json:"publish_at" gorm:"index;comment:定时上架时间"`
+	UnpublishAt *time.Time `json:"unpublish_at" gorm:"index;comment:定时下架时间"`
 
 	// 关联
 	Category Category       `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
@@ -119,6 +126,7 @@ type ProductSKU struct {
 	Price      float64 `json:"price" gorm:"type:decimal(10,2);not null"`
 	Stock      int     `json:"stock" gorm:"default:0"`
 	AttrValues string  `json:"attr_values" gorm:"type:json;comment:属性值JSON"`
+	SpecKey    string  `json:"spec_key" gorm:"size:255;index;comment:规格值ID组合，按ID升序逗号分隔"`
 	Image      string  `json:"image" gorm:"size:500"`
 	Status     int8    `json:"status" gorm:"default:1"`
 
@@ -147,13 +155,14 @@ type Order struct {
 	PayAmount       float64 `json:"pay_amount" gorm:"type:decimal(10,2);not null"`
 	FreightAmount   float64 `json:"freight_amount" gorm:"type:decimal(10,2);default:0"`
 	DiscountAmount  float64 `json:"discount_amount" gorm:"type:decimal(10,2);default:0"`
-	Status          int8    `json:"status" gorm:"default:1;comment:1待付款 2待发货 3已发货 4已完成 5已取消"`
+	Status          int8    `json:"status" gorm:"default:1;comment:1待付款 2待发货 3已发货 4已完成 5已取消 6已退款"`
 	PaymentStatus   int8    `json:"payment_status" gorm:"default:0;comment:0未付款 1已付款"`
 	DeliveryStatus  int8    `json:"delivery_status" gorm:"default:0;comment:0未发货 1已发货 2已收货"`
 	BuyerMessage    string  `json:"buyer_message" gorm:"size:500"`
 	ReceiverName    string  `json:"receiver_name" gorm:"size:50"`
-	ReceiverPhone   string  `json:"receiver_phone" gorm:"size:20"`
-	ReceiverAddress string  `json:"receiver_address" gorm:"size:500"`
+	ReceiverPhone   string  `json:"receiver_phone" gorm:"size:255;serializer:encrypt"`
+	ReceiverPhoneHash string `json:"-" gorm:"size:64;index;comment:收货手机号盲索引"`
+	ReceiverAddress string  `json:"receiver_address" gorm:"type:text;serializer:encrypt"`
 
 	// 关联
 	User     User           `json:"user,omitempty" gorm:"foreignKey:UserID"`
@@ -187,20 +196,32 @@ type OrderPayment struct {
 	PaymentNo     string    `json:"payment_no" gorm:"size:64;uniqueIndex;not null"`
 	PaymentMethod string    `json:"payment_method" gorm:"size:20;not null;comment:wechat,alipay"`
 	Amount        float64   `json:"amount" gorm:"type:decimal(10,2);not null"`
-	Status        int8      `json:"status" gorm:"default:0;comment:0待支付 1支付成功 2支付失败"`
+	Status        int8      `json:"status" gorm:"default:0;comment:0待支付 1支付成功 2支付失败 3已取消 4待退款 5已全额退款"`
 	TradeNo       string    `json:"trade_no" gorm:"size:64;comment:第三方交易号"`
 	PayTime       time.Time `json:"pay_time"`
+	RefundAmount  float64   `json:"refund_amount" gorm:"type:decimal(10,2);default:0;comment:累计退款金额"`
 
 	// 关联
 	Order Order `json:"order,omitempty" gorm:"foreignKey:OrderID"`
 }
 
+// OrderRefund 订单退款记录
+type OrderRefund struct {
+	BaseModel
+	OrderID    uint64  `json:"order_id" gorm:"not null;index"`
+	PaymentID  uint64  `json:"payment_id" gorm:"not null;index"`
+	RefundNo   string  `json:"refund_no" gorm:"size:64;uniqueIndex;not null"`
+	Amount     float64 `json:"amount" gorm:"type:decimal(10,2);not null"`
+	Reason     string  `json:"reason" gorm:"size:255"`
+	OperatorID uint64  `json:"operator_id" gorm:"comment:操作管理员ID"`
+}
+
 // CartItem 购物车
 type CartItem struct {
 	BaseModel
 	UserID    uint64 `json:"user_id" gorm:"not null;uniqueIndex:uk_user_product_sku"`
 	ProductID uint64 `json:"product_id" gorm:"not null;uniqueIndex:uk_user_product_sku"`
-	SKUID     uint64 `json:"sku_id" gorm:"uniqueIndex:uk_user_product_sku"`
+	SKUID     uint64 `json:"sku_id" gorm:"column:sku_id;uniqueIndex:uk_user_product_sku"`
 	Quantity  int    `json:"quantity" gorm:"not null;default:1"`
 
 	// 关联
@@ -222,4 +243,228 @@ type Admin struct {
 	Role        string    `json:"role" gorm:"size:50;default:admin"`
 	Status      int8      `json:"status" gorm:"default:1;comment:1正常 0禁用"`
 	LastLoginAt time.Time `json:"last_login_at"`
-}
\ No newline at end of file
+	TwoFactorEnabled bool   `json:"two_factor_enabled" gorm:"default:false;comment:是否启用两步验证"`
+	TOTPSecret       string `json:"-" gorm:"size:255;serializer:encrypt;comment:TOTP密钥，加密存储"`
+}
+
+// LoginLog 登录日志
+type LoginLog struct {
+	BaseModel
+	UserID      uint64 `json:"user_id" gorm:"index"`
+	LoginType   string `json:"login_type" gorm:"size:20;not null;comment:phone,wechat,password"`
+	Account     string `json:"account" gorm:"size:255;serializer:encrypt;comment:加密存储"`
+	AccountHash string `json:"-" gorm:"size:64;index;comment:账号盲索引"`
+	IP          string `json:"ip" gorm:"size:64;index"`
+	UserAgent   string `json:"user_agent" gorm:"size:500"`
+	Platform    string `json:"platform" gorm:"size:20"`
+	Result      int8   `json:"result" gorm:"default:0;index;comment:1成功 0失败"`
+	FailReason  string `json:"fail_reason" gorm:"size:200"`
+}
+
+// UserSession 用户登录会话
+type UserSession struct {
+	BaseModel
+	UserID     uint64    `json:"user_id" gorm:"not null;index"`
+	SessionID  string    `json:"session_id" gorm:"size:64;uniqueIndex;not null"`
+	Platform   string    `json:"platform" gorm:"size:20;index"`
+	Device     string    `json:"device" gorm:"size:100"`
+	UserAgent  string    `json:"user_agent" gorm:"size:500"`
+	IP         string    `json:"ip" gorm:"size:64"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at" gorm:"index"`
+	Status     int8      `json:"status" gorm:"default:1;index;comment:1有效 0已注销"`
+}
+
+// AdminRecoveryCode 管理员两步验证恢复码
+type AdminRecoveryCode struct {
+	BaseModel
+	AdminID  uint64     `json:"admin_id" gorm:"not null;index"`
+	CodeHash string     `json:"-" gorm:"size:64;not null;index"`
+	UsedAt   *time.Time `json:"used_at"`
+}
+
+// TwoFactorPolicy 两步验证角色策略
+type TwoFactorPolicy struct {
+	BaseModel
+	Role      string `json:"role" gorm:"size:50;uniqueIndex;not null"`
+	Required  bool   `json:"required" gorm:"default:false;comment:是否强制启用两步验证"`
+	UpdatedBy uint64 `json:"updated_by"`
+}
+
+// AccountMergeLog 账号合并记录
+type AccountMergeLog struct {
+	BaseModel
+	SourceUserID   uint64 `json:"source_user_id" gorm:"not null;index;comment:被合并账号"`
+	TargetUserID   uint64 `json:"target_user_id" gorm:"not null;index;comment:保留账号"`
+	Phone          string `json:"phone" gorm:"size:255;serializer:encrypt;comment:加密存储"`
+	MovedAuths     int    `json:"moved_auths"`
+	MovedOrders    int    `json:"moved_orders"`
+	MovedCartItems int    `json:"moved_cart_items"`
+	MovedAddresses int    `json:"moved_addresses"`
+	Conflicts      string `json:"conflicts" gorm:"type:text;comment:冲突处理明细JSON"`
+	IP             string `json:"ip" gorm:"size:64"`
+}
+
+// PrivacyRequest 用户隐私请求（数据导出、账号注销）
+type PrivacyRequest struct {
+	BaseModel
+	UserID      uint64     `json:"user_id" gorm:"not null;index"`
+	Type        string     `json:"type" gorm:"size:20;not null;index;comment:export,delete"`
+	Status      int8       `json:"status" gorm:"default:0;index;comment:0待处理 1处理中 2已完成 3已取消 4失败"`
+	FilePath    string     `json:"-" gorm:"size:500"`
+	ScheduledAt time.Time  `json:"scheduled_at" gorm:"index;comment:计划执行时间"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ExpiresAt   *time.Time `json:"expires_at" gorm:"comment:导出文件过期时间"`
+	FailReason  string     `json:"fail_reason" gorm:"size:500"`
+}
+
+// AdminAuditLog 管理员操作审计日志
+type AdminAuditLog struct {
+	BaseModel
+	AdminID    uint64 `json:"admin_id" gorm:"not null;index"`
+	AdminName  string `json:"admin_name" gorm:"size:50"`
+	Action     string `json:"action" gorm:"size:50;not null;index;comment:如product.update"`
+	TargetType string `json:"target_type" gorm:"size:50;index:idx_audit_target"`
+	TargetID   string `json:"target_id" gorm:"size:64;index:idx_audit_target"`
+	Before     string `json:"before" gorm:"type:text;comment:变更前快照JSON"`
+	After      string `json:"after" gorm:"type:text;comment:变更后快照JSON"`
+	Diff       string `json:"diff" gorm:"type:text;comment:字段差异JSON"`
+	Params     string `json:"params" gorm:"type:text;comment:请求参数"`
+	Method     string `json:"method" gorm:"size:10"`
+	Path       string `json:"path" gorm:"size:255"`
+	StatusCode int    `json:"status_code"`
+	IP         string `json:"ip" gorm:"size:64"`
+	RequestID  string `json:"request_id" gorm:"size:32;index"`
+}
+
+// MemberLevel 会员等级
+type MemberLevel struct {
+	BaseModel
+	Name        string  `json:"name" gorm:"size:50;not null"`
+	Level       int     `json:"level" gorm:"uniqueIndex;not null;comment:等级序号，越大等级越高"`
+	MinSpend    float64 `json:"min_spend" gorm:"type:decimal(12,2);default:0;comment:累计消费门槛"`
+	PointsRatio float64 `json:"points_ratio" gorm:"type:decimal(6,2);default:1;comment:每消费1元获得的积分"`
+	Status      int8    `json:"status" gorm:"default:1;comment:0禁用 1启用"`
+}
+
+// UserPoints 用户积分账户
+type UserPoints struct {
+	BaseModel
+	UserID      uint64  `json:"user_id" gorm:"uniqueIndex;not null"`
+	Balance     int64   `json:"balance" gorm:"default:0;comment:可用积分"`
+	TotalEarned int64   `json:"total_earned" gorm:"default:0;comment:累计获得积分"`
+	TotalSpend  float64 `json:"total_spend" gorm:"type:decimal(12,2);default:0;comment:累计消费金额，用于计算等级"`
+	LevelID     uint64  `json:"level_id" gorm:"index"`
+}
+
+// PointsLedger 积分流水，只追加不修改
+type PointsLedger struct {
+	BaseModel
+	UserID       uint64     `json:"user_id" gorm:"not null;index"`
+	Type         string     `json:"type" gorm:"size:20;not null;index;comment:earn,refund,expire,adjust"`
+	Points       int64      `json:"points" gorm:"not null;comment:正数为增加 负数为扣减"`
+	BalanceAfter int64      `json:"balance_after"`
+	OrderID      uint64     `json:"order_id" gorm:"index"`
+	BizKey       *string    `json:"-" gorm:"size:64;uniqueIndex;comment:幂等键"`
+	ExpiresAt    *time.Time `json:"expires_at" gorm:"index;comment:获得积分的过期时间"`
+	Remark       string     `json:"remark" gorm:"size:255"`
+}
+
+// ProductFavorite 商品收藏
+type ProductFavorite struct {
+	BaseModel
+	UserID    uint64 `json:"user_id" gorm:"not null;uniqueIndex:idx_favorite_user_product"`
+	ProductID uint64 `json:"product_id" gorm:"not null;uniqueIndex:idx_favorite_user_product;index"`
+
+	// 关联
+	Product Product `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+}
+
+// UserFootprint 用户浏览足迹，同一商品每天一条
+type UserFootprint struct {
+	BaseModel
+	UserID    uint64    `json:"user_id" gorm:"not null;uniqueIndex:idx_footprint_user_product_date;index:idx_footprint_user_viewed"`
+	ProductID uint64    `json:"product_id" gorm:"not null;uniqueIndex:idx_footprint_user_product_date"`
+	ViewDate  time.Time `json:"view_date" gorm:"type:date;not null;uniqueIndex:idx_footprint_user_product_date;index"`
+	ViewedAt  time.Time `json:"viewed_at" gorm:"not null;index:idx_footprint_user_viewed;comment:当天最后浏览时间"`
+}
+
+// Notification 站内通知
+type Notification struct {
+	BaseModel
+	UserID   uint64     `json:"user_id" gorm:"not null;index:idx_notification_user_read"`
+	Category string     `json:"category" gorm:"size:20;not null;index;comment:order,payment,account"`
+	Type     string     `json:"type" gorm:"size:50;not null;comment:如order_shipped"`
+	Title    string     `json:"title" gorm:"size:100;not null"`
+	Content  string     `json:"content" gorm:"size:500"`
+	BizType  string     `json:"biz_type" gorm:"size:20;comment:关联业务对象类型"`
+	BizID    uint64     `json:"biz_id"`
+	IsRead   bool       `json:"is_read" gorm:"default:false;index:idx_notification_user_read"`
+	ReadAt   *time.Time `json:"read_at"`
+}
+
+// ProductSpec 商品规格（如颜色、尺码）
+type ProductSpec struct {
+	BaseModel
+	ProductID uint64 `json:"product_id" gorm:"not null;index"`
+	Name      string `json:"name" gorm:"size:50;not null"`
+	SortOrder int    `json:"sort_order" gorm:"default:0"`
+
+	// 关联
+	Values []ProductSpecValue `json:"values,omitempty" gorm:"foreignKey:SpecID"`
+}
+
+// ProductSpecValue 商品规格值
+type ProductSpecValue struct {
+	BaseModel
+	ProductID uint64 `json:"product_id" gorm:"not null;index"`
+	SpecID    uint64 `json:"spec_id" gorm:"not null;index"`
+	Value     string `json:"value" gorm:"size:100;not null"`
+	Image     string `json:"image" gorm:"size:500;comment:规格值配图，如颜色图"`
+	SortOrder int    `json:"sort_order" gorm:"default:0"`
+}
+
+// InventoryLedger 库存流水，每次库存变动一条
+type InventoryLedger struct {
+	BaseModel
+	ProductID    uint64  `json:"product_id" gorm:"not null;index:idx_inventory_target"`
+	SKUID        uint64  `json:"sku_id" gorm:"column:sku_id;default:0;index:idx_inventory_target;comment:0表示商品库存"`
+	Delta        int     `json:"delta" gorm:"not null;comment:正数为入库 负数为出库"`
+	Balance      int     `json:"balance" gorm:"comment:变动后库存"`
+	Reason       string  `json:"reason" gorm:"size:20;not null;index;comment:order,cancel,refund,adjust,import"`
+	RefID        string  `json:"ref_id" gorm:"size:64;index;comment:关联单据号，如订单号"`
+	OperatorType string  `json:"operator_type" gorm:"size:20;comment:user,admin,system"`
+	OperatorID   uint64  `json:"operator_id"`
+	BizKey       *string `json:"-" gorm:"size:128;uniqueIndex;comment:幂等键"`
+	Remark       string  `json:"remark" gorm:"size:255"`
+}
+
+// PriceSchedule 定时调价，开始时应用新价格，设置了结束时间的到期后恢复原价
+type PriceSchedule struct {
+	BaseModel
+	ProductID  uint64     `json:"product_id" gorm:"not null;index:idx_price_schedule_target"`
+	SKUID      uint64     `json:"sku_id" gorm:"column:sku_id;default:0;index:idx_price_schedule_target;comment:0表示商品价格"`
+	Price      float64    `json:"price" gorm:"type:decimal(10,2);not null"`
+	PrevPrice  float64    `json:"prev_price" gorm:"type:decimal(10,2);comment:生效前价格，结束时恢复"`
+	StartAt    time.Time  `json:"start_at" gorm:"not null;index"`
+	EndAt      *time.Time `json:"end_at" gorm:"index;comment:为空表示永久调价"`
+	Status     int8       `json:"status" gorm:"default:0;index;comment:0待生效 1生效中 2已结束 3已取消"`
+	AppliedAt  *time.Time `json:"applied_at"`
+	EndedAt    *time.Time `json:"ended_at"`
+	OperatorID uint64     `json:"operator_id"`
+	Remark     string     `json:"remark" gorm:"size:255"`
+}
+
+// PriceHistory 商品与SKU价格变更历史
+type PriceHistory struct {
+	BaseModel
+	ProductID    uint64  `json:"product_id" gorm:"not null;index:idx_price_history_target"`
+	SKUID        uint64  `json:"sku_id" gorm:"column:sku_id;default:0;index:idx_price_history_target;comment:0表示商品价格"`
+	OldPrice     float64 `json:"old_price" gorm:"type:decimal(10,2)"`
+	NewPrice     float64 `json:"new_price" gorm:"type:decimal(10,2)"`
+	Source       string  `json:"source" gorm:"size:20;not null;comment:manual,schedule,revert"`
+	ScheduleID   uint64  `json:"schedule_id" gorm:"default:0;index"`
+	OperatorType string  `json:"operator_type" gorm:"size:20;comment:admin,system"`
+	OperatorID   uint64  `json:"operator_id"`
+	Remark       string  `json:"remark" gorm:"size:255"`
+}
@@ -0,0 +1,406 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+)
+
+// AccountMergeRepository 账号合并仓储接口
+type AccountMergeRepository interface {
+	Merge(sourceID, targetID uint64, maxAddresses int, log *model.AccountMergeLog) error
+	GetLogsByUserID(userID uint64) ([]*model.AccountMergeLog, error)
+}
+
+// accountMergeRepository 账号合并仓储实现
+type accountMergeRepository struct {
+	db *gorm.DB
+}
+
+// NewAccountMergeRepository 创建账号合并仓储
+func NewAccountMergeRepository(db *gorm.DB) AccountMergeRepository {
+	return &accountMergeRepository{db: db}
+}
+
+// Merge 在同一事务中将源账号的数据迁移到目标账号，并写入合并记录；
+// 合并后的收货地址数不超过maxAddresses，0表示不限
+func (r *accountMergeRepository) Merge(sourceID, targetID uint64, maxAddresses int, log *model.AccountMergeLog) error {
+	if sourceID == targetID {
+		return errors.New("cannot merge account into itself")
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var source, target model.User
+		if err := tx.First(&source, sourceID).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&target, targetID).Error; err != nil {
+			return err
+		}
+
+		var conflicts []string
+
+		// 认证信息：同类型以目标账号为准
+		movedAuths, authConflicts, err := r.mergeAuths(tx, sourceID, targetID)
+		if err != nil {
+			return err
+		}
+		conflicts = append(conflicts, authConflicts...)
+
+		// 微信openid迁移到目标账号
+		if source.WechatOpenID != "" {
+			if target.WechatOpenID == "" {
+				if err := tx.Model(&model.User{}).Where("id = ?", sourceID).Update("wechat_openid", "").Error; err != nil {
+					return err
+				}
+				if err := tx.Model(&model.User{}).Where("id = ?", targetID).Update("wechat_openid", source.WechatOpenID).Error; err != nil {
+					return err
+				}
+			} else if target.WechatOpenID != source.WechatOpenID {
+				conflicts = append(conflicts, "wechat_openid: kept target binding")
+			}
+		}
+
+		// 订单整体迁移，支付记录随订单关联
+		result := tx.Model(&model.Order{}).Where("user_id = ?", sourceID).Update("user_id", targetID)
+		if result.Error != nil {
+			return result.Error
+		}
+		log.MovedOrders = int(result.RowsAffected)
+
+		movedCart, cartConflicts, err := r.mergeCartItems(tx, sourceID, targetID)
+		if err != nil {
+			return err
+		}
+		log.MovedCartItems = movedCart
+		conflicts = append(conflicts, cartConflicts...)
+
+		movedAddresses, addressConflicts, err := r.mergeAddresses(tx, sourceID, targetID, maxAddresses)
+		if err != nil {
+			return err
+		}
+		log.MovedAddresses = movedAddresses
+		conflicts = append(conflicts, addressConflicts...)
+
+		if err := r.mergePoints(tx, sourceID, targetID); err != nil {
+			return err
+		}
+		if err := r.mergeFavorites(tx, sourceID, targetID); err != nil {
+			return err
+		}
+		if err := r.mergeFootprints(tx, sourceID, targetID); err != nil {
+			return err
+		}
+
+		// 通知、登录日志和会话记录整体迁移，源账号的会话同时标记为已注销
+		if err := tx.Model(&model.Notification{}).Where("user_id = ?", sourceID).Update("user_id", targetID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.LoginLog{}).Where("user_id = ?", sourceID).Update("user_id", targetID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.UserSession{}).Where("user_id = ?", sourceID).Updates(map[string]interface{}{
+			"user_id": targetID,
+			"status":  0,
+		}).Error; err != nil {
+			return err
+		}
+
+		profileConflicts, err := r.mergeProfile(tx, sourceID, targetID)
+		if err != nil {
+			return err
+		}
+		conflicts = append(conflicts, profileConflicts...)
+
+		// 停用并删除源账号
+		if err := tx.Model(&model.User{}).Where("id = ?", sourceID).Update("status", 0).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&model.User{}, sourceID).Error; err != nil {
+			return err
+		}
+
+		log.SourceUserID = sourceID
+		log.TargetUserID = targetID
+		log.MovedAuths = movedAuths
+		if len(conflicts) > 0 {
+			data, _ := json.Marshal(conflicts)
+			log.Conflicts = string(data)
+		}
+		return tx.Create(log).Error
+	})
+}
+
+// GetLogsByUserID 获取与用户相关的合并记录
+func (r *accountMergeRepository) GetLogsByUserID(userID uint64) ([]*model.AccountMergeLog, error) {
+	var logs []*model.AccountMergeLog
+	err := r.db.Where("source_user_id = ? OR target_user_id = ?", userID, userID).
+		Order("created_at DESC").Find(&logs).Error
+	return logs, err
+}
+
+// mergeAuths 迁移认证信息
+func (r *accountMergeRepository) mergeAuths(tx *gorm.DB, sourceID, targetID uint64) (int, []string, error) {
+	var sourceAuths []model.UserAuth
+	if err := tx.Where("user_id = ?", sourceID).Find(&sourceAuths).Error; err != nil {
+		return 0, nil, err
+	}
+
+	moved := 0
+	var conflicts []string
+	for _, auth := range sourceAuths {
+		var count int64
+		if err := tx.Model(&model.UserAuth{}).Where("user_id = ? AND auth_type = ?", targetID, auth.AuthType).Count(&count).Error; err != nil {
+			return 0, nil, err
+		}
+
+		if count > 0 {
+			conflicts = append(conflicts, fmt.Sprintf("auth %s: kept target credential", auth.AuthType))
+			if err := tx.Delete(&model.UserAuth{}, auth.ID).Error; err != nil {
+				return 0, nil, err
+			}
+			continue
+		}
+
+		// 清理目标账号已软删除的同类型记录，避免唯一索引冲突
+		if err := tx.Unscoped().Where("user_id = ? AND auth_type = ?", targetID, auth.AuthType).Delete(&model.UserAuth{}).Error; err != nil {
+			return 0, nil, err
+		}
+		if err := tx.Model(&model.UserAuth{}).Where("id = ?", auth.ID).Update("user_id", targetID).Error; err != nil {
+			return 0, nil, err
+		}
+		moved++
+	}
+
+	return moved, conflicts, nil
+}
+
+// mergeCartItems 迁移购物车，相同商品规格合并数量
+func (r *accountMergeRepository) mergeCartItems(tx *gorm.DB, sourceID, targetID uint64) (int, []string, error) {
+	var sourceItems []model.CartItem
+	if err := tx.Where("user_id = ?", sourceID).Find(&sourceItems).Error; err != nil {
+		return 0, nil, err
+	}
+
+	moved := 0
+	var conflicts []string
+	for _, item := range sourceItems {
+		var existing model.CartItem
+		err := tx.Where("user_id = ? AND product_id = ? AND sku_id = ?", targetID, item.ProductID, item.SKUID).First(&existing).Error
+		if err == nil {
+			conflicts = append(conflicts, fmt.Sprintf("cart product %d sku %d: quantities combined", item.ProductID, item.SKUID))
+			if err := tx.Model(&existing).Update("quantity", existing.Quantity+item.Quantity).Error; err != nil {
+				return 0, nil, err
+			}
+			if err := tx.Delete(&model.CartItem{}, item.ID).Error; err != nil {
+				return 0, nil, err
+			}
+			moved++
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil, err
+		}
+
+		if err := tx.Unscoped().Where("user_id = ? AND product_id = ? AND sku_id = ?", targetID, item.ProductID, item.SKUID).
+			Delete(&model.CartItem{}).Error; err != nil {
+			return 0, nil, err
+		}
+		if err := tx.Model(&model.CartItem{}).Where("id = ?", item.ID).Update("user_id", targetID).Error; err != nil {
+			return 0, nil, err
+		}
+		moved++
+	}
+
+	return moved, conflicts, nil
+}
+
+// mergeAddresses 迁移收货地址，目标账号已有默认地址时取消源地址的默认标记；
+// 超出数量上限时优先保留源账号的默认地址和最近更新的地址，其余删除
+func (r *accountMergeRepository) mergeAddresses(tx *gorm.DB, sourceID, targetID uint64, maxAddresses int) (int, []string, error) {
+	var conflicts []string
+	if maxAddresses > 0 {
+		var targetCount int64
+		if err := tx.Model(&model.UserAddress{}).Where("user_id = ?", targetID).Count(&targetCount).Error; err != nil {
+			return 0, nil, err
+		}
+
+		var sourceIDs []uint64
+		if err := tx.Model(&model.UserAddress{}).Where("user_id = ?", sourceID).
+			Order("is_default DESC, updated_at DESC, id DESC").Pluck("id", &sourceIDs).Error; err != nil {
+			return 0, nil, err
+		}
+
+		keep := maxAddresses - int(targetCount)
+		if keep < 0 {
+			keep = 0
+		}
+		if len(sourceIDs) > keep {
+			dropped := sourceIDs[keep:]
+			if err := tx.Delete(&model.UserAddress{}, dropped).Error; err != nil {
+				return 0, nil, err
+			}
+			conflicts = append(conflicts, fmt.Sprintf("addresses: %d dropped over limit %d", len(dropped), maxAddresses))
+		}
+	}
+
+	// 先按默认标记挑选保留的地址，再取消源地址的默认标记
+	var defaultCount int64
+	if err := tx.Model(&model.UserAddress{}).Where("user_id = ? AND is_default = ?", targetID, 1).Count(&defaultCount).Error; err != nil {
+		return 0, nil, err
+	}
+	if defaultCount > 0 {
+		if err := tx.Model(&model.UserAddress{}).Where("user_id = ?", sourceID).Update("is_default", 0).Error; err != nil {
+			return 0, nil, err
+		}
+	}
+
+	result := tx.Model(&model.UserAddress{}).Where("user_id = ?", sourceID).Update("user_id", targetID)
+	return int(result.RowsAffected), conflicts, result.Error
+}
+
+// mergePoints 迁移积分流水并合并积分账户，等级按合并后的累计消费重新计算
+func (r *accountMergeRepository) mergePoints(tx *gorm.DB, sourceID, targetID uint64) error {
+	var source model.UserPoints
+	if err := tx.Where("user_id = ?", sourceID).First(&source).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if err := tx.Model(&model.PointsLedger{}).Where("user_id = ?", sourceID).Update("user_id", targetID).Error; err != nil {
+		return err
+	}
+
+	var target model.UserPoints
+	err := tx.Where("user_id = ?", targetID).First(&target).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return tx.Model(&model.UserPoints{}).Where("id = ?", source.ID).Update("user_id", targetID).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	totalSpend := target.TotalSpend + source.TotalSpend
+	var level model.MemberLevel
+	levelID := target.LevelID
+	err = tx.Where("status = ? AND min_spend <= ?", 1, totalSpend).Order("min_spend DESC, level DESC").First(&level).Error
+	if err == nil {
+		levelID = level.ID
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	if err := tx.Model(&target).Updates(map[string]interface{}{
+		"balance":      target.Balance + source.Balance,
+		"total_earned": target.TotalEarned + source.TotalEarned,
+		"total_spend":  totalSpend,
+		"level_id":     levelID,
+	}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Delete(&model.UserPoints{}, source.ID).Error
+}
+
+// mergeFavorites 迁移商品收藏，双方都收藏的商品只保留一条并扣减收藏数
+func (r *accountMergeRepository) mergeFavorites(tx *gorm.DB, sourceID, targetID uint64) error {
+	var duplicates []uint64
+	if err := tx.Model(&model.ProductFavorite{}).Where("user_id = ?", sourceID).
+		Where("product_id IN (?)", tx.Model(&model.ProductFavorite{}).Select("product_id").Where("user_id = ?", targetID)).
+		Pluck("product_id", &duplicates).Error; err != nil {
+		return err
+	}
+
+	if len(duplicates) > 0 {
+		if err := tx.Unscoped().Where("user_id = ? AND product_id IN ?", sourceID, duplicates).Delete(&model.ProductFavorite{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Product{}).Where("id IN ? AND favorite_count > 0", duplicates).
+			UpdateColumn("favorite_count", gorm.Expr("favorite_count - 1")).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Model(&model.ProductFavorite{}).Where("user_id = ?", sourceID).Update("user_id", targetID).Error
+}
+
+// mergeFootprints 迁移浏览足迹，双方同一天浏览过同一商品时保留较晚的浏览时间
+func (r *accountMergeRepository) mergeFootprints(tx *gorm.DB, sourceID, targetID uint64) error {
+	var duplicates []struct {
+		SourceID uint64
+		TargetID uint64
+		ViewedAt time.Time
+	}
+	if err := tx.Table("user_footprints AS s").
+		Select("s.id AS source_id, t.id AS target_id, s.viewed_at").
+		Joins("JOIN user_footprints AS t ON t.product_id = s.product_id AND t.view_date = s.view_date AND t.user_id = ?", targetID).
+		Where("s.user_id = ?", sourceID).
+		Scan(&duplicates).Error; err != nil {
+		return err
+	}
+
+	for _, duplicate := range duplicates {
+		if err := tx.Model(&model.UserFootprint{}).Where("id = ? AND viewed_at < ?", duplicate.TargetID, duplicate.ViewedAt).
+			Update("viewed_at", duplicate.ViewedAt).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(&model.UserFootprint{}, duplicate.SourceID).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Model(&model.UserFootprint{}).Where("user_id = ?", sourceID).Update("user_id", targetID).Error
+}
+
+// mergeProfile 合并用户资料，目标账号已有的字段保持不变
+func (r *accountMergeRepository) mergeProfile(tx *gorm.DB, sourceID, targetID uint64) ([]string, error) {
+	var sourceProfile model.UserProfile
+	if err := tx.Where("user_id = ?", sourceID).First(&sourceProfile).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var targetProfile model.UserProfile
+	err := tx.Where("user_id = ?", targetID).First(&targetProfile).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, tx.Model(&model.UserProfile{}).Where("id = ?", sourceProfile.ID).Update("user_id", targetID).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []string
+	updates := map[string]interface{}{}
+	if targetProfile.Nickname == "" {
+		updates["nickname"] = sourceProfile.Nickname
+	} else if sourceProfile.Nickname != "" && sourceProfile.Nickname != targetProfile.Nickname {
+		conflicts = append(conflicts, "profile nickname: kept target value")
+	}
+	if targetProfile.Avatar == "" {
+		updates["avatar"] = sourceProfile.Avatar
+	} else if sourceProfile.Avatar != "" && sourceProfile.Avatar != targetProfile.Avatar {
+		conflicts = append(conflicts, "profile avatar: kept target value")
+	}
+	if targetProfile.Gender == 0 {
+		updates["gender"] = sourceProfile.Gender
+	}
+	if targetProfile.Birthday.IsZero() {
+		updates["birthday"] = sourceProfile.Birthday
+	}
+
+	if len(updates) > 0 {
+		if err := tx.Model(&targetProfile).Updates(updates).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return conflicts, tx.Delete(&model.UserProfile{}, sourceProfile.ID).Error
+}
@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+)
+
+// newMergeTestDB 创建账号合并测试数据库，返回源账号与目标账号
+func newMergeTestDB(t *testing.T) (*gorm.DB, *model.User, *model.User) {
+	t.Helper()
+
+	db := newTestDB(t,
+		&model.User{},
+		&model.UserProfile{},
+		&model.UserAuth{},
+		&model.UserAddress{},
+		&model.Order{},
+		&model.CartItem{},
+		&model.UserPoints{},
+		&model.PointsLedger{},
+		&model.MemberLevel{},
+		&model.ProductFavorite{},
+		&model.UserFootprint{},
+		&model.Notification{},
+		&model.LoginLog{},
+		&model.UserSession{},
+		&model.AccountMergeLog{},
+	)
+
+	source := &model.User{Username: "source", WechatOpenID: "wx-source", Status: 1}
+	target := &model.User{Username: "target", Phone: "13800000001", WechatOpenID: "wx-target", Status: 1}
+	for _, user := range []*model.User{source, target} {
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	return db, source, target
+}
+
+// mustCreate 创建测试记录
+func mustCreate(t *testing.T, db *gorm.DB, values ...interface{}) {
+	t.Helper()
+
+	for _, value := range values {
+		if err := db.Create(value).Error; err != nil {
+			t.Fatalf("create %T: %v", value, err)
+		}
+	}
+}
+
+func TestAccountMergeConflicts(t *testing.T) {
+	today := time.Now().Truncate(24 * time.Hour)
+
+	cases := []struct {
+		name         string
+		maxAddresses int
+		setup        func(t *testing.T, db *gorm.DB, source, target *model.User)
+		check        func(t *testing.T, db *gorm.DB, source, target *model.User)
+		wantConflict string
+	}{
+		{
+			name: "same auth type keeps target credential",
+			setup: func(t *testing.T, db *gorm.DB, source, target *model.User) {
+				mustCreate(t, db,
+					&model.UserAuth{UserID: source.ID, AuthType: "password", AuthKey: "source", PasswordHash: "source"},
+					&model.UserAuth{UserID: source.ID, AuthType: "wechat", AuthKey: "wx-source"},
+					&model.UserAuth{UserID: target.ID, AuthType: "password", AuthKey: "13800000001", PasswordHash: "target"},
+				)
+			},
+			check: func(t *testing.T, db *gorm.DB, source, target *model.User) {
+				var auths []model.UserAuth
+				db.Where("user_id = ?", target.ID).Order("auth_type").Find(&auths)
+				if len(auths) != 2 || auths[0].PasswordHash != "target" || auths[1].AuthType != "wechat" {
+					t.Errorf("target auths = %+v", auths)
+				}
+			},
+			wantConflict: "auth password: kept target credential",
+		},
+		{
+			name: "cart quantities combined",
+			setup: func(t *testing.T, db *gorm.DB, source, target *model.User) {
+				mustCreate(t, db,
+					&model.CartItem{UserID: source.ID, ProductID: 1, SKUID: 2, Quantity: 2},
+					&model.CartItem{UserID: source.ID, ProductID: 3, Quantity: 1},
+					&model.CartItem{UserID: target.ID, ProductID: 1, SKUID: 2, Quantity: 3},
+				)
+			},
+			check: func(t *testing.T, db *gorm.DB, source, target *model.User) {
+				var items []model.CartItem
+				db.Where("user_id = ?", target.ID).Order("product_id").Find(&items)
+				if len(items) != 2 || items[0].Quantity != 5 || items[1].ProductID != 3 {
+					t.Errorf("target cart = %+v", items)
+				}
+			},
+			wantConflict: "quantities combined",
+		},
+		{
+			name:         "addresses over limit keep source default",
+			maxAddresses: 2,
+			setup: func(t *testing.T, db *gorm.DB, source, target *model.User) {
+				mustCreate(t, db,
+					&model.UserAddress{UserID: target.ID, Name: "t", Phone: "1", Province: "p", City: "c", District: "d", Address: "target", IsDefault: 1},
+					&model.UserAddress{UserID: source.ID, Name: "s", Phone: "1", Province: "p", City: "c", District: "d", Address: "source default", IsDefault: 1},
+					&model.UserAddress{UserID: source.ID, Name: "s", Phone: "1", Province: "p", City: "c", District: "d", Address: "source other"},
+				)
+			},
+			check: func(t *testing.T, db *gorm.DB, source, target *model.User) {
+				var addresses []model.UserAddress
+				db.Where("user_id = ?", target.ID).Order("id").Find(&addresses)
+				if len(addresses) != 2 || addresses[1].Address != "source default" {
+					t.Fatalf("target addresses = %+v", addresses)
+				}
+				if addresses[0].IsDefault != 1 || addresses[1].IsDefault != 0 {
+					t.Errorf("only the target default should remain default: %+v", addresses)
+				}
+			},
+			wantConflict: "addresses: 1 dropped over limit 2",
+		},
+		{
+			name: "profile keeps target values and fills blanks",
+			setup: func(t *testing.T, db *gorm.DB, source, target *model.User) {
+				mustCreate(t, db,
+					&model.UserProfile{UserID: source.ID, Nickname: "source", Avatar: "source.png", Gender: 2},
+					&model.UserProfile{UserID: target.ID, Nickname: "target"},
+				)
+			},
+			check: func(t *testing.T, db *gorm.DB, source, target *model.User) {
+				var profiles []model.UserProfile
+				db.Where("user_id IN ?", []uint64{source.ID, target.ID}).Find(&profiles)
+				if len(profiles) != 1 || profiles[0].Nickname != "target" || profiles[0].Avatar != "source.png" || profiles[0].Gender != 2 {
+					t.Errorf("profiles = %+v", profiles)
+				}
+			},
+			wantConflict: "profile nickname: kept target value",
+		},
+		{
+			name: "duplicate favorites and footprints",
+			setup: func(t *testing.T, db *gorm.DB, source, target *model.User) {
+				product := &model.Product{CategoryID: 1, Name: "p", Price: 1, Status: 1, FavoriteCount: 2}
+				mustCreate(t, db, product)
+				mustCreate(t, db,
+					&model.ProductFavorite{UserID: source.ID, ProductID: product.ID},
+					&model.ProductFavorite{UserID: target.ID, ProductID: product.ID},
+					&model.UserFootprint{UserID: source.ID, ProductID: product.ID, ViewDate: today, ViewedAt: today.Add(2 * time.Hour)},
+					&model.UserFootprint{UserID: target.ID, ProductID: product.ID, ViewDate: today, ViewedAt: today.Add(time.Hour)},
+				)
+			},
+			check: func(t *testing.T, db *gorm.DB, source, target *model.User) {
+				var favorites, footprints int64
+				db.Model(&model.ProductFavorite{}).Count(&favorites)
+				db.Model(&model.UserFootprint{}).Count(&footprints)
+				if favorites != 1 || footprints != 1 {
+					t.Errorf("favorites = %d, footprints = %d, want 1 and 1", favorites, footprints)
+				}
+				var product model.Product
+				db.First(&product)
+				if product.FavoriteCount != 1 {
+					t.Errorf("favorite count = %d, want 1", product.FavoriteCount)
+				}
+				var footprint model.UserFootprint
+				db.First(&footprint)
+				if footprint.UserID != target.ID || !footprint.ViewedAt.Equal(today.Add(2*time.Hour)) {
+					t.Errorf("footprint = %+v", footprint)
+				}
+			},
+		},
+		{
+			name: "points combined and level recalculated",
+			setup: func(t *testing.T, db *gorm.DB, source, target *model.User) {
+				mustCreate(t, db,
+					&model.MemberLevel{Name: "normal", Level: 1, PointsRatio: 1, Status: 1},
+					&model.MemberLevel{Name: "silver", Level: 2, MinSpend: 100, PointsRatio: 1, Status: 1},
+					&model.UserPoints{UserID: source.ID, Balance: 30, TotalEarned: 40, TotalSpend: 60},
+					&model.UserPoints{UserID: target.ID, Balance: 20, TotalEarned: 20, TotalSpend: 50},
+				)
+			},
+			check: func(t *testing.T, db *gorm.DB, source, target *model.User) {
+				var accounts []model.UserPoints
+				db.Find(&accounts)
+				if len(accounts) != 1 || accounts[0].UserID != target.ID || accounts[0].Balance != 50 ||
+					accounts[0].TotalEarned != 60 || accounts[0].TotalSpend != 110 || accounts[0].LevelID != 2 {
+					t.Errorf("points accounts = %+v", accounts)
+				}
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, source, target := newMergeTestDB(t)
+			tc.setup(t, db, source, target)
+
+			log := &model.AccountMergeLog{Phone: target.Phone}
+			if err := NewAccountMergeRepository(db).Merge(source.ID, target.ID, tc.maxAddresses, log); err != nil {
+				t.Fatalf("Merge: %v", err)
+			}
+			tc.check(t, db, source, target)
+
+			if tc.wantConflict != "" && !strings.Contains(log.Conflicts, tc.wantConflict) {
+				t.Errorf("conflicts = %s, want %q", log.Conflicts, tc.wantConflict)
+			}
+			if err := db.First(&model.User{}, source.ID).Error; err == nil {
+				t.Error("source user should be deleted")
+			}
+		})
+	}
+}
+
+func TestAccountMergeMovesOrdersAndSessions(t *testing.T) {
+	db, source, target := newMergeTestDB(t)
+	mustCreate(t, db,
+		&model.Order{OrderNo: "ORD1", UserID: source.ID, PayAmount: 10},
+		&model.UserSession{UserID: source.ID, SessionID: "s1", Status: 1},
+	)
+
+	repo := NewAccountMergeRepository(db)
+	if err := repo.Merge(source.ID, source.ID, 0, &model.AccountMergeLog{}); err == nil {
+		t.Error("merging an account into itself should fail")
+	}
+
+	log := &model.AccountMergeLog{Phone: target.Phone}
+	if err := repo.Merge(source.ID, target.ID, 0, log); err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+	if log.MovedOrders != 1 || log.SourceUserID != source.ID || log.TargetUserID != target.ID {
+		t.Errorf("merge log = %+v", log)
+	}
+
+	var session model.UserSession
+	db.Where("session_id = ?", "s1").First(&session)
+	if session.UserID != target.ID || session.Status != 0 {
+		t.Errorf("session = %+v, want moved and revoked", session)
+	}
+
+	logs, err := repo.GetLogsByUserID(source.ID)
+	if err != nil || len(logs) != 1 || logs[0].Phone != target.Phone {
+		t.Errorf("logs = %+v, err %v", logs, err)
+	}
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+)
+
+// AdminAuditLogQuery 审计日志查询条件
+type AdminAuditLogQuery struct {
+	AdminID    uint64
+	Action     string
+	TargetType string
+	TargetID   string
+	RequestID  string
+	StartTime  *time.Time
+	EndTime    *time.Time
+}
+
+// AdminAuditLogRepository 审计日志仓储接口
+type AdminAuditLogRepository interface {
+	Create(log *model.AdminAuditLog) error
+	GetByID(id uint64) (*model.AdminAuditLog, error)
+	List(query *AdminAuditLogQuery, page, pageSize int) ([]*model.AdminAuditLog, int64, error)
+}
+
+// adminAuditLogRepository 审计日志仓储实现
+type adminAuditLogRepository struct {
+	db *gorm.DB
+}
+
+// NewAdminAuditLogRepository 创建审计日志仓储
+func NewAdminAuditLogRepository(db *gorm.DB) AdminAuditLogRepository {
+	return &adminAuditLogRepository{db: db}
+}
+
+// Create 创建审计日志
+func (r *adminAuditLogRepository) Create(log *model.AdminAuditLog) error {
+	return r.db.Create(log).Error
+}
+
+// GetByID 根据ID获取审计日志
+func (r *adminAuditLogRepository) GetByID(id uint64) (*model.AdminAuditLog, error) {
+	var log model.AdminAuditLog
+	err := r.db.First(&log, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
+
+// List 按条件查询审计日志
+func (r *adminAuditLogRepository) List(query *AdminAuditLogQuery, page, pageSize int) ([]*model.AdminAuditLog, int64, error) {
+	var logs []*model.AdminAuditLog
+	var total int64
+
+	db := r.db.Model(&model.AdminAuditLog{})
+	if query.AdminID > 0 {
+		db = db.Where("admin_id = ?", query.AdminID)
+	}
+	if query.Action != "" {
+		db = db.Where("action = ?", query.Action)
+	}
+	if query.TargetType != "" {
+		db = db.Where("target_type = ?", query.TargetType)
+	}
+	if query.TargetID != "" {
+		db = db.Where("target_id = ?", query.TargetID)
+	}
+	if query.RequestID != "" {
+		db = db.Where("request_id = ?", query.RequestID)
+	}
+	if query.StartTime != nil {
+		db = db.Where("created_at >= ?", *query.StartTime)
+	}
+	if query.EndTime != nil {
+		db = db.Where("created_at <= ?", *query.EndTime)
+	}
+
+	// 计算总数
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 分页查询，列表不返回快照大字段
+	offset := (page - 1) * pageSize
+	err := db.Omit("before", "after").
+		Offset(offset).Limit(pageSize).
+		Order("id DESC").
+		Find(&logs).Error
+
+	return logs, total, err
+}
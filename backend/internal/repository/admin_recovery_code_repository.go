@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+)
+
+// AdminRecoveryCodeRepository 管理员恢复码仓储接口
+type AdminRecoveryCodeRepository interface {
+	Replace(adminID uint64, codes []*model.AdminRecoveryCode) error
+	DeleteByAdminID(adminID uint64) error
+	Consume(adminID uint64, codeHash string) (bool, error)
+	CountUnused(adminID uint64) (int64, error)
+}
+
+// adminRecoveryCodeRepository 管理员恢复码仓储实现
+type adminRecoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+// NewAdminRecoveryCodeRepository 创建管理员恢复码仓储
+func NewAdminRecoveryCodeRepository(db *gorm.DB) AdminRecoveryCodeRepository {
+	return &adminRecoveryCodeRepository{db: db}
+}
+
+// Replace 作废旧恢复码并保存新的一组
+func (r *adminRecoveryCodeRepository) Replace(adminID uint64, codes []*model.AdminRecoveryCode) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("admin_id = ?", adminID).Delete(&model.AdminRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// DeleteByAdminID 删除管理员所有恢复码
+func (r *adminRecoveryCodeRepository) DeleteByAdminID(adminID uint64) error {
+	return r.db.Where("admin_id = ?", adminID).Delete(&model.AdminRecoveryCode{}).Error
+}
+
+// Consume 使用恢复码，每个恢复码仅可使用一次
+func (r *adminRecoveryCodeRepository) Consume(adminID uint64, codeHash string) (bool, error) {
+	result := r.db.Model(&model.AdminRecoveryCode{}).
+		Where("admin_id = ? AND code_hash = ? AND used_at IS NULL", adminID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CountUnused 统计未使用的恢复码数量
+func (r *adminRecoveryCodeRepository) CountUnused(adminID uint64) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.AdminRecoveryCode{}).Where("admin_id = ? AND used_at IS NULL", adminID).Count(&count).Error
+	return count, err
+}
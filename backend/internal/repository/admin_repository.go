@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+	"mall/pkg/utils"
+)
+
+// AdminRepository 管理员仓储接口
+type AdminRepository interface {
+	GetByID(id uint64) (*model.Admin, error)
+	GetByUsername(username string) (*model.Admin, error)
+	Update(admin *model.Admin) error
+	UpdateLastLogin(id uint64, t time.Time) error
+	UpdateTwoFactor(id uint64, enabled bool, secret string) error
+}
+
+// adminRepository 管理员仓储实现
+type adminRepository struct {
+	db *gorm.DB
+}
+
+// NewAdminRepository 创建管理员仓储
+func NewAdminRepository(db *gorm.DB) AdminRepository {
+	return &adminRepository{db: db}
+}
+
+// GetByID 根据ID获取管理员
+func (r *adminRepository) GetByID(id uint64) (*model.Admin, error) {
+	var admin model.Admin
+	err := r.db.First(&admin, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &admin, nil
+}
+
+// GetByUsername 根据用户名获取管理员
+func (r *adminRepository) GetByUsername(username string) (*model.Admin, error) {
+	var admin model.Admin
+	err := r.db.Where("username = ?", username).First(&admin).Error
+	if err != nil {
+		return nil, err
+	}
+	return &admin, nil
+}
+
+// Update 更新管理员
+func (r *adminRepository) Update(admin *model.Admin) error {
+	return r.db.Save(admin).Error
+}
+
+// UpdateLastLogin 更新最后登录时间
+func (r *adminRepository) UpdateLastLogin(id uint64, t time.Time) error {
+	return r.db.Model(&model.Admin{}).Where("id = ?", id).Update("last_login_at", t).Error
+}
+
+// UpdateTwoFactor 更新两步验证状态
+func (r *adminRepository) UpdateTwoFactor(id uint64, enabled bool, secret string) error {
+	// map更新不经过序列化器，需手动加密
+	encryptedSecret, err := utils.EncryptField(secret)
+	if err != nil {
+		return err
+	}
+
+	return r.db.Model(&model.Admin{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"two_factor_enabled": enabled,
+		"totp_secret":        encryptedSecret,
+	}).Error
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"mall/internal/model"
+)
+
+// FootprintRecord 商品最近一次浏览记录
+type FootprintRecord struct {
+	ProductID uint64
+	ViewedAt  time.Time
+}
+
+// FootprintRepository 浏览足迹仓储接口
+type FootprintRepository interface {
+	Upsert(footprints []*model.UserFootprint) error
+	GetLatest(userID uint64, limit int) ([]*FootprintRecord, error)
+	DeleteByProducts(userID uint64, productIDs []uint64) error
+	DeleteAll(userID uint64) error
+	DeleteBefore(before time.Time) (int64, error)
+	TrimToLimit(userID uint64, limit int) error
+}
+
+// footprintRepository 浏览足迹仓储实现
+type footprintRepository struct {
+	db *gorm.DB
+}
+
+// NewFootprintRepository 创建浏览足迹仓储
+func NewFootprintRepository(db *gorm.DB) FootprintRepository {
+	return &footprintRepository{db: db}
+}
+
+// Upsert 批量写入足迹，同一用户商品当天已有记录时更新浏览时间
+func (r *footprintRepository) Upsert(footprints []*model.UserFootprint) error {
+	if len(footprints) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "product_id"}, {Name: "view_date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"viewed_at", "updated_at"}),
+	}).CreateInBatches(footprints, 100).Error
+}
+
+// GetLatest 获取用户每个商品最近一次浏览时间，按浏览时间倒序
+func (r *footprintRepository) GetLatest(userID uint64, limit int) ([]*FootprintRecord, error) {
+	var records []*FootprintRecord
+	err := r.latestQuery(userID).
+		Limit(limit).
+		Scan(&records).Error
+	return records, err
+}
+
+// latestQuery 每个商品只取最近一天的足迹，直接读取viewed_at列以保留时间类型
+func (r *footprintRepository) latestQuery(userID uint64) *gorm.DB {
+	latest := r.db.Model(&model.UserFootprint{}).
+		Select("product_id, MAX(view_date) AS view_date").
+		Where("user_id = ?", userID).
+		Group("product_id")
+	return r.db.Table("user_footprints AS fp").
+		Select("fp.product_id, fp.viewed_at").
+		Joins("JOIN (?) AS latest ON latest.product_id = fp.product_id AND latest.view_date = fp.view_date", latest).
+		Where("fp.user_id = ?", userID).
+		Order("fp.viewed_at DESC")
+}
+
+// DeleteByProducts 删除用户指定商品的足迹
+func (r *footprintRepository) DeleteByProducts(userID uint64, productIDs []uint64) error {
+	return r.db.Unscoped().Where("user_id = ? AND product_id IN ?", userID, productIDs).Delete(&model.UserFootprint{}).Error
+}
+
+// DeleteAll 清空用户足迹
+func (r *footprintRepository) DeleteAll(userID uint64) error {
+	return r.db.Unscoped().Where("user_id = ?", userID).Delete(&model.UserFootprint{}).Error
+}
+
+// DeleteBefore 删除超过保留期的足迹
+func (r *footprintRepository) DeleteBefore(before time.Time) (int64, error) {
+	result := r.db.Unscoped().Where("view_date < ?", before).Delete(&model.UserFootprint{})
+	return result.RowsAffected, result.Error
+}
+
+// TrimToLimit 只保留用户最近浏览的limit个商品，更早的足迹删除
+func (r *footprintRepository) TrimToLimit(userID uint64, limit int) error {
+	if limit <= 0 {
+		return nil
+	}
+
+	var cutoff []*FootprintRecord
+	err := r.latestQuery(userID).
+		Offset(limit - 1).
+		Limit(1).
+		Scan(&cutoff).Error
+	if err != nil || len(cutoff) == 0 {
+		return err
+	}
+
+	return r.db.Unscoped().Where("user_id = ? AND viewed_at < ?", userID, cutoff[0].ViewedAt).Delete(&model.UserFootprint{}).Error
+}
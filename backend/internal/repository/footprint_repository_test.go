@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"mall/internal/model"
+)
+
+func TestFootprintLatest(t *testing.T) {
+	db := newTestDB(t, &model.UserFootprint{})
+	repo := NewFootprintRepository(db)
+	today := time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local)
+	at := func(days, hour int) time.Time { return today.AddDate(0, 0, days).Add(time.Duration(hour) * time.Hour) }
+
+	// 商品1先后两天浏览，以最近一天为准
+	err := repo.Upsert([]*model.UserFootprint{
+		{UserID: 1, ProductID: 1, ViewDate: at(-3, 0), ViewedAt: at(-3, 9)},
+		{UserID: 1, ProductID: 2, ViewDate: at(-2, 0), ViewedAt: at(-2, 9)},
+		{UserID: 1, ProductID: 3, ViewDate: at(-1, 0), ViewedAt: at(-1, 9)},
+		{UserID: 1, ProductID: 1, ViewDate: at(0, 0), ViewedAt: at(0, 9)},
+		{UserID: 2, ProductID: 1, ViewDate: at(-5, 0), ViewedAt: at(-5, 9)},
+	})
+	if err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	// 同一天再次浏览只更新浏览时间
+	if err := repo.Upsert([]*model.UserFootprint{{UserID: 1, ProductID: 3, ViewDate: at(-1, 0), ViewedAt: at(-1, 10)}}); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+
+	steps := []struct {
+		name     string
+		trim     int
+		want     []uint64
+		wantRows int64
+	}{
+		{"no trim", 0, []uint64{1, 3, 2}, 4},
+		{"older days of kept products trimmed too", 2, []uint64{1, 3}, 2},
+		{"trim to one product", 1, []uint64{1}, 1},
+	}
+	for _, step := range steps {
+		if err := repo.TrimToLimit(1, step.trim); err != nil {
+			t.Fatalf("%s: TrimToLimit: %v", step.name, err)
+		}
+		records, err := repo.GetLatest(1, 10)
+		if err != nil {
+			t.Fatalf("%s: GetLatest: %v", step.name, err)
+		}
+		var ids []uint64
+		for _, record := range records {
+			ids = append(ids, record.ProductID)
+		}
+		var rows int64
+		db.Model(&model.UserFootprint{}).Where("user_id = ?", 1).Count(&rows)
+		if len(ids) != len(step.want) || rows != step.wantRows {
+			t.Fatalf("%s: products = %v, rows = %d", step.name, ids, rows)
+		}
+		for i := range ids {
+			if ids[i] != step.want[i] {
+				t.Errorf("%s: products = %v, want %v", step.name, ids, step.want)
+				break
+			}
+		}
+		if !records[0].ViewedAt.Equal(at(0, 9)) {
+			t.Errorf("%s: latest viewed at = %v", step.name, records[0].ViewedAt)
+		}
+	}
+
+	// 其他用户的足迹不受影响
+	if records, _ := repo.GetLatest(2, 10); len(records) != 1 {
+		t.Errorf("other user footprints = %d, want 1", len(records))
+	}
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"mall/internal/model"
+)
+
+// ErrInsufficientStock 出库后库存将小于0
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// TxFunc 在仓储事务内执行的附加操作，如库存变动，使其与主数据写入同时提交或回滚
+type TxFunc func(tx *gorm.DB) error
+
+// InventoryChange 库存变动，SetTo不为空时将库存设为该值，否则按Entry.Delta增减
+type InventoryChange struct {
+	Entry *model.InventoryLedger
+	SetTo *int
+}
+
+// InventoryQuery 库存流水查询条件
+type InventoryQuery struct {
+	ProductID uint64
+	SKUID     uint64
+	Reason    string
+	RefID     string
+}
+
+// InventoryRepository 库存仓储接口，商品与SKU库存只能通过此仓储变更
+type InventoryRepository interface {
+	Apply(tx *gorm.DB, changes []*InventoryChange) error
+	List(query *InventoryQuery, page, pageSize int) ([]*model.InventoryLedger, int64, error)
+}
+
+// inventoryRepository 库存仓储实现
+type inventoryRepository struct {
+	db *gorm.DB
+}
+
+// NewInventoryRepository 创建库存仓储
+func NewInventoryRepository(db *gorm.DB) InventoryRepository {
+	return &inventoryRepository{db: db}
+}
+
+// Apply 在同一事务中应用一组库存变动并写入流水，任一出库不足时全部回滚；
+// tx为调用方事务时在其中执行，为nil时单独开启事务；
+// 幂等键已存在的变动跳过，设置值与当前库存相同的变动不记录
+func (r *inventoryRepository) Apply(tx *gorm.DB, changes []*InventoryChange) error {
+	if tx == nil {
+		return r.db.Transaction(func(tx *gorm.DB) error {
+			return applyInventoryChanges(tx, changes)
+		})
+	}
+	return applyInventoryChanges(tx, changes)
+}
+
+// applyInventoryChanges 在事务中按顺序加锁并应用库存变动
+func applyInventoryChanges(tx *gorm.DB, changes []*InventoryChange) error {
+	// 按商品、SKU顺序加锁，避免并发事务互相等待
+	ordered := make([]*InventoryChange, len(changes))
+	copy(ordered, changes)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, b := ordered[i].Entry, ordered[j].Entry
+		if a.ProductID != b.ProductID {
+			return a.ProductID < b.ProductID
+		}
+		return a.SKUID < b.SKUID
+	})
+
+	for _, change := range ordered {
+		entry := change.Entry
+
+		if entry.BizKey != nil {
+			var count int64
+			if err := tx.Model(&model.InventoryLedger{}).Where("biz_key = ?", *entry.BizKey).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				continue
+			}
+		}
+
+		stock, err := lockStock(tx, entry.ProductID, entry.SKUID)
+		if err != nil {
+			return err
+		}
+
+		if change.SetTo != nil {
+			entry.Delta = *change.SetTo - stock
+		}
+		if entry.Delta == 0 {
+			continue
+		}
+		balance := stock + entry.Delta
+		if balance < 0 {
+			return fmt.Errorf("%w: product %d sku %d", ErrInsufficientStock, entry.ProductID, entry.SKUID)
+		}
+
+		if err := updateStock(tx, entry.ProductID, entry.SKUID, balance); err != nil {
+			return err
+		}
+		entry.Balance = balance
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List 分页查询库存流水
+func (r *inventoryRepository) List(query *InventoryQuery, page, pageSize int) ([]*model.InventoryLedger, int64, error) {
+	var entries []*model.InventoryLedger
+	var total int64
+
+	db := r.db.Model(&model.InventoryLedger{})
+	if query.ProductID > 0 {
+		db = db.Where("product_id = ?", query.ProductID)
+	}
+	if query.SKUID > 0 {
+		db = db.Where("sku_id = ?", query.SKUID)
+	}
+	if query.Reason != "" {
+		db = db.Where("reason = ?", query.Reason)
+	}
+	if query.RefID != "" {
+		db = db.Where("ref_id = ?", query.RefID)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := db.Order("id DESC").Offset(offset).Limit(pageSize).Find(&entries).Error
+	return entries, total, err
+}
+
+// lockStock 锁定商品或SKU并返回当前库存，已删除的商品或SKU仍可回补库存
+func lockStock(tx *gorm.DB, productID, skuID uint64) (int, error) {
+	locked := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"})
+	if skuID > 0 {
+		var sku model.ProductSKU
+		if err := locked.Select("id", "stock").Where("id = ? AND product_id = ?", skuID, productID).First(&sku).Error; err != nil {
+			return 0, err
+		}
+		return sku.Stock, nil
+	}
+
+	var product model.Product
+	if err := locked.Select("id", "stock").Where("id = ?", productID).First(&product).Error; err != nil {
+		return 0, err
+	}
+	return product.Stock, nil
+}
+
+// updateStock 写入商品或SKU库存
+func updateStock(tx *gorm.DB, productID, skuID uint64, stock int) error {
+	if skuID > 0 {
+		return tx.Unscoped().Model(&model.ProductSKU{}).Where("id = ?", skuID).Update("stock", stock).Error
+	}
+	return tx.Unscoped().Model(&model.Product{}).Where("id = ?", productID).Update("stock", stock).Error
+}
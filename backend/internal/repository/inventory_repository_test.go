@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+)
+
+// deduction 构造带幂等键的出库变动
+func deduction(productID, skuID uint64, qty int, bizKey string) *InventoryChange {
+	return &InventoryChange{Entry: &model.InventoryLedger{
+		ProductID: productID,
+		SKUID:     skuID,
+		Delta:     -qty,
+		Reason:    "order",
+		RefID:     bizKey,
+		BizKey:    &bizKey,
+	}}
+}
+
+// ledgerCount 统计流水条数
+func ledgerCount(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+
+	var count int64
+	if err := db.Model(&model.InventoryLedger{}).Count(&count).Error; err != nil {
+		t.Fatalf("count ledger: %v", err)
+	}
+	return count
+}
+
+func TestInventoryApplyWritesLedger(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewInventoryRepository(db)
+	product := createTestProduct(t, db, 10, 5)
+	sku := createTestSKU(t, db, product.ID, "SKU-1", 10, 8)
+
+	err := repo.Apply(nil, []*InventoryChange{
+		deduction(product.ID, sku.ID, 3, "order:1:sku"),
+		deduction(product.ID, 0, 2, "order:1:product"),
+	})
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	if stock := productStock(t, db, product.ID, sku.ID); stock != 5 {
+		t.Errorf("sku stock = %d, want 5", stock)
+	}
+	if stock := productStock(t, db, product.ID, 0); stock != 3 {
+		t.Errorf("product stock = %d, want 3", stock)
+	}
+
+	var entry model.InventoryLedger
+	db.Where("sku_id = ?", sku.ID).First(&entry)
+	if entry.Delta != -3 || entry.Balance != 5 {
+		t.Errorf("ledger entry = delta %d balance %d, want -3 and 5", entry.Delta, entry.Balance)
+	}
+}
+
+func TestInventoryApplyIsIdempotent(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewInventoryRepository(db)
+	product := createTestProduct(t, db, 10, 10)
+
+	for i := 0; i < 2; i++ {
+		if err := repo.Apply(nil, []*InventoryChange{deduction(product.ID, 0, 4, "order:1")}); err != nil {
+			t.Fatalf("Apply #%d: %v", i+1, err)
+		}
+	}
+
+	if stock := productStock(t, db, product.ID, 0); stock != 6 {
+		t.Errorf("stock = %d, want 6 after a repeated deduction", stock)
+	}
+	if count := ledgerCount(t, db); count != 1 {
+		t.Errorf("ledger entries = %d, want 1", count)
+	}
+
+	// 回补使用不同的幂等键，同样只执行一次
+	restock := func() *InventoryChange {
+		change := deduction(product.ID, 0, -4, "restock:1")
+		change.Entry.Reason = "cancel"
+		return change
+	}
+	repo.Apply(nil, []*InventoryChange{restock()})
+	repo.Apply(nil, []*InventoryChange{restock()})
+	if stock := productStock(t, db, product.ID, 0); stock != 10 {
+		t.Errorf("stock = %d, want 10 after a repeated restock", stock)
+	}
+}
+
+func TestInventoryApplyInsufficientStockRollsBack(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewInventoryRepository(db)
+	product := createTestProduct(t, db, 10, 10)
+	sku := createTestSKU(t, db, product.ID, "SKU-1", 10, 1)
+
+	err := repo.Apply(nil, []*InventoryChange{
+		deduction(product.ID, 0, 2, "order:1:product"),
+		deduction(product.ID, sku.ID, 2, "order:1:sku"),
+	})
+	if !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("Apply error = %v, want ErrInsufficientStock", err)
+	}
+
+	if stock := productStock(t, db, product.ID, 0); stock != 10 {
+		t.Errorf("product stock = %d, want 10 after rollback", stock)
+	}
+	if count := ledgerCount(t, db); count != 0 {
+		t.Errorf("ledger entries = %d, want 0 after rollback", count)
+	}
+
+	// 失败的幂等键未被占用，可以重试
+	if err := repo.Apply(nil, []*InventoryChange{deduction(product.ID, 0, 2, "order:1:product")}); err != nil {
+		t.Fatalf("retry Apply: %v", err)
+	}
+	if stock := productStock(t, db, product.ID, 0); stock != 8 {
+		t.Errorf("product stock = %d, want 8 after retry", stock)
+	}
+}
+
+func TestInventoryApplySetTo(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewInventoryRepository(db)
+	product := createTestProduct(t, db, 10, 10)
+
+	setTo := func(stock int) *InventoryChange {
+		return &InventoryChange{
+			Entry: &model.InventoryLedger{ProductID: product.ID, Reason: "adjust"},
+			SetTo: &stock,
+		}
+	}
+
+	if err := repo.Apply(nil, []*InventoryChange{setTo(25)}); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	var entry model.InventoryLedger
+	db.First(&entry)
+	if entry.Delta != 15 || entry.Balance != 25 {
+		t.Errorf("ledger entry = delta %d balance %d, want 15 and 25", entry.Delta, entry.Balance)
+	}
+
+	// 设置为当前值不记录流水
+	if err := repo.Apply(nil, []*InventoryChange{setTo(25)}); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if count := ledgerCount(t, db); count != 1 {
+		t.Errorf("ledger entries = %d, want 1", count)
+	}
+}
+
+func TestInventoryApplyJoinsCallerTransaction(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewInventoryRepository(db)
+	product := createTestProduct(t, db, 10, 10)
+
+	// 调用方事务回滚时库存与流水一并回滚
+	errAbort := errors.New("abort")
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := repo.Apply(tx, []*InventoryChange{deduction(product.ID, 0, 3, "order:1")}); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("transaction error = %v, want abort", err)
+	}
+	if stock := productStock(t, db, product.ID, 0); stock != 10 {
+		t.Errorf("stock = %d, want 10 after caller rollback", stock)
+	}
+	if count := ledgerCount(t, db); count != 0 {
+		t.Errorf("ledger entries = %d, want 0 after caller rollback", count)
+	}
+
+	// 库存不足时调用方的写入一并回滚
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Product{}).Where("id = ?", product.ID).Update("name", "已改名").Error; err != nil {
+			return err
+		}
+		return repo.Apply(tx, []*InventoryChange{deduction(product.ID, 0, 11, "order:2")})
+	})
+	if !errors.Is(err, ErrInsufficientStock) {
+		t.Fatalf("transaction error = %v, want ErrInsufficientStock", err)
+	}
+	var reloaded model.Product
+	db.First(&reloaded, product.ID)
+	if reloaded.Name != product.Name {
+		t.Errorf("product name = %q, caller write should be rolled back", reloaded.Name)
+	}
+}
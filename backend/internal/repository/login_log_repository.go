@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+	"mall/pkg/utils"
+)
+
+// LoginLogQuery 登录日志查询条件
+type LoginLogQuery struct {
+	UserID  uint64
+	Account string
+	IP      string
+	Result  *int8
+}
+
+// LoginLogRepository 登录日志仓储接口
+type LoginLogRepository interface {
+	Create(log *model.LoginLog) error
+	GetUserLogs(userID uint64, page, pageSize int) ([]*model.LoginLog, int64, error)
+	List(query *LoginLogQuery, page, pageSize int) ([]*model.LoginLog, int64, error)
+}
+
+// loginLogRepository 登录日志仓储实现
+type loginLogRepository struct {
+	db *gorm.DB
+}
+
+// NewLoginLogRepository 创建登录日志仓储
+func NewLoginLogRepository(db *gorm.DB) LoginLogRepository {
+	return &loginLogRepository{db: db}
+}
+
+// Create 创建登录日志
+func (r *loginLogRepository) Create(log *model.LoginLog) error {
+	return r.db.Create(log).Error
+}
+
+// GetUserLogs 获取用户登录日志
+func (r *loginLogRepository) GetUserLogs(userID uint64, page, pageSize int) ([]*model.LoginLog, int64, error) {
+	return r.List(&LoginLogQuery{UserID: userID}, page, pageSize)
+}
+
+// List 按条件查询登录日志
+func (r *loginLogRepository) List(query *LoginLogQuery, page, pageSize int) ([]*model.LoginLog, int64, error) {
+	var logs []*model.LoginLog
+	var total int64
+
+	db := r.db.Model(&model.LoginLog{})
+	if query.UserID > 0 {
+		db = db.Where("user_id = ?", query.UserID)
+	}
+	if query.Account != "" {
+		// 账号加密存储，通过盲索引查询
+		accountHash, err := utils.BlindIndex(query.Account)
+		if err != nil {
+			return nil, 0, err
+		}
+		db = db.Where("account_hash = ?", accountHash)
+	}
+	if query.IP != "" {
+		db = db.Where("ip = ?", query.IP)
+	}
+	if query.Result != nil {
+		db = db.Where("result = ?", *query.Result)
+	}
+
+	// 计算总数
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 分页查询
+	offset := (page - 1) * pageSize
+	err := db.Offset(offset).Limit(pageSize).
+		Order("id DESC").
+		Find(&logs).Error
+
+	return logs, total, err
+}
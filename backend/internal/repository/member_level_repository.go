@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+)
+
+// MemberLevelRepository 会员等级仓储接口
+type MemberLevelRepository interface {
+	List() ([]*model.MemberLevel, error)
+	GetByID(id uint64) (*model.MemberLevel, error)
+	Create(level *model.MemberLevel) error
+	Update(level *model.MemberLevel) error
+	Delete(id uint64) error
+	CountUsers(levelID uint64) (int64, error)
+}
+
+// memberLevelRepository 会员等级仓储实现
+type memberLevelRepository struct {
+	db *gorm.DB
+}
+
+// NewMemberLevelRepository 创建会员等级仓储
+func NewMemberLevelRepository(db *gorm.DB) MemberLevelRepository {
+	return &memberLevelRepository{db: db}
+}
+
+// List 获取全部会员等级，按等级序号升序
+func (r *memberLevelRepository) List() ([]*model.MemberLevel, error) {
+	var levels []*model.MemberLevel
+	err := r.db.Order("level ASC").Find(&levels).Error
+	return levels, err
+}
+
+// GetByID 根据ID获取会员等级
+func (r *memberLevelRepository) GetByID(id uint64) (*model.MemberLevel, error) {
+	var level model.MemberLevel
+	err := r.db.First(&level, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &level, nil
+}
+
+// Create 创建会员等级
+func (r *memberLevelRepository) Create(level *model.MemberLevel) error {
+	return r.db.Create(level).Error
+}
+
+// Update 更新会员等级
+func (r *memberLevelRepository) Update(level *model.MemberLevel) error {
+	return r.db.Save(level).Error
+}
+
+// Delete 删除会员等级
+func (r *memberLevelRepository) Delete(id uint64) error {
+	return r.db.Delete(&model.MemberLevel{}, id).Error
+}
+
+// CountUsers 统计处于该等级的用户数
+func (r *memberLevelRepository) CountUsers(levelID uint64) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.UserPoints{}).Where("level_id = ?", levelID).Count(&count).Error
+	return count, err
+}
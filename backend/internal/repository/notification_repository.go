@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+)
+
+// NotificationQuery 通知查询条件
+type NotificationQuery struct {
+	UserID     uint64
+	Category   string
+	UnreadOnly bool
+}
+
+// NotificationRepository 站内通知仓储接口
+type NotificationRepository interface {
+	Create(notification *model.Notification) error
+	List(query *NotificationQuery, page, pageSize int) ([]*model.Notification, int64, error)
+	CountUnread(userID uint64) (int64, error)
+	MarkRead(userID uint64, ids []uint64) (int64, error)
+	MarkAllRead(userID uint64, category string) (int64, error)
+}
+
+// notificationRepository 站内通知仓储实现
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationRepository 创建站内通知仓储
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+// Create 创建通知
+func (r *notificationRepository) Create(notification *model.Notification) error {
+	return r.db.Create(notification).Error
+}
+
+// List 分页获取用户通知，最新的在前
+func (r *notificationRepository) List(query *NotificationQuery, page, pageSize int) ([]*model.Notification, int64, error) {
+	var notifications []*model.Notification
+	var total int64
+
+	db := r.db.Model(&model.Notification{}).Where("user_id = ?", query.UserID)
+	if query.Category != "" {
+		db = db.Where("category = ?", query.Category)
+	}
+	if query.UnreadOnly {
+		db = db.Where("is_read = ?", false)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := db.Order("id DESC").Offset(offset).Limit(pageSize).Find(&notifications).Error
+	return notifications, total, err
+}
+
+// CountUnread 统计用户未读通知数
+func (r *notificationRepository) CountUnread(userID uint64) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.Notification{}).Where("user_id = ? AND is_read = ?", userID, false).Count(&count).Error
+	return count, err
+}
+
+// MarkRead 将用户的指定通知标记为已读，返回实际更新数
+func (r *notificationRepository) MarkRead(userID uint64, ids []uint64) (int64, error) {
+	result := r.db.Model(&model.Notification{}).
+		Where("user_id = ? AND id IN ? AND is_read = ?", userID, ids, false).
+		Updates(map[string]interface{}{"is_read": true, "read_at": time.Now()})
+	return result.RowsAffected, result.Error
+}
+
+// MarkAllRead 将用户全部或某一分类的通知标记为已读
+func (r *notificationRepository) MarkAllRead(userID uint64, category string) (int64, error) {
+	db := r.db.Model(&model.Notification{}).Where("user_id = ? AND is_read = ?", userID, false)
+	if category != "" {
+		db = db.Where("category = ?", category)
+	}
+	result := db.Updates(map[string]interface{}{"is_read": true, "read_at": time.Now()})
+	return result.RowsAffected, result.Error
+}
@@ -1,11 +1,26 @@
 package repository
 
 import (
+	"errors"
+	"math"
+	"time"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"mall/internal/model"
 )
 
+// RefundTxFunc 在退款事务中执行的回调，payment为退款前加锁读取的支付记录，fullRefund表示本次退款后已全额退款
+type RefundTxFunc func(tx *gorm.DB, payment *model.OrderPayment, fullRefund bool) error
+
+var (
+	// ErrPaymentNotRefundable 支付记录不可退款
+	ErrPaymentNotRefundable = errors.New("payment is not refundable")
+	// ErrRefundExceedsPaid 退款金额超过可退金额
+	ErrRefundExceedsPaid = errors.New("refund amount exceeds refundable amount")
+)
+
 // OrderPaymentRepository 订单支付仓储接口
 type OrderPaymentRepository interface {
 	Create(payment *model.OrderPayment) error
@@ -14,6 +29,8 @@ type OrderPaymentRepository interface {
 	GetByOrderID(orderID uint64) ([]*model.OrderPayment, error)
 	Update(payment *model.OrderPayment) error
 	UpdateStatus(id uint64, status int8, tradeNo string) error
+	MarkPaid(id uint64, tradeNo string) (bool, error)
+	AddRefund(refund *model.OrderRefund, inTx RefundTxFunc) (*model.OrderPayment, error)
 }
 
 // orderPaymentRepository 订单支付仓储实现
@@ -76,4 +93,69 @@ func (r *orderPaymentRepository) UpdateStatus(id uint64, status int8, tradeNo st
 	}
 	
 	return r.db.Model(&model.OrderPayment{}).Where("id = ?", id).Updates(updates).Error
-}
\ No newline at end of file
+}
+
+// MarkPaid 将未成功的支付记录标记为支付成功，已处理过的重复回调返回false
+func (r *orderPaymentRepository) MarkPaid(id uint64, tradeNo string) (bool, error) {
+	result := r.db.Model(&model.OrderPayment{}).Where("id = ? AND status IN ?", id, []int8{0, 2, 3}).Updates(map[string]interface{}{
+		"status":   1,
+		"trade_no": tradeNo,
+		"pay_time": time.Now(),
+	})
+	return result.RowsAffected > 0, result.Error
+}
+
+// AddRefund 在同一事务中锁定支付记录、校验可退金额、写入退款记录并累加退款金额，再执行inTx（如扣回积分），任一步骤失败全部回滚；
+// 支付成功的记录全额退款后订单转为已退款
+func (r *orderPaymentRepository) AddRefund(refund *model.OrderRefund, inTx RefundTxFunc) (*model.OrderPayment, error) {
+	var payment model.OrderPayment
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, refund.PaymentID).Error; err != nil {
+			return err
+		}
+		if payment.Status != 1 && payment.Status != 4 {
+			return ErrPaymentNotRefundable
+		}
+
+		// 按分比较，避免浮点误差
+		paidCents := math.Round(payment.Amount * 100)
+		refundedCents := math.Round((payment.RefundAmount + refund.Amount) * 100)
+		if refundedCents > paidCents {
+			return ErrRefundExceedsPaid
+		}
+
+		refund.OrderID = payment.OrderID
+		if err := tx.Create(refund).Error; err != nil {
+			return err
+		}
+
+		locked := payment
+		fullRefund := refundedCents == paidCents
+		payment.RefundAmount = refundedCents / 100
+		updates := map[string]interface{}{"refund_amount": payment.RefundAmount}
+		if fullRefund {
+			payment.Status = 5
+			updates["status"] = payment.Status
+		}
+		if err := tx.Model(&model.OrderPayment{}).Where("id = ?", payment.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		// 待退款的支付记录对应已关闭或已由其他支付单付款的订单，不改变订单状态
+		if fullRefund && locked.Status == 1 {
+			if err := tx.Model(&model.Order{}).Where("id = ? AND status IN ?", payment.OrderID, []int8{2, 3, 4}).
+				Update("status", 6).Error; err != nil {
+				return err
+			}
+		}
+
+		if inTx == nil {
+			return nil
+		}
+		return inTx(tx, &locked, fullRefund)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
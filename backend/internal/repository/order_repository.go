@@ -1,19 +1,25 @@
 package repository
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 
 	"mall/internal/model"
+	"mall/pkg/utils"
 )
 
 // OrderRepository 订单仓储接口
 type OrderRepository interface {
 	Create(order *model.Order) error
+	CreateWithItems(order *model.Order, items []*model.OrderItem, inTx TxFunc) error
 	GetByID(id uint64) (*model.Order, error)
 	GetByOrderNo(orderNo string) (*model.Order, error)
 	GetWithDetails(id uint64) (*model.Order, error)
 	Update(order *model.Order) error
 	UpdateStatus(id uint64, status int8) error
+	UpdateStatusFrom(id uint64, from, to int8) (bool, error)
+	GetUnpaidBefore(before time.Time, limit int) ([]*model.Order, error)
 	GetUserOrders(userID uint64, page, pageSize int, status int8) ([]*model.Order, int64, error)
 	GetOrders(page, pageSize int, status int8) ([]*model.Order, int64, error)
 	Search(keyword string, page, pageSize int) ([]*model.Order, int64, error)
@@ -34,6 +40,27 @@ func (r *orderRepository) Create(order *model.Order) error {
 	return r.db.Create(order).Error
 }
 
+// CreateWithItems 在同一事务中创建订单与订单项并执行inTx（如扣减库存），任一失败全部回滚
+func (r *orderRepository) CreateWithItems(order *model.Order, items []*model.OrderItem, inTx TxFunc) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(order).Error; err != nil {
+			return err
+		}
+		for _, item := range items {
+			item.OrderID = order.ID
+		}
+		if len(items) > 0 {
+			if err := tx.Create(items).Error; err != nil {
+				return err
+			}
+		}
+		if inTx == nil {
+			return nil
+		}
+		return inTx(tx)
+	})
+}
+
 // GetByID 根据ID获取订单
 func (r *orderRepository) GetByID(id uint64) (*model.Order, error) {
 	var order model.Order
@@ -141,12 +168,16 @@ func (r *orderRepository) Search(keyword string, page, pageSize int) ([]*model.O
 	var orders []*model.Order
 	var total int64
 
+	phoneHash, err := utils.BlindIndex(keyword)
+	if err != nil {
+		return nil, 0, err
+	}
 	query := r.db.Model(&model.Order{}).
 		Preload("User").
 		Preload("Items").
 		Preload("Items.Product").
-		Where("order_no LIKE ? OR receiver_name LIKE ? OR receiver_phone LIKE ?",
-			"%"+keyword+"%", "%"+keyword+"%", "%"+keyword+"%")
+		Where("order_no LIKE ? OR receiver_name LIKE ? OR receiver_phone_hash = ?",
+			"%"+keyword+"%", "%"+keyword+"%", phoneHash)
 
 	// 计算总数
 	if err := query.Count(&total).Error; err != nil {
@@ -155,9 +186,23 @@ func (r *orderRepository) Search(keyword string, page, pageSize int) ([]*model.O
 
 	// 分页查询
 	offset := (page - 1) * pageSize
-	err := query.Offset(offset).Limit(pageSize).
+	err = query.Offset(offset).Limit(pageSize).
 		Order("created_at DESC").
 		Find(&orders).Error
 
 	return orders, total, err
-}
\ No newline at end of file
+}
+
+// UpdateStatusFrom 仅当订单处于指定状态时更新，返回是否更新成功
+func (r *orderRepository) UpdateStatusFrom(id uint64, from, to int8) (bool, error) {
+	result := r.db.Model(&model.Order{}).Where("id = ? AND status = ?", id, from).Update("status", to)
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetUnpaidBefore 获取指定时间前创建且仍未付款的订单
+func (r *orderRepository) GetUnpaidBefore(before time.Time, limit int) ([]*model.Order, error) {
+	var orders []*model.Order
+	err := r.db.Where("status = ? AND created_at < ?", 1, before).
+		Order("created_at ASC").Limit(limit).Find(&orders).Error
+	return orders, err
+}
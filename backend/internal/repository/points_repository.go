@@ -0,0 +1,298 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"mall/internal/model"
+)
+
+// ErrPointsEntryExists 相同幂等键的积分流水已存在
+var ErrPointsEntryExists = errors.New("points entry already exists")
+
+// PointsRepository 积分仓储接口
+type PointsRepository interface {
+	GetAccount(userID uint64) (*model.UserPoints, error)
+	GetLedger(userID uint64, page, pageSize int) ([]*model.PointsLedger, int64, error)
+	AddEntry(tx *gorm.DB, entry *model.PointsLedger, spendDelta float64) (*model.UserPoints, error)
+	GetOrderEntries(tx *gorm.DB, orderID uint64) ([]*model.PointsLedger, error)
+	GetOrderRefundedAmount(orderID uint64) (float64, error)
+	GetUnearnedOrders(since time.Time, limit int) ([]*model.Order, error)
+	GetExpirablePoints(userID uint64, before time.Time) (int64, error)
+	GetExpirableUsers(before time.Time, limit int) ([]uint64, error)
+	Expire(userID uint64, now time.Time) (int64, error)
+	RefreshLevels() error
+}
+
+// pointsRepository 积分仓储实现
+type pointsRepository struct {
+	db *gorm.DB
+}
+
+// NewPointsRepository 创建积分仓储
+func NewPointsRepository(db *gorm.DB) PointsRepository {
+	return &pointsRepository{db: db}
+}
+
+// GetAccount 获取用户积分账户
+func (r *pointsRepository) GetAccount(userID uint64) (*model.UserPoints, error) {
+	var account model.UserPoints
+	err := r.db.Where("user_id = ?", userID).First(&account).Error
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// GetLedger 分页获取用户积分流水
+func (r *pointsRepository) GetLedger(userID uint64, page, pageSize int) ([]*model.PointsLedger, int64, error) {
+	var entries []*model.PointsLedger
+	var total int64
+
+	query := r.db.Model(&model.PointsLedger{}).Where("user_id = ?", userID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&entries).Error
+	return entries, total, err
+}
+
+// AddEntry 在同一事务中追加积分流水、更新账户余额与累计消费并重新计算等级，tx为调用方事务时在其中执行，为nil时单独开启事务
+// 扣减积分时最多扣到0，实际扣减数写回entry.Points
+func (r *pointsRepository) AddEntry(tx *gorm.DB, entry *model.PointsLedger, spendDelta float64) (*model.UserPoints, error) {
+	if tx != nil {
+		return r.addEntry(tx, entry, spendDelta)
+	}
+
+	var account *model.UserPoints
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		account, err = r.addEntry(tx, entry, spendDelta)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// addEntry 在事务中锁定账户并追加积分流水
+func (r *pointsRepository) addEntry(tx *gorm.DB, entry *model.PointsLedger, spendDelta float64) (*model.UserPoints, error) {
+	account, err := r.lockAccount(tx, entry.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.BizKey != nil {
+		var count int64
+		if err := tx.Model(&model.PointsLedger{}).Where("biz_key = ?", *entry.BizKey).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, ErrPointsEntryExists
+		}
+	}
+
+	if entry.Points < 0 && account.Balance+entry.Points < 0 {
+		entry.Points = -account.Balance
+	}
+	account.Balance += entry.Points
+	if entry.Points > 0 {
+		account.TotalEarned += entry.Points
+	}
+	account.TotalSpend += spendDelta
+	if account.TotalSpend < 0 {
+		account.TotalSpend = 0
+	}
+
+	level, err := r.resolveLevel(tx, account.TotalSpend)
+	if err != nil {
+		return nil, err
+	}
+	account.LevelID = level
+
+	// 带幂等键的流水即使积分为0也记录，用于标记订单已计入累计消费
+	if entry.Points != 0 || entry.BizKey != nil {
+		entry.BalanceAfter = account.Balance
+		if err := tx.Create(entry).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Model(&model.UserPoints{}).Where("id = ?", account.ID).Updates(map[string]interface{}{
+		"balance":      account.Balance,
+		"total_earned": account.TotalEarned,
+		"total_spend":  account.TotalSpend,
+		"level_id":     account.LevelID,
+	}).Error
+	if err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// GetOrderEntries 获取订单相关的积分获得与退回流水，tx为nil时使用默认连接
+func (r *pointsRepository) GetOrderEntries(tx *gorm.DB, orderID uint64) ([]*model.PointsLedger, error) {
+	if tx == nil {
+		tx = r.db
+	}
+	var entries []*model.PointsLedger
+	err := tx.Where("order_id = ? AND type IN ?", orderID, []string{"earn", "refund"}).
+		Order("id ASC").Find(&entries).Error
+	return entries, err
+}
+
+// GetOrderRefundedAmount 获取订单累计退款金额
+func (r *pointsRepository) GetOrderRefundedAmount(orderID uint64) (float64, error) {
+	var amount float64
+	err := r.db.Model(&model.OrderRefund{}).Select("COALESCE(SUM(amount), 0)").
+		Where("order_id = ?", orderID).Scan(&amount).Error
+	return amount, err
+}
+
+// GetUnearnedOrders 获取指定时间后完成但尚无积分发放流水的订单
+func (r *pointsRepository) GetUnearnedOrders(since time.Time, limit int) ([]*model.Order, error) {
+	var orders []*model.Order
+	err := r.db.Where("status = ? AND updated_at >= ?", 4, since).
+		Where("NOT EXISTS (?)", r.db.Model(&model.PointsLedger{}).Select("1").
+			Where("points_ledgers.order_id = orders.id AND points_ledgers.type = ?", "earn")).
+		Order("id ASC").
+		Limit(limit).
+		Find(&orders).Error
+	return orders, err
+}
+
+// GetExpirablePoints 计算截至指定时间将过期的积分
+// 扣减按先进先出消耗最早获得的积分，退款扣回的积分与原获得积分相抵，过期数为到期的获得积分减去其余已扣减积分
+func (r *pointsRepository) GetExpirablePoints(userID uint64, before time.Time) (int64, error) {
+	return r.expirablePoints(r.db, userID, before)
+}
+
+// GetExpirableUsers 获取存在到期积分的用户
+func (r *pointsRepository) GetExpirableUsers(before time.Time, limit int) ([]uint64, error) {
+	var userIDs []uint64
+	err := r.db.Model(&model.PointsLedger{}).
+		Select("user_id").
+		Group("user_id").
+		Having("SUM(CASE WHEN type IN ? AND expires_at <= ? THEN points ELSE 0 END) > SUM(CASE WHEN points < 0 AND type <> ? THEN -points ELSE 0 END)",
+			[]string{"earn", "refund"}, before, "refund").
+		Limit(limit).
+		Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+// Expire 扣除用户已到期的积分，返回扣除数
+func (r *pointsRepository) Expire(userID uint64, now time.Time) (int64, error) {
+	var expired int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		account, err := r.lockAccount(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		expired, err = r.expirablePoints(tx, userID, now)
+		if err != nil {
+			return err
+		}
+		if expired > account.Balance {
+			expired = account.Balance
+		}
+		if expired <= 0 {
+			expired = 0
+			return nil
+		}
+
+		account.Balance -= expired
+		entry := &model.PointsLedger{
+			UserID:       userID,
+			Type:         "expire",
+			Points:       -expired,
+			BalanceAfter: account.Balance,
+			Remark:       "积分过期",
+		}
+		if err := tx.Create(entry).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.UserPoints{}).Where("id = ?", account.ID).Update("balance", account.Balance).Error
+	})
+	return expired, err
+}
+
+// RefreshLevels 会员等级配置变化后重新计算所有用户的等级
+func (r *pointsRepository) RefreshLevels() error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var levels []*model.MemberLevel
+		if err := tx.Where("status = ?", 1).Order("min_spend ASC, level ASC").Find(&levels).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&model.UserPoints{}).Where("1 = 1").Update("level_id", 0).Error; err != nil {
+			return err
+		}
+		// 门槛由低到高依次覆盖，最终保留满足条件的最高等级
+		for _, level := range levels {
+			if err := tx.Model(&model.UserPoints{}).Where("total_spend >= ?", level.MinSpend).
+				Update("level_id", level.ID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// lockAccount 锁定用户积分账户，不存在时创建
+func (r *pointsRepository) lockAccount(tx *gorm.DB, userID uint64) (*model.UserPoints, error) {
+	var account model.UserPoints
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&account).Error
+	if err == nil {
+		return &account, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	account = model.UserPoints{UserID: userID}
+	if err := tx.Create(&account).Error; err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// resolveLevel 获取累计消费满足门槛的最高启用等级
+func (r *pointsRepository) resolveLevel(tx *gorm.DB, totalSpend float64) (uint64, error) {
+	var level model.MemberLevel
+	err := tx.Where("status = ? AND min_spend <= ?", 1, totalSpend).
+		Order("min_spend DESC, level DESC").First(&level).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return level.ID, nil
+}
+
+// expirablePoints 计算截至指定时间到期且尚未被扣减的积分
+func (r *pointsRepository) expirablePoints(tx *gorm.DB, userID uint64, before time.Time) (int64, error) {
+	var result struct {
+		Expiring int64
+		Consumed int64
+	}
+	err := tx.Model(&model.PointsLedger{}).
+		Select("COALESCE(SUM(CASE WHEN type IN ? AND expires_at <= ? THEN points ELSE 0 END), 0) AS expiring, "+
+			"COALESCE(SUM(CASE WHEN points < 0 AND type <> ? THEN -points ELSE 0 END), 0) AS consumed", []string{"earn", "refund"}, before, "refund").
+		Where("user_id = ?", userID).
+		Scan(&result).Error
+	if err != nil {
+		return 0, err
+	}
+	if result.Expiring <= result.Consumed {
+		return 0, nil
+	}
+	return result.Expiring - result.Consumed, nil
+}
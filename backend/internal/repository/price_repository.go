@@ -0,0 +1,299 @@
+package repository
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"mall/internal/model"
+)
+
+// 调价计划状态
+const (
+	PriceScheduleStatusPending   int8 = 0
+	PriceScheduleStatusActive    int8 = 1
+	PriceScheduleStatusFinished  int8 = 2
+	PriceScheduleStatusCancelled int8 = 3
+)
+
+// 价格变更来源
+const (
+	PriceSourceManual   = "manual"
+	PriceSourceSchedule = "schedule"
+	PriceSourceRevert   = "revert"
+)
+
+// 价格变更操作人类型
+const (
+	PriceOperatorAdmin  = "admin"
+	PriceOperatorSystem = "system"
+)
+
+var (
+	// ErrPriceScheduleOverlap 同一商品或SKU的调价时间段重叠
+	ErrPriceScheduleOverlap = errors.New("price schedule overlaps an existing schedule")
+	// ErrPriceScheduleClosed 调价计划已结束或已取消
+	ErrPriceScheduleClosed = errors.New("price schedule already closed")
+)
+
+// PriceRepository 价格仓储接口，定时调价的应用与恢复都会写入价格历史
+type PriceRepository interface {
+	CreateSchedule(schedule *model.PriceSchedule) error
+	GetSchedule(id uint64) (*model.PriceSchedule, error)
+	ListSchedules(productID uint64) ([]*model.PriceSchedule, error)
+	FindDueSchedules(now time.Time, limit int) ([]*model.PriceSchedule, error)
+	ApplySchedule(id uint64, now time.Time) error
+	CloseSchedule(id uint64, status int8, operatorID uint64, now time.Time) error
+	AddHistory(tx *gorm.DB, entries []*model.PriceHistory) error
+	ListHistory(productID, skuID uint64, page, pageSize int) ([]*model.PriceHistory, int64, error)
+}
+
+// priceRepository 价格仓储实现
+type priceRepository struct {
+	db *gorm.DB
+}
+
+// NewPriceRepository 创建价格仓储
+func NewPriceRepository(db *gorm.DB) PriceRepository {
+	return &priceRepository{db: db}
+}
+
+// CreateSchedule 创建调价计划，同一目标未结束的计划时间段不能重叠；
+// 永久调价视为开始时刻的一个时间点
+func (r *priceRepository) CreateSchedule(schedule *model.PriceSchedule) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockProduct(tx, schedule.ProductID); err != nil {
+			return err
+		}
+
+		end := scheduleEnd(schedule)
+		var count int64
+		err := tx.Model(&model.PriceSchedule{}).
+			Where("product_id = ? AND sku_id = ? AND status IN ?", schedule.ProductID, schedule.SKUID,
+				[]int8{PriceScheduleStatusPending, PriceScheduleStatusActive}).
+			Where("start_at <= ? AND COALESCE(end_at, start_at) >= ?", end, schedule.StartAt).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrPriceScheduleOverlap
+		}
+
+		return tx.Create(schedule).Error
+	})
+}
+
+// GetSchedule 根据ID获取调价计划
+func (r *priceRepository) GetSchedule(id uint64) (*model.PriceSchedule, error) {
+	var schedule model.PriceSchedule
+	err := r.db.First(&schedule, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// ListSchedules 获取商品的调价计划，按开始时间倒序
+func (r *priceRepository) ListSchedules(productID uint64) ([]*model.PriceSchedule, error) {
+	var schedules []*model.PriceSchedule
+	err := r.db.Where("product_id = ?", productID).Order("start_at DESC, id DESC").Find(&schedules).Error
+	return schedules, err
+}
+
+// FindDueSchedules 查找到期需要生效或恢复的调价计划
+func (r *priceRepository) FindDueSchedules(now time.Time, limit int) ([]*model.PriceSchedule, error) {
+	var schedules []*model.PriceSchedule
+	err := r.db.Where("(status = ? AND start_at <= ?) OR (status = ? AND end_at <= ?)",
+		PriceScheduleStatusPending, now, PriceScheduleStatusActive, now).
+		Order("start_at ASC, id ASC").
+		Limit(limit).
+		Find(&schedules).Error
+	return schedules, err
+}
+
+// ApplySchedule 应用待生效的调价计划并记录原价；永久调价直接结束，
+// 开始前已过结束时间的计划不再调价，目标已删除的计划被取消
+func (r *priceRepository) ApplySchedule(id uint64, now time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		schedule, err := lockSchedule(tx, id)
+		if err != nil {
+			return err
+		}
+		if schedule.Status != PriceScheduleStatusPending || schedule.StartAt.After(now) {
+			return nil
+		}
+		if schedule.EndAt != nil && !schedule.EndAt.After(now) {
+			return closeSchedule(tx, schedule, PriceScheduleStatusFinished, now)
+		}
+
+		current, err := lockPrice(tx, schedule.ProductID, schedule.SKUID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return closeSchedule(tx, schedule, PriceScheduleStatusCancelled, now)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := updatePrice(tx, schedule.ProductID, schedule.SKUID, schedule.Price); err != nil {
+			return err
+		}
+		err = tx.Create(&model.PriceHistory{
+			ProductID:    schedule.ProductID,
+			SKUID:        schedule.SKUID,
+			OldPrice:     current,
+			NewPrice:     schedule.Price,
+			Source:       PriceSourceSchedule,
+			ScheduleID:   schedule.ID,
+			OperatorType: PriceOperatorSystem,
+			Remark:       schedule.Remark,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		status := PriceScheduleStatusActive
+		if schedule.EndAt == nil {
+			status = PriceScheduleStatusFinished
+		}
+		return tx.Model(schedule).Updates(map[string]interface{}{
+			"status":     status,
+			"prev_price": current,
+			"applied_at": now,
+		}).Error
+	})
+}
+
+// CloseSchedule 结束或取消调价计划，生效中的计划恢复原价；
+// 若生效期间价格已被手动修改则保留当前价格
+func (r *priceRepository) CloseSchedule(id uint64, status int8, operatorID uint64, now time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		schedule, err := lockSchedule(tx, id)
+		if err != nil {
+			return err
+		}
+		if schedule.Status != PriceScheduleStatusPending && schedule.Status != PriceScheduleStatusActive {
+			return ErrPriceScheduleClosed
+		}
+
+		if schedule.Status == PriceScheduleStatusActive {
+			current, err := lockPrice(tx, schedule.ProductID, schedule.SKUID)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			if err == nil && samePrice(current, schedule.Price) {
+				if err := updatePrice(tx, schedule.ProductID, schedule.SKUID, schedule.PrevPrice); err != nil {
+					return err
+				}
+				entry := &model.PriceHistory{
+					ProductID:    schedule.ProductID,
+					SKUID:        schedule.SKUID,
+					OldPrice:     current,
+					NewPrice:     schedule.PrevPrice,
+					Source:       PriceSourceRevert,
+					ScheduleID:   schedule.ID,
+					OperatorType: PriceOperatorSystem,
+					OperatorID:   operatorID,
+				}
+				if operatorID > 0 {
+					entry.OperatorType = PriceOperatorAdmin
+				}
+				if err := tx.Create(entry).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		return closeSchedule(tx, schedule, status, now)
+	})
+}
+
+// AddHistory 写入价格变更历史，tx为调用方事务时与价格修改一同提交
+func (r *priceRepository) AddHistory(tx *gorm.DB, entries []*model.PriceHistory) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Create(&entries).Error
+}
+
+// ListHistory 分页查询价格变更历史，skuID为0时返回商品及其全部SKU的记录
+func (r *priceRepository) ListHistory(productID, skuID uint64, page, pageSize int) ([]*model.PriceHistory, int64, error) {
+	var entries []*model.PriceHistory
+	var total int64
+
+	db := r.db.Model(&model.PriceHistory{}).Where("product_id = ?", productID)
+	if skuID > 0 {
+		db = db.Where("sku_id = ?", skuID)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := db.Order("id DESC").Offset(offset).Limit(pageSize).Find(&entries).Error
+	return entries, total, err
+}
+
+// lockSchedule 锁定调价计划
+func lockSchedule(tx *gorm.DB, id uint64) (*model.PriceSchedule, error) {
+	var schedule model.PriceSchedule
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&schedule, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// closeSchedule 将调价计划标记为已结束或已取消
+func closeSchedule(tx *gorm.DB, schedule *model.PriceSchedule, status int8, now time.Time) error {
+	return tx.Model(schedule).Updates(map[string]interface{}{
+		"status":   status,
+		"ended_at": now,
+	}).Error
+}
+
+// scheduleEnd 返回调价计划占用时间段的结束时刻
+func scheduleEnd(schedule *model.PriceSchedule) time.Time {
+	if schedule.EndAt != nil {
+		return *schedule.EndAt
+	}
+	return schedule.StartAt
+}
+
+// lockPrice 锁定商品或SKU并返回当前价格
+func lockPrice(tx *gorm.DB, productID, skuID uint64) (float64, error) {
+	locked := tx.Clauses(clause.Locking{Strength: "UPDATE"})
+	if skuID > 0 {
+		var sku model.ProductSKU
+		if err := locked.Select("id", "price").Where("id = ? AND product_id = ?", skuID, productID).First(&sku).Error; err != nil {
+			return 0, err
+		}
+		return sku.Price, nil
+	}
+
+	var product model.Product
+	if err := locked.Select("id", "price").Where("id = ?", productID).First(&product).Error; err != nil {
+		return 0, err
+	}
+	return product.Price, nil
+}
+
+// updatePrice 写入商品或SKU价格
+func updatePrice(tx *gorm.DB, productID, skuID uint64, price float64) error {
+	if skuID > 0 {
+		return tx.Model(&model.ProductSKU{}).Where("id = ?", skuID).Update("price", price).Error
+	}
+	return tx.Model(&model.Product{}).Where("id = ?", productID).Update("price", price).Error
+}
+
+// samePrice 按分比较两个价格是否相同
+func samePrice(a, b float64) bool {
+	return math.Abs(a-b) < 0.005
+}
@@ -0,0 +1,289 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+)
+
+// createTestSchedule 直接写入调价计划，跳过开始时间校验
+func createTestSchedule(t *testing.T, db *gorm.DB, schedule *model.PriceSchedule) *model.PriceSchedule {
+	t.Helper()
+
+	if err := db.Create(schedule).Error; err != nil {
+		t.Fatalf("create schedule: %v", err)
+	}
+	return schedule
+}
+
+// loadSchedule 重新读取调价计划
+func loadSchedule(t *testing.T, db *gorm.DB, id uint64) *model.PriceSchedule {
+	t.Helper()
+
+	var schedule model.PriceSchedule
+	if err := db.First(&schedule, id).Error; err != nil {
+		t.Fatalf("load schedule: %v", err)
+	}
+	return &schedule
+}
+
+// currentPrice 读取商品或SKU当前价格
+func currentPrice(t *testing.T, db *gorm.DB, productID, skuID uint64) float64 {
+	t.Helper()
+
+	price, err := lockPrice(db, productID, skuID)
+	if err != nil {
+		t.Fatalf("load price: %v", err)
+	}
+	return price
+}
+
+// priceHistory 按ID升序读取价格历史
+func priceHistory(t *testing.T, db *gorm.DB) []*model.PriceHistory {
+	t.Helper()
+
+	var entries []*model.PriceHistory
+	if err := db.Order("id ASC").Find(&entries).Error; err != nil {
+		t.Fatalf("load history: %v", err)
+	}
+	return entries
+}
+
+func TestApplyAndRevertSchedule(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewPriceRepository(db)
+	product := createTestProduct(t, db, 100, 10)
+	sku := createTestSKU(t, db, product.ID, "SKU-1", 120, 10)
+
+	now := time.Now()
+	end := now.Add(time.Hour)
+	schedule := createTestSchedule(t, db, &model.PriceSchedule{
+		ProductID: product.ID,
+		SKUID:     sku.ID,
+		Price:     99,
+		StartAt:   now.Add(-time.Minute),
+		EndAt:     &end,
+		Remark:    "限时折扣",
+	})
+
+	if err := repo.ApplySchedule(schedule.ID, now); err != nil {
+		t.Fatalf("ApplySchedule: %v", err)
+	}
+	if price := currentPrice(t, db, product.ID, sku.ID); price != 99 {
+		t.Errorf("sku price = %v, want 99", price)
+	}
+	if price := currentPrice(t, db, product.ID, 0); price != 100 {
+		t.Errorf("product price = %v, sku schedule should not change it", price)
+	}
+	applied := loadSchedule(t, db, schedule.ID)
+	if applied.Status != 1 || applied.PrevPrice != 120 || applied.AppliedAt == nil {
+		t.Errorf("applied schedule = status %d prev %v applied_at %v", applied.Status, applied.PrevPrice, applied.AppliedAt)
+	}
+
+	// 重复执行不会再次调价
+	if err := repo.ApplySchedule(schedule.ID, now); err != nil {
+		t.Fatalf("repeat ApplySchedule: %v", err)
+	}
+
+	if err := repo.CloseSchedule(schedule.ID, 2, 0, end); err != nil {
+		t.Fatalf("CloseSchedule: %v", err)
+	}
+	if price := currentPrice(t, db, product.ID, sku.ID); price != 120 {
+		t.Errorf("sku price = %v, want 120 after revert", price)
+	}
+	closed := loadSchedule(t, db, schedule.ID)
+	if closed.Status != 2 || closed.EndedAt == nil {
+		t.Errorf("closed schedule = status %d ended_at %v", closed.Status, closed.EndedAt)
+	}
+
+	entries := priceHistory(t, db)
+	if len(entries) != 2 {
+		t.Fatalf("history entries = %d, want 2", len(entries))
+	}
+	if e := entries[0]; e.Source != "schedule" || e.SKUID != sku.ID || e.OldPrice != 120 || e.NewPrice != 99 || e.ScheduleID != schedule.ID || e.Remark != "限时折扣" {
+		t.Errorf("apply history = %+v", e)
+	}
+	if e := entries[1]; e.Source != "revert" || e.OldPrice != 99 || e.NewPrice != 120 || e.OperatorType != "system" {
+		t.Errorf("revert history = %+v", e)
+	}
+
+	if err := repo.CloseSchedule(schedule.ID, 3, 0, end); !errors.Is(err, ErrPriceScheduleClosed) {
+		t.Errorf("closing a finished schedule = %v, want ErrPriceScheduleClosed", err)
+	}
+}
+
+func TestCloseScheduleKeepsManualPrice(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewPriceRepository(db)
+	product := createTestProduct(t, db, 100, 10)
+
+	now := time.Now()
+	end := now.Add(time.Hour)
+	schedule := createTestSchedule(t, db, &model.PriceSchedule{ProductID: product.ID, Price: 80, StartAt: now, EndAt: &end})
+	if err := repo.ApplySchedule(schedule.ID, now); err != nil {
+		t.Fatalf("ApplySchedule: %v", err)
+	}
+
+	// 生效期间手动改价，取消时保留手动价格
+	db.Model(&model.Product{}).Where("id = ?", product.ID).Update("price", 90)
+	if err := repo.CloseSchedule(schedule.ID, 3, 7, now.Add(time.Minute)); err != nil {
+		t.Fatalf("CloseSchedule: %v", err)
+	}
+	if price := currentPrice(t, db, product.ID, 0); price != 90 {
+		t.Errorf("price = %v, want the manual price 90", price)
+	}
+	if entries := priceHistory(t, db); len(entries) != 1 {
+		t.Errorf("history entries = %d, want only the apply entry", len(entries))
+	}
+	if status := loadSchedule(t, db, schedule.ID).Status; status != 3 {
+		t.Errorf("status = %d, want cancelled", status)
+	}
+}
+
+func TestCancelActiveScheduleByAdmin(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewPriceRepository(db)
+	product := createTestProduct(t, db, 100, 10)
+
+	now := time.Now()
+	end := now.Add(time.Hour)
+	schedule := createTestSchedule(t, db, &model.PriceSchedule{ProductID: product.ID, Price: 80, StartAt: now, EndAt: &end})
+	repo.ApplySchedule(schedule.ID, now)
+
+	if err := repo.CloseSchedule(schedule.ID, 3, 7, now.Add(time.Minute)); err != nil {
+		t.Fatalf("CloseSchedule: %v", err)
+	}
+	if price := currentPrice(t, db, product.ID, 0); price != 100 {
+		t.Errorf("price = %v, want 100 after cancel", price)
+	}
+	entries := priceHistory(t, db)
+	if last := entries[len(entries)-1]; last.Source != "revert" || last.OperatorType != "admin" || last.OperatorID != 7 {
+		t.Errorf("revert history = %+v, want admin 7", last)
+	}
+}
+
+func TestApplyScheduleEdgeCases(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewPriceRepository(db)
+	product := createTestProduct(t, db, 100, 10)
+	now := time.Now()
+
+	// 未到开始时间不处理
+	future := createTestSchedule(t, db, &model.PriceSchedule{ProductID: product.ID, Price: 50, StartAt: now.Add(time.Hour)})
+	repo.ApplySchedule(future.ID, now)
+	if status := loadSchedule(t, db, future.ID).Status; status != 0 {
+		t.Errorf("future schedule status = %d, want pending", status)
+	}
+
+	// 永久调价生效后直接结束
+	permanent := createTestSchedule(t, db, &model.PriceSchedule{ProductID: product.ID, Price: 95, StartAt: now.Add(-time.Minute)})
+	if err := repo.ApplySchedule(permanent.ID, now); err != nil {
+		t.Fatalf("ApplySchedule: %v", err)
+	}
+	if status := loadSchedule(t, db, permanent.ID).Status; status != 2 {
+		t.Errorf("permanent schedule status = %d, want finished", status)
+	}
+	if price := currentPrice(t, db, product.ID, 0); price != 95 {
+		t.Errorf("price = %v, want 95", price)
+	}
+
+	// 错过整个时间段的计划不再调价
+	ended := now.Add(-time.Minute)
+	missed := createTestSchedule(t, db, &model.PriceSchedule{ProductID: product.ID, Price: 10, StartAt: now.Add(-time.Hour), EndAt: &ended})
+	repo.ApplySchedule(missed.ID, now)
+	if status := loadSchedule(t, db, missed.ID).Status; status != 2 {
+		t.Errorf("missed schedule status = %d, want finished", status)
+	}
+	if price := currentPrice(t, db, product.ID, 0); price != 95 {
+		t.Errorf("price = %v, missed schedule should not change it", price)
+	}
+
+	// 目标SKU已删除时取消计划
+	sku := createTestSKU(t, db, product.ID, "SKU-1", 100, 1)
+	orphan := createTestSchedule(t, db, &model.PriceSchedule{ProductID: product.ID, SKUID: sku.ID, Price: 10, StartAt: now.Add(-time.Minute)})
+	db.Delete(&model.ProductSKU{}, sku.ID)
+	if err := repo.ApplySchedule(orphan.ID, now); err != nil {
+		t.Fatalf("ApplySchedule: %v", err)
+	}
+	if status := loadSchedule(t, db, orphan.ID).Status; status != 3 {
+		t.Errorf("orphan schedule status = %d, want cancelled", status)
+	}
+}
+
+func TestFindDueSchedules(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewPriceRepository(db)
+	product := createTestProduct(t, db, 100, 10)
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	later := now.Add(time.Hour)
+
+	due := createTestSchedule(t, db, &model.PriceSchedule{ProductID: product.ID, Price: 1, StartAt: past})
+	createTestSchedule(t, db, &model.PriceSchedule{ProductID: product.ID, Price: 2, StartAt: later})
+	expired := createTestSchedule(t, db, &model.PriceSchedule{ProductID: product.ID, Price: 3, StartAt: now.Add(-time.Hour), EndAt: &past, Status: 1})
+	createTestSchedule(t, db, &model.PriceSchedule{ProductID: product.ID, Price: 4, StartAt: now.Add(-time.Hour), EndAt: &later, Status: 1})
+
+	schedules, err := repo.FindDueSchedules(now, 10)
+	if err != nil {
+		t.Fatalf("FindDueSchedules: %v", err)
+	}
+	if len(schedules) != 2 || schedules[0].ID != expired.ID || schedules[1].ID != due.ID {
+		t.Errorf("due schedules = %+v, want the expired active one then the due pending one", schedules)
+	}
+}
+
+func TestCreateScheduleRejectsOverlap(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewPriceRepository(db)
+	product := createTestProduct(t, db, 100, 10)
+	start := time.Now().Add(time.Hour)
+	end := start.Add(2 * time.Hour)
+
+	if err := repo.CreateSchedule(&model.PriceSchedule{ProductID: product.ID, Price: 80, StartAt: start, EndAt: &end}); err != nil {
+		t.Fatalf("CreateSchedule: %v", err)
+	}
+
+	overlapEnd := end.Add(time.Hour)
+	err := repo.CreateSchedule(&model.PriceSchedule{ProductID: product.ID, Price: 70, StartAt: start.Add(time.Hour), EndAt: &overlapEnd})
+	if !errors.Is(err, ErrPriceScheduleOverlap) {
+		t.Errorf("overlapping schedule error = %v, want ErrPriceScheduleOverlap", err)
+	}
+	if err := repo.CreateSchedule(&model.PriceSchedule{ProductID: product.ID, Price: 70, StartAt: start.Add(time.Hour)}); !errors.Is(err, ErrPriceScheduleOverlap) {
+		t.Errorf("permanent schedule inside a range error = %v, want ErrPriceScheduleOverlap", err)
+	}
+
+	// 其他SKU与不重叠的时间段不受影响
+	sku := createTestSKU(t, db, product.ID, "SKU-1", 100, 1)
+	if err := repo.CreateSchedule(&model.PriceSchedule{ProductID: product.ID, SKUID: sku.ID, Price: 70, StartAt: start, EndAt: &end}); err != nil {
+		t.Errorf("sku schedule: %v", err)
+	}
+	if err := repo.CreateSchedule(&model.PriceSchedule{ProductID: product.ID, Price: 70, StartAt: end.Add(time.Minute)}); err != nil {
+		t.Errorf("later schedule: %v", err)
+	}
+}
+
+func TestAddHistoryJoinsCallerTransaction(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewPriceRepository(db)
+	product := createTestProduct(t, db, 100, 10)
+
+	errAbort := errors.New("abort")
+	db.Transaction(func(tx *gorm.DB) error {
+		tx.Model(&model.Product{}).Where("id = ?", product.ID).Update("price", 90)
+		if err := repo.AddHistory(tx, []*model.PriceHistory{{ProductID: product.ID, OldPrice: 100, NewPrice: 90, Source: "manual"}}); err != nil {
+			t.Fatalf("AddHistory: %v", err)
+		}
+		return errAbort
+	})
+
+	if entries := priceHistory(t, db); len(entries) != 0 {
+		t.Errorf("history entries = %d, want 0 after rollback", len(entries))
+	}
+	if price := currentPrice(t, db, product.ID, 0); price != 100 {
+		t.Errorf("price = %v, want 100 after rollback", price)
+	}
+}
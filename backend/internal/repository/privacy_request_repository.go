@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+	"mall/pkg/utils"
+)
+
+// 隐私请求状态
+const (
+	PrivacyStatusPending    int8 = 0
+	PrivacyStatusProcessing int8 = 1
+	PrivacyStatusCompleted  int8 = 2
+	PrivacyStatusCancelled  int8 = 3
+	PrivacyStatusFailed     int8 = 4
+)
+
+// PrivacyRequestRepository 隐私请求仓储接口
+type PrivacyRequestRepository interface {
+	Create(req *model.PrivacyRequest) error
+	GetByID(id uint64) (*model.PrivacyRequest, error)
+	GetActiveByUserAndType(userID uint64, reqType string) (*model.PrivacyRequest, error)
+	GetUserRequests(userID uint64) ([]*model.PrivacyRequest, error)
+	GetDue(reqType string, before time.Time, limit int) ([]*model.PrivacyRequest, error)
+	GetExpiredExports(before time.Time, limit int) ([]*model.PrivacyRequest, error)
+	Claim(id uint64) (bool, error)
+	UpdateFields(id uint64, fields map[string]interface{}) error
+	LoadUserData(userID uint64) (*model.User, error)
+	AnonymizeUser(userID uint64) error
+}
+
+// privacyRequestRepository 隐私请求仓储实现
+type privacyRequestRepository struct {
+	db *gorm.DB
+}
+
+// NewPrivacyRequestRepository 创建隐私请求仓储
+func NewPrivacyRequestRepository(db *gorm.DB) PrivacyRequestRepository {
+	return &privacyRequestRepository{db: db}
+}
+
+// Create 创建隐私请求
+func (r *privacyRequestRepository) Create(req *model.PrivacyRequest) error {
+	return r.db.Create(req).Error
+}
+
+// GetByID 根据ID获取隐私请求
+func (r *privacyRequestRepository) GetByID(id uint64) (*model.PrivacyRequest, error) {
+	var req model.PrivacyRequest
+	err := r.db.First(&req, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// GetActiveByUserAndType 获取用户待处理或处理中的请求
+func (r *privacyRequestRepository) GetActiveByUserAndType(userID uint64, reqType string) (*model.PrivacyRequest, error) {
+	var req model.PrivacyRequest
+	err := r.db.Where("user_id = ? AND type = ? AND status IN ?", userID, reqType,
+		[]int8{PrivacyStatusPending, PrivacyStatusProcessing}).
+		Order("created_at DESC").First(&req).Error
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// GetUserRequests 获取用户的隐私请求列表
+func (r *privacyRequestRepository) GetUserRequests(userID uint64) ([]*model.PrivacyRequest, error) {
+	var reqs []*model.PrivacyRequest
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Limit(50).Find(&reqs).Error
+	return reqs, err
+}
+
+// GetDue 获取已到执行时间的待处理请求
+func (r *privacyRequestRepository) GetDue(reqType string, before time.Time, limit int) ([]*model.PrivacyRequest, error) {
+	var reqs []*model.PrivacyRequest
+	err := r.db.Where("type = ? AND status = ? AND scheduled_at <= ?", reqType, PrivacyStatusPending, before).
+		Order("scheduled_at ASC").Limit(limit).Find(&reqs).Error
+	return reqs, err
+}
+
+// GetExpiredExports 获取导出文件已过期的请求
+func (r *privacyRequestRepository) GetExpiredExports(before time.Time, limit int) ([]*model.PrivacyRequest, error) {
+	var reqs []*model.PrivacyRequest
+	err := r.db.Where("type = ? AND status = ? AND file_path <> '' AND expires_at <= ?", "export", PrivacyStatusCompleted, before).
+		Limit(limit).Find(&reqs).Error
+	return reqs, err
+}
+
+// Claim 将待处理请求标记为处理中，多实例下只有一个实例能认领成功
+func (r *privacyRequestRepository) Claim(id uint64) (bool, error) {
+	result := r.db.Model(&model.PrivacyRequest{}).
+		Where("id = ? AND status = ?", id, PrivacyStatusPending).
+		Update("status", PrivacyStatusProcessing)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// UpdateFields 更新请求字段
+func (r *privacyRequestRepository) UpdateFields(id uint64, fields map[string]interface{}) error {
+	return r.db.Model(&model.PrivacyRequest{}).Where("id = ?", id).Updates(fields).Error
+}
+
+// LoadUserData 加载用户全部关联数据用于导出
+func (r *privacyRequestRepository) LoadUserData(userID uint64) (*model.User, error) {
+	var user model.User
+	err := r.db.Preload("Profile").
+		Preload("Addresses").
+		Preload("AuthInfos").
+		Preload("Orders", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at DESC")
+		}).
+		Preload("Orders.Items").
+		Preload("Orders.Payments").
+		Preload("CartItems").
+		First(&user, userID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// AnonymizeUser 在同一事务中抹除用户个人信息，订单金额等财务数据保留
+func (r *privacyRequestRepository) AnonymizeUser(userID uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		placeholder := fmt.Sprintf("deleted_%d", userID)
+
+		// map更新不经过序列化器，需手动加密
+		phone := fmt.Sprintf("del_%d", userID)
+		encryptedPhone, err := utils.EncryptField(phone)
+		if err != nil {
+			return err
+		}
+		phoneHash, err := utils.BlindIndex(phone)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"username":      placeholder,
+			"phone":         encryptedPhone,
+			"phone_hash":    phoneHash,
+			"email":         "",
+			"wechat_openid": placeholder,
+			"status":        0,
+		}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&model.UserProfile{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+			"nickname": "已注销用户",
+			"avatar":   "",
+			"gender":   0,
+			"birthday": time.Time{},
+		}).Error; err != nil {
+			return err
+		}
+
+		// 收货人姓名为加密字段，占位值同样加密写入
+		maskedName, err := utils.EncryptField("***")
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&model.UserAddress{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+			"name":     maskedName,
+			"phone":    "",
+			"province": "",
+			"city":     "",
+			"district": "",
+			"address":  "",
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&model.UserAddress{}).Error; err != nil {
+			return err
+		}
+
+		// 历史订单保留金额与商品明细，仅清除收货人信息
+		if err := tx.Model(&model.Order{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+			"receiver_name":       "***",
+			"receiver_phone":      "",
+			"receiver_phone_hash": "",
+			"receiver_address":    "",
+			"buyer_message":       "",
+		}).Error; err != nil {
+			return err
+		}
+
+		// 登录凭证彻底删除
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&model.UserAuth{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&model.CartItem{}).Error; err != nil {
+			return err
+		}
+		// 收藏物理删除并同步扣减商品收藏数
+		if err := tx.Model(&model.Product{}).
+			Where("favorite_count > 0 AND id IN (?)", tx.Model(&model.ProductFavorite{}).Select("product_id").Where("user_id = ?", userID)).
+			UpdateColumn("favorite_count", gorm.Expr("favorite_count - 1")).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&model.ProductFavorite{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&model.UserFootprint{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&model.Notification{}).Error; err != nil {
+			return err
+		}
+		encryptedAccount, err := utils.EncryptField(placeholder)
+		if err != nil {
+			return err
+		}
+		accountHash, err := utils.BlindIndex(placeholder)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&model.LoginLog{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+			"account":      encryptedAccount,
+			"account_hash": accountHash,
+			"ip":           "",
+			"user_agent":   "",
+		}).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&model.User{}, userID).Error
+	})
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"mall/internal/model"
+)
+
+func TestAnonymizeUser(t *testing.T) {
+	db := newTestDB(t,
+		&model.User{},
+		&model.UserProfile{},
+		&model.UserAuth{},
+		&model.UserAddress{},
+		&model.Order{},
+		&model.OrderItem{},
+		&model.OrderPayment{},
+		&model.CartItem{},
+		&model.ProductFavorite{},
+		&model.UserFootprint{},
+		&model.Notification{},
+		&model.LoginLog{},
+	)
+	user := &model.User{Username: "alice", Phone: "13800000001", Email: "a@example.com", WechatOpenID: "wx-alice", Status: 1}
+	other := &model.User{Username: "bob", Phone: "13800000002", WechatOpenID: "wx-bob", Status: 1}
+	mustCreate(t, db, user, other)
+	product := createTestProduct(t, db, 10, 5)
+	db.Model(product).Update("favorite_count", 2)
+
+	order := &model.Order{UserID: user.ID, OrderNo: "ORD1", TotalAmount: 20, PayAmount: 18, Status: 4,
+		BuyerMessage: "ring the bell", ReceiverName: "Alice", ReceiverPhone: "13800000001", ReceiverAddress: "1 Main St"}
+	mustCreate(t, db,
+		&model.UserProfile{UserID: user.ID, Nickname: "alice", Avatar: "a.png", Gender: 2, Birthday: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)},
+		&model.UserAuth{UserID: user.ID, AuthType: "password", AuthKey: "13800000001", PasswordHash: "hash"},
+		&model.UserAddress{UserID: user.ID, Name: "Alice", Phone: "13800000001", Province: "p", City: "c", District: "d", Address: "1 Main St"},
+		order,
+		&model.CartItem{UserID: user.ID, ProductID: product.ID, Quantity: 1},
+		&model.ProductFavorite{UserID: user.ID, ProductID: product.ID},
+		&model.ProductFavorite{UserID: other.ID, ProductID: product.ID},
+		&model.UserFootprint{UserID: user.ID, ProductID: product.ID, ViewDate: time.Now(), ViewedAt: time.Now()},
+		&model.Notification{UserID: user.ID, Category: "order", Type: "order_shipped", Title: "shipped"},
+		&model.LoginLog{UserID: user.ID, LoginType: "password", Account: "13800000001", IP: "10.0.0.1", UserAgent: "ua", Result: 1},
+	)
+	mustCreate(t, db, &model.OrderPayment{OrderID: order.ID, PaymentNo: "PAY1", PaymentMethod: "wechat", Amount: 18, Status: 1})
+
+	if err := NewPrivacyRequestRepository(db).AnonymizeUser(user.ID); err != nil {
+		t.Fatalf("AnonymizeUser: %v", err)
+	}
+
+	var got model.User
+	if err := db.Unscoped().First(&got, user.ID).Error; err != nil {
+		t.Fatalf("load user: %v", err)
+	}
+	if !got.DeletedAt.Valid || got.Status != 0 || got.Username != "deleted_1" || got.Phone != "del_1" ||
+		got.Email != "" || got.WechatOpenID != "deleted_1" {
+		t.Errorf("user = %+v", got)
+	}
+	if _, err := NewUserRepository(db).GetByPhone("13800000001"); err == nil {
+		t.Error("original phone should no longer resolve to the user")
+	}
+
+	var profile model.UserProfile
+	db.Where("user_id = ?", user.ID).First(&profile)
+	if profile.Nickname != "已注销用户" || profile.Avatar != "" || profile.Gender != 0 {
+		t.Errorf("profile = %+v", profile)
+	}
+
+	var address model.UserAddress
+	db.Unscoped().Where("user_id = ?", user.ID).First(&address)
+	if !address.DeletedAt.Valid || address.Name != "***" || address.Phone != "" || address.Address != "" {
+		t.Errorf("address = %+v", address)
+	}
+
+	// 财务数据保留，收货信息清除
+	var gotOrder model.Order
+	db.Preload("Payments").First(&gotOrder, order.ID)
+	if gotOrder.PayAmount != 18 || gotOrder.TotalAmount != 20 || len(gotOrder.Payments) != 1 {
+		t.Errorf("financial data changed: %+v", gotOrder)
+	}
+	if gotOrder.ReceiverName != "***" || gotOrder.ReceiverPhone != "" || gotOrder.ReceiverAddress != "" || gotOrder.BuyerMessage != "" {
+		t.Errorf("receiver fields not cleared: %+v", gotOrder)
+	}
+
+	counts := []struct {
+		name  string
+		model interface{}
+		want  int64
+	}{
+		{"auths", &model.UserAuth{}, 0},
+		{"cart items", &model.CartItem{}, 0},
+		{"favorites", &model.ProductFavorite{}, 0},
+		{"footprints", &model.UserFootprint{}, 0},
+		{"notifications", &model.Notification{}, 0},
+	}
+	for _, c := range counts {
+		var count int64
+		db.Unscoped().Model(c.model).Where("user_id = ? AND deleted_at IS NULL", user.ID).Count(&count)
+		if count != c.want {
+			t.Errorf("%s = %d, want %d", c.name, count, c.want)
+		}
+	}
+	var favorites int64
+	db.Model(&model.ProductFavorite{}).Where("user_id = ?", other.ID).Count(&favorites)
+	if favorites != 1 || loadProduct(t, db, product.ID).FavoriteCount != 1 {
+		t.Errorf("other favorites = %d, favorite count = %d", favorites, loadProduct(t, db, product.ID).FavoriteCount)
+	}
+
+	var log model.LoginLog
+	db.Where("user_id = ?", user.ID).First(&log)
+	if log.Account != "deleted_1" || log.IP != "" || log.UserAgent != "" || log.Result != 1 {
+		t.Errorf("login log = %+v", log)
+	}
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"mall/internal/model"
+)
+
+// ProductFavoriteRepository 商品收藏仓储接口
+type ProductFavoriteRepository interface {
+	Add(userID, productID uint64) (bool, error)
+	Remove(userID, productID uint64) (bool, error)
+	Exists(userID, productID uint64) (bool, error)
+	GetUserFavorites(userID uint64, page, pageSize int) ([]*model.ProductFavorite, int64, error)
+}
+
+// productFavoriteRepository 商品收藏仓储实现
+type productFavoriteRepository struct {
+	db *gorm.DB
+}
+
+// NewProductFavoriteRepository 创建商品收藏仓储
+func NewProductFavoriteRepository(db *gorm.DB) ProductFavoriteRepository {
+	return &productFavoriteRepository{db: db}
+}
+
+// Add 添加收藏并在同一事务中累加商品收藏数，已收藏时返回false
+func (r *productFavoriteRepository) Add(userID, productID uint64) (bool, error) {
+	added := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		favorite := &model.ProductFavorite{UserID: userID, ProductID: productID}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(favorite)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		added = true
+		return tx.Model(&model.Product{}).Where("id = ?", productID).
+			UpdateColumn("favorite_count", gorm.Expr("favorite_count + 1")).Error
+	})
+	return added, err
+}
+
+// Remove 取消收藏并在同一事务中扣减商品收藏数，未收藏时返回false
+func (r *productFavoriteRepository) Remove(userID, productID uint64) (bool, error) {
+	removed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// 直接物理删除，避免软删除记录占用唯一索引
+		result := tx.Unscoped().Where("user_id = ? AND product_id = ?", userID, productID).Delete(&model.ProductFavorite{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		removed = true
+		return tx.Model(&model.Product{}).Where("id = ? AND favorite_count > 0", productID).
+			UpdateColumn("favorite_count", gorm.Expr("favorite_count - 1")).Error
+	})
+	return removed, err
+}
+
+// Exists 判断用户是否已收藏商品
+func (r *productFavoriteRepository) Exists(userID, productID uint64) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.ProductFavorite{}).Where("user_id = ? AND product_id = ?", userID, productID).Count(&count).Error
+	return count > 0, err
+}
+
+// GetUserFavorites 分页获取用户收藏，关联商品当前价格、库存与状态
+func (r *productFavoriteRepository) GetUserFavorites(userID uint64, page, pageSize int) ([]*model.ProductFavorite, int64, error) {
+	var favorites []*model.ProductFavorite
+	var total int64
+
+	query := r.db.Model(&model.ProductFavorite{}).Where("user_id = ?", userID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.
+		// 已删除的商品仍需展示为失效状态
+		Preload("Product", func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped()
+		}).
+		Preload("Product.Images", func(db *gorm.DB) *gorm.DB {
+			return db.Where("is_main = ?", 1)
+		}).
+		Order("id DESC").
+		Offset(offset).Limit(pageSize).
+		Find(&favorites).Error
+	return favorites, total, err
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"mall/internal/model"
+)
+
+// ErrImageOrderMismatch 排序的图片与商品现有图片不一致
+var ErrImageOrderMismatch = errors.New("image ids do not match product images")
+
+// ProductImageRepository 商品图片仓储接口，所有写操作保证商品有且仅有一张主图
+type ProductImageRepository interface {
+	GetByProductID(productID uint64) ([]*model.ProductImage, error)
+	Add(image *model.ProductImage) error
+	Remove(productID, imageID uint64) (bool, error)
+	Reorder(productID uint64, imageIDs []uint64) error
+	SetMain(productID, imageID uint64) (bool, error)
+}
+
+// productImageRepository 商品图片仓储实现
+type productImageRepository struct {
+	db *gorm.DB
+}
+
+// NewProductImageRepository 创建商品图片仓储
+func NewProductImageRepository(db *gorm.DB) ProductImageRepository {
+	return &productImageRepository{db: db}
+}
+
+// GetByProductID 获取商品图片，按排序返回
+func (r *productImageRepository) GetByProductID(productID uint64) ([]*model.ProductImage, error) {
+	var images []*model.ProductImage
+	err := r.db.Where("product_id = ?", productID).Order("sort_order ASC, id ASC").Find(&images).Error
+	return images, err
+}
+
+// Add 添加图片，设为主图时取消原主图，商品没有主图时新图片自动成为主图
+func (r *productImageRepository) Add(image *model.ProductImage) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockProduct(tx, image.ProductID); err != nil {
+			return err
+		}
+
+		if image.IsMain == 1 {
+			if err := clearMainImage(tx, image.ProductID); err != nil {
+				return err
+			}
+		} else {
+			image.IsMain = 0
+		}
+		if err := tx.Create(image).Error; err != nil {
+			return err
+		}
+		return ensureMainImage(tx, image.ProductID)
+	})
+}
+
+// Remove 删除图片，删除主图时由排序最靠前的图片接替，图片不存在时返回false
+func (r *productImageRepository) Remove(productID, imageID uint64) (bool, error) {
+	removed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockProduct(tx, productID); err != nil {
+			return err
+		}
+
+		result := tx.Where("id = ? AND product_id = ?", imageID, productID).Delete(&model.ProductImage{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		removed = true
+		return ensureMainImage(tx, productID)
+	})
+	return removed, err
+}
+
+// Reorder 按传入顺序重排图片，必须包含商品的全部图片
+func (r *productImageRepository) Reorder(productID uint64, imageIDs []uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockProduct(tx, productID); err != nil {
+			return err
+		}
+
+		var existing []uint64
+		if err := tx.Model(&model.ProductImage{}).Where("product_id = ?", productID).Pluck("id", &existing).Error; err != nil {
+			return err
+		}
+		if len(existing) != len(imageIDs) {
+			return ErrImageOrderMismatch
+		}
+		known := make(map[uint64]bool, len(existing))
+		for _, id := range existing {
+			known[id] = true
+		}
+		for _, id := range imageIDs {
+			if !known[id] {
+				return ErrImageOrderMismatch
+			}
+			delete(known, id)
+		}
+
+		for i, id := range imageIDs {
+			if err := tx.Model(&model.ProductImage{}).Where("id = ?", id).Update("sort_order", i).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SetMain 设置主图，图片不存在时返回false
+func (r *productImageRepository) SetMain(productID, imageID uint64) (bool, error) {
+	found := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockProduct(tx, productID); err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&model.ProductImage{}).Where("id = ? AND product_id = ?", imageID, productID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return nil
+		}
+
+		found = true
+		if err := clearMainImage(tx, productID); err != nil {
+			return err
+		}
+		return tx.Model(&model.ProductImage{}).Where("id = ?", imageID).Update("is_main", 1).Error
+	})
+	return found, err
+}
+
+// lockProduct 锁定商品行，串行化同一商品的图片操作
+func lockProduct(tx *gorm.DB, productID uint64) error {
+	var product model.Product
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", productID).First(&product).Error
+}
+
+// clearMainImage 取消商品的主图标记
+func clearMainImage(tx *gorm.DB, productID uint64) error {
+	return tx.Model(&model.ProductImage{}).Where("product_id = ? AND is_main = ?", productID, 1).Update("is_main", 0).Error
+}
+
+// ensureMainImage 商品有图片但没有主图时，将排序最靠前的图片设为主图
+func ensureMainImage(tx *gorm.DB, productID uint64) error {
+	var count int64
+	if err := tx.Model(&model.ProductImage{}).Where("product_id = ? AND is_main = ?", productID, 1).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	var first model.ProductImage
+	err := tx.Where("product_id = ?", productID).Order("sort_order ASC, id ASC").First(&first).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Model(&first).Update("is_main", 1).Error
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"mall/internal/model"
+)
+
+// imageLayout 按排序返回商品图片地址及主图地址
+func imageLayout(t *testing.T, repo ProductImageRepository, productID uint64) ([]string, string) {
+	t.Helper()
+
+	images, err := repo.GetByProductID(productID)
+	if err != nil {
+		t.Fatalf("GetByProductID: %v", err)
+	}
+	var urls []string
+	main := ""
+	for _, image := range images {
+		urls = append(urls, image.ImageURL)
+		if image.IsMain == 1 {
+			if main != "" {
+				t.Fatalf("more than one main image: %s and %s", main, image.ImageURL)
+			}
+			main = image.ImageURL
+		}
+	}
+	return urls, main
+}
+
+func TestProductImages(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewProductImageRepository(db)
+	product := createTestProduct(t, db, 10, 1)
+	other := createTestProduct(t, db, 10, 1)
+	if err := repo.Add(&model.ProductImage{ProductID: other.ID, ImageURL: "other.png"}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	add := func(url string, sort int, main int8) func() error {
+		return func() error {
+			return repo.Add(&model.ProductImage{ProductID: product.ID, ImageURL: url, SortOrder: sort, IsMain: main})
+		}
+	}
+	id := func(url string) uint64 {
+		var image model.ProductImage
+		db.Where("product_id = ? AND image_url = ?", product.ID, url).First(&image)
+		return image.ID
+	}
+	setMain := func(url string, wantFound bool) func() error {
+		return func() error {
+			found, err := repo.SetMain(product.ID, id(url))
+			if err == nil && found != wantFound {
+				return errors.New("unexpected found result")
+			}
+			return err
+		}
+	}
+	remove := func(imageID func() uint64, wantRemoved bool) func() error {
+		return func() error {
+			removed, err := repo.Remove(product.ID, imageID())
+			if err == nil && removed != wantRemoved {
+				return errors.New("unexpected removed result")
+			}
+			return err
+		}
+	}
+	reorder := func(urls ...string) func() error {
+		return func() error {
+			ids := make([]uint64, len(urls))
+			for i, url := range urls {
+				ids[i] = id(url)
+			}
+			return repo.Reorder(product.ID, ids)
+		}
+	}
+
+	steps := []struct {
+		name     string
+		run      func() error
+		wantErr  error
+		wantURLs []string
+		wantMain string
+	}{
+		{"first image becomes main", add("a.png", 0, 0), nil, []string{"a.png"}, "a.png"},
+		{"second image keeps main", add("b.png", 1, 0), nil, []string{"a.png", "b.png"}, "a.png"},
+		{"new main replaces old", add("c.png", 2, 1), nil, []string{"a.png", "b.png", "c.png"}, "c.png"},
+		{"set main", setMain("b.png", true), nil, []string{"a.png", "b.png", "c.png"}, "b.png"},
+		{"set main of another product", setMain("other.png", false), nil, []string{"a.png", "b.png", "c.png"}, "b.png"},
+		{"reorder", reorder("c.png", "b.png", "a.png"), nil, []string{"c.png", "b.png", "a.png"}, "b.png"},
+		{"reorder missing image", reorder("c.png", "b.png"), ErrImageOrderMismatch, []string{"c.png", "b.png", "a.png"}, "b.png"},
+		{"reorder duplicate image", reorder("c.png", "b.png", "b.png"), ErrImageOrderMismatch, []string{"c.png", "b.png", "a.png"}, "b.png"},
+		{"remove main moves it to first", remove(func() uint64 { return id("b.png") }, true), nil, []string{"c.png", "a.png"}, "c.png"},
+		{"remove unknown image", remove(func() uint64 { return 999 }, false), nil, []string{"c.png", "a.png"}, "c.png"},
+		{"remove other images", remove(func() uint64 { return id("a.png") }, true), nil, []string{"c.png"}, "c.png"},
+		{"remove last image", remove(func() uint64 { return id("c.png") }, true), nil, nil, ""},
+	}
+	for _, step := range steps {
+		if err := step.run(); !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: err = %v, want %v", step.name, err, step.wantErr)
+		}
+		urls, main := imageLayout(t, repo, product.ID)
+		if len(urls) != len(step.wantURLs) || main != step.wantMain {
+			t.Fatalf("%s: images = %v, main = %s", step.name, urls, main)
+		}
+		for i := range urls {
+			if urls[i] != step.wantURLs[i] {
+				t.Errorf("%s: images = %v, want %v", step.name, urls, step.wantURLs)
+				break
+			}
+		}
+	}
+
+	if urls, main := imageLayout(t, repo, other.ID); len(urls) != 1 || main != "other.png" {
+		t.Errorf("other product images = %v, main = %s", urls, main)
+	}
+	if err := repo.Add(&model.ProductImage{ProductID: 999, ImageURL: "x.png"}); err == nil {
+		t.Error("adding an image to an unknown product should fail")
+	}
+}
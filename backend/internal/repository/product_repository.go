@@ -1,24 +1,36 @@
 package repository
 
 import (
+	"errors"
+	"time"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"mall/internal/model"
 )
 
+// ErrSKUNotInProduct 待更新的SKU不属于该商品
+var ErrSKUNotInProduct = errors.New("sku does not belong to product")
+
 // ProductRepository 商品仓储接口
 type ProductRepository interface {
-	Create(product *model.Product) error
+	Create(product *model.Product, inTx TxFunc) error
 	GetByID(id uint64) (*model.Product, error)
 	GetWithDetails(id uint64) (*model.Product, error)
-	Update(product *model.Product) error
+	Update(product *model.Product, inTx TxFunc) error
+	UpdateWithSKUs(product *model.Product, skus []*model.ProductSKU, inTx TxFunc) error
 	Delete(id uint64) error
 	List(page, pageSize int, categoryID uint64, status int8) ([]*model.Product, int64, error)
 	Search(keyword string, page, pageSize int) ([]*model.Product, int64, error)
 	GetByCategoryID(categoryID uint64, page, pageSize int) ([]*model.Product, int64, error)
 	GetHotProducts(limit int) ([]*model.Product, error)
-	UpdateStock(id uint64, stock int) error
 	UpdateSales(id uint64, sales int) error
+	SetListingSchedule(id uint64, publishAt, unpublishAt *time.Time) error
+	ClearListingSchedule(tx *gorm.DB, id uint64) error
+	FindDueListings(now time.Time, limit int) ([]*model.Product, error)
+	ApplyListingSchedule(id uint64, now time.Time) (bool, error)
+	ListUpcomingLaunches(page, pageSize int) ([]*model.Product, int64, error)
 	// 为SearchService添加的方法
 	GetProductsByIDs(ids []int) ([]model.Product, error)
 	GetAllProducts() ([]model.Product, error)
@@ -34,9 +46,37 @@ func NewProductRepository(db *gorm.DB) ProductRepository {
 	return &productRepository{db: db}
 }
 
-// Create 创建商品
-func (r *productRepository) Create(product *model.Product) error {
-	return r.db.Create(product).Error
+// Create 在同一事务中创建商品及其关联并执行inTx（如写入初始库存）
+func (r *productRepository) Create(product *model.Product, inTx TxFunc) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// status有默认值，零值创建时会被默认值覆盖，创建后按请求恢复
+		status := product.Status
+		skuStatus := make([]int8, len(product.SKUs))
+		for i := range product.SKUs {
+			skuStatus[i] = product.SKUs[i].Status
+		}
+		if err := tx.Create(product).Error; err != nil {
+			return err
+		}
+		if status == 0 {
+			if err := tx.Model(product).Update("status", 0).Error; err != nil {
+				return err
+			}
+		}
+		for i := range product.SKUs {
+			if skuStatus[i] != 0 {
+				continue
+			}
+			if err := tx.Model(&product.SKUs[i]).Update("status", 0).Error; err != nil {
+				return err
+			}
+		}
+
+		if inTx == nil {
+			return nil
+		}
+		return inTx(tx)
+	})
 }
 
 // GetByID 根据ID获取商品
@@ -65,9 +105,106 @@ func (r *productRepository) GetWithDetails(id uint64) (*model.Product, error) {
 	return &product, nil
 }
 
-// Update 更新商品
-func (r *productRepository) Update(product *model.Product) error {
-	return r.db.Save(product).Error
+// Update 在同一事务中更新商品并执行inTx，库存只能通过库存仓储变更，定时上下架时间单独设置
+func (r *productRepository) Update(product *model.Product, inTx TxFunc) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("stock", "publish_at", "unpublish_at").Save(product).Error; err != nil {
+			return err
+		}
+		if inTx == nil {
+			return nil
+		}
+		return inTx(tx)
+	})
+}
+
+// UpdateWithSKUs 在同一事务中更新商品并同步SKU：带ID的按ID更新，其余按编码匹配，
+// 未匹配的以0库存新建（同商品已软删除的同编码SKU会被恢复），不在列表中的软删除；
+// 库存不在此处修改，由inTx在同一事务中通过库存仓储记录流水后变更
+func (r *productRepository) UpdateWithSKUs(product *model.Product, skus []*model.ProductSKU, inTx TxFunc) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations, "stock", "publish_at", "unpublish_at").Save(product).Error; err != nil {
+			return err
+		}
+
+		var existing []*model.ProductSKU
+		if err := tx.Unscoped().Where("product_id = ?", product.ID).Find(&existing).Error; err != nil {
+			return err
+		}
+		byID := make(map[uint64]*model.ProductSKU, len(existing))
+		byCode := make(map[string]*model.ProductSKU, len(existing))
+		for _, sku := range existing {
+			byID[sku.ID] = sku
+			byCode[sku.SKUCode] = sku
+		}
+
+		keep := make(map[uint64]bool, len(skus))
+		for _, sku := range skus {
+			var old *model.ProductSKU
+			if sku.ID > 0 {
+				old = byID[sku.ID]
+				if old == nil || old.DeletedAt.Valid {
+					return ErrSKUNotInProduct
+				}
+			} else {
+				old = byCode[sku.SKUCode]
+			}
+
+			sku.ProductID = product.ID
+			if old == nil {
+				// status有默认值，零值创建时会被数据库默认值覆盖，部分驱动还会回填到结构体
+				status := sku.Status
+				sku.Stock = 0
+				if err := tx.Create(sku).Error; err != nil {
+					return err
+				}
+				if status == 0 {
+					if err := tx.Model(sku).Update("status", 0).Error; err != nil {
+						return err
+					}
+				}
+				keep[sku.ID] = true
+				continue
+			}
+
+			sku.ID = old.ID
+			sku.CreatedAt = old.CreatedAt
+			sku.Stock = old.Stock
+			if sku.SpecKey == "" {
+				sku.SpecKey = old.SpecKey
+			}
+			if err := tx.Unscoped().Model(&model.ProductSKU{}).Where("id = ?", old.ID).Updates(map[string]interface{}{
+				"sku_code":    sku.SKUCode,
+				"name":        sku.Name,
+				"price":       sku.Price,
+				"attr_values": sku.AttrValues,
+				"spec_key":    sku.SpecKey,
+				"image":       sku.Image,
+				"status":      sku.Status,
+				"deleted_at":  nil,
+			}).Error; err != nil {
+				return err
+			}
+			keep[old.ID] = true
+		}
+
+		var removed []uint64
+		for _, sku := range existing {
+			if !keep[sku.ID] && !sku.DeletedAt.Valid {
+				removed = append(removed, sku.ID)
+			}
+		}
+		if len(removed) > 0 {
+			if err := tx.Where("id IN ?", removed).Delete(&model.ProductSKU{}).Error; err != nil {
+				return err
+			}
+		}
+
+		if inTx == nil {
+			return nil
+		}
+		return inTx(tx)
+	})
 }
 
 // Delete 删除商品（软删除）
@@ -138,7 +275,7 @@ func (r *productRepository) GetByCategoryID(categoryID uint64, page, pageSize in
 	return r.List(page, pageSize, categoryID, 1)
 }
 
-// GetHotProducts 获取热门商品
+// GetHotProducts 获取热门商品，按销量与收藏数之和排序
 func (r *productRepository) GetHotProducts(limit int) ([]*model.Product, error) {
 	var products []*model.Product
 	err := r.db.Preload("Category").
@@ -146,17 +283,12 @@ func (r *productRepository) GetHotProducts(limit int) ([]*model.Product, error)
 			return db.Where("is_main = ?", 1)
 		}).
 		Where("status = ?", 1).
-		Order("sales DESC").
+		Order("sales + favorite_count DESC, id DESC").
 		Limit(limit).
 		Find(&products).Error
 	return products, err
 }
 
-// UpdateStock 更新商品库存
-func (r *productRepository) UpdateStock(id uint64, stock int) error {
-	return r.db.Model(&model.Product{}).Where("id = ?", id).Update("stock", stock).Error
-}
-
 // UpdateSales 更新商品销量
 func (r *productRepository) UpdateSales(id uint64, sales int) error {
 	return r.db.Model(&model.Product{}).Where("id = ?", id).Update("sales", sales).Error
@@ -184,4 +316,93 @@ func (r *productRepository) GetAllProducts() ([]model.Product, error) {
 		Where("status = ?", 1).
 		Find(&products).Error
 	return products, err
+}
+
+// SetListingSchedule 设置定时上下架时间，传nil清除
+func (r *productRepository) SetListingSchedule(id uint64, publishAt, unpublishAt *time.Time) error {
+	return r.db.Model(&model.Product{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"publish_at":   publishAt,
+		"unpublish_at": unpublishAt,
+	}).Error
+}
+
+// ClearListingSchedule 清除待执行的定时上下架时间，tx为调用方事务时与状态修改一同提交
+func (r *productRepository) ClearListingSchedule(tx *gorm.DB, id uint64) error {
+	if tx == nil {
+		tx = r.db
+	}
+	return tx.Model(&model.Product{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"publish_at":   nil,
+		"unpublish_at": nil,
+	}).Error
+}
+
+// FindDueListings 查找定时上架或下架时间已到的商品
+func (r *productRepository) FindDueListings(now time.Time, limit int) ([]*model.Product, error) {
+	var products []*model.Product
+	err := r.db.Select("id").
+		Where("publish_at <= ? OR unpublish_at <= ?", now, now).
+		Order("id ASC").
+		Limit(limit).
+		Find(&products).Error
+	return products, err
+}
+
+// ApplyListingSchedule 按到期的上下架时间切换商品状态并清空已执行的时间，
+// 上架与下架都已到期时以较晚的一个为准，返回状态是否发生变化
+func (r *productRepository) ApplyListingSchedule(id uint64, now time.Time) (bool, error) {
+	changed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var product model.Product
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "status", "publish_at", "unpublish_at").
+			Where("id = ?", id).
+			First(&product).Error
+		if err != nil {
+			return err
+		}
+
+		publishDue := product.PublishAt != nil && !product.PublishAt.After(now)
+		unpublishDue := product.UnpublishAt != nil && !product.UnpublishAt.After(now)
+		status := product.Status
+		updates := map[string]interface{}{}
+		if publishDue {
+			status = 1
+			updates["publish_at"] = nil
+		}
+		if unpublishDue {
+			if !publishDue || !product.UnpublishAt.Before(*product.PublishAt) {
+				status = 0
+			}
+			updates["unpublish_at"] = nil
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+
+		changed = status != product.Status
+		updates["status"] = status
+		return tx.Model(&model.Product{}).Where("id = ?", id).Updates(updates).Error
+	})
+	return changed, err
+}
+
+// ListUpcomingLaunches 分页获取待定时上架的商品，按上架时间升序
+func (r *productRepository) ListUpcomingLaunches(page, pageSize int) ([]*model.Product, int64, error) {
+	var products []*model.Product
+	var total int64
+
+	query := r.db.Model(&model.Product{}).Where("publish_at IS NOT NULL")
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Preload("Category").Preload("Images", func(db *gorm.DB) *gorm.DB {
+		return db.Where("is_main = ?", 1)
+	}).Offset(offset).Limit(pageSize).
+		Order("publish_at ASC, id ASC").
+		Find(&products).Error
+
+	return products, total, err
 }
\ No newline at end of file
@@ -0,0 +1,330 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+)
+
+// setListing 直接写入商品状态与定时上下架时间
+func setListing(t *testing.T, db *gorm.DB, id uint64, status int8, publishAt, unpublishAt *time.Time) {
+	t.Helper()
+
+	err := db.Model(&model.Product{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       status,
+		"publish_at":   publishAt,
+		"unpublish_at": unpublishAt,
+	}).Error
+	if err != nil {
+		t.Fatalf("set listing: %v", err)
+	}
+}
+
+// loadProduct 重新读取商品
+func loadProduct(t *testing.T, db *gorm.DB, id uint64) *model.Product {
+	t.Helper()
+
+	var product model.Product
+	if err := db.First(&product, id).Error; err != nil {
+		t.Fatalf("load product: %v", err)
+	}
+	return &product
+}
+
+func TestApplyListingSchedule(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	earlier := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	cases := []struct {
+		name          string
+		status        int8
+		publishAt     *time.Time
+		unpublishAt   *time.Time
+		wantStatus    int8
+		wantChanged   bool
+		wantPublish   bool
+		wantUnpublish bool
+	}{
+		{"publish due", 0, &past, nil, 1, true, false, false},
+		{"unpublish due", 1, nil, &past, 0, true, false, false},
+		{"publish due keeps future unpublish", 0, &past, &future, 1, true, false, true},
+		{"nothing due", 0, &future, nil, 0, false, true, false},
+		{"both due, unpublish later", 1, &earlier, &past, 0, true, false, false},
+		{"both due, publish later", 0, &past, &earlier, 1, true, false, false},
+		{"publish due on listed product", 1, &past, nil, 1, false, false, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := newTestDB(t)
+			repo := NewProductRepository(db)
+			product := createTestProduct(t, db, 100, 10)
+			setListing(t, db, product.ID, tc.status, tc.publishAt, tc.unpublishAt)
+
+			changed, err := repo.ApplyListingSchedule(product.ID, now)
+			if err != nil {
+				t.Fatalf("ApplyListingSchedule: %v", err)
+			}
+			if changed != tc.wantChanged {
+				t.Errorf("changed = %v, want %v", changed, tc.wantChanged)
+			}
+
+			got := loadProduct(t, db, product.ID)
+			if got.Status != tc.wantStatus {
+				t.Errorf("status = %d, want %d", got.Status, tc.wantStatus)
+			}
+			if (got.PublishAt != nil) != tc.wantPublish {
+				t.Errorf("publish_at = %v, want set: %v", got.PublishAt, tc.wantPublish)
+			}
+			if (got.UnpublishAt != nil) != tc.wantUnpublish {
+				t.Errorf("unpublish_at = %v, want set: %v", got.UnpublishAt, tc.wantUnpublish)
+			}
+		})
+	}
+}
+
+func TestFindDueListings(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewProductRepository(db)
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Hour)
+
+	publish := createTestProduct(t, db, 100, 10)
+	setListing(t, db, publish.ID, 0, &past, nil)
+	unpublish := createTestProduct(t, db, 100, 10)
+	setListing(t, db, unpublish.ID, 1, &future, &past)
+	pending := createTestProduct(t, db, 100, 10)
+	setListing(t, db, pending.ID, 0, &future, nil)
+	createTestProduct(t, db, 100, 10)
+
+	products, err := repo.FindDueListings(now, 10)
+	if err != nil {
+		t.Fatalf("FindDueListings: %v", err)
+	}
+	if len(products) != 2 || products[0].ID != publish.ID || products[1].ID != unpublish.ID {
+		t.Errorf("due listings = %+v, want products %d and %d", products, publish.ID, unpublish.ID)
+	}
+}
+
+func TestUpdateKeepsListingSchedule(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewProductRepository(db)
+	product := createTestProduct(t, db, 100, 10)
+	future := time.Now().Add(time.Hour)
+	setListing(t, db, product.ID, 0, &future, nil)
+
+	// 编辑商品时不覆盖定时上下架时间
+	loaded := loadProduct(t, db, product.ID)
+	loaded.PublishAt = nil
+	loaded.Name = "新名称"
+	if err := repo.Update(loaded, nil); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got := loadProduct(t, db, product.ID); got.PublishAt == nil || got.Name != "新名称" {
+		t.Errorf("product = name %q publish_at %v, want renamed with schedule kept", got.Name, got.PublishAt)
+	}
+
+	// 手动修改状态时在同一事务中清除定时
+	loaded.Status = 1
+	err := repo.Update(loaded, func(tx *gorm.DB) error {
+		return repo.ClearListingSchedule(tx, product.ID)
+	})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got := loadProduct(t, db, product.ID)
+	if got.Status != 1 || got.PublishAt != nil || got.UnpublishAt != nil {
+		t.Errorf("product = status %d publish_at %v unpublish_at %v, want listed without schedule", got.Status, got.PublishAt, got.UnpublishAt)
+	}
+	if changed, _ := repo.ApplyListingSchedule(product.ID, future.Add(time.Minute)); changed {
+		t.Error("cleared schedule should not change the status")
+	}
+}
+
+// loadSKUs 读取商品的全部SKU（包含已删除），按ID升序
+func loadSKUs(t *testing.T, db *gorm.DB, productID uint64) []*model.ProductSKU {
+	t.Helper()
+
+	var skus []*model.ProductSKU
+	if err := db.Unscoped().Where("product_id = ?", productID).Order("id ASC").Find(&skus).Error; err != nil {
+		t.Fatalf("load skus: %v", err)
+	}
+	return skus
+}
+
+func TestUpdateWithSKUsDiff(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewProductRepository(db)
+	product := createTestProduct(t, db, 100, 0)
+	kept := createTestSKU(t, db, product.ID, "SKU-KEEP", 100, 7)
+	byCode := createTestSKU(t, db, product.ID, "SKU-CODE", 100, 3)
+	removed := createTestSKU(t, db, product.ID, "SKU-REMOVE", 100, 2)
+
+	skus := []*model.ProductSKU{
+		{BaseModel: model.BaseModel{ID: kept.ID}, SKUCode: "SKU-KEEP-2", Name: "改名", Price: 90, Stock: 99, Status: 1},
+		{SKUCode: "SKU-CODE", Name: "按编码匹配", Price: 80, Status: 1},
+		{SKUCode: "SKU-NEW", Name: "新增", Price: 70, Stock: 5, Status: 0},
+	}
+	var seen []uint64
+	err := repo.UpdateWithSKUs(product, skus, func(tx *gorm.DB) error {
+		// 事务内已能拿到新建SKU的ID
+		for _, sku := range skus {
+			seen = append(seen, sku.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateWithSKUs: %v", err)
+	}
+	if seen[0] != kept.ID || seen[1] != byCode.ID || seen[2] == 0 {
+		t.Errorf("sku ids inside transaction = %v", seen)
+	}
+
+	all := loadSKUs(t, db, product.ID)
+	if len(all) != 4 {
+		t.Fatalf("skus = %d, want 4", len(all))
+	}
+	got := make(map[uint64]*model.ProductSKU, len(all))
+	for _, sku := range all {
+		got[sku.ID] = sku
+	}
+
+	// 已有SKU保留库存，只更新其他字段
+	if sku := got[kept.ID]; sku.SKUCode != "SKU-KEEP-2" || sku.Price != 90 || sku.Stock != 7 || sku.DeletedAt.Valid {
+		t.Errorf("kept sku = %+v", sku)
+	}
+	if sku := got[byCode.ID]; sku.Name != "按编码匹配" || sku.Price != 80 || sku.Stock != 3 {
+		t.Errorf("sku matched by code = %+v", sku)
+	}
+	if sku := got[removed.ID]; !sku.DeletedAt.Valid {
+		t.Error("sku missing from the list should be soft deleted")
+	}
+	// 新SKU以0库存创建，状态按请求保存
+	if sku := got[skus[2].ID]; sku.Stock != 0 || sku.Status != 0 || sku.ProductID != product.ID {
+		t.Errorf("new sku = %+v, want stock 0 and status 0", sku)
+	}
+}
+
+func TestUpdateWithSKUsRestoresDeletedCode(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewProductRepository(db)
+	product := createTestProduct(t, db, 100, 0)
+	deleted := createTestSKU(t, db, product.ID, "SKU-1", 100, 4)
+	db.Delete(&model.ProductSKU{}, deleted.ID)
+
+	skus := []*model.ProductSKU{{SKUCode: "SKU-1", Name: "恢复", Price: 60, Status: 1}}
+	if err := repo.UpdateWithSKUs(product, skus, nil); err != nil {
+		t.Fatalf("UpdateWithSKUs: %v", err)
+	}
+
+	all := loadSKUs(t, db, product.ID)
+	if len(all) != 1 || all[0].ID != deleted.ID || all[0].DeletedAt.Valid || all[0].Stock != 4 || all[0].Price != 60 {
+		t.Errorf("skus = %+v, want the deleted sku restored with its stock", all)
+	}
+}
+
+func TestUpdateWithSKUsRollsBack(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewProductRepository(db)
+	product := createTestProduct(t, db, 100, 0)
+	sku := createTestSKU(t, db, product.ID, "SKU-1", 100, 4)
+	other := createTestProduct(t, db, 100, 0)
+	foreign := createTestSKU(t, db, other.ID, "SKU-OTHER", 100, 1)
+
+	// 其他商品的SKU ID被拒绝，之前的写入全部回滚
+	product.Name = "不应保存"
+	skus := []*model.ProductSKU{
+		{SKUCode: "SKU-NEW", Price: 1, Status: 1},
+		{BaseModel: model.BaseModel{ID: foreign.ID}, SKUCode: "SKU-OTHER", Price: 1, Status: 1},
+	}
+	if err := repo.UpdateWithSKUs(product, skus, nil); err != ErrSKUNotInProduct {
+		t.Fatalf("UpdateWithSKUs error = %v, want ErrSKUNotInProduct", err)
+	}
+	if got := loadProduct(t, db, product.ID); got.Name == "不应保存" {
+		t.Error("product update should be rolled back")
+	}
+	if all := loadSKUs(t, db, product.ID); len(all) != 1 || all[0].DeletedAt.Valid {
+		t.Errorf("skus = %+v, want only the original sku", all)
+	}
+
+	// inTx失败同样回滚
+	errAbort := gorm.ErrInvalidData
+	skus = []*model.ProductSKU{{SKUCode: "SKU-NEW", Price: 1, Status: 1}}
+	err := repo.UpdateWithSKUs(product, skus, func(tx *gorm.DB) error { return errAbort })
+	if err != errAbort {
+		t.Fatalf("UpdateWithSKUs error = %v, want the inTx error", err)
+	}
+	if all := loadSKUs(t, db, product.ID); len(all) != 1 || all[0].ID != sku.ID || all[0].DeletedAt.Valid {
+		t.Errorf("skus = %+v, want the original sku untouched", all)
+	}
+}
+
+func TestCreateKeepsDisabledSKUs(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewProductRepository(db)
+
+	product := &model.Product{
+		CategoryID: 1,
+		Name:       "新商品",
+		Price:      100,
+		Status:     1,
+		SKUs: []model.ProductSKU{
+			{SKUCode: "SKU-ON", Price: 100, Status: 1},
+			{SKUCode: "SKU-OFF", Price: 100, Status: 0},
+		},
+	}
+	var ids []uint64
+	err := repo.Create(product, func(tx *gorm.DB) error {
+		for _, sku := range product.SKUs {
+			ids = append(ids, sku.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if len(ids) != 2 || ids[0] == 0 || ids[1] == 0 {
+		t.Errorf("sku ids inside transaction = %v", ids)
+	}
+
+	all := loadSKUs(t, db, product.ID)
+	if len(all) != 2 || all[0].Status != 1 || all[1].Status != 0 {
+		t.Errorf("skus = %+v, want the second sku disabled", all)
+	}
+
+	// 编码重复时商品与SKU一并回滚
+	duplicate := &model.Product{CategoryID: 1, Name: "重复", Price: 1, SKUs: []model.ProductSKU{{SKUCode: "SKU-ON", Price: 1}}}
+	if err := repo.Create(duplicate, nil); err == nil {
+		t.Fatal("duplicate sku code should fail")
+	}
+	var count int64
+	db.Model(&model.Product{}).Where("name = ?", "重复").Count(&count)
+	if count != 0 {
+		t.Error("product should be rolled back with its skus")
+	}
+}
+
+func TestCreateScheduledProductStaysUnlisted(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewProductRepository(db)
+	publishAt := time.Now().Add(time.Hour)
+
+	product := &model.Product{CategoryID: 1, Name: "预售商品", Price: 100, Status: 0, PublishAt: &publishAt}
+	if err := repo.Create(product, nil); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	got := loadProduct(t, db, product.ID)
+	if got.Status != 0 || got.PublishAt == nil {
+		t.Errorf("product = status %d publish_at %v, want unlisted until publish_at", got.Status, got.PublishAt)
+	}
+	if changed, _ := repo.ApplyListingSchedule(product.ID, publishAt.Add(time.Minute)); !changed {
+		t.Error("product should be listed once publish_at passes")
+	}
+}
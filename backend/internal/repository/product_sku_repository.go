@@ -1,11 +1,16 @@
 package repository
 
 import (
+	"errors"
+
 	"gorm.io/gorm"
 
 	"mall/internal/model"
 )
 
+// ErrSKUCodeExists SKU编码已被其他SKU使用
+var ErrSKUCodeExists = errors.New("sku code already exists")
+
 // ProductSKURepository 商品SKU仓储接口
 type ProductSKURepository interface {
 	Create(sku *model.ProductSKU) error
@@ -14,8 +19,8 @@ type ProductSKURepository interface {
 	GetByProductID(productID uint64) ([]*model.ProductSKU, error)
 	Update(sku *model.ProductSKU) error
 	Delete(id uint64) error
-	UpdateStock(id uint64, stock int) error
-	BatchUpdateStock(skuIDs []uint64, stocks []int) error
+	SyncSpecMatrix(productID uint64, skus []*model.ProductSKU, inTx func(tx *gorm.DB, created []uint64) error) ([]uint64, error)
+	FindByCodes(codes []string) ([]*model.ProductSKU, error)
 }
 
 // productSKURepository 商品SKU仓储实现
@@ -62,6 +67,16 @@ func (r *productSKURepository) GetByProductID(productID uint64) ([]*model.Produc
 	return skus, err
 }
 
+// FindByCodes 按编码查询SKU，包含已软删除的SKU（仍占用唯一索引）
+func (r *productSKURepository) FindByCodes(codes []string) ([]*model.ProductSKU, error) {
+	var skus []*model.ProductSKU
+	if len(codes) == 0 {
+		return skus, nil
+	}
+	err := r.db.Unscoped().Where("sku_code IN ?", codes).Find(&skus).Error
+	return skus, err
+}
+
 // Update 更新SKU
 func (r *productSKURepository) Update(sku *model.ProductSKU) error {
 	return r.db.Save(sku).Error
@@ -72,30 +87,85 @@ func (r *productSKURepository) Delete(id uint64) error {
 	return r.db.Delete(&model.ProductSKU{}, id).Error
 }
 
-// UpdateStock 更新SKU库存
-func (r *productSKURepository) UpdateStock(id uint64, stock int) error {
-	return r.db.Model(&model.ProductSKU{}).Where("id = ?", id).Update("stock", stock).Error
-}
+// SyncSpecMatrix 按规格组合同步SKU：已有组合保留价格库存并重新启用，新组合按传入值以0库存创建，
+// 不在组合中的SKU（包括没有规格键的旧SKU）被停用，inTx在同一事务中处理新建的SKU（如写入初始库存），返回新建的SKU ID
+func (r *productSKURepository) SyncSpecMatrix(productID uint64, skus []*model.ProductSKU, inTx func(tx *gorm.DB, created []uint64) error) ([]uint64, error) {
+	var created []uint64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockProduct(tx, productID); err != nil {
+			return err
+		}
 
-// BatchUpdateStock 批量更新SKU库存
-func (r *productSKURepository) BatchUpdateStock(skuIDs []uint64, stocks []int) error {
-	if len(skuIDs) != len(stocks) {
-		return gorm.ErrInvalidData
-	}
+		var existing []*model.ProductSKU
+		if err := tx.Where("product_id = ?", productID).Find(&existing).Error; err != nil {
+			return err
+		}
+		existingByKey := make(map[string]*model.ProductSKU, len(existing))
+		for _, sku := range existing {
+			if sku.SpecKey != "" {
+				existingByKey[sku.SpecKey] = sku
+			}
+		}
 
-	tx := r.db.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+		// 新建SKU的编码需要全局唯一，已软删除的SKU同样占用唯一索引
+		var codes []string
+		for _, sku := range skus {
+			if existingByKey[sku.SpecKey] == nil {
+				codes = append(codes, sku.SKUCode)
+			}
+		}
+		if len(codes) > 0 {
+			var count int64
+			if err := tx.Unscoped().Model(&model.ProductSKU{}).Where("sku_code IN ?", codes).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrSKUCodeExists
+			}
 		}
-	}()
 
-	for i, skuID := range skuIDs {
-		if err := tx.Model(&model.ProductSKU{}).Where("id = ?", skuID).Update("stock", stocks[i]).Error; err != nil {
-			tx.Rollback()
-			return err
+		keep := make(map[uint64]bool, len(skus))
+		for _, sku := range skus {
+			if old := existingByKey[sku.SpecKey]; old != nil {
+				keep[old.ID] = true
+				if err := tx.Model(&model.ProductSKU{}).Where("id = ?", old.ID).Updates(map[string]interface{}{
+					"name":        sku.Name,
+					"attr_values": sku.AttrValues,
+					"status":      1,
+				}).Error; err != nil {
+					return err
+				}
+				name, attrValues := sku.Name, sku.AttrValues
+				*sku = *old
+				sku.Name, sku.AttrValues, sku.Status = name, attrValues, 1
+				continue
+			}
+
+			sku.ProductID = productID
+			sku.Stock = 0
+			if err := tx.Create(sku).Error; err != nil {
+				return err
+			}
+			keep[sku.ID] = true
+			created = append(created, sku.ID)
 		}
-	}
 
-	return tx.Commit().Error
+		var disabled []uint64
+		for _, sku := range existing {
+			if !keep[sku.ID] && sku.Status == 1 {
+				disabled = append(disabled, sku.ID)
+			}
+		}
+		if len(disabled) > 0 {
+			if err := tx.Model(&model.ProductSKU{}).Where("id IN ?", disabled).Update("status", 0).Error; err != nil {
+				return err
+			}
+		}
+
+		if inTx == nil {
+			return nil
+		}
+		return inTx(tx, created)
+	})
+	return created, err
 }
\ No newline at end of file
@@ -0,0 +1,280 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+)
+
+// ErrSpecNotFound 规格或规格值ID不属于该商品，或被重复使用
+var ErrSpecNotFound = errors.New("spec or spec value not found")
+
+// ProductSpecRepository 商品规格仓储接口
+type ProductSpecRepository interface {
+	GetByProductID(productID uint64) ([]*model.ProductSpec, error)
+	Save(productID uint64, specs []*model.ProductSpec) error
+}
+
+// productSpecRepository 商品规格仓储实现
+type productSpecRepository struct {
+	db *gorm.DB
+}
+
+// NewProductSpecRepository 创建商品规格仓储
+func NewProductSpecRepository(db *gorm.DB) ProductSpecRepository {
+	return &productSpecRepository{db: db}
+}
+
+// GetByProductID 获取商品规格及规格值，按排序返回
+func (r *productSpecRepository) GetByProductID(productID uint64) ([]*model.ProductSpec, error) {
+	return loadProductSpecs(r.db, productID)
+}
+
+// Save 保存商品全部规格，带ID的规格与规格值原地更新（支持改名），其余按名称匹配已有记录以保持ID不变，
+// 未出现的规格与规格值被删除，规格组合因此失效的SKU会被停用，其余SKU同步名称与属性
+func (r *productSpecRepository) Save(productID uint64, specs []*model.ProductSpec) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockProduct(tx, productID); err != nil {
+			return err
+		}
+
+		existing, err := loadProductSpecs(tx, productID)
+		if err != nil {
+			return err
+		}
+		existingSpecs := make(map[uint64]*model.ProductSpec, len(existing))
+		existingSpecNames := make(map[string]*model.ProductSpec, len(existing))
+		for _, spec := range existing {
+			existingSpecs[spec.ID] = spec
+			existingSpecNames[spec.Name] = spec
+		}
+
+		keptSpecs := make(map[uint64]bool)
+		keptValues := make(map[uint64]bool)
+		for i, spec := range specs {
+			values := spec.Values
+			spec.ProductID = productID
+			spec.SortOrder = i
+			spec.Values = nil
+
+			var old *model.ProductSpec
+			if spec.ID > 0 {
+				if old = existingSpecs[spec.ID]; old == nil {
+					return ErrSpecNotFound
+				}
+			} else {
+				old = existingSpecNames[spec.Name]
+			}
+			if old != nil && keptSpecs[old.ID] {
+				return ErrSpecNotFound
+			}
+
+			if old != nil {
+				spec.ID = old.ID
+				if err := tx.Model(&model.ProductSpec{}).Where("id = ?", old.ID).Updates(map[string]interface{}{
+					"name":       spec.Name,
+					"sort_order": i,
+				}).Error; err != nil {
+					return err
+				}
+			} else if err := tx.Create(spec).Error; err != nil {
+				return err
+			}
+			keptSpecs[spec.ID] = true
+
+			oldValues := make(map[uint64]bool)
+			oldValueNames := make(map[string]uint64)
+			if old != nil {
+				for _, value := range old.Values {
+					oldValues[value.ID] = true
+					oldValueNames[value.Value] = value.ID
+				}
+			}
+			for j := range values {
+				value := &values[j]
+				value.ProductID = productID
+				value.SpecID = spec.ID
+				value.SortOrder = j
+
+				id := value.ID
+				if id > 0 && !oldValues[id] {
+					return ErrSpecNotFound
+				}
+				if id == 0 {
+					id = oldValueNames[value.Value]
+				}
+				if id > 0 && keptValues[id] {
+					return ErrSpecNotFound
+				}
+
+				if id > 0 {
+					value.ID = id
+					if err := tx.Model(&model.ProductSpecValue{}).Where("id = ?", id).Updates(map[string]interface{}{
+						"value":      value.Value,
+						"image":      value.Image,
+						"sort_order": j,
+					}).Error; err != nil {
+						return err
+					}
+				} else if err := tx.Create(value).Error; err != nil {
+					return err
+				}
+				keptValues[value.ID] = true
+			}
+			spec.Values = values
+		}
+
+		// 删除不再使用的规格与规格值
+		for _, spec := range existing {
+			for _, value := range spec.Values {
+				if !keptValues[value.ID] {
+					if err := tx.Delete(&model.ProductSpecValue{}, value.ID).Error; err != nil {
+						return err
+					}
+				}
+			}
+			if !keptSpecs[spec.ID] {
+				if err := tx.Delete(&model.ProductSpec{}, spec.ID).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		return syncSpecSKUs(tx, productID, specs)
+	})
+}
+
+// loadProductSpecs 加载商品规格及规格值
+func loadProductSpecs(db *gorm.DB, productID uint64) ([]*model.ProductSpec, error) {
+	var specs []*model.ProductSpec
+	err := db.Where("product_id = ?", productID).
+		Preload("Values", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sort_order ASC, id ASC")
+		}).
+		Order("sort_order ASC, id ASC").
+		Find(&specs).Error
+	return specs, err
+}
+
+// syncSpecSKUs 停用规格组合与当前规格不匹配的SKU，其余SKU按当前规格值刷新名称与属性
+func syncSpecSKUs(tx *gorm.DB, productID uint64, specs []*model.ProductSpec) error {
+	var skus []*model.ProductSKU
+	if err := tx.Where("product_id = ? AND spec_key <> ? AND status = ?", productID, "", 1).Find(&skus).Error; err != nil {
+		return err
+	}
+
+	var invalid []uint64
+	for _, sku := range skus {
+		name, attrValues, ok := describeSpecKey(sku.SpecKey, specs)
+		if !ok {
+			invalid = append(invalid, sku.ID)
+			continue
+		}
+		if name == sku.Name && attrValues == sku.AttrValues {
+			continue
+		}
+		if err := tx.Model(&model.ProductSKU{}).Where("id = ?", sku.ID).Updates(map[string]interface{}{
+			"name":        name,
+			"attr_values": attrValues,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	if len(invalid) == 0 {
+		return nil
+	}
+	return tx.Model(&model.ProductSKU{}).Where("id IN ?", invalid).Update("status", 0).Error
+}
+
+// describeSpecKey 按规格顺序生成规格键对应的SKU名称与属性JSON，规格键与当前规格不匹配时返回false
+func describeSpecKey(key string, specs []*model.ProductSpec) (string, string, bool) {
+	values := matchSpecKey(key, specs)
+	if values == nil {
+		return "", "", false
+	}
+
+	names := make([]string, 0, len(values))
+	attrs := make(map[string]string, len(values))
+	for i, value := range values {
+		names = append(names, value.Value)
+		attrs[specs[i].Name] = value.Value
+	}
+	attrValues, err := json.Marshal(attrs)
+	if err != nil {
+		return "", "", false
+	}
+	return strings.Join(names, " "), string(attrValues), true
+}
+
+// BuildSpecKey 将规格值ID组合转换为SKU的规格键，ID升序排列，规格调整顺序后规格键不变
+func BuildSpecKey(valueIDs []uint64) string {
+	sorted := make([]uint64, len(valueIDs))
+	copy(sorted, valueIDs)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	parts := make([]string, 0, len(sorted))
+	for _, id := range sorted {
+		parts = append(parts, strconv.FormatUint(id, 10))
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseSpecKey 解析SKU的规格键
+func ParseSpecKey(key string) []uint64 {
+	if key == "" {
+		return nil
+	}
+	parts := strings.Split(key, ",")
+	ids := make([]uint64, 0, len(parts))
+	for _, part := range parts {
+		id, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return nil
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// SpecKeyMatches 判断规格键是否恰好包含每个规格的一个规格值
+func SpecKeyMatches(key string, specs []*model.ProductSpec) bool {
+	return matchSpecKey(key, specs) != nil
+}
+
+// matchSpecKey 将规格键中的规格值按规格顺序排列，不是每个规格恰好一个规格值时返回nil
+func matchSpecKey(key string, specs []*model.ProductSpec) []*model.ProductSpecValue {
+	ids := ParseSpecKey(key)
+	if len(specs) == 0 || len(ids) != len(specs) {
+		return nil
+	}
+
+	matched := make([]*model.ProductSpecValue, len(specs))
+	for _, id := range ids {
+		found := false
+		for i, spec := range specs {
+			for j := range spec.Values {
+				if spec.Values[j].ID == id {
+					if matched[i] != nil {
+						return nil
+					}
+					matched[i] = &spec.Values[j]
+					found = true
+					break
+				}
+			}
+			if found {
+				break
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+	return matched
+}
@@ -0,0 +1,244 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+)
+
+// testSpecs 构造规格请求，每项为规格名及其规格值
+func testSpecs(defs ...[]string) []*model.ProductSpec {
+	specs := make([]*model.ProductSpec, 0, len(defs))
+	for _, def := range defs {
+		spec := &model.ProductSpec{Name: def[0]}
+		for _, value := range def[1:] {
+			spec.Values = append(spec.Values, model.ProductSpecValue{Value: value})
+		}
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
+// specValueID 按规格值名称查找ID
+func specValueID(t *testing.T, specs []*model.ProductSpec, value string) uint64 {
+	t.Helper()
+
+	for _, spec := range specs {
+		for _, v := range spec.Values {
+			if v.Value == value {
+				return v.ID
+			}
+		}
+	}
+	t.Fatalf("spec value %s not found", value)
+	return 0
+}
+
+func TestSpecKey(t *testing.T) {
+	if key := BuildSpecKey([]uint64{12, 3, 7}); key != "3,7,12" {
+		t.Errorf("BuildSpecKey = %s, want 3,7,12", key)
+	}
+	if key := BuildSpecKey([]uint64{7, 12, 3}); key != BuildSpecKey([]uint64{3, 12, 7}) {
+		t.Error("spec key should not depend on value order")
+	}
+
+	if ids := ParseSpecKey("3,7,12"); len(ids) != 3 || ids[0] != 3 || ids[2] != 12 {
+		t.Errorf("ParseSpecKey = %v", ids)
+	}
+	for _, key := range []string{"", "3,,7", "a,1", "-1"} {
+		if ids := ParseSpecKey(key); ids != nil {
+			t.Errorf("ParseSpecKey(%q) = %v, want nil", key, ids)
+		}
+	}
+}
+
+func TestSpecKeyMatches(t *testing.T) {
+	specs := []*model.ProductSpec{
+		{Name: "颜色", Values: []model.ProductSpecValue{{BaseModel: model.BaseModel{ID: 10}, Value: "红"}, {BaseModel: model.BaseModel{ID: 11}, Value: "蓝"}}},
+		{Name: "尺码", Values: []model.ProductSpecValue{{BaseModel: model.BaseModel{ID: 2}, Value: "S"}, {BaseModel: model.BaseModel{ID: 3}, Value: "M"}}},
+	}
+
+	cases := map[string]bool{
+		"2,10":   true,
+		"10,2":   true,
+		"3,11":   true,
+		"10":     false,
+		"10,11":  false,
+		"2,3":    false,
+		"2,10,3": false,
+		"2,99":   false,
+		"":       false,
+	}
+	for key, want := range cases {
+		if got := SpecKeyMatches(key, specs); got != want {
+			t.Errorf("SpecKeyMatches(%q) = %v, want %v", key, got, want)
+		}
+	}
+
+	// 匹配结果按规格顺序排列，与规格键中的顺序无关
+	values := matchSpecKey("2,11", specs)
+	if len(values) != 2 || values[0].Value != "蓝" || values[1].Value != "S" {
+		t.Errorf("matchSpecKey = %+v, want 蓝 then S", values)
+	}
+	if name, attrs, ok := describeSpecKey("2,11", specs); !ok || name != "蓝 S" || attrs != `{"尺码":"S","颜色":"蓝"}` {
+		t.Errorf("describeSpecKey = %q %q %v", name, attrs, ok)
+	}
+}
+
+func TestSaveSpecsKeepsIDs(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewProductSpecRepository(db)
+	product := createTestProduct(t, db, 100, 0)
+
+	if err := repo.Save(product.ID, testSpecs([]string{"颜色", "红", "蓝"}, []string{"尺码", "S", "M"})); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	saved, _ := repo.GetByProductID(product.ID)
+	red, small := specValueID(t, saved, "红"), specValueID(t, saved, "S")
+
+	// 调整规格顺序并按名称提交，规格值ID不变
+	if err := repo.Save(product.ID, testSpecs([]string{"尺码", "S", "M"}, []string{"颜色", "红", "蓝"})); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	reordered, _ := repo.GetByProductID(product.ID)
+	if reordered[0].Name != "尺码" || specValueID(t, reordered, "红") != red || specValueID(t, reordered, "S") != small {
+		t.Errorf("reordered specs = %+v, want the same value ids", reordered)
+	}
+
+	// 带ID改名时原地更新
+	renamed := testSpecs([]string{"尺码", "S", "M"}, []string{"颜色", "大红", "蓝"})
+	renamed[1].Values[0].ID = red
+	if err := repo.Save(product.ID, renamed); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	after, _ := repo.GetByProductID(product.ID)
+	if specValueID(t, after, "大红") != red {
+		t.Error("renamed value should keep its id")
+	}
+
+	// 其他商品的规格值ID或重复使用的ID被拒绝
+	invalid := testSpecs([]string{"颜色", "红"})
+	invalid[0].Values[0].ID = 9999
+	if err := repo.Save(product.ID, invalid); !errors.Is(err, ErrSpecNotFound) {
+		t.Errorf("foreign value id error = %v, want ErrSpecNotFound", err)
+	}
+	duplicate := testSpecs([]string{"颜色", "红", "蓝"})
+	duplicate[0].Values[0].ID = red
+	duplicate[0].Values[1].ID = red
+	if err := repo.Save(product.ID, duplicate); !errors.Is(err, ErrSpecNotFound) {
+		t.Errorf("duplicate value id error = %v, want ErrSpecNotFound", err)
+	}
+}
+
+func TestSaveSpecsSyncsSKUs(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewProductSpecRepository(db)
+	product := createTestProduct(t, db, 100, 0)
+
+	if err := repo.Save(product.ID, testSpecs([]string{"颜色", "红", "蓝"}, []string{"尺码", "S"})); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	saved, _ := repo.GetByProductID(product.ID)
+	red, blue, small := specValueID(t, saved, "红"), specValueID(t, saved, "蓝"), specValueID(t, saved, "S")
+
+	redSKU := createTestSKU(t, db, product.ID, "SKU-RED", 100, 1)
+	blueSKU := createTestSKU(t, db, product.ID, "SKU-BLUE", 100, 1)
+	db.Model(redSKU).Update("spec_key", BuildSpecKey([]uint64{red, small}))
+	db.Model(blueSKU).Update("spec_key", BuildSpecKey([]uint64{blue, small}))
+
+	// 改名刷新SKU名称与属性，删除的规格值使对应SKU停用
+	renamed := testSpecs([]string{"颜色", "大红"}, []string{"尺码", "S"})
+	renamed[0].Values[0].ID = red
+	if err := repo.Save(product.ID, renamed); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	var got model.ProductSKU
+	db.First(&got, redSKU.ID)
+	if got.Status != 1 || got.Name != "大红 S" || got.AttrValues != `{"尺码":"S","颜色":"大红"}` {
+		t.Errorf("renamed sku = status %d name %q attrs %s", got.Status, got.Name, got.AttrValues)
+	}
+	var disabled model.ProductSKU
+	db.First(&disabled, blueSKU.ID)
+	if disabled.Status != 0 {
+		t.Error("sku using a removed value should be disabled")
+	}
+}
+
+func TestSyncSpecMatrix(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewProductSKURepository(db)
+	product := createTestProduct(t, db, 100, 0)
+
+	existing := createTestSKU(t, db, product.ID, "P-1-3", 88, 6)
+	db.Model(existing).Update("spec_key", "1,3")
+	legacy := createTestSKU(t, db, product.ID, "LEGACY", 100, 2)
+
+	skus := []*model.ProductSKU{
+		{SKUCode: "P-1-3", Name: "红 S", Price: 100, Stock: 5, SpecKey: "1,3", Status: 1},
+		{SKUCode: "P-1-4", Name: "红 M", Price: 100, Stock: 5, SpecKey: "1,4", Status: 1},
+		{SKUCode: "P-2-3", Name: "蓝 S", Price: 100, Stock: 5, SpecKey: "2,3", Status: 1},
+	}
+	var inTxCreated []uint64
+	created, err := repo.SyncSpecMatrix(product.ID, skus, func(tx *gorm.DB, ids []uint64) error {
+		inTxCreated = ids
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("SyncSpecMatrix: %v", err)
+	}
+	if len(created) != 2 || len(inTxCreated) != 2 || created[0] != skus[1].ID || created[1] != skus[2].ID {
+		t.Errorf("created = %v, inTx got %v", created, inTxCreated)
+	}
+
+	// 已有组合保留价格与库存
+	if skus[0].ID != existing.ID || skus[0].Price != 88 || skus[0].Stock != 6 || skus[0].Name != "红 S" {
+		t.Errorf("existing combination = %+v", skus[0])
+	}
+	for _, sku := range skus[1:] {
+		if stock := productStock(t, db, product.ID, sku.ID); stock != 0 {
+			t.Errorf("new sku %s stock = %d, want 0", sku.SKUCode, stock)
+		}
+	}
+	var got model.ProductSKU
+	db.First(&got, legacy.ID)
+	if got.Status != 0 {
+		t.Error("sku without a spec key should be disabled")
+	}
+
+	// 再次生成不重复创建
+	again := []*model.ProductSKU{{SKUCode: "P-1-4", SpecKey: "1,4", Price: 1, Status: 1}}
+	created, err = repo.SyncSpecMatrix(product.ID, again, nil)
+	if err != nil || len(created) != 0 {
+		t.Errorf("repeat SyncSpecMatrix = %v, %v; want nothing created", created, err)
+	}
+	var missing model.ProductSKU
+	db.First(&missing, existing.ID)
+	if missing.Status != 0 {
+		t.Error("combination missing from the matrix should be disabled")
+	}
+}
+
+func TestSyncSpecMatrixRejectsUsedCode(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewProductSKURepository(db)
+	product := createTestProduct(t, db, 100, 0)
+	other := createTestProduct(t, db, 100, 0)
+	taken := createTestSKU(t, db, other.ID, "P-1", 100, 1)
+	db.Delete(taken)
+
+	// 已删除的SKU同样占用编码
+	skus := []*model.ProductSKU{
+		{SKUCode: "P-2", SpecKey: "2", Price: 1, Status: 1},
+		{SKUCode: "P-1", SpecKey: "1", Price: 1, Status: 1},
+	}
+	if _, err := repo.SyncSpecMatrix(product.ID, skus, nil); !errors.Is(err, ErrSKUCodeExists) {
+		t.Fatalf("SyncSpecMatrix error = %v, want ErrSKUCodeExists", err)
+	}
+	if all := loadSKUs(t, db, product.ID); len(all) != 0 {
+		t.Errorf("skus = %d, want none after rollback", len(all))
+	}
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"encoding/base64"
+	"os"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"mall/internal/model"
+	"mall/pkg/config"
+	"mall/pkg/utils"
+)
+
+func TestMain(m *testing.M) {
+	// 加密字段的密钥只加载一次，测试开始前使用固定密钥完成加载
+	cfg := &config.Config{}
+	cfg.Encryption.ActiveVersion = 1
+	cfg.Encryption.Keys = map[string]string{"1": base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))}
+	cfg.Encryption.BlindIndexKey = "blind-index-test-key"
+	config.GlobalConfig = cfg
+	if err := utils.InitFieldCrypto(); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+// newTestDB 创建内存SQLite数据库，迁移商品相关表及额外指定的表
+func newTestDB(t *testing.T, models ...interface{}) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	// 内存库按连接隔离，只保留一个连接
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("get sql db: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	err = db.AutoMigrate(
+		&model.Category{},
+		&model.Product{},
+		&model.ProductSKU{},
+		&model.ProductImage{},
+		&model.ProductSpec{},
+		&model.ProductSpecValue{},
+		&model.InventoryLedger{},
+		&model.PriceSchedule{},
+		&model.PriceHistory{},
+	)
+	if err == nil {
+		err = db.AutoMigrate(models...)
+	}
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// createTestProduct 创建测试商品
+func createTestProduct(t *testing.T, db *gorm.DB, price float64, stock int) *model.Product {
+	t.Helper()
+
+	product := &model.Product{CategoryID: 1, Name: "测试商品", Price: price, Stock: stock, Status: 1}
+	if err := db.Create(product).Error; err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	return product
+}
+
+// createTestSKU 创建测试SKU
+func createTestSKU(t *testing.T, db *gorm.DB, productID uint64, code string, price float64, stock int) *model.ProductSKU {
+	t.Helper()
+
+	sku := &model.ProductSKU{ProductID: productID, SKUCode: code, Name: code, Price: price, Stock: stock, Status: 1}
+	if err := db.Create(sku).Error; err != nil {
+		t.Fatalf("create sku: %v", err)
+	}
+	return sku
+}
+
+// productStock 读取商品或SKU当前库存
+func productStock(t *testing.T, db *gorm.DB, productID, skuID uint64) int {
+	t.Helper()
+
+	stock, err := lockStock(db, productID, skuID)
+	if err != nil {
+		t.Fatalf("load stock: %v", err)
+	}
+	return stock
+}
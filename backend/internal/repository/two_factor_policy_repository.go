@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+)
+
+// TwoFactorPolicyRepository 两步验证策略仓储接口
+type TwoFactorPolicyRepository interface {
+	List() ([]*model.TwoFactorPolicy, error)
+	GetByRole(role string) (*model.TwoFactorPolicy, error)
+	Save(policy *model.TwoFactorPolicy) error
+}
+
+// twoFactorPolicyRepository 两步验证策略仓储实现
+type twoFactorPolicyRepository struct {
+	db *gorm.DB
+}
+
+// NewTwoFactorPolicyRepository 创建两步验证策略仓储
+func NewTwoFactorPolicyRepository(db *gorm.DB) TwoFactorPolicyRepository {
+	return &twoFactorPolicyRepository{db: db}
+}
+
+// List 获取所有策略
+func (r *twoFactorPolicyRepository) List() ([]*model.TwoFactorPolicy, error) {
+	var policies []*model.TwoFactorPolicy
+	err := r.db.Order("role ASC").Find(&policies).Error
+	return policies, err
+}
+
+// GetByRole 根据角色获取策略
+func (r *twoFactorPolicyRepository) GetByRole(role string) (*model.TwoFactorPolicy, error) {
+	var policy model.TwoFactorPolicy
+	err := r.db.Where("role = ?", role).First(&policy).Error
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// Save 创建或更新角色策略
+func (r *twoFactorPolicyRepository) Save(policy *model.TwoFactorPolicy) error {
+	existing, err := r.GetByRole(policy.Role)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return r.db.Create(policy).Error
+		}
+		return err
+	}
+
+	policy.ID = existing.ID
+	policy.CreatedAt = existing.CreatedAt
+	return r.db.Save(policy).Error
+}
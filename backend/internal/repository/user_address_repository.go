@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"mall/internal/model"
+)
+
+// ErrAddressLimitReached 地址数量达到上限
+var ErrAddressLimitReached = errors.New("address limit reached")
+
+// UserAddressRepository 用户地址仓储接口
+type UserAddressRepository interface {
+	Create(address *model.UserAddress, maxPerUser int) error
+	GetByID(id uint64) (*model.UserAddress, error)
+	GetByUserID(userID uint64) ([]*model.UserAddress, error)
+	GetDefault(userID uint64) (*model.UserAddress, error)
+	Update(address *model.UserAddress) error
+	Delete(userID, id uint64) error
+	SetDefault(userID, id uint64) error
+}
+
+// userAddressRepository 用户地址仓储实现
+type userAddressRepository struct {
+	db *gorm.DB
+}
+
+// NewUserAddressRepository 创建用户地址仓储
+func NewUserAddressRepository(db *gorm.DB) UserAddressRepository {
+	return &userAddressRepository{db: db}
+}
+
+// Create 创建地址，在同一事务中校验数量上限并维护唯一默认地址
+func (r *userAddressRepository) Create(address *model.UserAddress, maxPerUser int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// 锁定用户记录，避免并发创建超出上限
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&model.User{}, address.UserID).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&model.UserAddress{}).Where("user_id = ?", address.UserID).Count(&count).Error; err != nil {
+			return err
+		}
+		if maxPerUser > 0 && count >= int64(maxPerUser) {
+			return ErrAddressLimitReached
+		}
+
+		// 第一个地址自动设为默认
+		if count == 0 {
+			address.IsDefault = 1
+		}
+		if address.IsDefault == 1 {
+			if err := tx.Model(&model.UserAddress{}).Where("user_id = ? AND is_default = ?", address.UserID, 1).
+				Update("is_default", 0).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(address).Error
+	})
+}
+
+// GetByID 根据ID获取地址
+func (r *userAddressRepository) GetByID(id uint64) (*model.UserAddress, error) {
+	var address model.UserAddress
+	err := r.db.First(&address, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+// GetByUserID 获取用户地址列表，默认地址在前
+func (r *userAddressRepository) GetByUserID(userID uint64) ([]*model.UserAddress, error) {
+	var addresses []*model.UserAddress
+	err := r.db.Where("user_id = ?", userID).
+		Order("is_default DESC, updated_at DESC").
+		Find(&addresses).Error
+	return addresses, err
+}
+
+// GetDefault 获取用户默认地址
+func (r *userAddressRepository) GetDefault(userID uint64) (*model.UserAddress, error) {
+	var address model.UserAddress
+	err := r.db.Where("user_id = ? AND is_default = ?", userID, 1).First(&address).Error
+	if err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+// Update 更新地址，设为默认时取消其他默认地址
+func (r *userAddressRepository) Update(address *model.UserAddress) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if address.IsDefault == 1 {
+			if err := tx.Model(&model.UserAddress{}).
+				Where("user_id = ? AND id <> ? AND is_default = ?", address.UserID, address.ID, 1).
+				Update("is_default", 0).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(address).Error
+	})
+}
+
+// Delete 删除地址，删除默认地址时将最近更新的地址设为默认
+func (r *userAddressRepository) Delete(userID, id uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var address model.UserAddress
+		if err := tx.Where("id = ? AND user_id = ?", id, userID).First(&address).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&address).Error; err != nil {
+			return err
+		}
+		if address.IsDefault != 1 {
+			return nil
+		}
+
+		var next model.UserAddress
+		err := tx.Where("user_id = ?", userID).Order("updated_at DESC").First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&next).Update("is_default", 1).Error
+	})
+}
+
+// SetDefault 设置默认地址
+func (r *userAddressRepository) SetDefault(userID, id uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.UserAddress{}).Where("id = ? AND user_id = ?", id, userID).Update("is_default", 1)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Model(&model.UserAddress{}).Where("user_id = ? AND id <> ? AND is_default = ?", userID, id, 1).
+			Update("is_default", 0).Error
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"gorm.io/gorm"
 
 	"mall/internal/model"
+	"mall/pkg/utils"
 )
 
 // UserAuthRepository 用户认证仓储接口
@@ -43,7 +44,12 @@ func (r *userAuthRepository) GetByUserIDAndType(userID uint64, authType string)
 // GetByAuthKey 根据认证key获取认证信息
 func (r *userAuthRepository) GetByAuthKey(authKey string) (*model.UserAuth, error) {
 	var auth model.UserAuth
-	err := r.db.Preload("User").Where("auth_key = ?", authKey).First(&auth).Error
+	// 认证key加密存储，通过盲索引查询
+	authKeyHash, err := utils.BlindIndex(authKey)
+	if err != nil {
+		return nil, err
+	}
+	err = r.db.Preload("User").Where("auth_key_hash = ?", authKeyHash).First(&auth).Error
 	if err != nil {
 		return nil, err
 	}
@@ -4,8 +4,15 @@ import (
 	"gorm.io/gorm"
 
 	"mall/internal/model"
+	"mall/pkg/utils"
 )
 
+// UserQuery 用户查询条件
+type UserQuery struct {
+	Keyword string
+	Status  *int8
+}
+
 // UserRepository 用户仓储接口
 type UserRepository interface {
 	// 基础CRUD
@@ -21,6 +28,9 @@ type UserRepository interface {
 	Search(keyword string, page, pageSize int) ([]*model.User, int64, error)
 	GetUserWithProfile(id uint64) (*model.User, error)
 	GetUserWithAuth(id uint64) (*model.User, error)
+	Query(query *UserQuery, page, pageSize int) ([]*model.User, int64, error)
+	GetUserDetail(id uint64, orderLimit int) (*model.User, error)
+	UpdateStatus(id uint64, status int8) error
 }
 
 // userRepository 用户仓储实现
@@ -51,7 +61,12 @@ func (r *userRepository) GetByID(id uint64) (*model.User, error) {
 // GetByPhone 根据手机号获取用户
 func (r *userRepository) GetByPhone(phone string) (*model.User, error) {
 	var user model.User
-	err := r.db.Where("phone = ?", phone).First(&user).Error
+	// 手机号加密存储，通过盲索引查询
+	phoneHash, err := utils.BlindIndex(phone)
+	if err != nil {
+		return nil, err
+	}
+	err = r.db.Where("phone_hash = ?", phoneHash).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -99,9 +114,13 @@ func (r *userRepository) Search(keyword string, page, pageSize int) ([]*model.Us
 	var users []*model.User
 	var total int64
 
+	phoneHash, err := utils.BlindIndex(keyword)
+	if err != nil {
+		return nil, 0, err
+	}
 	query := r.db.Model(&model.User{}).Where(
-		"username LIKE ? OR phone LIKE ?",
-		"%"+keyword+"%", "%"+keyword+"%",
+		"username LIKE ? OR phone_hash = ?",
+		"%"+keyword+"%", phoneHash,
 	)
 
 	// 计算总数
@@ -111,7 +130,7 @@ func (r *userRepository) Search(keyword string, page, pageSize int) ([]*model.Us
 
 	// 分页查询
 	offset := (page - 1) * pageSize
-	err := query.Offset(offset).Limit(pageSize).Find(&users).Error
+	err = query.Offset(offset).Limit(pageSize).Find(&users).Error
 	return users, total, err
 }
 
@@ -133,4 +152,61 @@ func (r *userRepository) GetUserWithAuth(id uint64) (*model.User, error) {
 		return nil, err
 	}
 	return &user, nil
-}
\ No newline at end of file
+}
+
+// Query 按条件查询用户，关键字匹配用户名、ID或手机号
+func (r *userRepository) Query(query *UserQuery, page, pageSize int) ([]*model.User, int64, error) {
+	var users []*model.User
+	var total int64
+
+	db := r.db.Model(&model.User{})
+	if query.Keyword != "" {
+		phoneHash, err := utils.BlindIndex(query.Keyword)
+		if err != nil {
+			return nil, 0, err
+		}
+		db = db.Where("username LIKE ? OR id = ? OR phone_hash = ?",
+			"%"+query.Keyword+"%", query.Keyword, phoneHash)
+	}
+	if query.Status != nil {
+		db = db.Where("status = ?", *query.Status)
+	}
+
+	// 计算总数
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 分页查询
+	offset := (page - 1) * pageSize
+	err := db.Preload("Profile").
+		Offset(offset).Limit(pageSize).
+		Order("id DESC").
+		Find(&users).Error
+
+	return users, total, err
+}
+
+// GetUserDetail 获取用户详情（包含资料、地址、认证信息和最近订单）
+func (r *userRepository) GetUserDetail(id uint64, orderLimit int) (*model.User, error) {
+	var user model.User
+	err := r.db.Preload("Profile").
+		Preload("Addresses", func(db *gorm.DB) *gorm.DB {
+			return db.Order("is_default DESC, updated_at DESC")
+		}).
+		Preload("AuthInfos").
+		Preload("Orders", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at DESC").Limit(orderLimit)
+		}).
+		Where("id = ?", id).
+		First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateStatus 更新用户状态
+func (r *userRepository) UpdateStatus(id uint64, status int8) error {
+	return r.db.Model(&model.User{}).Where("id = ?", id).Update("status", status).Error
+}
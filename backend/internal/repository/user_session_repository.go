@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+)
+
+// UserSessionRepository 用户会话仓储接口
+type UserSessionRepository interface {
+	Create(session *model.UserSession) error
+	GetByID(id uint64) (*model.UserSession, error)
+	GetBySessionID(sessionID string) (*model.UserSession, error)
+	GetActiveByUserID(userID uint64) ([]*model.UserSession, error)
+	GetActiveByPlatform(userID uint64, platform string) ([]*model.UserSession, error)
+	UpdateLastSeen(sessionID string, lastSeenAt time.Time) error
+	UpdateExpiresAt(sessionID string, expiresAt time.Time) error
+	Revoke(ids []uint64) error
+}
+
+// userSessionRepository 用户会话仓储实现
+type userSessionRepository struct {
+	db *gorm.DB
+}
+
+// NewUserSessionRepository 创建用户会话仓储
+func NewUserSessionRepository(db *gorm.DB) UserSessionRepository {
+	return &userSessionRepository{db: db}
+}
+
+// Create 创建会话
+func (r *userSessionRepository) Create(session *model.UserSession) error {
+	return r.db.Create(session).Error
+}
+
+// GetByID 根据ID获取会话
+func (r *userSessionRepository) GetByID(id uint64) (*model.UserSession, error) {
+	var session model.UserSession
+	err := r.db.Where("id = ?", id).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetBySessionID 根据会话标识获取会话
+func (r *userSessionRepository) GetBySessionID(sessionID string) (*model.UserSession, error) {
+	var session model.UserSession
+	err := r.db.Where("session_id = ?", sessionID).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetActiveByUserID 获取用户所有有效会话
+func (r *userSessionRepository) GetActiveByUserID(userID uint64) ([]*model.UserSession, error) {
+	var sessions []*model.UserSession
+	err := r.db.Where("user_id = ? AND status = ? AND expires_at > ?", userID, 1, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// GetActiveByPlatform 获取用户在指定平台的有效会话（按创建时间升序）
+func (r *userSessionRepository) GetActiveByPlatform(userID uint64, platform string) ([]*model.UserSession, error) {
+	var sessions []*model.UserSession
+	err := r.db.Where("user_id = ? AND platform = ? AND status = ? AND expires_at > ?", userID, platform, 1, time.Now()).
+		Order("created_at ASC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// UpdateLastSeen 更新最近活跃时间
+func (r *userSessionRepository) UpdateLastSeen(sessionID string, lastSeenAt time.Time) error {
+	return r.db.Model(&model.UserSession{}).Where("session_id = ?", sessionID).Update("last_seen_at", lastSeenAt).Error
+}
+
+// UpdateExpiresAt 更新过期时间
+func (r *userSessionRepository) UpdateExpiresAt(sessionID string, expiresAt time.Time) error {
+	return r.db.Model(&model.UserSession{}).Where("session_id = ?", sessionID).Update("expires_at", expiresAt).Error
+}
+
+// Revoke 注销会话
+func (r *userSessionRepository) Revoke(ids []uint64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Model(&model.UserSession{}).Where("id IN ?", ids).Update("status", 0).Error
+}
@@ -9,44 +9,124 @@ import (
 
 // AdminRoutes 管理后台路由组
 type AdminRoutes struct {
-	categoryHandler *handler.CategoryHandler
-	productHandler  *handler.ProductHandler
-	orderHandler    *handler.OrderHandler
-	paymentHandler  *handler.PaymentHandler
+	authHandler      *handler.AuthHandler
+	adminAuthHandler *handler.AdminAuthHandler
+	categoryHandler  *handler.CategoryHandler
+	productHandler   *handler.ProductHandler
+	orderHandler     *handler.OrderHandler
+	paymentHandler   *handler.PaymentHandler
+	auditHandler     *handler.AuditHandler
+	loyaltyHandler   *handler.LoyaltyHandler
+	uploadHandler    *handler.UploadHandler
+	inventoryHandler *handler.InventoryHandler
+	priceHandler     *handler.PriceHandler
 }
 
 // NewAdminRoutes 创建管理后台路由组
-func NewAdminRoutes(categoryHandler *handler.CategoryHandler, productHandler *handler.ProductHandler, 
-	orderHandler *handler.OrderHandler, paymentHandler *handler.PaymentHandler) *AdminRoutes {
+func NewAdminRoutes(authHandler *handler.AuthHandler, adminAuthHandler *handler.AdminAuthHandler, categoryHandler *handler.CategoryHandler, productHandler *handler.ProductHandler,
+	orderHandler *handler.OrderHandler, paymentHandler *handler.PaymentHandler, auditHandler *handler.AuditHandler, loyaltyHandler *handler.LoyaltyHandler, uploadHandler *handler.UploadHandler, inventoryHandler *handler.InventoryHandler, priceHandler *handler.PriceHandler) *AdminRoutes {
 	return &AdminRoutes{
-		categoryHandler: categoryHandler,
-		productHandler:  productHandler,
-		orderHandler:    orderHandler,
-		paymentHandler:  paymentHandler,
+		authHandler:      authHandler,
+		adminAuthHandler: adminAuthHandler,
+		categoryHandler:  categoryHandler,
+		productHandler:   productHandler,
+		orderHandler:     orderHandler,
+		paymentHandler:   paymentHandler,
+		auditHandler:     auditHandler,
+		loyaltyHandler:   loyaltyHandler,
+		uploadHandler:    uploadHandler,
+		inventoryHandler: inventoryHandler,
+		priceHandler:     priceHandler,
 	}
 }
 
 // RegisterRoutes 注册管理后台相关路由
 func (r *AdminRoutes) RegisterRoutes(router *gin.RouterGroup) {
+	// 管理员登录（无需认证）
+	adminAuth := router.Group("/admin/auth")
+	{
+		adminAuth.POST("/login", middleware.LoginRateLimiter(), r.adminAuthHandler.Login)
+		adminAuth.POST("/2fa/setup", middleware.LoginRateLimiter(), r.adminAuthHandler.SetupTwoFactorByChallenge)
+		adminAuth.POST("/2fa/verify", middleware.LoginRateLimiter(), r.adminAuthHandler.VerifyTwoFactor)
+	}
+
 	admin := router.Group("/admin")
-	admin.Use(middleware.AdminAuth())
+	admin.Use(middleware.AdminAuth(), r.auditHandler.Audit())
+	audit := r.auditHandler.Action
 	{
+		// 两步验证
+		admin2FA := admin.Group("/2fa")
+		{
+			admin2FA.GET("", r.adminAuthHandler.GetTwoFactorStatus)
+			admin2FA.POST("/setup", audit("admin.2fa_setup", "admin", ""), r.adminAuthHandler.SetupTwoFactor)
+			admin2FA.POST("/enable", audit("admin.2fa_enable", "admin", ""), r.adminAuthHandler.EnableTwoFactor)
+			admin2FA.POST("/disable", audit("admin.2fa_disable", "admin", ""), r.adminAuthHandler.DisableTwoFactor)
+			admin2FA.POST("/recovery-codes", audit("admin.recovery_codes", "admin", ""), r.adminAuthHandler.RegenerateRecoveryCodes)
+			admin2FA.GET("/policies", r.adminAuthHandler.ListTwoFactorPolicies)
+			admin2FA.PUT("/policies", middleware.SuperAdminAuth(), audit("two_factor_policy.update", "two_factor_policy", ""), r.adminAuthHandler.UpdateTwoFactorPolicy)
+		}
+
+		// 用户管理
+		adminUsers := admin.Group("/users")
+		{
+			adminUsers.GET("", r.authHandler.ListUsers)
+			adminUsers.GET("/:id", r.authHandler.GetUserDetail)
+			adminUsers.PUT("/:id/status", audit("user.status", "user", "id"), r.authHandler.UpdateUserStatus)
+			adminUsers.PUT("/:id/password", audit("user.reset_password", "user", "id"), r.authHandler.ResetUserPassword)
+			adminUsers.PUT("/:id/unlock", audit("user.unlock", "user", "id"), r.authHandler.UnlockAccount)
+			adminUsers.GET("/:id/merge-logs", r.authHandler.GetMergeLogs)
+		}
+
+		// 登录日志
+		admin.GET("/login-logs", r.authHandler.ListLoginLogs)
+
+		// 操作审计日志
+		admin.GET("/audit-logs", r.auditHandler.ListAuditLogs)
+		admin.GET("/audit-logs/:id", r.auditHandler.GetAuditLog)
+
 		// 分类管理
 		adminCategories := admin.Group("/categories")
 		{
-			adminCategories.POST("", r.categoryHandler.CreateCategory)
-			adminCategories.PUT("/:id", r.categoryHandler.UpdateCategory)
-			adminCategories.DELETE("/:id", r.categoryHandler.DeleteCategory)
+			adminCategories.POST("", audit("category.create", "category", ""), r.categoryHandler.CreateCategory)
+			adminCategories.PUT("/:id", audit("category.update", "category", "id"), r.categoryHandler.UpdateCategory)
+			adminCategories.DELETE("/:id", audit("category.delete", "category", "id"), r.categoryHandler.DeleteCategory)
 		}
 
 		// 商品管理
 		adminProducts := admin.Group("/products")
 		{
 			adminProducts.GET("", r.productHandler.GetProductList)
-			adminProducts.POST("", r.productHandler.CreateProduct)
-			adminProducts.PUT("/:id", r.productHandler.UpdateProduct)
-			adminProducts.DELETE("/:id", r.productHandler.DeleteProduct)
-			adminProducts.PUT("/:id/stock", r.productHandler.UpdateProductStock)
+			adminProducts.GET("/upcoming-launches", r.productHandler.GetUpcomingLaunches)
+			adminProducts.POST("", audit("product.create", "product", ""), r.productHandler.CreateProduct)
+			adminProducts.PUT("/:id", audit("product.update", "product", "id"), r.productHandler.UpdateProduct)
+			adminProducts.DELETE("/:id", audit("product.delete", "product", "id"), r.productHandler.DeleteProduct)
+			adminProducts.PUT("/:id/stock", audit("product.stock", "product", "id"), r.productHandler.UpdateProductStock)
+			adminProducts.GET("/:id/images", r.productHandler.ListProductImages)
+			adminProducts.POST("/:id/images", audit("product.image_add", "product", "id"), r.productHandler.AddProductImage)
+			adminProducts.PUT("/:id/images/sort", audit("product.image_sort", "product", "id"), r.productHandler.ReorderProductImages)
+			adminProducts.PUT("/:id/images/:imageId/main", audit("product.image_main", "product", "id"), r.productHandler.SetMainProductImage)
+			adminProducts.DELETE("/:id/images/:imageId", audit("product.image_remove", "product", "id"), r.productHandler.RemoveProductImage)
+			adminProducts.GET("/:id/specs", r.productHandler.GetProductSpecs)
+			adminProducts.PUT("/:id/specs", audit("product.specs", "product", "id"), r.productHandler.SaveProductSpecs)
+			adminProducts.POST("/:id/skus/generate", audit("product.sku_generate", "product", "id"), r.productHandler.GenerateSKUs)
+			adminProducts.GET("/:id/price-schedules", r.priceHandler.ListPriceSchedules)
+			adminProducts.POST("/:id/price-schedules", audit("product.price_schedule_create", "product", "id"), r.priceHandler.CreatePriceSchedule)
+			adminProducts.DELETE("/:id/price-schedules/:scheduleId", audit("product.price_schedule_cancel", "product", "id"), r.priceHandler.CancelPriceSchedule)
+			adminProducts.GET("/:id/price-history", r.priceHandler.GetPriceHistory)
+			adminProducts.PUT("/:id/listing-schedule", audit("product.listing_schedule", "product", "id"), r.productHandler.SetListingSchedule)
+		}
+
+		// 库存流水与批量导入
+		adminInventory := admin.Group("/inventory")
+		{
+			adminInventory.GET("/ledger", r.inventoryHandler.GetLedger)
+			adminInventory.POST("/import", audit("inventory.import", "inventory", ""), r.inventoryHandler.ImportStocks)
+		}
+
+		// 文件上传
+		adminUploads := admin.Group("/uploads")
+		{
+			adminUploads.POST("/product-image", audit("upload.product_image", "upload", ""), r.uploadHandler.UploadProductImage)
 		}
 
 		// 订单管理
@@ -54,13 +134,22 @@ func (r *AdminRoutes) RegisterRoutes(router *gin.RouterGroup) {
 		{
 			adminOrders.GET("", r.orderHandler.GetOrders)
 			adminOrders.GET("/search", r.orderHandler.SearchOrders)
-			adminOrders.PUT("/:id/status", r.orderHandler.UpdateOrderStatus)
+			adminOrders.PUT("/:id/status", audit("order.status", "order", "id"), r.orderHandler.UpdateOrderStatus)
+		}
+
+		// 会员等级与积分比例
+		adminMemberLevels := admin.Group("/member-levels")
+		{
+			adminMemberLevels.GET("", r.loyaltyHandler.ListMemberLevels)
+			adminMemberLevels.POST("", audit("member_level.create", "member_level", ""), r.loyaltyHandler.CreateMemberLevel)
+			adminMemberLevels.PUT("/:id", audit("member_level.update", "member_level", "id"), r.loyaltyHandler.UpdateMemberLevel)
+			adminMemberLevels.DELETE("/:id", audit("member_level.delete", "member_level", "id"), r.loyaltyHandler.DeleteMemberLevel)
 		}
 
 		// 支付管理
 		adminPayment := admin.Group("/payment")
 		{
-			adminPayment.POST("/:paymentNo/refund", r.paymentHandler.RefundPayment)
+			adminPayment.POST("/:paymentNo/refund", audit("payment.refund", "payment", "paymentNo"), r.paymentHandler.RefundPayment)
 		}
 	}
-}
\ No newline at end of file
+}
@@ -24,11 +24,15 @@ func (r *AuthRoutes) RegisterRoutes(router *gin.RouterGroup) {
 	auth := router.Group("/auth")
 	{
 		auth.POST("/sms/send", middleware.SMSRateLimiter(), r.authHandler.SendSMSCode)
+		auth.POST("/sms/register", middleware.SMSRateLimiter(), r.authHandler.SendRegisterCode)
 		auth.POST("/login/phone", middleware.LoginRateLimiter(), r.authHandler.LoginByPhone)
 		auth.POST("/login/wechat", middleware.LoginRateLimiter(), r.authHandler.LoginByWechat)
 		auth.POST("/login/password", middleware.LoginRateLimiter(), r.authHandler.LoginByPassword)
-		auth.POST("/register", r.authHandler.Register)
+		auth.POST("/register", middleware.LoginRateLimiter(), r.authHandler.Register)
 		auth.POST("/refresh", r.authHandler.RefreshToken)
+		auth.POST("/password/forgot", middleware.SMSRateLimiter(), r.authHandler.ForgotPassword)
+		auth.POST("/password/verify", middleware.LoginRateLimiter(), r.authHandler.VerifyPasswordReset)
+		auth.POST("/password/reset", r.authHandler.ResetPassword)
 		auth.POST("/logout", middleware.JWT(), r.authHandler.Logout)
 	}
 }
\ No newline at end of file
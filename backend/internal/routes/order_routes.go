@@ -27,7 +27,7 @@ func NewOrderRoutes(orderHandler *handler.OrderHandler, cartHandler *handler.Car
 func (r *OrderRoutes) RegisterRoutes(router *gin.RouterGroup) {
 	// 购物车相关路由
 	cart := router.Group("/cart")
-	cart.Use(middleware.UserAuth())
+	cart.Use(middleware.UserAuth(), middleware.RequestSignature())
 	{
 		cart.GET("", r.cartHandler.GetUserCart)
 		cart.GET("/count", r.cartHandler.GetCartCount)
@@ -39,10 +39,10 @@ func (r *OrderRoutes) RegisterRoutes(router *gin.RouterGroup) {
 
 	// 订单相关路由
 	orders := router.Group("/orders")
-	orders.Use(middleware.UserAuth())
+	orders.Use(middleware.UserAuth(), middleware.RequestSignature())
 	{
 		orders.GET("", r.orderHandler.GetUserOrders)
-		orders.POST("", r.orderHandler.CreateOrder)
+		orders.POST("", middleware.RateLimiter("order_create"), r.orderHandler.CreateOrder)
 		orders.GET("/:id", r.orderHandler.GetOrderDetail)
 		orders.PUT("/:id/cancel", r.orderHandler.CancelOrder)
 		orders.PUT("/:id/confirm", r.orderHandler.ConfirmOrder)
@@ -51,9 +51,9 @@ func (r *OrderRoutes) RegisterRoutes(router *gin.RouterGroup) {
 	// 支付相关路由
 	payment := router.Group("/payment")
 	{
-		payment.POST("", middleware.UserAuth(), r.paymentHandler.CreatePayment)
+		payment.POST("", middleware.UserAuth(), middleware.RequestSignature(), middleware.RateLimiter("payment_create"), r.paymentHandler.CreatePayment)
 		payment.GET("/:paymentNo/status", r.paymentHandler.GetPaymentStatus)
-		payment.PUT("/:paymentNo/cancel", middleware.UserAuth(), r.paymentHandler.CancelPayment)
+		payment.PUT("/:paymentNo/cancel", middleware.UserAuth(), middleware.RequestSignature(), r.paymentHandler.CancelPayment)
 		
 		// 支付回调（无需认证）
 		payment.POST("/wechat/callback", r.paymentHandler.WechatCallback)
@@ -4,6 +4,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"mall/internal/handler"
+	"mall/internal/middleware"
 )
 
 // ProductRoutes 商品路由组
@@ -39,6 +40,6 @@ func (r *ProductRoutes) RegisterRoutes(router *gin.RouterGroup) {
 		products.GET("/hot", r.productHandler.GetHotProducts)
 		products.GET("/category/:categoryId", r.productHandler.GetProductsByCategory)
 		products.GET("/:id", r.productHandler.GetProduct)
-		products.GET("/:id/detail", r.productHandler.GetProductDetail)
+		products.GET("/:id/detail", middleware.OptionalUserAuth(), r.productHandler.GetProductDetail)
 	}
 }
\ No newline at end of file
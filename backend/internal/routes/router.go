@@ -1,26 +1,40 @@
 package routes
 
 import (
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"mall/internal/handler"
 	"mall/internal/middleware"
+	"mall/pkg/config"
+	"mall/pkg/utils"
 )
 
 // Handlers 处理器容器
 type Handlers struct {
-	AuthHandler     *handler.AuthHandler
-	CategoryHandler *handler.CategoryHandler
-	ProductHandler  *handler.ProductHandler
-	CartHandler     *handler.CartHandler
-	OrderHandler    *handler.OrderHandler
-	PaymentHandler  *handler.PaymentHandler
+	AuthHandler         *handler.AuthHandler
+	CategoryHandler     *handler.CategoryHandler
+	ProductHandler      *handler.ProductHandler
+	CartHandler         *handler.CartHandler
+	OrderHandler        *handler.OrderHandler
+	PaymentHandler      *handler.PaymentHandler
+	AdminAuthHandler    *handler.AdminAuthHandler
+	PrivacyHandler      *handler.PrivacyHandler
+	AuditHandler        *handler.AuditHandler
+	AddressHandler      *handler.AddressHandler
+	LoyaltyHandler      *handler.LoyaltyHandler
+	FavoriteHandler     *handler.FavoriteHandler
+	FootprintHandler    *handler.FootprintHandler
+	NotificationHandler *handler.NotificationHandler
+	UploadHandler       *handler.UploadHandler
+	InventoryHandler    *handler.InventoryHandler
+	PriceHandler        *handler.PriceHandler
 }
 
 // SetupRouter 设置路由
-func SetupRouter(authHandler *handler.AuthHandler, categoryHandler *handler.CategoryHandler, productHandler *handler.ProductHandler, cartHandler *handler.CartHandler, orderHandler *handler.OrderHandler, paymentHandler *handler.PaymentHandler) *gin.Engine {
+func SetupRouter(authHandler *handler.AuthHandler, categoryHandler *handler.CategoryHandler, productHandler *handler.ProductHandler, cartHandler *handler.CartHandler, orderHandler *handler.OrderHandler, paymentHandler *handler.PaymentHandler, adminAuthHandler *handler.AdminAuthHandler, privacyHandler *handler.PrivacyHandler, auditHandler *handler.AuditHandler, addressHandler *handler.AddressHandler, loyaltyHandler *handler.LoyaltyHandler, favoriteHandler *handler.FavoriteHandler, footprintHandler *handler.FootprintHandler, notificationHandler *handler.NotificationHandler, uploadHandler *handler.UploadHandler, inventoryHandler *handler.InventoryHandler, priceHandler *handler.PriceHandler) *gin.Engine {
 	router := gin.New()
 
 	// 设置中间件
@@ -29,14 +43,31 @@ func SetupRouter(authHandler *handler.AuthHandler, categoryHandler *handler.Cate
 	// 注册健康检查路由
 	setupHealthCheck(router)
 
+	// 注册公开的JWT公钥集合
+	setupWellKnown(router)
+
+	// 注册本地上传文件访问路由
+	setupUploads(router)
+
 	// 注册API路由
 	handlers := &Handlers{
-		AuthHandler:     authHandler,
-		CategoryHandler: categoryHandler,
-		ProductHandler:  productHandler,
-		CartHandler:     cartHandler,
-		OrderHandler:    orderHandler,
-		PaymentHandler:  paymentHandler,
+		AuthHandler:         authHandler,
+		CategoryHandler:     categoryHandler,
+		ProductHandler:      productHandler,
+		CartHandler:         cartHandler,
+		OrderHandler:        orderHandler,
+		PaymentHandler:      paymentHandler,
+		AdminAuthHandler:    adminAuthHandler,
+		PrivacyHandler:      privacyHandler,
+		AuditHandler:        auditHandler,
+		AddressHandler:      addressHandler,
+		LoyaltyHandler:      loyaltyHandler,
+		FavoriteHandler:     favoriteHandler,
+		FootprintHandler:    footprintHandler,
+		NotificationHandler: notificationHandler,
+		UploadHandler:       uploadHandler,
+		InventoryHandler:    inventoryHandler,
+		PriceHandler:        priceHandler,
 	}
 	registerAPIRoutes(router, handlers)
 
@@ -45,7 +76,6 @@ func SetupRouter(authHandler *handler.AuthHandler, categoryHandler *handler.Cate
 
 // setupMiddleware 设置中间件
 func setupMiddleware(router *gin.Engine) {
-	router.Use(middleware.Logger())
 	router.Use(middleware.Recovery())
 	router.Use(middleware.RequestLogger())
 	router.Use(middleware.CORS())
@@ -62,6 +92,30 @@ func setupHealthCheck(router *gin.Engine) {
 	})
 }
 
+// setupWellKnown 设置公钥发现路由，供其他服务验证token
+func setupWellKnown(router *gin.Engine) {
+	router.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(200, utils.GetJWKS())
+	})
+}
+
+// setupUploads 使用本地存储时提供上传文件的静态访问
+func setupUploads(router *gin.Engine) {
+	cfg := config.GetConfig().Upload
+	if cfg.Driver == "s3" {
+		return
+	}
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = "/uploads"
+	}
+	if !strings.HasPrefix(baseURL, "/") {
+		return
+	}
+	router.Static(baseURL, cfg.Path)
+}
+
 // registerAPIRoutes 注册API路由
 func registerAPIRoutes(router *gin.Engine, handlers *Handlers) {
 	// API版本分组
@@ -69,10 +123,10 @@ func registerAPIRoutes(router *gin.Engine, handlers *Handlers) {
 
 	// 创建路由组实例
 	authRoutes := NewAuthRoutes(handlers.AuthHandler)
-	userRoutes := NewUserRoutes(handlers.AuthHandler)
+	userRoutes := NewUserRoutes(handlers.AuthHandler, handlers.PrivacyHandler, handlers.AddressHandler, handlers.LoyaltyHandler, handlers.FavoriteHandler, handlers.FootprintHandler, handlers.NotificationHandler, handlers.UploadHandler)
 	productRoutes := NewProductRoutes(handlers.ProductHandler, handlers.CategoryHandler)
 	orderRoutes := NewOrderRoutes(handlers.OrderHandler, handlers.CartHandler, handlers.PaymentHandler)
-	adminRoutes := NewAdminRoutes(handlers.CategoryHandler, handlers.ProductHandler, handlers.OrderHandler, handlers.PaymentHandler)
+	adminRoutes := NewAdminRoutes(handlers.AuthHandler, handlers.AdminAuthHandler, handlers.CategoryHandler, handlers.ProductHandler, handlers.OrderHandler, handlers.PaymentHandler, handlers.AuditHandler, handlers.LoyaltyHandler, handlers.UploadHandler, handlers.InventoryHandler, handlers.PriceHandler)
 
 	// 注册路由组
 	authRoutes.RegisterRoutes(v1)
@@ -80,4 +134,4 @@ func registerAPIRoutes(router *gin.Engine, handlers *Handlers) {
 	productRoutes.RegisterRoutes(v1)
 	orderRoutes.RegisterRoutes(v1)
 	adminRoutes.RegisterRoutes(v1)
-}
\ No newline at end of file
+}
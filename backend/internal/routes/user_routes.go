@@ -9,13 +9,27 @@ import (
 
 // UserRoutes 用户路由组
 type UserRoutes struct {
-	authHandler *handler.AuthHandler
+	authHandler         *handler.AuthHandler
+	privacyHandler      *handler.PrivacyHandler
+	addressHandler      *handler.AddressHandler
+	loyaltyHandler      *handler.LoyaltyHandler
+	favoriteHandler     *handler.FavoriteHandler
+	footprintHandler    *handler.FootprintHandler
+	notificationHandler *handler.NotificationHandler
+	uploadHandler       *handler.UploadHandler
 }
 
 // NewUserRoutes 创建用户路由组
-func NewUserRoutes(authHandler *handler.AuthHandler) *UserRoutes {
+func NewUserRoutes(authHandler *handler.AuthHandler, privacyHandler *handler.PrivacyHandler, addressHandler *handler.AddressHandler, loyaltyHandler *handler.LoyaltyHandler, favoriteHandler *handler.FavoriteHandler, footprintHandler *handler.FootprintHandler, notificationHandler *handler.NotificationHandler, uploadHandler *handler.UploadHandler) *UserRoutes {
 	return &UserRoutes{
-		authHandler: authHandler,
+		authHandler:         authHandler,
+		privacyHandler:      privacyHandler,
+		addressHandler:      addressHandler,
+		loyaltyHandler:      loyaltyHandler,
+		favoriteHandler:     favoriteHandler,
+		footprintHandler:    footprintHandler,
+		notificationHandler: notificationHandler,
+		uploadHandler:       uploadHandler,
 	}
 }
 
@@ -27,7 +41,59 @@ func (r *UserRoutes) RegisterRoutes(router *gin.RouterGroup) {
 		user.GET("/info", r.authHandler.GetUserInfo)
 		user.GET("/profile", r.authHandler.GetProfile)
 		user.PUT("/profile", r.authHandler.UpdateProfile)
+		user.POST("/avatar", middleware.RateLimiter("upload"), r.uploadHandler.UploadAvatar)
 		user.PUT("/password", r.authHandler.ChangePassword)
+		user.POST("/bind-phone/code", middleware.SMSRateLimiter(), r.authHandler.SendBindCode)
 		user.POST("/bind-phone", r.authHandler.BindPhone)
+		user.POST("/merge", r.authHandler.MergeAccount)
+		user.GET("/login-logs", r.authHandler.GetLoginLogs)
+		user.GET("/sessions", r.authHandler.GetSessions)
+		user.DELETE("/sessions/:id", r.authHandler.RevokeSession)
+
+		// 收货地址
+		addresses := user.Group("/addresses")
+		{
+			addresses.GET("", r.addressHandler.ListAddresses)
+			addresses.POST("", r.addressHandler.CreateAddress)
+			addresses.GET("/:id", r.addressHandler.GetAddress)
+			addresses.PUT("/:id", r.addressHandler.UpdateAddress)
+			addresses.DELETE("/:id", r.addressHandler.DeleteAddress)
+			addresses.PUT("/:id/default", r.addressHandler.SetDefaultAddress)
+		}
+
+		// 会员积分
+		user.GET("/points", r.loyaltyHandler.GetPoints)
+		user.GET("/points/ledger", r.loyaltyHandler.GetPointsLedger)
+
+		// 商品收藏
+		favorites := user.Group("/favorites")
+		{
+			favorites.GET("", r.favoriteHandler.ListFavorites)
+			favorites.POST("", r.favoriteHandler.AddFavorite)
+			favorites.DELETE("/:productId", r.favoriteHandler.RemoveFavorite)
+		}
+
+		// 浏览足迹
+		user.GET("/footprints", r.footprintHandler.ListFootprints)
+		user.DELETE("/footprints", r.footprintHandler.DeleteFootprints)
+
+		// 站内通知
+		notifications := user.Group("/notifications")
+		{
+			notifications.GET("", r.notificationHandler.ListNotifications)
+			notifications.GET("/unread-count", r.notificationHandler.GetUnreadCount)
+			notifications.PUT("/read", r.notificationHandler.MarkRead)
+		}
+
+		// 个人数据导出与账号注销
+		privacy := user.Group("/privacy")
+		{
+			privacy.GET("/requests", r.privacyHandler.GetRequests)
+			privacy.POST("/exports", r.privacyHandler.RequestExport)
+			privacy.GET("/exports/:id/download", r.privacyHandler.DownloadExport)
+			privacy.POST("/deletion/code", middleware.RateLimiter("sms_user"), r.privacyHandler.SendDeletionCode)
+			privacy.POST("/deletion", r.privacyHandler.RequestDeletion)
+			privacy.DELETE("/deletion", r.privacyHandler.CancelDeletion)
+		}
 	}
 }
\ No newline at end of file
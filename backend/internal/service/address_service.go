@@ -0,0 +1,186 @@
+package service
+
+import (
+	"errors"
+
+	"mall/internal/model"
+	"mall/internal/repository"
+	"mall/pkg/utils"
+)
+
+// maxAddressesPerUser 每个用户最多保存的收货地址数
+const maxAddressesPerUser = 20
+
+// AddressService 收货地址服务接口
+type AddressService interface {
+	ListAddresses(userID uint64) ([]*AddressResponse, error)
+	GetAddress(userID, id uint64) (*AddressResponse, error)
+	CreateAddress(userID uint64, req *AddressRequest) (*AddressResponse, error)
+	UpdateAddress(userID, id uint64, req *AddressRequest) (*AddressResponse, error)
+	DeleteAddress(userID, id uint64) error
+	SetDefault(userID, id uint64) error
+}
+
+// AddressRequest 收货地址请求
+type AddressRequest struct {
+	Name      string `json:"name" binding:"required,max=50"`
+	Phone     string `json:"phone" binding:"required"`
+	Province  string `json:"province" binding:"required,max=50"`
+	City      string `json:"city" binding:"required,max=50"`
+	District  string `json:"district" binding:"required,max=50"`
+	Address   string `json:"address" binding:"required,max=200"`
+	IsDefault bool   `json:"is_default"`
+}
+
+// AddressResponse 收货地址响应
+type AddressResponse struct {
+	ID          uint64 `json:"id"`
+	Name        string `json:"name"`
+	Phone       string `json:"phone"`
+	Province    string `json:"province"`
+	City        string `json:"city"`
+	District    string `json:"district"`
+	Address     string `json:"address"`
+	FullAddress string `json:"full_address"`
+	IsDefault   bool   `json:"is_default"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+// addressService 收货地址服务实现
+type addressService struct {
+	addressRepo repository.UserAddressRepository
+}
+
+// NewAddressService 创建收货地址服务
+func NewAddressService(addressRepo repository.UserAddressRepository) AddressService {
+	return &addressService{
+		addressRepo: addressRepo,
+	}
+}
+
+// ListAddresses 获取用户地址列表
+func (s *addressService) ListAddresses(userID uint64) ([]*AddressResponse, error) {
+	addresses, err := s.addressRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*AddressResponse, 0, len(addresses))
+	for _, address := range addresses {
+		items = append(items, s.toAddressResponse(address))
+	}
+	return items, nil
+}
+
+// GetAddress 获取地址详情
+func (s *addressService) GetAddress(userID, id uint64) (*AddressResponse, error) {
+	address, err := s.getUserAddress(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.toAddressResponse(address), nil
+}
+
+// CreateAddress 新增地址
+func (s *addressService) CreateAddress(userID uint64, req *AddressRequest) (*AddressResponse, error) {
+	if !utils.IsValidPhone(req.Phone) {
+		return nil, errors.New("invalid phone number format")
+	}
+
+	address := &model.UserAddress{UserID: userID}
+	s.fillAddress(address, req)
+
+	if err := s.addressRepo.Create(address, maxAddressesPerUser); err != nil {
+		if errors.Is(err, repository.ErrAddressLimitReached) {
+			return nil, errors.New("address limit reached")
+		}
+		return nil, err
+	}
+
+	return s.toAddressResponse(address), nil
+}
+
+// UpdateAddress 修改地址
+func (s *addressService) UpdateAddress(userID, id uint64, req *AddressRequest) (*AddressResponse, error) {
+	if !utils.IsValidPhone(req.Phone) {
+		return nil, errors.New("invalid phone number format")
+	}
+
+	address, err := s.getUserAddress(userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// 默认地址不能直接取消，需将其他地址设为默认
+	wasDefault := address.IsDefault == 1
+	s.fillAddress(address, req)
+	if wasDefault {
+		address.IsDefault = 1
+	}
+
+	if err := s.addressRepo.Update(address); err != nil {
+		return nil, err
+	}
+
+	return s.toAddressResponse(address), nil
+}
+
+// DeleteAddress 删除地址
+func (s *addressService) DeleteAddress(userID, id uint64) error {
+	if err := s.addressRepo.Delete(userID, id); err != nil {
+		return errors.New("address not found")
+	}
+	return nil
+}
+
+// SetDefault 设置默认地址
+func (s *addressService) SetDefault(userID, id uint64) error {
+	if err := s.addressRepo.SetDefault(userID, id); err != nil {
+		return errors.New("address not found")
+	}
+	return nil
+}
+
+// getUserAddress 获取属于用户的地址
+func (s *addressService) getUserAddress(userID, id uint64) (*model.UserAddress, error) {
+	address, err := s.addressRepo.GetByID(id)
+	if err != nil || address.UserID != userID {
+		return nil, errors.New("address not found")
+	}
+	return address, nil
+}
+
+// fillAddress 将请求写入地址模型
+func (s *addressService) fillAddress(address *model.UserAddress, req *AddressRequest) {
+	address.Name = req.Name
+	address.Phone = req.Phone
+	address.Province = req.Province
+	address.City = req.City
+	address.District = req.District
+	address.Address = req.Address
+	address.IsDefault = 0
+	if req.IsDefault {
+		address.IsDefault = 1
+	}
+}
+
+// toAddressResponse 转换为地址响应
+func (s *addressService) toAddressResponse(address *model.UserAddress) *AddressResponse {
+	return &AddressResponse{
+		ID:          address.ID,
+		Name:        address.Name,
+		Phone:       address.Phone,
+		Province:    address.Province,
+		City:        address.City,
+		District:    address.District,
+		Address:     address.Address,
+		FullAddress: FormatFullAddress(address),
+		IsDefault:   address.IsDefault == 1,
+		UpdatedAt:   address.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// FormatFullAddress 拼接完整收货地址
+func FormatFullAddress(address *model.UserAddress) string {
+	return address.Province + address.City + address.District + address.Address
+}
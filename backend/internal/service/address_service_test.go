@@ -0,0 +1,140 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+	"mall/internal/repository"
+)
+
+func newTestAddressService(t *testing.T) (*addressService, *gorm.DB) {
+	t.Helper()
+
+	db := newTestDB(t, &model.User{}, &model.UserAddress{})
+	for _, user := range []*model.User{
+		{Username: "alice", Phone: "13800000001", WechatOpenID: "openid-alice", Status: 1},
+		{Username: "bob", Phone: "13800000002", WechatOpenID: "openid-bob", Status: 1},
+	} {
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	return NewAddressService(repository.NewUserAddressRepository(db)).(*addressService), db
+}
+
+// newAddressRequest 构造地址请求
+func newAddressRequest(address string, isDefault bool) *AddressRequest {
+	return &AddressRequest{Name: "Alice", Phone: "13800000001", Province: "浙江省", City: "杭州市", District: "西湖区", Address: address, IsDefault: isDefault}
+}
+
+// defaultAddresses 返回用户的默认地址
+func defaultAddresses(t *testing.T, s *addressService, userID uint64) []string {
+	t.Helper()
+
+	items, err := s.ListAddresses(userID)
+	if err != nil {
+		t.Fatalf("ListAddresses: %v", err)
+	}
+	var defaults []string
+	for _, item := range items {
+		if item.IsDefault {
+			defaults = append(defaults, item.Address)
+		}
+	}
+	return defaults
+}
+
+func TestAddressDefault(t *testing.T) {
+	s, _ := newTestAddressService(t)
+
+	first, err := s.CreateAddress(1, newAddressRequest("first", false))
+	if err != nil {
+		t.Fatalf("CreateAddress: %v", err)
+	}
+	if !first.IsDefault || first.FullAddress != "浙江省杭州市西湖区first" {
+		t.Errorf("first address = %+v", first)
+	}
+	second, _ := s.CreateAddress(1, newAddressRequest("second", true))
+	third, _ := s.CreateAddress(1, newAddressRequest("third", false))
+
+	steps := []struct {
+		name    string
+		run     func() error
+		want    string
+		wantErr bool
+	}{
+		{"new default replaces old", func() error { return nil }, "second", false},
+		{"set default", func() error { return s.SetDefault(1, third.ID) }, "third", false},
+		{"default cannot be unset by update", func() error {
+			_, err := s.UpdateAddress(1, third.ID, newAddressRequest("third", false))
+			return err
+		}, "third", false},
+		{"other user cannot set default", func() error { return s.SetDefault(2, first.ID) }, "third", true},
+		{"deleting default promotes another", func() error { return s.DeleteAddress(1, third.ID) }, "", false},
+		{"invalid phone rejected", func() error {
+			req := newAddressRequest("second", true)
+			req.Phone = "123"
+			_, err := s.UpdateAddress(1, second.ID, req)
+			return err
+		}, "", true},
+	}
+	for _, step := range steps {
+		if err := step.run(); (err != nil) != step.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", step.name, err, step.wantErr)
+		}
+		defaults := defaultAddresses(t, s, 1)
+		if len(defaults) != 1 || (step.want != "" && defaults[0] != step.want) {
+			t.Errorf("%s: defaults = %v, want [%s]", step.name, defaults, step.want)
+		}
+	}
+}
+
+func TestAddressOwnership(t *testing.T) {
+	s, _ := newTestAddressService(t)
+	address, err := s.CreateAddress(1, newAddressRequest("home", false))
+	if err != nil {
+		t.Fatalf("CreateAddress: %v", err)
+	}
+
+	if _, err := s.GetAddress(2, address.ID); err == nil {
+		t.Error("other users should not read the address")
+	}
+	if _, err := s.UpdateAddress(2, address.ID, newAddressRequest("stolen", false)); err == nil {
+		t.Error("other users should not update the address")
+	}
+	if err := s.DeleteAddress(2, address.ID); err == nil {
+		t.Error("other users should not delete the address")
+	}
+	if got, err := s.GetAddress(1, address.ID); err != nil || got.Address != "home" {
+		t.Errorf("address = %+v, err %v", got, err)
+	}
+}
+
+func TestAddressLimit(t *testing.T) {
+	s, db := newTestAddressService(t)
+	for i := 0; i < maxAddressesPerUser; i++ {
+		if _, err := s.CreateAddress(1, newAddressRequest(fmt.Sprintf("addr %d", i), false)); err != nil {
+			t.Fatalf("CreateAddress %d: %v", i, err)
+		}
+	}
+
+	if _, err := s.CreateAddress(1, newAddressRequest("one more", false)); err == nil || err.Error() != "address limit reached" {
+		t.Errorf("err = %v, want address limit reached", err)
+	}
+	if _, err := s.CreateAddress(2, newAddressRequest("bob", false)); err != nil {
+		t.Errorf("limit should be per user: %v", err)
+	}
+
+	// 删除后释放名额
+	var address model.UserAddress
+	db.Where("user_id = ?", 1).First(&address)
+	if err := s.DeleteAddress(1, address.ID); err != nil {
+		t.Fatalf("DeleteAddress: %v", err)
+	}
+	if _, err := s.CreateAddress(1, newAddressRequest("one more", false)); err != nil {
+		t.Errorf("CreateAddress after delete: %v", err)
+	}
+}
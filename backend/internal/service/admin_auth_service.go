@@ -0,0 +1,518 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+	"mall/internal/repository"
+	"mall/pkg/cache"
+	"mall/pkg/config"
+	"mall/pkg/logger"
+	"mall/pkg/utils"
+)
+
+// AdminAuthService 管理员认证服务接口
+type AdminAuthService interface {
+	Login(req *AdminLoginRequest, client *ClientInfo) (*AdminLoginResponse, error)
+	SetupTwoFactorByChallenge(challengeToken string) (*TwoFactorSetupResponse, error)
+	VerifyTwoFactor(req *TwoFactorVerifyRequest) (*AdminLoginResponse, error)
+	GetTwoFactorStatus(adminID uint64) (*TwoFactorStatusResponse, error)
+	SetupTwoFactor(adminID uint64) (*TwoFactorSetupResponse, error)
+	EnableTwoFactor(adminID uint64, code string) (*RecoveryCodesResponse, error)
+	DisableTwoFactor(adminID uint64, code string) error
+	RegenerateRecoveryCodes(adminID uint64, code string) (*RecoveryCodesResponse, error)
+	ListTwoFactorPolicies() ([]*TwoFactorPolicyResponse, error)
+	UpdateTwoFactorPolicy(operatorID uint64, req *UpdateTwoFactorPolicyRequest) error
+}
+
+// AdminLoginRequest 管理员登录请求
+type AdminLoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// TwoFactorVerifyRequest 两步验证请求，动态口令与恢复码二选一
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+// TwoFactorCodeRequest 动态口令请求
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// UpdateTwoFactorPolicyRequest 更新两步验证策略请求
+type UpdateTwoFactorPolicyRequest struct {
+	Role     string `json:"role" binding:"required"`
+	Required bool   `json:"required"`
+}
+
+// AdminInfoResponse 管理员信息响应
+type AdminInfoResponse struct {
+	ID               uint64 `json:"id"`
+	Username         string `json:"username"`
+	Nickname         string `json:"nickname"`
+	Avatar           string `json:"avatar"`
+	Role             string `json:"role"`
+	TwoFactorEnabled bool   `json:"two_factor_enabled"`
+}
+
+// AdminLoginResponse 管理员登录响应
+type AdminLoginResponse struct {
+	Token                  string             `json:"token,omitempty"`
+	ExpiresIn              int64              `json:"expires_in,omitempty"`
+	Admin                  *AdminInfoResponse `json:"admin,omitempty"`
+	TwoFactorRequired      bool               `json:"two_factor_required"`
+	TwoFactorSetupRequired bool               `json:"two_factor_setup_required"`
+	ChallengeToken         string             `json:"challenge_token,omitempty"`
+	ChallengeExpiresIn     int64              `json:"challenge_expires_in,omitempty"`
+	RecoveryCodes          []string           `json:"recovery_codes,omitempty"`
+}
+
+// TwoFactorSetupResponse 两步验证绑定响应
+type TwoFactorSetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+	ExpiresIn       int64  `json:"expires_in"`
+}
+
+// TwoFactorStatusResponse 两步验证状态响应
+type TwoFactorStatusResponse struct {
+	Enabled               bool  `json:"enabled"`
+	Required              bool  `json:"required"`
+	RemainingRecoveryCode int64 `json:"remaining_recovery_codes"`
+}
+
+// RecoveryCodesResponse 恢复码响应，仅在生成时返回明文
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorPolicyResponse 两步验证策略响应
+type TwoFactorPolicyResponse struct {
+	Role      string `json:"role"`
+	Required  bool   `json:"required"`
+	UpdatedBy uint64 `json:"updated_by"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// 两步验证相关缓存key
+const (
+	adminChallengeKey         = "admin_2fa_challenge:%s"
+	adminChallengeAttemptsKey = "admin_2fa_challenge_attempts:%s"
+	adminPendingSecretKey     = "admin_2fa_pending:%d"
+	adminTOTPUsedKey          = "admin_totp_used:%d:%d"
+)
+
+// 两步验证参数
+const (
+	totpIssuer              = "Mall Admin"
+	adminChallengeTTL       = 5 * time.Minute
+	adminPendingSecretTTL   = 10 * time.Minute
+	adminChallengeMaxTries  = 5
+	adminRecoveryCodeCount  = 10
+	adminRecoveryCodeLength = 10
+)
+
+// adminRoles 可配置策略的管理员角色
+var adminRoles = []string{"admin", "super_admin"}
+
+// adminAuthService 管理员认证服务实现
+type adminAuthService struct {
+	adminRepo        repository.AdminRepository
+	recoveryCodeRepo repository.AdminRecoveryCodeRepository
+	policyRepo       repository.TwoFactorPolicyRepository
+}
+
+// NewAdminAuthService 创建管理员认证服务
+func NewAdminAuthService(
+	adminRepo repository.AdminRepository,
+	recoveryCodeRepo repository.AdminRecoveryCodeRepository,
+	policyRepo repository.TwoFactorPolicyRepository,
+) AdminAuthService {
+	return &adminAuthService{
+		adminRepo:        adminRepo,
+		recoveryCodeRepo: recoveryCodeRepo,
+		policyRepo:       policyRepo,
+	}
+}
+
+// Login 管理员账号密码登录，启用或被要求启用两步验证时返回挑战token
+func (s *adminAuthService) Login(req *AdminLoginRequest, client *ClientInfo) (*AdminLoginResponse, error) {
+	admin, err := s.adminRepo.GetByUsername(req.Username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("invalid username or password")
+		}
+		return nil, errors.New("failed to get admin")
+	}
+
+	if !utils.CheckPassword(req.Password, admin.PasswordHash) {
+		logger.Warn("Admin login failed", zap.String("username", req.Username), zap.String("ip", clientIP(client)))
+		return nil, errors.New("invalid username or password")
+	}
+
+	if admin.Status != 1 {
+		return nil, errors.New("admin account is disabled")
+	}
+
+	if admin.TwoFactorEnabled || s.isTwoFactorRequired(admin.Role) {
+		challenge, err := s.createChallenge(admin.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &AdminLoginResponse{
+			TwoFactorRequired:      admin.TwoFactorEnabled,
+			TwoFactorSetupRequired: !admin.TwoFactorEnabled,
+			ChallengeToken:         challenge,
+			ChallengeExpiresIn:     int64(adminChallengeTTL.Seconds()),
+		}, nil
+	}
+
+	return s.issueToken(admin)
+}
+
+// SetupTwoFactorByChallenge 登录过程中被策略要求绑定两步验证时生成密钥
+func (s *adminAuthService) SetupTwoFactorByChallenge(challengeToken string) (*TwoFactorSetupResponse, error) {
+	adminID, err := s.getChallengeAdminID(challengeToken)
+	if err != nil {
+		return nil, err
+	}
+	return s.SetupTwoFactor(adminID)
+}
+
+// VerifyTwoFactor 完成两步验证并签发token
+func (s *adminAuthService) VerifyTwoFactor(req *TwoFactorVerifyRequest) (*AdminLoginResponse, error) {
+	if req.Code == "" && req.RecoveryCode == "" {
+		return nil, errors.New("verification code or recovery code is required")
+	}
+
+	adminID, err := s.getChallengeAdminID(req.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// 限制单个挑战的校验次数
+	ctx := context.Background()
+	attemptsKey := fmt.Sprintf(adminChallengeAttemptsKey, req.ChallengeToken)
+	attempts, err := cache.Incr(ctx, attemptsKey)
+	if err != nil {
+		return nil, errors.New("failed to verify code")
+	}
+	if attempts == 1 {
+		cache.Expire(ctx, attemptsKey, adminChallengeTTL)
+	}
+	if attempts > adminChallengeMaxTries {
+		cache.Del(ctx, fmt.Sprintf(adminChallengeKey, req.ChallengeToken), attemptsKey)
+		return nil, errors.New("too many attempts, please login again")
+	}
+
+	admin, err := s.adminRepo.GetByID(adminID)
+	if err != nil {
+		return nil, errors.New("admin not found")
+	}
+	if admin.Status != 1 {
+		return nil, errors.New("admin account is disabled")
+	}
+
+	var recoveryCodes []string
+	if admin.TwoFactorEnabled {
+		if req.RecoveryCode != "" {
+			ok, err := s.recoveryCodeRepo.Consume(admin.ID, hashRecoveryCode(req.RecoveryCode))
+			if err != nil || !ok {
+				return nil, errors.New("invalid recovery code")
+			}
+		} else if !s.verifyTOTP(admin.ID, admin.TOTPSecret, req.Code) {
+			return nil, errors.New("invalid verification code")
+		}
+	} else {
+		// 策略强制绑定：校验通过后启用两步验证
+		codes, err := s.EnableTwoFactor(admin.ID, req.Code)
+		if err != nil {
+			return nil, err
+		}
+		recoveryCodes = codes.RecoveryCodes
+		admin.TwoFactorEnabled = true
+	}
+
+	cache.Del(ctx, fmt.Sprintf(adminChallengeKey, req.ChallengeToken), attemptsKey)
+
+	resp, err := s.issueToken(admin)
+	if err != nil {
+		return nil, err
+	}
+	resp.RecoveryCodes = recoveryCodes
+	return resp, nil
+}
+
+// GetTwoFactorStatus 获取两步验证状态
+func (s *adminAuthService) GetTwoFactorStatus(adminID uint64) (*TwoFactorStatusResponse, error) {
+	admin, err := s.adminRepo.GetByID(adminID)
+	if err != nil {
+		return nil, errors.New("admin not found")
+	}
+
+	status := &TwoFactorStatusResponse{
+		Enabled:  admin.TwoFactorEnabled,
+		Required: s.isTwoFactorRequired(admin.Role),
+	}
+	if admin.TwoFactorEnabled {
+		status.RemainingRecoveryCode, _ = s.recoveryCodeRepo.CountUnused(admin.ID)
+	}
+	return status, nil
+}
+
+// SetupTwoFactor 生成待确认的TOTP密钥及扫码地址
+func (s *adminAuthService) SetupTwoFactor(adminID uint64) (*TwoFactorSetupResponse, error) {
+	admin, err := s.adminRepo.GetByID(adminID)
+	if err != nil {
+		return nil, errors.New("admin not found")
+	}
+
+	if admin.TwoFactorEnabled {
+		return nil, errors.New("two-factor authentication already enabled")
+	}
+
+	secret := utils.GenerateTOTPSecret()
+	ctx := context.Background()
+	if err := cache.Set(ctx, fmt.Sprintf(adminPendingSecretKey, admin.ID), secret, adminPendingSecretTTL); err != nil {
+		return nil, errors.New("failed to setup two-factor authentication")
+	}
+
+	return &TwoFactorSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(totpIssuer, admin.Username, secret),
+		ExpiresIn:       int64(adminPendingSecretTTL.Seconds()),
+	}, nil
+}
+
+// EnableTwoFactor 校验待确认密钥生成的动态口令并启用两步验证
+func (s *adminAuthService) EnableTwoFactor(adminID uint64, code string) (*RecoveryCodesResponse, error) {
+	ctx := context.Background()
+	pendingKey := fmt.Sprintf(adminPendingSecretKey, adminID)
+	secret, err := cache.Get(ctx, pendingKey)
+	if err != nil {
+		return nil, errors.New("two-factor setup expired, please setup again")
+	}
+
+	if !s.verifyTOTP(adminID, secret, code) {
+		return nil, errors.New("invalid verification code")
+	}
+
+	if err := s.adminRepo.UpdateTwoFactor(adminID, true, secret); err != nil {
+		return nil, errors.New("failed to enable two-factor authentication")
+	}
+	cache.Del(ctx, pendingKey)
+
+	return s.generateRecoveryCodes(adminID)
+}
+
+// DisableTwoFactor 关闭两步验证，角色策略强制时不允许关闭
+func (s *adminAuthService) DisableTwoFactor(adminID uint64, code string) error {
+	admin, err := s.adminRepo.GetByID(adminID)
+	if err != nil {
+		return errors.New("admin not found")
+	}
+
+	if !admin.TwoFactorEnabled {
+		return errors.New("two-factor authentication not enabled")
+	}
+
+	if s.isTwoFactorRequired(admin.Role) {
+		return errors.New("two-factor authentication is required for your role")
+	}
+
+	if !s.verifyTOTP(admin.ID, admin.TOTPSecret, code) {
+		return errors.New("invalid verification code")
+	}
+
+	if err := s.adminRepo.UpdateTwoFactor(admin.ID, false, ""); err != nil {
+		return errors.New("failed to disable two-factor authentication")
+	}
+
+	return s.recoveryCodeRepo.DeleteByAdminID(admin.ID)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧恢复码全部作废
+func (s *adminAuthService) RegenerateRecoveryCodes(adminID uint64, code string) (*RecoveryCodesResponse, error) {
+	admin, err := s.adminRepo.GetByID(adminID)
+	if err != nil {
+		return nil, errors.New("admin not found")
+	}
+
+	if !admin.TwoFactorEnabled {
+		return nil, errors.New("two-factor authentication not enabled")
+	}
+
+	if !s.verifyTOTP(admin.ID, admin.TOTPSecret, code) {
+		return nil, errors.New("invalid verification code")
+	}
+
+	return s.generateRecoveryCodes(admin.ID)
+}
+
+// ListTwoFactorPolicies 获取各角色两步验证策略
+func (s *adminAuthService) ListTwoFactorPolicies() ([]*TwoFactorPolicyResponse, error) {
+	policies, err := s.policyRepo.List()
+	if err != nil {
+		return nil, err
+	}
+
+	policyMap := make(map[string]*model.TwoFactorPolicy, len(policies))
+	for _, policy := range policies {
+		policyMap[policy.Role] = policy
+	}
+
+	var result []*TwoFactorPolicyResponse
+	for _, role := range adminRoles {
+		item := &TwoFactorPolicyResponse{Role: role}
+		if policy, ok := policyMap[role]; ok {
+			item.Required = policy.Required
+			item.UpdatedBy = policy.UpdatedBy
+			item.UpdatedAt = policy.UpdatedAt.Format("2006-01-02 15:04:05")
+		}
+		result = append(result, item)
+	}
+
+	return result, nil
+}
+
+// UpdateTwoFactorPolicy 设置角色是否强制两步验证
+func (s *adminAuthService) UpdateTwoFactorPolicy(operatorID uint64, req *UpdateTwoFactorPolicyRequest) error {
+	if !utils.StringInSlice(req.Role, adminRoles) {
+		return errors.New("invalid role")
+	}
+
+	policy := &model.TwoFactorPolicy{
+		Role:      req.Role,
+		Required:  req.Required,
+		UpdatedBy: operatorID,
+	}
+	if err := s.policyRepo.Save(policy); err != nil {
+		return errors.New("failed to update two-factor policy")
+	}
+
+	logger.Info("Two-factor policy updated",
+		zap.String("role", req.Role),
+		zap.Bool("required", req.Required),
+		zap.Uint64("operator_id", operatorID),
+	)
+	return nil
+}
+
+// isTwoFactorRequired 角色是否被策略要求启用两步验证
+func (s *adminAuthService) isTwoFactorRequired(role string) bool {
+	policy, err := s.policyRepo.GetByRole(role)
+	if err != nil {
+		return false
+	}
+	return policy.Required
+}
+
+// verifyTOTP 校验动态口令，同一时间步的口令只能使用一次
+func (s *adminAuthService) verifyTOTP(adminID uint64, secret, code string) bool {
+	if secret == "" {
+		return false
+	}
+
+	step, ok := utils.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return false
+	}
+
+	key := fmt.Sprintf(adminTOTPUsedKey, adminID, step)
+	fresh, err := cache.SetNX(context.Background(), key, "1", 3*utils.TOTPPeriod*time.Second)
+	return err == nil && fresh
+}
+
+// createChallenge 创建两步验证挑战token
+func (s *adminAuthService) createChallenge(adminID uint64) (string, error) {
+	challenge := utils.GenerateRandomString(48)
+	key := fmt.Sprintf(adminChallengeKey, challenge)
+	if err := cache.Set(context.Background(), key, adminID, adminChallengeTTL); err != nil {
+		return "", errors.New("failed to create login challenge")
+	}
+	return challenge, nil
+}
+
+// getChallengeAdminID 根据挑战token获取管理员ID
+func (s *adminAuthService) getChallengeAdminID(challenge string) (uint64, error) {
+	val, err := cache.Get(context.Background(), fmt.Sprintf(adminChallengeKey, challenge))
+	if err != nil {
+		return 0, errors.New("invalid or expired challenge token")
+	}
+
+	adminID, err := strconv.ParseUint(val, 10, 64)
+	if err != nil {
+		return 0, errors.New("invalid or expired challenge token")
+	}
+	return adminID, nil
+}
+
+// generateRecoveryCodes 生成一组新的恢复码，仅保存哈希
+func (s *adminAuthService) generateRecoveryCodes(adminID uint64) (*RecoveryCodesResponse, error) {
+	codes := make([]string, 0, adminRecoveryCodeCount)
+	records := make([]*model.AdminRecoveryCode, 0, adminRecoveryCodeCount)
+	for i := 0; i < adminRecoveryCodeCount; i++ {
+		raw := strings.ToLower(utils.GenerateRandomString(adminRecoveryCodeLength))
+		code := raw[:adminRecoveryCodeLength/2] + "-" + raw[adminRecoveryCodeLength/2:]
+		codes = append(codes, code)
+		records = append(records, &model.AdminRecoveryCode{
+			AdminID:  adminID,
+			CodeHash: hashRecoveryCode(code),
+		})
+	}
+
+	if err := s.recoveryCodeRepo.Replace(adminID, records); err != nil {
+		return nil, errors.New("failed to generate recovery codes")
+	}
+
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// issueToken 签发管理员token
+func (s *adminAuthService) issueToken(admin *model.Admin) (*AdminLoginResponse, error) {
+	token, err := utils.GenerateToken(int64(admin.ID), admin.Username, admin.Role, "admin")
+	if err != nil {
+		return nil, errors.New("failed to generate token")
+	}
+
+	s.adminRepo.UpdateLastLogin(admin.ID, time.Now())
+
+	return &AdminLoginResponse{
+		Token:     token,
+		ExpiresIn: int64(config.GetConfig().JWT.ExpireHours) * 3600,
+		Admin: &AdminInfoResponse{
+			ID:               admin.ID,
+			Username:         admin.Username,
+			Nickname:         admin.Nickname,
+			Avatar:           admin.Avatar,
+			Role:             admin.Role,
+			TwoFactorEnabled: admin.TwoFactorEnabled,
+		},
+	}, nil
+}
+
+// hashRecoveryCode 恢复码哈希，忽略大小写和分隔符
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return utils.SHA256Hash(normalized)
+}
+
+// clientIP 获取客户端IP
+func clientIP(client *ClientInfo) string {
+	if client == nil {
+		return ""
+	}
+	return client.IP
+}
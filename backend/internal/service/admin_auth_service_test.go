@@ -0,0 +1,155 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"mall/internal/model"
+	"mall/pkg/cache"
+	"mall/pkg/utils"
+)
+
+// fakeRecoveryCodeRepo 内存恢复码仓储
+type fakeRecoveryCodeRepo struct {
+	codes map[uint64][]*model.AdminRecoveryCode
+}
+
+func newFakeRecoveryCodeRepo() *fakeRecoveryCodeRepo {
+	return &fakeRecoveryCodeRepo{codes: make(map[uint64][]*model.AdminRecoveryCode)}
+}
+
+func (r *fakeRecoveryCodeRepo) Replace(adminID uint64, codes []*model.AdminRecoveryCode) error {
+	r.codes[adminID] = codes
+	return nil
+}
+
+func (r *fakeRecoveryCodeRepo) DeleteByAdminID(adminID uint64) error {
+	delete(r.codes, adminID)
+	return nil
+}
+
+func (r *fakeRecoveryCodeRepo) Consume(adminID uint64, codeHash string) (bool, error) {
+	for i, code := range r.codes[adminID] {
+		if code.CodeHash == codeHash {
+			r.codes[adminID] = append(r.codes[adminID][:i], r.codes[adminID][i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeRecoveryCodeRepo) CountUnused(adminID uint64) (int64, error) {
+	return int64(len(r.codes[adminID])), nil
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	repo := newFakeRecoveryCodeRepo()
+	s := &adminAuthService{recoveryCodeRepo: repo}
+
+	resp, err := s.generateRecoveryCodes(1)
+	if err != nil {
+		t.Fatalf("generateRecoveryCodes: %v", err)
+	}
+	if len(resp.RecoveryCodes) != adminRecoveryCodeCount {
+		t.Fatalf("got %d codes, want %d", len(resp.RecoveryCodes), adminRecoveryCodeCount)
+	}
+
+	format := regexp.MustCompile(`^[a-z0-9]{5}-[a-z0-9]{5}$`)
+	seen := make(map[string]bool)
+	for i, code := range resp.RecoveryCodes {
+		if !format.MatchString(code) {
+			t.Errorf("code %q has unexpected format", code)
+		}
+		if seen[code] {
+			t.Errorf("duplicate code %q", code)
+		}
+		seen[code] = true
+
+		// 只保存哈希，不保存明文
+		stored := repo.codes[1][i]
+		if stored.AdminID != 1 || stored.CodeHash == code || stored.CodeHash != hashRecoveryCode(code) {
+			t.Errorf("code %q stored as %+v", code, stored)
+		}
+	}
+}
+
+func TestRegenerateRecoveryCodesInvalidatesOldCodes(t *testing.T) {
+	repo := newFakeRecoveryCodeRepo()
+	s := &adminAuthService{recoveryCodeRepo: repo}
+
+	old, _ := s.generateRecoveryCodes(1)
+	if _, err := s.generateRecoveryCodes(1); err != nil {
+		t.Fatalf("generateRecoveryCodes: %v", err)
+	}
+
+	if ok, _ := repo.Consume(1, hashRecoveryCode(old.RecoveryCodes[0])); ok {
+		t.Error("old recovery code should no longer be accepted")
+	}
+	if count, _ := repo.CountUnused(1); count != adminRecoveryCodeCount {
+		t.Errorf("unused codes = %d, want %d", count, adminRecoveryCodeCount)
+	}
+}
+
+func TestHashRecoveryCodeNormalizesInput(t *testing.T) {
+	want := hashRecoveryCode("abcde-12345")
+	for _, input := range []string{"ABCDE-12345", "abcde12345", "abcde 12345", " ABCDE-12345 "} {
+		if got := hashRecoveryCode(input); got != want {
+			t.Errorf("hashRecoveryCode(%q) differs from the canonical code", input)
+		}
+	}
+	if hashRecoveryCode("abcde-12346") == want {
+		t.Error("different codes should have different hashes")
+	}
+}
+
+func TestVerifyTOTPRejectsReplay(t *testing.T) {
+	mr := miniredis.RunT(t)
+	prev := cache.RDB
+	cache.RDB = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { cache.RDB = prev })
+
+	s := &adminAuthService{}
+	secret := utils.GenerateTOTPSecret()
+	code := currentTOTPCode(t, secret)
+
+	if !s.verifyTOTP(1, secret, code) {
+		t.Fatal("first use of the code should be accepted")
+	}
+	if s.verifyTOTP(1, secret, code) {
+		t.Error("replayed code should be rejected")
+	}
+	if !s.verifyTOTP(2, secret, code) {
+		t.Error("used steps are tracked per admin")
+	}
+	if s.verifyTOTP(3, "", code) {
+		t.Error("admin without a secret should be rejected")
+	}
+}
+
+// currentTOTPCode 按RFC 6238计算当前时间步的口令
+func currentTOTPCode(t *testing.T, secret string) string {
+	t.Helper()
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatalf("decode secret: %v", err)
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(time.Now().Unix()/utils.TOTPPeriod))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", value%1000000)
+}
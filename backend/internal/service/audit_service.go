@@ -0,0 +1,347 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"mall/internal/model"
+	"mall/internal/repository"
+	"mall/pkg/logger"
+)
+
+// AuditService 管理员审计服务接口
+type AuditService interface {
+	RegisterSnapshot(targetType string, loader SnapshotLoader)
+	Snapshot(targetType, targetID string) interface{}
+	Record(entry *AuditEntry) error
+	ListLogs(req *AuditLogListRequest) (*AuditLogListResponse, error)
+	GetLog(id uint64) (*AuditLogResponse, error)
+}
+
+// SnapshotLoader 按目标ID加载变更前后的对象快照
+type SnapshotLoader func(targetID string) (interface{}, error)
+
+// AuditEntry 审计记录
+type AuditEntry struct {
+	AdminID    uint64
+	AdminName  string
+	Action     string
+	TargetType string
+	TargetID   string
+	Before     interface{}
+	After      interface{}
+	Params     string
+	Method     string
+	Path       string
+	StatusCode int
+	IP         string
+	RequestID  string
+}
+
+// AuditLogListRequest 审计日志列表请求
+type AuditLogListRequest struct {
+	Page       int    `json:"page" form:"page"`
+	PageSize   int    `json:"page_size" form:"page_size"`
+	AdminID    uint64 `json:"admin_id" form:"admin_id"`
+	Action     string `json:"action" form:"action"`
+	TargetType string `json:"target_type" form:"target_type"`
+	TargetID   string `json:"target_id" form:"target_id"`
+	RequestID  string `json:"request_id" form:"request_id"`
+	StartTime  string `json:"start_time" form:"start_time"`
+	EndTime    string `json:"end_time" form:"end_time"`
+}
+
+// AuditLogResponse 审计日志响应
+type AuditLogResponse struct {
+	ID         uint64          `json:"id"`
+	AdminID    uint64          `json:"admin_id"`
+	AdminName  string          `json:"admin_name"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	Diff       json.RawMessage `json:"diff,omitempty"`
+	Params     string          `json:"params,omitempty"`
+	Method     string          `json:"method"`
+	Path       string          `json:"path"`
+	StatusCode int             `json:"status_code"`
+	IP         string          `json:"ip"`
+	RequestID  string          `json:"request_id"`
+	CreatedAt  string          `json:"created_at"`
+}
+
+// AuditLogListResponse 审计日志列表响应
+type AuditLogListResponse struct {
+	Items      []*AuditLogResponse `json:"items"`
+	Total      int64               `json:"total"`
+	Page       int                 `json:"page"`
+	PageSize   int                 `json:"page_size"`
+	TotalPages int                 `json:"total_pages"`
+}
+
+// FieldChange 字段变更
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// 不参与差异比较的字段
+var auditIgnoredFields = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+	"deleted_at": true,
+}
+
+// 快照中不落库的敏感字段
+var auditRedactedFields = map[string]bool{
+	"password_hash": true,
+	"salt":          true,
+	"credential":    true,
+}
+
+// auditService 管理员审计服务实现
+type auditService struct {
+	auditLogRepo repository.AdminAuditLogRepository
+	mu           sync.RWMutex
+	loaders      map[string]SnapshotLoader
+}
+
+// NewAuditService 创建管理员审计服务
+func NewAuditService(auditLogRepo repository.AdminAuditLogRepository) AuditService {
+	return &auditService{
+		auditLogRepo: auditLogRepo,
+		loaders:      make(map[string]SnapshotLoader),
+	}
+}
+
+// RegisterSnapshot 注册目标类型的快照加载函数
+func (s *auditService) RegisterSnapshot(targetType string, loader SnapshotLoader) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loaders[targetType] = loader
+}
+
+// Snapshot 加载目标对象快照，未注册或加载失败时返回nil
+func (s *auditService) Snapshot(targetType, targetID string) interface{} {
+	if targetType == "" || targetID == "" {
+		return nil
+	}
+
+	s.mu.RLock()
+	loader, ok := s.loaders[targetType]
+	s.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	snapshot, err := loader(targetID)
+	if err != nil {
+		return nil
+	}
+	return snapshot
+}
+
+// Record 写入审计日志并计算变更差异
+func (s *auditService) Record(entry *AuditEntry) error {
+	log := &model.AdminAuditLog{
+		AdminID:    entry.AdminID,
+		AdminName:  entry.AdminName,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		Params:     entry.Params,
+		Method:     entry.Method,
+		Path:       entry.Path,
+		StatusCode: entry.StatusCode,
+		IP:         entry.IP,
+		RequestID:  entry.RequestID,
+	}
+
+	before := toAuditMap(entry.Before)
+	after := toAuditMap(entry.After)
+	if before != nil {
+		data, _ := json.Marshal(before)
+		log.Before = string(data)
+	}
+	if after != nil {
+		data, _ := json.Marshal(after)
+		log.After = string(data)
+	}
+	if diff := diffAuditMaps(before, after); len(diff) > 0 {
+		data, _ := json.Marshal(diff)
+		log.Diff = string(data)
+	}
+
+	if err := s.auditLogRepo.Create(log); err != nil {
+		logger.Error("Failed to save admin audit log",
+			zap.String("action", entry.Action),
+			zap.String("request_id", entry.RequestID),
+			zap.Error(err),
+		)
+		return err
+	}
+	return nil
+}
+
+// ListLogs 查询审计日志
+func (s *auditService) ListLogs(req *AuditLogListRequest) (*AuditLogListResponse, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	query := &repository.AdminAuditLogQuery{
+		AdminID:    req.AdminID,
+		Action:     req.Action,
+		TargetType: req.TargetType,
+		TargetID:   req.TargetID,
+		RequestID:  req.RequestID,
+	}
+	if req.StartTime != "" {
+		t, err := time.ParseInLocation("2006-01-02 15:04:05", req.StartTime, time.Local)
+		if err != nil {
+			return nil, errors.New("invalid start time")
+		}
+		query.StartTime = &t
+	}
+	if req.EndTime != "" {
+		t, err := time.ParseInLocation("2006-01-02 15:04:05", req.EndTime, time.Local)
+		if err != nil {
+			return nil, errors.New("invalid end time")
+		}
+		query.EndTime = &t
+	}
+
+	logs, total, err := s.auditLogRepo.List(query, req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []*AuditLogResponse
+	for _, log := range logs {
+		items = append(items, s.toAuditLogResponse(log))
+	}
+
+	totalPages := int(total) / req.PageSize
+	if int(total)%req.PageSize > 0 {
+		totalPages++
+	}
+
+	return &AuditLogListResponse{
+		Items:      items,
+		Total:      total,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// GetLog 获取审计日志详情（包含完整快照）
+func (s *auditService) GetLog(id uint64) (*AuditLogResponse, error) {
+	log, err := s.auditLogRepo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("audit log not found")
+	}
+	return s.toAuditLogResponse(log), nil
+}
+
+// toAuditLogResponse 转换为审计日志响应
+func (s *auditService) toAuditLogResponse(log *model.AdminAuditLog) *AuditLogResponse {
+	resp := &AuditLogResponse{
+		ID:         log.ID,
+		AdminID:    log.AdminID,
+		AdminName:  log.AdminName,
+		Action:     log.Action,
+		TargetType: log.TargetType,
+		TargetID:   log.TargetID,
+		Params:     log.Params,
+		Method:     log.Method,
+		Path:       log.Path,
+		StatusCode: log.StatusCode,
+		IP:         log.IP,
+		RequestID:  log.RequestID,
+		CreatedAt:  log.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if log.Before != "" {
+		resp.Before = json.RawMessage(log.Before)
+	}
+	if log.After != "" {
+		resp.After = json.RawMessage(log.After)
+	}
+	if log.Diff != "" {
+		resp.Diff = json.RawMessage(log.Diff)
+	}
+	return resp
+}
+
+// toAuditMap 将快照对象统一转换为JSON字段映射
+func toAuditMap(v interface{}) map[string]interface{} {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil
+	}
+	for key, value := range m {
+		if auditRedactedFields[key] {
+			m[key] = "***"
+			continue
+		}
+		// 快照中的个人信息脱敏保存
+		if text, ok := value.(string); ok {
+			switch key {
+			case "phone", "receiver_phone":
+				m[key] = logger.MaskPhone(text)
+			case "address", "receiver_address":
+				m[key] = logger.MaskAddress(text)
+			}
+		}
+	}
+	return m
+}
+
+// diffAuditMaps 按顶层字段比较快照差异
+func diffAuditMaps(before, after map[string]interface{}) map[string]*FieldChange {
+	if before == nil && after == nil {
+		return nil
+	}
+
+	diff := make(map[string]*FieldChange)
+	for key, oldValue := range before {
+		if auditIgnoredFields[key] {
+			continue
+		}
+		newValue, ok := after[key]
+		if !ok || !auditValueEqual(oldValue, newValue) {
+			diff[key] = &FieldChange{Before: oldValue, After: newValue}
+		}
+	}
+	for key, newValue := range after {
+		if auditIgnoredFields[key] {
+			continue
+		}
+		if _, ok := before[key]; !ok {
+			diff[key] = &FieldChange{Before: nil, After: newValue}
+		}
+	}
+	return diff
+}
+
+// auditValueEqual 比较两个JSON值是否相同
+func auditValueEqual(a, b interface{}) bool {
+	da, _ := json.Marshal(a)
+	db, _ := json.Marshal(b)
+	return string(da) == string(db)
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"mall/internal/model"
+	"mall/internal/repository"
+)
+
+// auditTarget 审计快照测试对象
+type auditTarget struct {
+	ID           uint64  `json:"id"`
+	Name         string  `json:"name"`
+	Price        float64 `json:"price"`
+	Phone        string  `json:"phone"`
+	PasswordHash string  `json:"password_hash"`
+	UpdatedAt    string  `json:"updated_at"`
+}
+
+func newTestAuditService(t *testing.T) *auditService {
+	t.Helper()
+
+	db := newTestDB(t, &model.AdminAuditLog{})
+	return NewAuditService(repository.NewAdminAuditLogRepository(db)).(*auditService)
+}
+
+func TestAuditSnapshot(t *testing.T) {
+	s := newTestAuditService(t)
+	s.RegisterSnapshot("product", func(targetID string) (interface{}, error) {
+		if targetID != "1" {
+			return nil, errors.New("not found")
+		}
+		return &auditTarget{ID: 1, Name: "p"}, nil
+	})
+
+	cases := []struct {
+		name       string
+		targetType string
+		targetID   string
+		wantNil    bool
+	}{
+		{"registered target", "product", "1", false},
+		{"loader error", "product", "2", true},
+		{"unregistered type", "order", "1", true},
+		{"missing id", "product", "", true},
+	}
+	for _, tc := range cases {
+		if got := s.Snapshot(tc.targetType, tc.targetID); (got == nil) != tc.wantNil {
+			t.Errorf("%s: snapshot = %v", tc.name, got)
+		}
+	}
+}
+
+func TestAuditRecordDiff(t *testing.T) {
+	s := newTestAuditService(t)
+
+	before := &auditTarget{ID: 1, Name: "old", Price: 10, Phone: "13800001234", PasswordHash: "old-hash", UpdatedAt: "t1"}
+	after := &auditTarget{ID: 1, Name: "new", Price: 10, Phone: "13800001234", PasswordHash: "new-hash", UpdatedAt: "t2"}
+	if err := s.Record(&AuditEntry{AdminID: 1, Action: "product.update", TargetType: "product", TargetID: "1",
+		Before: before, After: after, Method: "PUT", StatusCode: 200, RequestID: "req-1"}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if err := s.Record(&AuditEntry{AdminID: 2, Action: "product.delete", TargetType: "product", TargetID: "2",
+		Before: &auditTarget{ID: 2, Name: "gone"}, Method: "DELETE", StatusCode: 200}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	log, err := s.GetLog(1)
+	if err != nil {
+		t.Fatalf("GetLog: %v", err)
+	}
+	var diff map[string]*FieldChange
+	if err := json.Unmarshal(log.Diff, &diff); err != nil {
+		t.Fatalf("decode diff: %v", err)
+	}
+	// 未变化字段和时间戳不计入差异，敏感字段即使变化也只记录为***
+	if len(diff) != 1 || diff["name"] == nil || diff["name"].Before != "old" || diff["name"].After != "new" {
+		t.Errorf("diff = %s", log.Diff)
+	}
+	var snapshot map[string]interface{}
+	json.Unmarshal(log.Before, &snapshot)
+	if snapshot["password_hash"] != "***" || snapshot["phone"] == "13800001234" {
+		t.Errorf("before snapshot not redacted: %s", log.Before)
+	}
+
+	deleted, _ := s.GetLog(2)
+	json.Unmarshal(deleted.Diff, &diff)
+	if deleted.After != nil || diff["name"] == nil || diff["name"].After != nil {
+		t.Errorf("delete diff = %s, after = %s", deleted.Diff, deleted.After)
+	}
+}
+
+func TestAuditListLogs(t *testing.T) {
+	s := newTestAuditService(t)
+	entries := []*AuditEntry{
+		{AdminID: 1, Action: "product.update", TargetType: "product", TargetID: "1", RequestID: "req-1"},
+		{AdminID: 1, Action: "product.update", TargetType: "product", TargetID: "2", RequestID: "req-2"},
+		{AdminID: 2, Action: "order.ship", TargetType: "order", TargetID: "1", RequestID: "req-3"},
+	}
+	for _, entry := range entries {
+		if err := s.Record(entry); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	cases := []struct {
+		name    string
+		req     *AuditLogListRequest
+		want    int64
+		wantErr bool
+	}{
+		{"all", &AuditLogListRequest{}, 3, false},
+		{"by admin", &AuditLogListRequest{AdminID: 1}, 2, false},
+		{"by action", &AuditLogListRequest{Action: "order.ship"}, 1, false},
+		{"by target", &AuditLogListRequest{TargetType: "product", TargetID: "2"}, 1, false},
+		{"by request id", &AuditLogListRequest{RequestID: "req-3"}, 1, false},
+		{"future start time", &AuditLogListRequest{StartTime: "2999-01-01 00:00:00"}, 0, false},
+		{"invalid time", &AuditLogListRequest{EndTime: "yesterday"}, 0, true},
+	}
+	for _, tc := range cases {
+		resp, err := s.ListLogs(tc.req)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tc.name, err, tc.wantErr)
+			continue
+		}
+		if err == nil && resp.Total != tc.want {
+			t.Errorf("%s: total = %d, want %d", tc.name, resp.Total, tc.want)
+		}
+	}
+
+	resp, _ := s.ListLogs(&AuditLogListRequest{PageSize: 2})
+	if len(resp.Items) != 2 || resp.TotalPages != 2 || resp.Items[0].RequestID != "req-3" {
+		t.Errorf("first page = %+v", resp)
+	}
+}
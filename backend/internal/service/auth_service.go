@@ -2,42 +2,86 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"math/rand"
+	"strconv"
+	"strings"
 	"time"
 
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 
 	"mall/internal/model"
 	"mall/internal/repository"
 	"mall/pkg/cache"
+	"mall/pkg/config"
+	"mall/pkg/logger"
+	"mall/pkg/sms"
 	"mall/pkg/utils"
 )
 
 // AuthService 认证服务接口
 type AuthService interface {
 	// 手机号登录相关
-	SendSMSCode(phone string) error
-	VerifySMSCode(phone, code string) bool
-	LoginByPhone(phone, code string) (*LoginResponse, error)
+	SendSMSCode(req *SendSMSCodeRequest, ip string) error
+	SendRegisterCode(req *SendSMSCodeRequest, ip string) error
+	SendBindCode(userID uint64, req *SendSMSCodeRequest, ip string) error
+	VerifySMSCode(scene, phone, code string) bool
+	LoginByPhone(phone, code string, client *ClientInfo) (*LoginResponse, error)
 	
 	// 微信登录相关
-	LoginByWechat(code string) (*LoginResponse, error)
+	LoginByWechat(code string, client *ClientInfo) (*LoginResponse, error)
 	
 	// 密码登录相关
 	RegisterByPassword(req *RegisterRequest) error
-	LoginByPassword(username, password string) (*LoginResponse, error)
+	LoginByPassword(username, password string, client *ClientInfo) (*LoginResponse, error)
 	
 	// 通用方法
 	RefreshToken(token string) (*TokenResponse, error)
-	Logout(userID uint64) error
+	Logout(userID uint64, sessionID string) error
 	GetUserInfo(userID uint64) (*UserInfoResponse, error)
 	
 	// 用户资料相关
 	UpdateProfile(userID uint64, req *UpdateProfileRequest) error
 	ChangePassword(userID uint64, req *ChangePasswordRequest) error
-	BindPhone(userID uint64, req *BindPhoneRequest) error
+	BindPhone(userID uint64, req *BindPhoneRequest) (*BindPhoneResponse, error)
+	MergeAccount(userID uint64, req *MergeAccountRequest, platform string, client *ClientInfo) (*LoginResponse, error)
+	GetMergeLogs(userID uint64) ([]*model.AccountMergeLog, error)
+
+	// 找回密码相关
+	SendPasswordResetCode(phone, ip string) error
+	VerifyPasswordResetCode(phone, code string) (*PasswordResetTicketResponse, error)
+	ResetPassword(req *ResetPasswordRequest) error
+
+	// 登录安全相关
+	GetLoginLogs(userID uint64, page, pageSize int) (*LoginLogListResponse, error)
+	ListLoginLogs(req *LoginLogListRequest) (*LoginLogListResponse, error)
+	UnlockAccount(userID uint64) error
+
+	// 用户管理（管理员）
+	ListUsers(req *AdminUserListRequest) (*AdminUserListResponse, error)
+	GetUserDetail(userID uint64, reveal bool) (*AdminUserDetailResponse, error)
+	UpdateUserStatus(userID uint64, status int8) error
+	AdminResetPassword(userID uint64, password string) error
+}
+
+// ClientInfo 客户端信息
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+	Device    string
+	Platform  string
+}
+
+// platformOr 返回客户端声明的平台，未声明或不支持时返回默认平台
+func (c *ClientInfo) platformOr(fallback string) string {
+	if c != nil && (c.Platform == "web" || c.Platform == "miniprogram") {
+		return c.Platform
+	}
+	return fallback
 }
 
 // LoginResponse 登录响应
@@ -53,6 +97,11 @@ type TokenResponse struct {
 	ExpiresIn int64  `json:"expires_in"`
 }
 
+// SendSMSCodeRequest 发送短信验证码请求，验证码场景由接口决定
+type SendSMSCodeRequest struct {
+	Phone string `json:"phone" binding:"required"`
+}
+
 // RegisterRequest 注册请求
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required"`
@@ -81,6 +130,45 @@ type BindPhoneRequest struct {
 	Code  string `json:"code" binding:"required"`
 }
 
+// BindPhoneResponse 绑定手机号响应，手机号已被其他账号使用时返回合并凭证
+type BindPhoneResponse struct {
+	Bound         bool              `json:"bound"`
+	MergeRequired bool              `json:"merge_required"`
+	MergeTicket   string            `json:"merge_ticket,omitempty"`
+	ExpiresIn     int64             `json:"expires_in,omitempty"`
+	TargetUser    *UserInfoResponse `json:"target_user,omitempty"`
+}
+
+// MergeAccountRequest 合并账号请求
+type MergeAccountRequest struct {
+	Ticket string `json:"ticket" binding:"required"`
+}
+
+// mergeTicket 账号合并凭证内容
+type mergeTicket struct {
+	SourceUserID uint64 `json:"source_user_id"`
+	TargetUserID uint64 `json:"target_user_id"`
+	Phone        string `json:"phone"`
+}
+
+// VerifyPasswordResetRequest 校验找回密码验证码请求
+type VerifyPasswordResetRequest struct {
+	Phone string `json:"phone" binding:"required"`
+	Code  string `json:"code" binding:"required"`
+}
+
+// PasswordResetTicketResponse 找回密码凭证响应
+type PasswordResetTicketResponse struct {
+	Ticket    string `json:"ticket"`
+	ExpiresIn int64  `json:"expires_in"`
+}
+
+// ResetPasswordRequest 重置密码请求
+type ResetPasswordRequest struct {
+	Ticket      string `json:"ticket" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
 // UserInfoResponse 用户信息响应
 type UserInfoResponse struct {
 	ID       uint64 `json:"id"`
@@ -91,105 +179,250 @@ type UserInfoResponse struct {
 	Status   int8   `json:"status"`
 }
 
+// AdminUserListRequest 用户列表请求（管理员）
+type AdminUserListRequest struct {
+	Page     int    `json:"page" form:"page"`
+	PageSize int    `json:"page_size" form:"page_size"`
+	Keyword  string `json:"keyword" form:"keyword"`
+	Status   *int8  `json:"status" form:"status"`
+	Reveal   bool   `json:"-" form:"-"` // 是否展示完整手机号
+}
+
+// AdminUserResponse 用户响应（管理员）
+type AdminUserResponse struct {
+	ID        uint64 `json:"id"`
+	Username  string `json:"username"`
+	Phone     string `json:"phone"`
+	Email     string `json:"email"`
+	Nickname  string `json:"nickname"`
+	Avatar    string `json:"avatar"`
+	Status    int8   `json:"status"`
+	CreatedAt string `json:"created_at"`
+}
+
+// AdminUserListResponse 用户列表响应（管理员）
+type AdminUserListResponse struct {
+	Items      []*AdminUserResponse `json:"items"`
+	Total      int64                `json:"total"`
+	Page       int                  `json:"page"`
+	PageSize   int                  `json:"page_size"`
+	TotalPages int                  `json:"total_pages"`
+}
+
+// AdminUserDetailResponse 用户详情响应（管理员）
+type AdminUserDetailResponse struct {
+	*AdminUserResponse
+	Gender       int8                   `json:"gender"`
+	Birthday     string                 `json:"birthday"`
+	Locked       bool                   `json:"locked"`
+	Addresses    []*AddressResponse     `json:"addresses"`
+	AuthBindings []*UserAuthBinding     `json:"auth_bindings"`
+	RecentOrders []*AdminUserOrderBrief `json:"recent_orders"`
+}
+
+// UserAuthBinding 用户认证绑定
+type UserAuthBinding struct {
+	AuthType  string `json:"auth_type"`
+	AuthKey   string `json:"auth_key"`
+	CreatedAt string `json:"created_at"`
+}
+
+// AdminUserOrderBrief 用户最近订单
+type AdminUserOrderBrief struct {
+	ID        uint64  `json:"id"`
+	OrderNo   string  `json:"order_no"`
+	PayAmount float64 `json:"pay_amount"`
+	Status    int8    `json:"status"`
+	CreatedAt string  `json:"created_at"`
+}
+
+// AdminResetPasswordRequest 重置用户密码请求（管理员）
+type AdminResetPasswordRequest struct {
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+// UpdateUserStatusRequest 更新用户状态请求（管理员）
+type UpdateUserStatusRequest struct {
+	Status *int8 `json:"status" binding:"required"`
+}
+
+// LoginLogListRequest 登录日志列表请求（管理员）
+type LoginLogListRequest struct {
+	Page     int    `json:"page" form:"page"`
+	PageSize int    `json:"page_size" form:"page_size"`
+	UserID   uint64 `json:"user_id" form:"user_id"`
+	Account  string `json:"account" form:"account"`
+	IP       string `json:"ip" form:"ip"`
+	Result   *int8  `json:"result" form:"result"`
+	Reveal   bool   `json:"-" form:"-"` // 是否展示完整账号
+}
+
+// LoginLogResponse 登录日志响应
+type LoginLogResponse struct {
+	ID         uint64 `json:"id"`
+	UserID     uint64 `json:"user_id"`
+	LoginType  string `json:"login_type"`
+	Account    string `json:"account"`
+	IP         string `json:"ip"`
+	UserAgent  string `json:"user_agent"`
+	Platform   string `json:"platform"`
+	Result     int8   `json:"result"`
+	FailReason string `json:"fail_reason"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// LoginLogListResponse 登录日志列表响应
+type LoginLogListResponse struct {
+	Items      []*LoginLogResponse `json:"items"`
+	Total      int64               `json:"total"`
+	Page       int                 `json:"page"`
+	PageSize   int                 `json:"page_size"`
+	TotalPages int                 `json:"total_pages"`
+}
+
+// 登录安全相关缓存key
+const (
+	loginFailKey      = "login_fail:%d"
+	loginLockKey      = "login_lock:%d"
+	loginLockLevelKey = "login_lock_level:%d"
+
+	// 找回密码凭证
+	passwordResetTicketKey = "password_reset_ticket:%s"
+
+	// 账号合并凭证
+	mergeTicketKey = "account_merge_ticket:%s"
+)
+
+// passwordResetTicketTTL 找回密码凭证有效期
+const passwordResetTicketTTL = 10 * time.Minute
+
+// mergeTicketTTL 账号合并凭证有效期
+const mergeTicketTTL = 10 * time.Minute
+
 // authService 认证服务实现
 type authService struct {
-	userRepo     repository.UserRepository
-	userAuthRepo repository.UserAuthRepository
+	userRepo       repository.UserRepository
+	userAuthRepo   repository.UserAuthRepository
+	loginLogRepo   repository.LoginLogRepository
+	mergeRepo      repository.AccountMergeRepository
+	smsService     SMSService
+	sessionService SessionService
+	notifier       NotificationService
+	footprints     FootprintService
 }
 
 // NewAuthService 创建认证服务
-func NewAuthService(userRepo repository.UserRepository, userAuthRepo repository.UserAuthRepository) AuthService {
+func NewAuthService(
+	userRepo repository.UserRepository,
+	userAuthRepo repository.UserAuthRepository,
+	loginLogRepo repository.LoginLogRepository,
+	mergeRepo repository.AccountMergeRepository,
+	smsService SMSService,
+	sessionService SessionService,
+	notifier NotificationService,
+	footprints FootprintService,
+) AuthService {
 	return &authService{
-		userRepo:     userRepo,
-		userAuthRepo: userAuthRepo,
+		userRepo:       userRepo,
+		userAuthRepo:   userAuthRepo,
+		loginLogRepo:   loginLogRepo,
+		mergeRepo:      mergeRepo,
+		smsService:     smsService,
+		sessionService: sessionService,
+		notifier:       notifier,
+		footprints:     footprints,
 	}
 }
 
-// SendSMSCode 发送短信验证码
-func (s *authService) SendSMSCode(phone string) error {
-	// 验证手机号格式
-	if !utils.IsValidPhone(phone) {
+// SendSMSCode 发送登录验证码，未注册的手机号登录时自动注册
+func (s *authService) SendSMSCode(req *SendSMSCodeRequest, ip string) error {
+	return s.smsService.SendCode(sms.SceneLogin, req.Phone, ip)
+}
+
+// SendRegisterCode 发送注册验证码，手机号已注册时不发送但返回相同结果，避免枚举账号
+func (s *authService) SendRegisterCode(req *SendSMSCodeRequest, ip string) error {
+	if !utils.IsValidPhone(req.Phone) {
 		return errors.New("invalid phone number format")
 	}
-
-	// 生成验证码
-	code := utils.GenerateVerifyCode()
-
-	// 存储到Redis，5分钟过期
-	ctx := context.Background()
-	key := fmt.Sprintf("sms_code:%s", phone)
-	err := cache.Set(ctx, key, code, 5*time.Minute)
-	if err != nil {
-		return errors.New("failed to store verification code")
+	if _, err := s.userRepo.GetByPhone(req.Phone); err == nil {
+		return nil
 	}
-
-	// TODO: 调用第三方短信服务发送验证码
-	// 这里暂时记录日志
-	fmt.Printf("SMS code for %s: %s\n", phone, code)
-
-	return nil
+	return s.smsService.SendCode(sms.SceneRegister, req.Phone, ip)
 }
 
-// VerifySMSCode 验证短信验证码
-func (s *authService) VerifySMSCode(phone, code string) bool {
-	ctx := context.Background()
-	key := fmt.Sprintf("sms_code:%s", phone)
-	
-	storedCode, err := cache.Get(ctx, key)
-	if err != nil {
-		return false
-	}
-
-	// 验证成功后删除验证码
-	if storedCode == code {
-		cache.Del(ctx, key)
-		return true
+// SendBindCode 发送绑定手机号验证码，仅限已登录用户
+func (s *authService) SendBindCode(userID uint64, req *SendSMSCodeRequest, ip string) error {
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		return errors.New("user not found")
 	}
+	return s.smsService.SendCode(sms.SceneBind, req.Phone, ip)
+}
 
-	return false
+// VerifySMSCode 验证短信验证码
+func (s *authService) VerifySMSCode(scene, phone, code string) bool {
+	return s.smsService.VerifyCode(scene, phone, code)
 }
 
 // LoginByPhone 手机号登录
-func (s *authService) LoginByPhone(phone, code string) (*LoginResponse, error) {
+func (s *authService) LoginByPhone(phone, code string, client *ClientInfo) (resp *LoginResponse, err error) {
+	platform := client.platformOr("web")
+	var userID uint64
+	defer func() { s.recordLogin(userID, "phone", phone, platform, client, err) }()
+
+	// 已注册用户先检查锁定状态，验证码错误计入登录失败
+	user, err := s.userRepo.GetByPhone(phone)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("failed to get user")
+	}
+	if user != nil {
+		userID = user.ID
+		if err := s.checkAccountLocked(user.ID); err != nil {
+			return nil, err
+		}
+	}
+
 	// 验证验证码
-	if !s.VerifySMSCode(phone, code) {
+	if !s.VerifySMSCode(sms.SceneLogin, phone, code) {
+		if user != nil {
+			if lockErr := s.recordLoginFailure(user.ID); lockErr != nil {
+				return nil, lockErr
+			}
+		}
 		return nil, errors.New("invalid verification code")
 	}
 
-	// 查找用户
-	user, err := s.userRepo.GetByPhone(phone)
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			// 用户不存在，自动注册
-			user = &model.User{
-				Phone:  phone,
-				Status: 1,
-			}
-			if err := s.userRepo.Create(user); err != nil {
-				return nil, errors.New("failed to create user")
-			}
+	if user == nil {
+		// 用户不存在，自动注册
+		user = &model.User{
+			Phone:  phone,
+			Status: 1,
+		}
+		if err := s.userRepo.Create(user); err != nil {
+			return nil, errors.New("failed to create user")
+		}
 
-			// 创建认证信息
-			auth := &model.UserAuth{
-				UserID:   user.ID,
-				AuthType: "phone",
-				AuthKey:  phone,
-			}
-			if err := s.userAuthRepo.Create(auth); err != nil {
-				return nil, errors.New("failed to create auth info")
-			}
-		} else {
-			return nil, errors.New("failed to get user")
+		// 创建认证信息
+		auth := &model.UserAuth{
+			UserID:   user.ID,
+			AuthType: "phone",
+			AuthKey:  phone,
+		}
+		if err := s.userAuthRepo.Create(auth); err != nil {
+			return nil, errors.New("failed to create auth info")
 		}
+	} else {
+		s.clearLoginFailures(user.ID)
 	}
 
+	userID = user.ID
+
 	// 检查用户状态
 	if user.Status != 1 {
 		return nil, errors.New("user account is disabled")
 	}
 
 	// 生成token
-	token, err := utils.GenerateToken(int64(user.ID), user.Username, "user", "web")
+	token, err := s.issueToken(user, platform, client)
 	if err != nil {
 		return nil, errors.New("failed to generate token")
 	}
@@ -210,11 +443,15 @@ func (s *authService) LoginByPhone(phone, code string) (*LoginResponse, error) {
 }
 
 // LoginByWechat 微信登录
-func (s *authService) LoginByWechat(code string) (*LoginResponse, error) {
+func (s *authService) LoginByWechat(code string, client *ClientInfo) (resp *LoginResponse, err error) {
 	// TODO: 调用微信API获取openid
 	// 这里暂时模拟
 	openID := fmt.Sprintf("wx_openid_%d", rand.Int63())
 
+	platform := client.platformOr("miniprogram")
+	var userID uint64
+	defer func() { s.recordLogin(userID, "wechat", openID, platform, client, err) }()
+
 	// 查找用户
 	user, err := s.userRepo.GetByWechatOpenID(openID)
 	if err != nil {
@@ -242,13 +479,20 @@ func (s *authService) LoginByWechat(code string) (*LoginResponse, error) {
 		}
 	}
 
+	userID = user.ID
+
+	// 检查账号是否被锁定
+	if err := s.checkAccountLocked(user.ID); err != nil {
+		return nil, err
+	}
+
 	// 检查用户状态
 	if user.Status != 1 {
 		return nil, errors.New("user account is disabled")
 	}
 
 	// 生成token
-	token, err := utils.GenerateToken(int64(user.ID), user.Username, "user", "miniprogram")
+	token, err := s.issueToken(user, platform, client)
 	if err != nil {
 		return nil, errors.New("failed to generate token")
 	}
@@ -271,7 +515,7 @@ func (s *authService) LoginByWechat(code string) (*LoginResponse, error) {
 // RegisterByPassword 密码注册
 func (s *authService) RegisterByPassword(req *RegisterRequest) error {
 	// 验证验证码
-	if !s.VerifySMSCode(req.Phone, req.Code) {
+	if !s.VerifySMSCode(sms.SceneRegister, req.Phone, req.Code) {
 		return errors.New("invalid verification code")
 	}
 
@@ -312,17 +556,31 @@ func (s *authService) RegisterByPassword(req *RegisterRequest) error {
 }
 
 // LoginByPassword 密码登录
-func (s *authService) LoginByPassword(username, password string) (*LoginResponse, error) {
+func (s *authService) LoginByPassword(username, password string, client *ClientInfo) (resp *LoginResponse, err error) {
+	platform := client.platformOr("web")
+	var userID uint64
+	defer func() { s.recordLogin(userID, "password", username, platform, client, err) }()
+
 	// 获取认证信息
 	auth, err := s.userAuthRepo.GetByAuthKey(username)
 	if err != nil {
 		return nil, errors.New("invalid username or password")
 	}
+	userID = auth.UserID
+
+	// 检查账号是否被锁定
+	if err := s.checkAccountLocked(auth.UserID); err != nil {
+		return nil, err
+	}
 
 	// 验证密码
 	if !utils.CheckPassword(password, auth.PasswordHash) {
+		if lockErr := s.recordLoginFailure(auth.UserID); lockErr != nil {
+			return nil, lockErr
+		}
 		return nil, errors.New("invalid username or password")
 	}
+	s.clearLoginFailures(auth.UserID)
 
 	// 获取用户信息
 	user := &auth.User
@@ -331,7 +589,7 @@ func (s *authService) LoginByPassword(username, password string) (*LoginResponse
 	}
 
 	// 生成token
-	token, err := utils.GenerateToken(int64(user.ID), user.Username, "user", "web")
+	token, err := s.issueToken(user, platform, client)
 	if err != nil {
 		return nil, errors.New("failed to generate token")
 	}
@@ -353,23 +611,33 @@ func (s *authService) LoginByPassword(username, password string) (*LoginResponse
 
 // RefreshToken 刷新token
 func (s *authService) RefreshToken(token string) (*TokenResponse, error) {
+	claims, err := utils.ParseToken(token)
+	if err != nil || !utils.ValidateSession(claims) {
+		return nil, errors.New("failed to refresh token")
+	}
+
 	newToken, err := utils.RefreshToken(token)
 	if err != nil {
 		return nil, errors.New("failed to refresh token")
 	}
 
+	// 同步延长会话有效期
+	if err := s.sessionService.ExtendSession(claims.ID); err != nil {
+		return nil, errors.New("failed to refresh token")
+	}
+
 	return &TokenResponse{
 		Token:     newToken,
 		ExpiresIn: 168 * 3600, // 7天
 	}, nil
 }
 
-// Logout 登出
-func (s *authService) Logout(userID uint64) error {
-	// TODO: 将token加入黑名单
-	ctx := context.Background()
-	key := fmt.Sprintf("token_blacklist:%d", userID)
-	return cache.Set(ctx, key, "1", 24*time.Hour)
+// Logout 登出，注销当前会话
+func (s *authService) Logout(userID uint64, sessionID string) error {
+	if sessionID == "" {
+		return nil
+	}
+	return s.sessionService.RevokeBySessionID(userID, sessionID)
 }
 
 // GetUserInfo 获取用户信息
@@ -443,25 +711,33 @@ func (s *authService) ChangePassword(userID uint64, req *ChangePasswordRequest)
 
 	userAuth.PasswordHash = newPasswordHash
 
-	return s.userAuthRepo.Update(userAuth)
+	if err := s.userAuthRepo.Update(userAuth); err != nil {
+		return err
+	}
+
+	s.notifyPasswordChanged(userID, "您的登录密码已修改，如非本人操作请立即重置密码")
+	return nil
 }
 
-// BindPhone 绑定手机号
-func (s *authService) BindPhone(userID uint64, req *BindPhoneRequest) error {
+// BindPhone 绑定手机号，手机号已属于其他账号时签发合并凭证
+func (s *authService) BindPhone(userID uint64, req *BindPhoneRequest) (*BindPhoneResponse, error) {
 	// 验证验证码
-	if !s.VerifySMSCode(req.Phone, req.Code) {
-		return errors.New("invalid verification code")
+	if !s.VerifySMSCode(sms.SceneBind, req.Phone, req.Code) {
+		return nil, errors.New("invalid verification code")
 	}
 
-	// 检查手机号是否已被其他用户绑定
-	if _, err := s.userRepo.GetByPhone(req.Phone); err == nil {
-		return errors.New("phone number already bound to another account")
+	// 手机号已被其他用户绑定，验证码已证明手机号归属，引导用户合并账号
+	if owner, err := s.userRepo.GetByPhone(req.Phone); err == nil {
+		if owner.ID == userID {
+			return &BindPhoneResponse{Bound: true}, nil
+		}
+		return s.createMergeTicket(userID, owner, req.Phone)
 	}
 
 	// 获取用户
 	user, err := s.userRepo.GetByID(userID)
 	if err != nil {
-		return errors.New("user not found")
+		return nil, errors.New("user not found")
 	}
 
 	// 更新手机号
@@ -469,7 +745,7 @@ func (s *authService) BindPhone(userID uint64, req *BindPhoneRequest) error {
 
 	// 更新认证信息
 	if err := s.userRepo.Update(user); err != nil {
-		return errors.New("failed to update user phone")
+		return nil, errors.New("failed to update user phone")
 	}
 
 	// 更新认证信息中的手机号
@@ -486,8 +762,632 @@ func (s *authService) BindPhone(userID uint64, req *BindPhoneRequest) error {
 	}
 
 	if userAuth.ID == 0 {
-		return s.userAuthRepo.Create(userAuth)
+		err = s.userAuthRepo.Create(userAuth)
 	} else {
-		return s.userAuthRepo.Update(userAuth)
+		err = s.userAuthRepo.Update(userAuth)
+	}
+	if err != nil {
+		return nil, errors.New("failed to update auth info")
+	}
+
+	return &BindPhoneResponse{Bound: true}, nil
+}
+
+// MergeAccount 将当前账号合并到手机号所属账号，返回保留账号的登录信息
+func (s *authService) MergeAccount(userID uint64, req *MergeAccountRequest, platform string, client *ClientInfo) (*LoginResponse, error) {
+	ctx := context.Background()
+	key := fmt.Sprintf(mergeTicketKey, req.Ticket)
+
+	// 原子地取出凭证，并发请求只有一个能拿到
+	data, err := cache.GetDel(ctx, key)
+	if err != nil {
+		return nil, errors.New("invalid or expired merge ticket")
+	}
+
+	var ticket mergeTicket
+	if err := json.Unmarshal([]byte(data), &ticket); err != nil || ticket.SourceUserID != userID {
+		return nil, errors.New("invalid or expired merge ticket")
+	}
+
+	target, err := s.userRepo.GetByID(ticket.TargetUserID)
+	if err != nil || target.Phone != ticket.Phone {
+		return nil, errors.New("target account not found")
 	}
-}
\ No newline at end of file
+	if target.Status != 1 {
+		return nil, errors.New("target account is disabled")
+	}
+
+	// 合并时会话记录随账号迁移，先记下源账号的在线会话，合并成功后再使其失效
+	sessionIDs, err := s.sessionService.ActiveSessionIDs(ticket.SourceUserID)
+	if err != nil {
+		logger.Error("Failed to load sessions", zap.Uint64("user_id", ticket.SourceUserID), zap.Error(err))
+	}
+
+	mergeLog := &model.AccountMergeLog{
+		Phone: ticket.Phone,
+		IP:    clientIP(client),
+	}
+	if err := s.mergeRepo.Merge(ticket.SourceUserID, ticket.TargetUserID, maxAddressesPerUser, mergeLog); err != nil {
+		logger.Error("Failed to merge accounts",
+			zap.Uint64("source_user_id", ticket.SourceUserID),
+			zap.Uint64("target_user_id", ticket.TargetUserID),
+			zap.Error(err),
+		)
+		// 合并失败时放回凭证，用户保持登录并可直接重试，无需重新验证短信
+		if err := cache.Set(ctx, key, data, mergeTicketTTL); err != nil {
+			logger.Warn("Failed to restore merge ticket", zap.Uint64("user_id", userID), zap.Error(err))
+		}
+		return nil, errors.New("failed to merge accounts")
+	}
+
+	// 会话记录已在合并事务中标记为注销，清除缓存中的会话并撤销源账号此前签发的token
+	if err := utils.RemoveSessions(sessionIDs...); err != nil {
+		logger.Error("Failed to remove sessions", zap.Uint64("user_id", ticket.SourceUserID), zap.Error(err))
+	}
+	if err := utils.RevokeUserTokens(ticket.SourceUserID); err != nil {
+		logger.Error("Failed to revoke tokens", zap.Uint64("user_id", ticket.SourceUserID), zap.Error(err))
+	}
+
+	logger.Info("Accounts merged",
+		zap.Uint64("source_user_id", ticket.SourceUserID),
+		zap.Uint64("target_user_id", ticket.TargetUserID),
+		zap.Int("moved_orders", mergeLog.MovedOrders),
+	)
+
+	// 迁移Redis中的足迹并清除目标账号的未读通知数缓存
+	if err := s.footprints.MoveUser(ticket.SourceUserID, ticket.TargetUserID); err != nil {
+		logger.Error("Failed to move footprints", zap.Uint64("source_user_id", ticket.SourceUserID), zap.Error(err))
+	}
+	cache.Del(ctx, fmt.Sprintf(notificationUnreadKey, ticket.TargetUserID))
+
+	target, err = s.userRepo.GetByID(ticket.TargetUserID)
+	if err != nil {
+		return nil, errors.New("failed to get user")
+	}
+
+	if platform == "" {
+		platform = "web"
+	}
+	token, err := s.issueToken(target, platform, client)
+	if err != nil {
+		return nil, errors.New("failed to generate token")
+	}
+
+	return &LoginResponse{
+		Token:     token,
+		ExpiresIn: 168 * 3600, // 7天
+		User: &UserInfoResponse{
+			ID:       target.ID,
+			Username: target.Username,
+			Phone:    target.Phone,
+			Status:   target.Status,
+		},
+	}, nil
+}
+
+// GetMergeLogs 获取用户的账号合并记录
+func (s *authService) GetMergeLogs(userID uint64) ([]*model.AccountMergeLog, error) {
+	return s.mergeRepo.GetLogsByUserID(userID)
+}
+
+// createMergeTicket 签发账号合并凭证
+func (s *authService) createMergeTicket(sourceUserID uint64, target *model.User, phone string) (*BindPhoneResponse, error) {
+	data, err := json.Marshal(&mergeTicket{
+		SourceUserID: sourceUserID,
+		TargetUserID: target.ID,
+		Phone:        phone,
+	})
+	if err != nil {
+		return nil, errors.New("failed to create merge ticket")
+	}
+
+	ticket := utils.GenerateRandomString(32)
+	if err := cache.Set(context.Background(), fmt.Sprintf(mergeTicketKey, ticket), string(data), mergeTicketTTL); err != nil {
+		return nil, errors.New("failed to create merge ticket")
+	}
+
+	return &BindPhoneResponse{
+		MergeRequired: true,
+		MergeTicket:   ticket,
+		ExpiresIn:     int64(mergeTicketTTL.Seconds()),
+		TargetUser: &UserInfoResponse{
+			ID:       target.ID,
+			Username: target.Username,
+			Phone:    logger.MaskPhone(target.Phone),
+			Status:   target.Status,
+		},
+	}, nil
+}
+
+// SendPasswordResetCode 发送找回密码验证码，未注册的手机号同样返回成功，避免枚举账号
+func (s *authService) SendPasswordResetCode(phone, ip string) error {
+	if _, err := s.userRepo.GetByPhone(phone); err != nil {
+		return nil
+	}
+
+	return s.smsService.SendCode(sms.SceneReset, phone, ip)
+}
+
+// VerifyPasswordResetCode 校验找回密码验证码并签发短期重置凭证
+func (s *authService) VerifyPasswordResetCode(phone, code string) (*PasswordResetTicketResponse, error) {
+	if !s.VerifySMSCode(sms.SceneReset, phone, code) {
+		return nil, errors.New("invalid verification code")
+	}
+
+	user, err := s.userRepo.GetByPhone(phone)
+	if err != nil {
+		return nil, errors.New("invalid verification code")
+	}
+
+	ticket := utils.GenerateRandomString(32)
+	key := fmt.Sprintf(passwordResetTicketKey, ticket)
+	if err := cache.Set(context.Background(), key, user.ID, passwordResetTicketTTL); err != nil {
+		return nil, errors.New("failed to create reset ticket")
+	}
+
+	return &PasswordResetTicketResponse{
+		Ticket:    ticket,
+		ExpiresIn: int64(passwordResetTicketTTL.Seconds()),
+	}, nil
+}
+
+// ResetPassword 使用重置凭证设置新密码，并撤销已有登录状态
+func (s *authService) ResetPassword(req *ResetPasswordRequest) error {
+	ctx := context.Background()
+	key := fmt.Sprintf(passwordResetTicketKey, req.Ticket)
+
+	// 凭证一次性使用，读取与删除原子完成，避免并发重复使用
+	val, err := cache.GetDel(ctx, key)
+	if err != nil {
+		return errors.New("invalid or expired reset ticket")
+	}
+
+	userID, err := strconv.ParseUint(val, 10, 64)
+	if err != nil {
+		return errors.New("invalid or expired reset ticket")
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if err := s.setPassword(user, req.NewPassword); err != nil {
+		return err
+	}
+
+	s.notifyPasswordChanged(user.ID, "您已通过短信验证重置登录密码，如非本人操作请联系客服")
+	return nil
+}
+
+// setPassword 设置用户密码，成功后解除锁定并撤销已有token
+func (s *authService) setPassword(user *model.User, password string) error {
+	ctx := context.Background()
+	userID := user.ID
+
+	passwordHash, err := utils.HashPassword(password)
+	if err != nil {
+		return errors.New("failed to hash new password")
+	}
+
+	userAuth, err := s.userAuthRepo.GetByUserIDAndType(userID, "password")
+	if err != nil {
+		// 未设置过密码，创建密码认证记录
+		userAuth = &model.UserAuth{
+			UserID:       userID,
+			AuthType:     "password",
+			AuthKey:      user.Phone,
+			PasswordHash: passwordHash,
+			Salt:         utils.GenerateSalt(),
+		}
+		if err := s.userAuthRepo.Create(userAuth); err != nil {
+			return errors.New("failed to reset password")
+		}
+	} else {
+		userAuth.PasswordHash = passwordHash
+		if err := s.userAuthRepo.Update(userAuth); err != nil {
+			return errors.New("failed to reset password")
+		}
+	}
+
+	// 重置成功后解除锁定并撤销已有token
+	s.clearLoginFailures(userID)
+	cache.Del(ctx, fmt.Sprintf(loginLockKey, userID))
+
+	if err := s.sessionService.RevokeAllSessions(userID); err != nil {
+		logger.Error("Failed to revoke sessions", zap.Uint64("user_id", userID), zap.Error(err))
+	}
+
+	return utils.RevokeUserTokens(userID)
+}
+
+// issueToken 创建登录会话并签发绑定会话的token
+func (s *authService) issueToken(user *model.User, platform string, client *ClientInfo) (string, error) {
+	session, err := s.sessionService.CreateSession(user.ID, platform, client)
+	if err != nil {
+		return "", err
+	}
+	return utils.GenerateSessionToken(int64(user.ID), user.Username, "user", platform, session.SessionID)
+}
+
+// GetLoginLogs 获取用户最近登录记录
+func (s *authService) GetLoginLogs(userID uint64, page, pageSize int) (*LoginLogListResponse, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	logs, total, err := s.loginLogRepo.GetUserLogs(userID, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toLoginLogListResponse(logs, total, page, pageSize), nil
+}
+
+// ListLoginLogs 查询登录日志（管理员）
+func (s *authService) ListLoginLogs(req *LoginLogListRequest) (*LoginLogListResponse, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	query := &repository.LoginLogQuery{
+		UserID:  req.UserID,
+		Account: req.Account,
+		IP:      req.IP,
+		Result:  req.Result,
+	}
+	logs, total, err := s.loginLogRepo.List(query, req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	response := s.toLoginLogListResponse(logs, total, req.Page, req.PageSize)
+	if !req.Reveal {
+		for _, item := range response.Items {
+			item.Account = maskAccount(item.LoginType, item.Account)
+		}
+	}
+	return response, nil
+}
+
+// toAdminAddressResponse 转换为管理端地址响应，未授权查看时脱敏
+func (s *authService) toAdminAddressResponse(address *model.UserAddress, reveal bool) *AddressResponse {
+	response := &AddressResponse{
+		ID:          address.ID,
+		Name:        address.Name,
+		Phone:       address.Phone,
+		Province:    address.Province,
+		City:        address.City,
+		District:    address.District,
+		Address:     address.Address,
+		FullAddress: FormatFullAddress(address),
+		IsDefault:   address.IsDefault == 1,
+		UpdatedAt:   address.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if !reveal {
+		response.Phone = logger.MaskPhone(response.Phone)
+		response.Address = logger.MaskAddress(response.Address)
+		response.FullAddress = logger.MaskAddress(response.FullAddress)
+	}
+	return response
+}
+
+// maskAccount 按账号类型脱敏，微信openid等非手机号账号保留首尾字符
+func maskAccount(accountType, account string) string {
+	switch accountType {
+	case "phone", "password":
+		return logger.MaskPhone(account)
+	default:
+		return logger.MaskAccount(account)
+	}
+}
+
+// UnlockAccount 解除账号锁定（管理员）
+func (s *authService) UnlockAccount(userID uint64) error {
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		return errors.New("user not found")
+	}
+
+	ctx := context.Background()
+	return cache.Del(ctx,
+		fmt.Sprintf(loginLockKey, userID),
+		fmt.Sprintf(loginFailKey, userID),
+		fmt.Sprintf(loginLockLevelKey, userID),
+	)
+}
+
+// checkAccountLocked 检查账号是否处于锁定状态
+func (s *authService) checkAccountLocked(userID uint64) error {
+	ctx := context.Background()
+	ttl, err := cache.TTL(ctx, fmt.Sprintf(loginLockKey, userID))
+	if err != nil || ttl <= 0 {
+		return nil
+	}
+
+	return fmt.Errorf("account is locked, please try again in %d minutes", int(math.Ceil(ttl.Minutes())))
+}
+
+// recordLoginFailure 记录登录失败，达到阈值后按级别递增锁定时长
+func (s *authService) recordLoginFailure(userID uint64) error {
+	cfg := config.GetConfig()
+	maxAttempts := cfg.Login.MaxFailedAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	window := time.Duration(cfg.Login.FailedWindowMinutes) * time.Minute
+	if window <= 0 {
+		window = time.Hour
+	}
+	lockoutMinutes := cfg.Login.LockoutMinutes
+	if len(lockoutMinutes) == 0 {
+		lockoutMinutes = []int{5, 30, 1440}
+	}
+
+	ctx := context.Background()
+	failKey := fmt.Sprintf(loginFailKey, userID)
+	count, err := cache.Incr(ctx, failKey)
+	if err != nil {
+		return nil
+	}
+	if count == 1 {
+		cache.Expire(ctx, failKey, window)
+	}
+	if count < int64(maxAttempts) {
+		return nil
+	}
+
+	// 连续锁定次数越多，锁定时间越长
+	levelKey := fmt.Sprintf(loginLockLevelKey, userID)
+	level, err := cache.Incr(ctx, levelKey)
+	if err != nil {
+		level = 1
+	}
+	cache.Expire(ctx, levelKey, 24*time.Hour)
+
+	idx := int(level) - 1
+	if idx >= len(lockoutMinutes) {
+		idx = len(lockoutMinutes) - 1
+	}
+	minutes := lockoutMinutes[idx]
+
+	cache.Set(ctx, fmt.Sprintf(loginLockKey, userID), "1", time.Duration(minutes)*time.Minute)
+	cache.Del(ctx, failKey)
+
+	logger.Warn("Account locked due to failed logins",
+		zap.Uint64("user_id", userID),
+		zap.Int64("lock_level", level),
+		zap.Int("lock_minutes", minutes),
+	)
+
+	return fmt.Errorf("too many failed attempts, account locked for %d minutes", minutes)
+}
+
+// clearLoginFailures 登录成功后清除失败计数
+func (s *authService) clearLoginFailures(userID uint64) {
+	ctx := context.Background()
+	cache.Del(ctx, fmt.Sprintf(loginFailKey, userID), fmt.Sprintf(loginLockLevelKey, userID))
+}
+
+// recordLogin 记录登录日志
+func (s *authService) recordLogin(userID uint64, loginType, account, platform string, client *ClientInfo, loginErr error) {
+	log := &model.LoginLog{
+		UserID:    userID,
+		LoginType: loginType,
+		Account:   account,
+		Platform:  platform,
+		Result:    1,
+	}
+	if client != nil {
+		log.IP = client.IP
+		log.UserAgent = client.UserAgent
+	}
+	if loginErr != nil {
+		log.Result = 0
+		log.FailReason = loginErr.Error()
+	}
+
+	if err := s.loginLogRepo.Create(log); err != nil {
+		logger.Error("Failed to record login log", zap.String("login_type", loginType), zap.Error(err))
+	}
+}
+
+// ListUsers 查询用户列表（管理员）
+func (s *authService) ListUsers(req *AdminUserListRequest) (*AdminUserListResponse, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	query := &repository.UserQuery{
+		Keyword: strings.TrimSpace(req.Keyword),
+		Status:  req.Status,
+	}
+	users, total, err := s.userRepo.Query(query, req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []*AdminUserResponse
+	for _, user := range users {
+		items = append(items, s.toAdminUserResponse(user, req.Reveal))
+	}
+
+	totalPages := int(total) / req.PageSize
+	if int(total)%req.PageSize > 0 {
+		totalPages++
+	}
+
+	return &AdminUserListResponse{
+		Items:      items,
+		Total:      total,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// GetUserDetail 获取用户详情（管理员）
+func (s *authService) GetUserDetail(userID uint64, reveal bool) (*AdminUserDetailResponse, error) {
+	user, err := s.userRepo.GetUserDetail(userID, 10)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	detail := &AdminUserDetailResponse{
+		AdminUserResponse: s.toAdminUserResponse(user, reveal),
+		Addresses:         []*AddressResponse{},
+		AuthBindings:      []*UserAuthBinding{},
+		RecentOrders:      []*AdminUserOrderBrief{},
+	}
+	if user.Profile != nil {
+		detail.Gender = user.Profile.Gender
+		if !user.Profile.Birthday.IsZero() {
+			detail.Birthday = user.Profile.Birthday.Format("2006-01-02")
+		}
+	}
+
+	locked, _ := cache.Exists(context.Background(), fmt.Sprintf(loginLockKey, userID))
+	detail.Locked = locked > 0
+
+	for i := range user.Addresses {
+		detail.Addresses = append(detail.Addresses, s.toAdminAddressResponse(&user.Addresses[i], reveal))
+	}
+
+	for _, auth := range user.AuthInfos {
+		authKey := auth.AuthKey
+		if !reveal {
+			authKey = maskAccount(auth.AuthType, authKey)
+		}
+		detail.AuthBindings = append(detail.AuthBindings, &UserAuthBinding{
+			AuthType:  auth.AuthType,
+			AuthKey:   authKey,
+			CreatedAt: auth.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+
+	for _, order := range user.Orders {
+		detail.RecentOrders = append(detail.RecentOrders, &AdminUserOrderBrief{
+			ID:        order.ID,
+			OrderNo:   order.OrderNo,
+			PayAmount: order.PayAmount,
+			Status:    order.Status,
+			CreatedAt: order.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+
+	return detail, nil
+}
+
+// UpdateUserStatus 启用或禁用用户（管理员），禁用时撤销全部会话
+func (s *authService) UpdateUserStatus(userID uint64, status int8) error {
+	if status != 0 && status != 1 {
+		return errors.New("invalid status")
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if user.Status == status {
+		return nil
+	}
+
+	if err := s.userRepo.UpdateStatus(userID, status); err != nil {
+		return errors.New("failed to update user status")
+	}
+
+	if status == 0 {
+		if err := s.sessionService.RevokeAllSessions(userID); err != nil {
+			logger.Error("Failed to revoke sessions", zap.Uint64("user_id", userID), zap.Error(err))
+		}
+		return utils.RevokeUserTokens(userID)
+	}
+	return nil
+}
+
+// AdminResetPassword 重置用户密码（管理员）
+func (s *authService) AdminResetPassword(userID uint64, password string) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+
+	if err := s.setPassword(user, password); err != nil {
+		return err
+	}
+
+	s.notifyPasswordChanged(user.ID, "客服已为您重置登录密码，请使用新密码登录并及时修改")
+	return nil
+}
+
+// notifyPasswordChanged 密码变更后发送账号安全通知
+func (s *authService) notifyPasswordChanged(userID uint64, content string) {
+	s.notifier.Notify(userID, &NotificationMessage{
+		Category: NotificationCategoryAccount,
+		Type:     "password_changed",
+		Title:    "登录密码已变更",
+		Content:  content,
+		BizType:  "user",
+		BizID:    userID,
+	})
+}
+
+// toAdminUserResponse 转换为管理员用户响应
+func (s *authService) toAdminUserResponse(user *model.User, reveal bool) *AdminUserResponse {
+	response := &AdminUserResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		Phone:     user.Phone,
+		Email:     user.Email,
+		Status:    user.Status,
+		CreatedAt: user.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if !reveal {
+		response.Phone = logger.MaskPhone(response.Phone)
+	}
+	if user.Profile != nil {
+		response.Nickname = user.Profile.Nickname
+		response.Avatar = user.Profile.Avatar
+	}
+	return response
+}
+
+// toLoginLogListResponse 转换为登录日志列表响应
+func (s *authService) toLoginLogListResponse(logs []*model.LoginLog, total int64, page, pageSize int) *LoginLogListResponse {
+	var items []*LoginLogResponse
+	for _, log := range logs {
+		items = append(items, &LoginLogResponse{
+			ID:         log.ID,
+			UserID:     log.UserID,
+			LoginType:  log.LoginType,
+			Account:    log.Account,
+			IP:         log.IP,
+			UserAgent:  log.UserAgent,
+			Platform:   log.Platform,
+			Result:     log.Result,
+			FailReason: log.FailReason,
+			CreatedAt:  log.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+
+	totalPages := int(total) / pageSize
+	if int(total)%pageSize > 0 {
+		totalPages++
+	}
+
+	return &LoginLogListResponse{
+		Items:      items,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+	}
+}
@@ -0,0 +1,417 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+	"mall/internal/repository"
+	"mall/pkg/config"
+	"mall/pkg/utils"
+)
+
+// newTestAuthService 基于内存SQLite和Redis创建认证服务，短信验证码由fakeSMSSender接收
+func newTestAuthService(t *testing.T) (*authService, *fakeSMSSender, *miniredis.Miniredis, *gorm.DB) {
+	t.Helper()
+
+	mr := useMiniredis(t)
+	cfg := &config.Config{}
+	cfg.JWT.Secret = "test-secret"
+	cfg.JWT.ExpireHours = 2
+	cfg.Login.MaxFailedAttempts = 3
+	cfg.Login.LockoutMinutes = []int{5, 30}
+	useConfig(t, cfg)
+
+	db := newTestDB(t,
+		&model.User{},
+		&model.UserProfile{},
+		&model.UserAuth{},
+		&model.UserAddress{},
+		&model.LoginLog{},
+		&model.UserSession{},
+		&model.AccountMergeLog{},
+		&model.Order{},
+		&model.CartItem{},
+		&model.Product{},
+		&model.UserPoints{},
+		&model.PointsLedger{},
+		&model.MemberLevel{},
+		&model.ProductFavorite{},
+		&model.UserFootprint{},
+		&model.Notification{},
+	)
+
+	productRepo := repository.NewProductRepository(db)
+	footprints := NewFootprintService(repository.NewFootprintRepository(db), productRepo)
+	t.Cleanup(footprints.Close)
+
+	sender := newFakeSMSSender()
+	s := &authService{
+		userRepo:       repository.NewUserRepository(db),
+		userAuthRepo:   repository.NewUserAuthRepository(db),
+		loginLogRepo:   repository.NewLoginLogRepository(db),
+		mergeRepo:      repository.NewAccountMergeRepository(db),
+		smsService:     &smsService{sender: sender},
+		sessionService: NewSessionService(repository.NewUserSessionRepository(db)),
+		notifier:       NewNotificationService(repository.NewNotificationRepository(db)),
+		footprints:     footprints,
+	}
+	return s, sender, mr, db
+}
+
+// createTestUser 创建带密码登录方式的用户
+func createTestUser(t *testing.T, db *gorm.DB, username, phone, password string) *model.User {
+	t.Helper()
+
+	user := &model.User{Username: username, Phone: phone, WechatOpenID: "openid-" + username, Status: 1}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	auth := &model.UserAuth{UserID: user.ID, AuthType: "password", AuthKey: phone, PasswordHash: hash}
+	if err := db.Create(auth).Error; err != nil {
+		t.Fatalf("create auth: %v", err)
+	}
+	return user
+}
+
+func TestLoginByPasswordProgressiveLockout(t *testing.T) {
+	s, _, mr, db := newTestAuthService(t)
+	user := createTestUser(t, db, "alice", "13800000001", "secret123")
+
+	steps := []struct {
+		name     string
+		password string
+		advance  time.Duration
+		wantErr  string
+	}{
+		{"first failure", "wrong", 0, "invalid username or password"},
+		{"second failure", "wrong", 0, "invalid username or password"},
+		{"third failure locks", "wrong", 0, "locked for 5 minutes"},
+		{"correct password while locked", "secret123", 0, "account is locked"},
+		{"failure after first lock", "wrong", 5 * time.Minute, "invalid username or password"},
+		{"failure after first lock", "wrong", 0, "invalid username or password"},
+		{"second lock is longer", "wrong", 0, "locked for 30 minutes"},
+		{"still locked before expiry", "secret123", 29 * time.Minute, "account is locked"},
+		{"login after lock expires", "secret123", time.Minute, ""},
+	}
+	for _, step := range steps {
+		mr.FastForward(step.advance)
+		resp, err := s.LoginByPassword("13800000001", step.password, &ClientInfo{IP: "10.0.0.1"})
+		if step.wantErr == "" {
+			if err != nil || resp.Token == "" {
+				t.Fatalf("%s: LoginByPassword: %v", step.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), step.wantErr) {
+			t.Fatalf("%s: err = %v, want %q", step.name, err, step.wantErr)
+		}
+	}
+
+	// 每次登录都记录日志
+	var failed, succeeded int64
+	db.Model(&model.LoginLog{}).Where("user_id = ? AND result = ?", user.ID, 0).Count(&failed)
+	db.Model(&model.LoginLog{}).Where("user_id = ? AND result = ?", user.ID, 1).Count(&succeeded)
+	if failed != 8 || succeeded != 1 {
+		t.Errorf("login logs failed=%d succeeded=%d, want 8 and 1", failed, succeeded)
+	}
+}
+
+func TestUnlockAccount(t *testing.T) {
+	s, _, _, db := newTestAuthService(t)
+	user := createTestUser(t, db, "alice", "13800000001", "secret123")
+
+	for i := 0; i < 3; i++ {
+		s.LoginByPassword("13800000001", "wrong", nil)
+	}
+	if _, err := s.LoginByPassword("13800000001", "secret123", nil); err == nil {
+		t.Fatal("account should be locked")
+	}
+
+	if err := s.UnlockAccount(user.ID); err != nil {
+		t.Fatalf("UnlockAccount: %v", err)
+	}
+	if _, err := s.LoginByPassword("13800000001", "secret123", nil); err != nil {
+		t.Errorf("login after unlock: %v", err)
+	}
+	if err := s.UnlockAccount(user.ID + 100); err == nil {
+		t.Error("unlocking an unknown user should fail")
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	s, sender, _, db := newTestAuthService(t)
+	user := createTestUser(t, db, "alice", "13800000001", "secret123")
+
+	// 未注册的手机号不发送验证码，但同样返回成功
+	if err := s.SendPasswordResetCode("13800000009", ""); err != nil {
+		t.Fatalf("SendPasswordResetCode unknown phone: %v", err)
+	}
+	if _, ok := sender.codes["13800000009"]; ok {
+		t.Error("code should not be sent to an unregistered phone")
+	}
+
+	login, err := s.LoginByPassword("13800000001", "secret123", nil)
+	if err != nil {
+		t.Fatalf("LoginByPassword: %v", err)
+	}
+	// 重置前锁定账号，重置后应自动解锁
+	for i := 0; i < 3; i++ {
+		s.LoginByPassword("13800000001", "wrong", nil)
+	}
+
+	if err := s.SendPasswordResetCode("13800000001", ""); err != nil {
+		t.Fatalf("SendPasswordResetCode: %v", err)
+	}
+	if _, err := s.VerifyPasswordResetCode("13800000001", "bad"); err == nil {
+		t.Error("wrong code should not issue a ticket")
+	}
+	ticket, err := s.VerifyPasswordResetCode("13800000001", sender.codes["13800000001"])
+	if err != nil {
+		t.Fatalf("VerifyPasswordResetCode: %v", err)
+	}
+
+	if err := s.ResetPassword(&ResetPasswordRequest{Ticket: ticket.Ticket, NewPassword: "newsecret"}); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+	if err := s.ResetPassword(&ResetPasswordRequest{Ticket: ticket.Ticket, NewPassword: "another"}); err == nil {
+		t.Error("reset ticket should be single use")
+	}
+
+	// 与重置同一秒签发的token也应失效，会话被注销
+	claims, err := utils.ParseToken(login.Token)
+	if err == nil || claims != nil {
+		t.Error("token issued before the reset should be revoked")
+	}
+	var active int64
+	db.Model(&model.UserSession{}).Where("user_id = ? AND status = ?", user.ID, 1).Count(&active)
+	if active != 0 {
+		t.Errorf("active sessions = %d, want 0", active)
+	}
+
+	if _, err := s.LoginByPassword("13800000001", "secret123", nil); err == nil {
+		t.Error("old password should be rejected")
+	}
+	if _, err := s.LoginByPassword("13800000001", "newsecret", nil); err != nil {
+		t.Errorf("login with new password: %v", err)
+	}
+}
+
+func TestMergeAccount(t *testing.T) {
+	s, sender, _, db := newTestAuthService(t)
+	target := createTestUser(t, db, "web-user", "13800000001", "secret123")
+	source := &model.User{Username: "wx-user", WechatOpenID: "wx-openid", Status: 1}
+	if err := db.Create(source).Error; err != nil {
+		t.Fatalf("create source: %v", err)
+	}
+	db.Create(&model.UserAuth{UserID: source.ID, AuthType: "wechat", AuthKey: "wx-openid"})
+	db.Create(&model.Order{OrderNo: "ORD1", UserID: source.ID, PayAmount: 10})
+
+	sourceLogin, err := s.issueToken(source, "miniprogram", nil)
+	if err != nil {
+		t.Fatalf("issueToken: %v", err)
+	}
+
+	if err := s.SendBindCode(source.ID, &SendSMSCodeRequest{Phone: "13800000001"}, ""); err != nil {
+		t.Fatalf("SendBindCode: %v", err)
+	}
+	bind, err := s.BindPhone(source.ID, &BindPhoneRequest{Phone: "13800000001", Code: sender.codes["13800000001"]})
+	if err != nil {
+		t.Fatalf("BindPhone: %v", err)
+	}
+	if !bind.MergeRequired || bind.MergeTicket == "" || bind.TargetUser.ID != target.ID {
+		t.Fatalf("bind response = %+v", bind)
+	}
+
+	// 合并失败时凭证放回，源账号保持登录
+	if err := db.Migrator().DropTable(&model.AccountMergeLog{}); err != nil {
+		t.Fatalf("drop table: %v", err)
+	}
+	if _, err := s.MergeAccount(source.ID, &MergeAccountRequest{Ticket: bind.MergeTicket}, "", nil); err == nil {
+		t.Fatal("merge should fail without the log table")
+	}
+	if _, err := utils.ParseToken(sourceLogin); err != nil {
+		t.Fatalf("source token should stay valid after a failed merge: %v", err)
+	}
+	if err := db.AutoMigrate(&model.AccountMergeLog{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	resp, err := s.MergeAccount(source.ID, &MergeAccountRequest{Ticket: bind.MergeTicket}, "", nil)
+	if err != nil {
+		t.Fatalf("MergeAccount: %v", err)
+	}
+	if resp.User.ID != target.ID || resp.Token == "" {
+		t.Errorf("merge response = %+v", resp.User)
+	}
+	if _, err := s.MergeAccount(source.ID, &MergeAccountRequest{Ticket: bind.MergeTicket}, "", nil); err == nil {
+		t.Error("ticket should be single use")
+	}
+
+	// 源账号的token失效，数据迁移到目标账号
+	if _, err := utils.ParseToken(sourceLogin); err == nil {
+		t.Error("source token should be revoked after the merge")
+	}
+	var order model.Order
+	db.Where("order_no = ?", "ORD1").First(&order)
+	if order.UserID != target.ID {
+		t.Errorf("order user = %d, want %d", order.UserID, target.ID)
+	}
+	if wechat, err := s.userAuthRepo.GetByUserIDAndType(target.ID, "wechat"); err != nil || wechat.AuthKey != "wx-openid" {
+		t.Errorf("wechat auth not moved: %v", err)
+	}
+	logs, err := s.GetMergeLogs(target.ID)
+	if err != nil || len(logs) != 1 || logs[0].MovedOrders != 1 || logs[0].Phone != "13800000001" {
+		t.Errorf("merge logs = %+v, err %v", logs, err)
+	}
+}
+
+func TestListUsers(t *testing.T) {
+	s, _, _, db := newTestAuthService(t)
+	alice := createTestUser(t, db, "alice", "13800000001", "secret123")
+	createTestUser(t, db, "alan", "13800000002", "secret123")
+	bob := createTestUser(t, db, "bob", "13800000003", "secret123")
+	db.Model(bob).Update("status", 0)
+
+	disabled := int8(0)
+	cases := []struct {
+		name  string
+		req   *AdminUserListRequest
+		want  []string
+		phone string
+	}{
+		{"all newest first", &AdminUserListRequest{}, []string{"bob", "alan", "alice"}, "138****0003"},
+		{"username keyword", &AdminUserListRequest{Keyword: " al "}, []string{"alan", "alice"}, "138****0002"},
+		{"exact phone", &AdminUserListRequest{Keyword: "13800000001"}, []string{"alice"}, "138****0001"},
+		{"user id", &AdminUserListRequest{Keyword: strconv.FormatUint(alice.ID, 10)}, []string{"alice"}, "138****0001"},
+		{"status filter", &AdminUserListRequest{Status: &disabled}, []string{"bob"}, "138****0003"},
+		{"reveal phone", &AdminUserListRequest{Keyword: "bob", Reveal: true}, []string{"bob"}, "13800000003"},
+	}
+	for _, tc := range cases {
+		resp, err := s.ListUsers(tc.req)
+		if err != nil {
+			t.Fatalf("%s: ListUsers: %v", tc.name, err)
+		}
+		var names []string
+		for _, item := range resp.Items {
+			names = append(names, item.Username)
+		}
+		if strings.Join(names, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("%s: users = %v, want %v", tc.name, names, tc.want)
+		}
+		if len(resp.Items) > 0 && resp.Items[0].Phone != tc.phone {
+			t.Errorf("%s: phone = %s, want %s", tc.name, resp.Items[0].Phone, tc.phone)
+		}
+	}
+}
+
+func TestGetUserDetail(t *testing.T) {
+	s, _, _, db := newTestAuthService(t)
+	user := createTestUser(t, db, "alice", "13800000001", "secret123")
+	address := &model.UserAddress{UserID: user.ID, Name: "Alice", Phone: "13800000001", Province: "浙江省", City: "杭州市",
+		District: "西湖区", Address: "文三路100号", IsDefault: 1}
+	if err := db.Create(address).Error; err != nil {
+		t.Fatalf("create address: %v", err)
+	}
+	for i := 0; i < 12; i++ {
+		order := &model.Order{OrderNo: fmt.Sprintf("ORD%d", i), UserID: user.ID, PayAmount: 10}
+		if err := db.Create(order).Error; err != nil {
+			t.Fatalf("create order: %v", err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		s.LoginByPassword("13800000001", "wrong", nil)
+	}
+
+	masked, err := s.GetUserDetail(user.ID, false)
+	if err != nil {
+		t.Fatalf("GetUserDetail: %v", err)
+	}
+	if !masked.Locked || len(masked.RecentOrders) != 10 || len(masked.AuthBindings) != 1 || len(masked.Addresses) != 1 {
+		t.Fatalf("detail = %+v", masked)
+	}
+	if masked.Phone != "138****0001" || masked.Addresses[0].Phone != "138****0001" ||
+		strings.Contains(masked.Addresses[0].FullAddress, "文三路") || masked.AuthBindings[0].AuthKey == "13800000001" {
+		t.Errorf("detail not masked: %+v %+v %+v", masked.AdminUserResponse, masked.Addresses[0], masked.AuthBindings[0])
+	}
+
+	revealed, _ := s.GetUserDetail(user.ID, true)
+	if revealed.Phone != "13800000001" || revealed.Addresses[0].Address != "文三路100号" || revealed.AuthBindings[0].AuthKey != "13800000001" {
+		t.Errorf("revealed detail = %+v %+v", revealed.AdminUserResponse, revealed.Addresses[0])
+	}
+
+	if _, err := s.GetUserDetail(user.ID+100, false); err == nil {
+		t.Error("unknown user should not be found")
+	}
+}
+
+func TestUpdateUserStatus(t *testing.T) {
+	s, _, _, db := newTestAuthService(t)
+	user := createTestUser(t, db, "alice", "13800000001", "secret123")
+	login, err := s.LoginByPassword("13800000001", "secret123", nil)
+	if err != nil {
+		t.Fatalf("LoginByPassword: %v", err)
+	}
+
+	if err := s.UpdateUserStatus(user.ID, 2); err == nil {
+		t.Error("invalid status should be rejected")
+	}
+	if err := s.UpdateUserStatus(user.ID, 0); err != nil {
+		t.Fatalf("UpdateUserStatus: %v", err)
+	}
+	if _, err := utils.ParseToken(login.Token); err == nil {
+		t.Error("disabling a user should revoke existing tokens")
+	}
+	if _, err := s.LoginByPassword("13800000001", "secret123", nil); err == nil {
+		t.Error("disabled user should not log in")
+	}
+
+	if err := s.UpdateUserStatus(user.ID, 1); err != nil {
+		t.Fatalf("UpdateUserStatus: %v", err)
+	}
+	if _, err := s.LoginByPassword("13800000001", "secret123", nil); err != nil {
+		t.Errorf("login after enabling: %v", err)
+	}
+}
+
+func TestAdminResetPassword(t *testing.T) {
+	s, _, _, db := newTestAuthService(t)
+	user := createTestUser(t, db, "alice", "13800000001", "secret123")
+	wechatOnly := &model.User{Username: "wechat-only", Phone: "13800000002", WechatOpenID: "openid-wechat-only", Status: 1}
+	if err := db.Create(wechatOnly).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		s.LoginByPassword("13800000001", "wrong", nil)
+	}
+
+	cases := []struct {
+		name  string
+		user  *model.User
+		phone string
+	}{
+		{"existing password replaced and account unlocked", user, "13800000001"},
+		{"password login created for users without one", wechatOnly, "13800000002"},
+	}
+	for _, tc := range cases {
+		if err := s.AdminResetPassword(tc.user.ID, "adminset1"); err != nil {
+			t.Fatalf("%s: AdminResetPassword: %v", tc.name, err)
+		}
+		if _, err := s.LoginByPassword(tc.phone, "adminset1", nil); err != nil {
+			t.Errorf("%s: login with new password: %v", tc.name, err)
+		}
+	}
+	if err := s.AdminResetPassword(user.ID+100, "adminset1"); err == nil {
+		t.Error("unknown user should not be reset")
+	}
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"errors"
+
+	"mall/internal/repository"
+)
+
+// FavoriteService 商品收藏服务接口
+type FavoriteService interface {
+	AddFavorite(userID, productID uint64) error
+	RemoveFavorite(userID, productID uint64) error
+	ListFavorites(userID uint64, req *FavoriteListRequest) (*FavoriteListResponse, error)
+}
+
+// FavoriteRequest 收藏请求
+type FavoriteRequest struct {
+	ProductID uint64 `json:"product_id" binding:"required"`
+}
+
+// FavoriteListRequest 收藏列表请求
+type FavoriteListRequest struct {
+	Page     int `json:"page" form:"page"`
+	PageSize int `json:"page_size" form:"page_size"`
+}
+
+// FavoriteItem 收藏条目，价格、库存与状态均为商品当前值
+type FavoriteItem struct {
+	ProductID     uint64  `json:"product_id"`
+	Name          string  `json:"name"`
+	MainImage     string  `json:"main_image"`
+	Price         float64 `json:"price"`
+	OriginalPrice float64 `json:"original_price"`
+	Stock         int     `json:"stock"`
+	Status        int8    `json:"status"`
+	Available     bool    `json:"available"` // 上架且有库存
+	FavoritedAt   string  `json:"favorited_at"`
+}
+
+// FavoriteListResponse 收藏列表响应
+type FavoriteListResponse struct {
+	Items      []*FavoriteItem `json:"items"`
+	Total      int64           `json:"total"`
+	Page       int             `json:"page"`
+	PageSize   int             `json:"page_size"`
+	TotalPages int             `json:"total_pages"`
+}
+
+// favoriteService 商品收藏服务实现
+type favoriteService struct {
+	favoriteRepo repository.ProductFavoriteRepository
+	productRepo  repository.ProductRepository
+}
+
+// NewFavoriteService 创建商品收藏服务
+func NewFavoriteService(
+	favoriteRepo repository.ProductFavoriteRepository,
+	productRepo repository.ProductRepository,
+) FavoriteService {
+	return &favoriteService{
+		favoriteRepo: favoriteRepo,
+		productRepo:  productRepo,
+	}
+}
+
+// AddFavorite 收藏商品，重复收藏不报错
+func (s *favoriteService) AddFavorite(userID, productID uint64) error {
+	if _, err := s.productRepo.GetByID(productID); err != nil {
+		return errors.New("product not found")
+	}
+
+	_, err := s.favoriteRepo.Add(userID, productID)
+	return err
+}
+
+// RemoveFavorite 取消收藏
+func (s *favoriteService) RemoveFavorite(userID, productID uint64) error {
+	removed, err := s.favoriteRepo.Remove(userID, productID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return errors.New("favorite not found")
+	}
+	return nil
+}
+
+// ListFavorites 分页获取收藏列表
+func (s *favoriteService) ListFavorites(userID uint64, req *FavoriteListRequest) (*FavoriteListResponse, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	favorites, total, err := s.favoriteRepo.GetUserFavorites(userID, req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*FavoriteItem, 0, len(favorites))
+	for _, favorite := range favorites {
+		product := favorite.Product
+		item := &FavoriteItem{
+			ProductID:     favorite.ProductID,
+			Name:          product.Name,
+			Price:         product.Price,
+			OriginalPrice: product.OriginalPrice,
+			Stock:         product.Stock,
+			Status:        product.Status,
+			Available:     product.ID > 0 && !product.DeletedAt.Valid && product.Status == 1 && product.Stock > 0,
+			FavoritedAt:   favorite.CreatedAt.Format("2006-01-02 15:04:05"),
+		}
+		// 已删除的商品按下架处理
+		if product.DeletedAt.Valid {
+			item.Status = 0
+		}
+		for _, image := range product.Images {
+			if image.IsMain == 1 {
+				item.MainImage = image.ImageURL
+				break
+			}
+		}
+		items = append(items, item)
+	}
+
+	totalPages := int((total + int64(req.PageSize) - 1) / int64(req.PageSize))
+
+	return &FavoriteListResponse{
+		Items:      items,
+		Total:      total,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
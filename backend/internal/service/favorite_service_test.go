@@ -0,0 +1,111 @@
+package service
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+	"mall/internal/repository"
+)
+
+func newTestFavoriteService(t *testing.T) (*favoriteService, *gorm.DB) {
+	t.Helper()
+
+	db := newTestDB(t, &model.Product{}, &model.ProductImage{}, &model.ProductFavorite{})
+	s := NewFavoriteService(repository.NewProductFavoriteRepository(db), repository.NewProductRepository(db)).(*favoriteService)
+	return s, db
+}
+
+func TestFavoriteCount(t *testing.T) {
+	s, db := newTestFavoriteService(t)
+	product := &model.Product{CategoryID: 1, Name: "p", Price: 10, Stock: 1, Status: 1}
+	if err := db.Create(product).Error; err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+
+	steps := []struct {
+		name      string
+		run       func() error
+		wantErr   bool
+		wantCount int
+	}{
+		{"add", func() error { return s.AddFavorite(1, product.ID) }, false, 1},
+		{"repeated add is ignored", func() error { return s.AddFavorite(1, product.ID) }, false, 1},
+		{"another user", func() error { return s.AddFavorite(2, product.ID) }, false, 2},
+		{"unknown product", func() error { return s.AddFavorite(1, product.ID+1) }, true, 2},
+		{"remove", func() error { return s.RemoveFavorite(1, product.ID) }, false, 1},
+		{"remove twice", func() error { return s.RemoveFavorite(1, product.ID) }, true, 1},
+		{"add again after remove", func() error { return s.AddFavorite(1, product.ID) }, false, 2},
+	}
+	for _, step := range steps {
+		if err := step.run(); (err != nil) != step.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", step.name, err, step.wantErr)
+		}
+		var got model.Product
+		db.First(&got, product.ID)
+		if got.FavoriteCount != step.wantCount {
+			t.Errorf("%s: favorite count = %d, want %d", step.name, got.FavoriteCount, step.wantCount)
+		}
+	}
+}
+
+func TestListFavorites(t *testing.T) {
+	s, db := newTestFavoriteService(t)
+	products := []*model.Product{
+		{CategoryID: 1, Name: "on sale", Price: 10, OriginalPrice: 12, Stock: 3, Status: 1},
+		{CategoryID: 1, Name: "sold out", Price: 10, Stock: 0, Status: 1},
+		{CategoryID: 1, Name: "off shelf", Price: 10, Stock: 3, Status: 1},
+		{CategoryID: 1, Name: "deleted", Price: 10, Stock: 3, Status: 1},
+	}
+	for _, product := range products {
+		if err := db.Create(product).Error; err != nil {
+			t.Fatalf("create product: %v", err)
+		}
+		if err := s.AddFavorite(1, product.ID); err != nil {
+			t.Fatalf("AddFavorite: %v", err)
+		}
+	}
+	db.Create(&model.ProductImage{ProductID: products[0].ID, ImageURL: "side.png"})
+	db.Create(&model.ProductImage{ProductID: products[0].ID, ImageURL: "main.png", IsMain: 1})
+	db.Model(products[2]).Update("status", 0)
+	db.Delete(products[3])
+	// 收藏后调价，列表展示商品当前价格
+	db.Model(products[0]).Update("price", 8)
+
+	resp, err := s.ListFavorites(1, &FavoriteListRequest{})
+	if err != nil {
+		t.Fatalf("ListFavorites: %v", err)
+	}
+	if resp.Total != 4 || len(resp.Items) != 4 {
+		t.Fatalf("favorites = %d/%d, want 4", len(resp.Items), resp.Total)
+	}
+
+	want := map[string]struct {
+		available bool
+		status    int8
+	}{
+		"on sale":   {true, 1},
+		"sold out":  {false, 1},
+		"off shelf": {false, 0},
+		"deleted":   {false, 0},
+	}
+	for _, item := range resp.Items {
+		w := want[item.Name]
+		if item.Available != w.available || item.Status != w.status {
+			t.Errorf("%s: available = %v, status = %d", item.Name, item.Available, item.Status)
+		}
+	}
+	// 最新收藏在前
+	if first := resp.Items[3]; first.Name != "on sale" || first.Price != 8 || first.MainImage != "main.png" {
+		t.Errorf("on sale item = %+v", first)
+	}
+
+	page, _ := s.ListFavorites(1, &FavoriteListRequest{Page: 2, PageSize: 3})
+	if len(page.Items) != 1 || page.TotalPages != 2 {
+		t.Errorf("second page = %d items, %d pages", len(page.Items), page.TotalPages)
+	}
+	if other, _ := s.ListFavorites(2, &FavoriteListRequest{}); other.Total != 0 {
+		t.Errorf("other user favorites = %d, want 0", other.Total)
+	}
+}
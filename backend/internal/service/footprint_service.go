@@ -0,0 +1,501 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"mall/internal/model"
+	"mall/internal/repository"
+	"mall/pkg/cache"
+	"mall/pkg/config"
+	"mall/pkg/logger"
+)
+
+const (
+	footprintSeenKey    = "footprint:seen:%d:%s" // 用户当日已浏览商品
+	footprintListKey    = "footprint:list:%d"    // 用户足迹，分数为最近浏览时间
+	footprintDirtyKey   = "footprint:dirty"      // 有未落库足迹的用户
+	footprintPendingKey = "footprint:pending:%d" // 用户自上次落库后新增的足迹
+	footprintLoadedKey  = "footprint:loaded:%d"  // 用户足迹已与MySQL合并的标记
+
+	footprintSeenTTL      = 48 * time.Hour
+	footprintLoadedTTL    = time.Hour
+	footprintQueueSize    = 1024
+	footprintPersistBatch = 100
+)
+
+// FootprintService 浏览足迹服务接口
+type FootprintService interface {
+	Record(userID, productID uint64)
+	ListFootprints(userID uint64, req *FootprintListRequest) (*FootprintListResponse, error)
+	DeleteFootprints(userID uint64, req *DeleteFootprintsRequest) error
+	GetRecentProductIDs(userID uint64, limit int) ([]uint64, error)
+	PersistPending() error
+	CleanupExpired() error
+	MoveUser(sourceID, targetID uint64) error
+	Close()
+}
+
+// FootprintListRequest 足迹列表请求
+type FootprintListRequest struct {
+	Page     int `json:"page" form:"page"`
+	PageSize int `json:"page_size" form:"page_size"`
+}
+
+// DeleteFootprintsRequest 批量删除足迹请求
+type DeleteFootprintsRequest struct {
+	ProductIDs []uint64 `json:"product_ids"`
+	All        bool     `json:"all"` // 清空全部足迹
+}
+
+// FootprintItem 足迹条目
+type FootprintItem struct {
+	ProductID uint64  `json:"product_id"`
+	Name      string  `json:"name"`
+	MainImage string  `json:"main_image"`
+	Price     float64 `json:"price"`
+	Status    int8    `json:"status"`
+	Available bool    `json:"available"` // 商品未删除且上架
+	ViewedAt  string  `json:"viewed_at"`
+}
+
+// FootprintGroup 按日期分组的足迹
+type FootprintGroup struct {
+	Date  string           `json:"date"`
+	Items []*FootprintItem `json:"items"`
+}
+
+// FootprintListResponse 足迹列表响应
+type FootprintListResponse struct {
+	Groups     []*FootprintGroup `json:"groups"`
+	Total      int64             `json:"total"`
+	Page       int               `json:"page"`
+	PageSize   int               `json:"page_size"`
+	TotalPages int               `json:"total_pages"`
+}
+
+// footprintEvent 待记录的浏览事件
+type footprintEvent struct {
+	userID    uint64
+	productID uint64
+	viewedAt  time.Time
+}
+
+// footprintService 浏览足迹服务实现
+type footprintService struct {
+	footprintRepo repository.FootprintRepository
+	productRepo   repository.ProductRepository
+	events        chan footprintEvent
+	quit          chan struct{}
+	done          chan struct{}
+	closeOnce     sync.Once
+}
+
+// NewFootprintService 创建浏览足迹服务，并启动异步记录协程
+func NewFootprintService(
+	footprintRepo repository.FootprintRepository,
+	productRepo repository.ProductRepository,
+) FootprintService {
+	s := &footprintService{
+		footprintRepo: footprintRepo,
+		productRepo:   productRepo,
+		events:        make(chan footprintEvent, footprintQueueSize),
+		quit:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	go s.consume()
+	return s
+}
+
+// Record 异步记录浏览，队列满时丢弃，不影响商品详情请求
+func (s *footprintService) Record(userID, productID uint64) {
+	select {
+	case <-s.quit:
+		return
+	default:
+	}
+
+	select {
+	case s.events <- footprintEvent{userID: userID, productID: productID, viewedAt: time.Now()}:
+	default:
+		logger.Warn("Footprint queue is full, dropping event", zap.Uint64("user_id", userID), zap.Uint64("product_id", productID))
+	}
+}
+
+// consume 逐条写入Redis，关闭时写完队列中剩余的事件后退出
+func (s *footprintService) consume() {
+	defer close(s.done)
+	for {
+		select {
+		case event := <-s.events:
+			s.record(event)
+		case <-s.quit:
+			for {
+				select {
+				case event := <-s.events:
+					s.record(event)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// record 将一次浏览写入Redis
+func (s *footprintService) record(event footprintEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	keys := cache.FootprintKeys{
+		Seen:    fmt.Sprintf(footprintSeenKey, event.userID, event.viewedAt.Format("20060102")),
+		List:    fmt.Sprintf(footprintListKey, event.userID),
+		Dirty:   footprintDirtyKey,
+		Pending: fmt.Sprintf(footprintPendingKey, event.userID),
+	}
+	if _, err := cache.RecordFootprint(ctx, keys, event.userID, event.productID, event.viewedAt,
+		footprintMaxItems(), footprintSeenTTL, footprintListTTL()); err != nil {
+		logger.Warn("Failed to record footprint",
+			zap.Uint64("user_id", event.userID),
+			zap.Uint64("product_id", event.productID),
+			zap.Error(err),
+		)
+	}
+}
+
+// Close 停止异步记录协程，应用退出时调用
+func (s *footprintService) Close() {
+	s.closeOnce.Do(func() { close(s.quit) })
+	<-s.done
+}
+
+// ListFootprints 分页获取足迹，按浏览日期分组
+func (s *footprintService) ListFootprints(userID uint64, req *FootprintListRequest) (*FootprintListResponse, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	ctx := context.Background()
+	if err := s.ensureLoaded(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf(footprintListKey, userID)
+	total, err := cache.ZCard(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	start := int64((req.Page - 1) * req.PageSize)
+	members, err := cache.ZRevRangeWithScores(ctx, key, start, start+int64(req.PageSize)-1)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(members))
+	for _, member := range members {
+		id, _ := strconv.Atoi(member.Member.(string))
+		ids = append(ids, id)
+	}
+	products := make(map[uint64]*model.Product, len(ids))
+	if len(ids) > 0 {
+		list, err := s.productRepo.GetProductsByIDs(ids)
+		if err != nil {
+			return nil, err
+		}
+		for i := range list {
+			products[list[i].ID] = &list[i]
+		}
+	}
+
+	groups := make([]*FootprintGroup, 0)
+	var current *FootprintGroup
+	for _, member := range members {
+		productID, _ := strconv.ParseUint(member.Member.(string), 10, 64)
+		viewedAt := time.Unix(int64(member.Score), 0)
+
+		item := &FootprintItem{
+			ProductID: productID,
+			ViewedAt:  viewedAt.Format("2006-01-02 15:04:05"),
+		}
+		if product, ok := products[productID]; ok {
+			item.Name = product.Name
+			item.Price = product.Price
+			item.Status = product.Status
+			item.Available = product.Status == 1
+			for _, image := range product.Images {
+				if image.IsMain == 1 {
+					item.MainImage = image.ImageURL
+					break
+				}
+			}
+		}
+
+		date := viewedAt.Format("2006-01-02")
+		if current == nil || current.Date != date {
+			current = &FootprintGroup{Date: date}
+			groups = append(groups, current)
+		}
+		current.Items = append(current.Items, item)
+	}
+
+	totalPages := int((total + int64(req.PageSize) - 1) / int64(req.PageSize))
+
+	return &FootprintListResponse{
+		Groups:     groups,
+		Total:      total,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// DeleteFootprints 批量删除或清空足迹，Redis与MySQL同时删除
+func (s *footprintService) DeleteFootprints(userID uint64, req *DeleteFootprintsRequest) error {
+	ctx := context.Background()
+	listKey := fmt.Sprintf(footprintListKey, userID)
+	seenKey := fmt.Sprintf(footprintSeenKey, userID, time.Now().Format("20060102"))
+	pendingKey := fmt.Sprintf(footprintPendingKey, userID)
+
+	if req.All {
+		if err := cache.Del(ctx, listKey, seenKey, pendingKey); err != nil {
+			return err
+		}
+		return s.footprintRepo.DeleteAll(userID)
+	}
+
+	if len(req.ProductIDs) == 0 {
+		return errors.New("product_ids is required")
+	}
+
+	if err := s.ensureLoaded(ctx, userID); err != nil {
+		return err
+	}
+	members := make([]interface{}, 0, len(req.ProductIDs))
+	for _, id := range req.ProductIDs {
+		members = append(members, strconv.FormatUint(id, 10))
+	}
+	if err := cache.ZRem(ctx, listKey, members...); err != nil {
+		return err
+	}
+	if err := cache.ZRem(ctx, pendingKey, members...); err != nil {
+		return err
+	}
+	// 删除后当天再次浏览需要重新记录
+	if err := cache.SRem(ctx, seenKey, members...); err != nil {
+		return err
+	}
+	return s.footprintRepo.DeleteByProducts(userID, req.ProductIDs)
+}
+
+// GetRecentProductIDs 获取用户最近浏览的商品ID，供商品推荐使用
+func (s *footprintService) GetRecentProductIDs(userID uint64, limit int) ([]uint64, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	ctx := context.Background()
+	if err := s.ensureLoaded(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	members, err := cache.ZRevRangeWithScores(ctx, fmt.Sprintf(footprintListKey, userID), 0, int64(limit)-1)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint64, 0, len(members))
+	for _, member := range members {
+		id, err := strconv.ParseUint(member.Member.(string), 10, 64)
+		if err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// PersistPending 将用户自上次落库后新增的足迹写入MySQL，并按上限裁剪
+func (s *footprintService) PersistPending() error {
+	ctx := context.Background()
+	for {
+		users, err := cache.SPopN(ctx, footprintDirtyKey, footprintPersistBatch)
+		if err != nil {
+			return err
+		}
+		if len(users) == 0 {
+			return nil
+		}
+
+		var footprints []*model.UserFootprint
+		taken := make(map[uint64][]redis.Z, len(users))
+		for _, user := range users {
+			userID, err := strconv.ParseUint(user, 10, 64)
+			if err != nil {
+				continue
+			}
+			members, err := cache.TakePendingFootprints(ctx, fmt.Sprintf(footprintPendingKey, userID))
+			if err != nil {
+				s.restore(ctx, taken)
+				s.requeue(ctx, users)
+				return err
+			}
+			taken[userID] = members
+			footprints = append(footprints, toUserFootprints(userID, members)...)
+		}
+
+		if err := s.footprintRepo.Upsert(footprints); err != nil {
+			s.restore(ctx, taken)
+			s.requeue(ctx, users)
+			return err
+		}
+		for userID := range taken {
+			if err := s.footprintRepo.TrimToLimit(userID, footprintMaxItems()); err != nil {
+				logger.Warn("Failed to trim footprints", zap.Uint64("user_id", userID), zap.Error(err))
+			}
+		}
+		if len(users) < footprintPersistBatch {
+			return nil
+		}
+	}
+}
+
+// CleanupExpired 删除超过保留期的足迹
+func (s *footprintService) CleanupExpired() error {
+	deleted, err := s.footprintRepo.DeleteBefore(time.Now().Add(-footprintListTTL()))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		logger.Info("Expired footprints cleaned up", zap.Int64("count", deleted))
+	}
+	return nil
+}
+
+// MoveUser 账号合并后迁移Redis中的足迹：源账号未落库的足迹转给目标账号，
+// 目标账号下次读取时重新合并MySQL中已迁移的足迹
+func (s *footprintService) MoveUser(sourceID, targetID uint64) error {
+	ctx := context.Background()
+	pending, err := cache.TakePendingFootprints(ctx, fmt.Sprintf(footprintPendingKey, sourceID))
+	if err != nil {
+		return err
+	}
+
+	if len(pending) > 0 {
+		if err := cache.MergeFootprints(ctx, fmt.Sprintf(footprintPendingKey, targetID), pending, 0, footprintListTTL()); err != nil {
+			return err
+		}
+		if err := cache.MergeFootprints(ctx, fmt.Sprintf(footprintListKey, targetID), pending, footprintMaxItems(), footprintListTTL()); err != nil {
+			return err
+		}
+		if err := cache.SAdd(ctx, footprintDirtyKey, targetID); err != nil {
+			return err
+		}
+	}
+
+	return cache.Del(ctx,
+		fmt.Sprintf(footprintListKey, sourceID),
+		fmt.Sprintf(footprintLoadedKey, sourceID),
+		fmt.Sprintf(footprintLoadedKey, targetID),
+	)
+}
+
+// ensureLoaded 将MySQL中的足迹合并进Redis；新浏览可能先于加载写入Redis，
+// 因此以单独的标记判断是否已加载，合并时保留较新的浏览时间
+func (s *footprintService) ensureLoaded(ctx context.Context, userID uint64) error {
+	loadedKey := fmt.Sprintf(footprintLoadedKey, userID)
+	exists, err := cache.Exists(ctx, loadedKey)
+	if err != nil {
+		return err
+	}
+	if exists > 0 {
+		return nil
+	}
+
+	records, err := s.footprintRepo.GetLatest(userID, footprintMaxItems())
+	if err != nil {
+		return err
+	}
+
+	members := make([]redis.Z, 0, len(records))
+	for _, record := range records {
+		members = append(members, redis.Z{
+			Score:  float64(record.ViewedAt.Unix()),
+			Member: strconv.FormatUint(record.ProductID, 10),
+		})
+	}
+	key := fmt.Sprintf(footprintListKey, userID)
+	if err := cache.MergeFootprints(ctx, key, members, footprintMaxItems(), footprintListTTL()); err != nil {
+		return err
+	}
+	return cache.Set(ctx, loadedKey, 1, footprintLoadedTTL)
+}
+
+// restore 落库失败时将取出的足迹放回待落库集合
+func (s *footprintService) restore(ctx context.Context, taken map[uint64][]redis.Z) {
+	for userID, members := range taken {
+		if len(members) == 0 {
+			continue
+		}
+		if err := cache.MergeFootprints(ctx, fmt.Sprintf(footprintPendingKey, userID), members, 0, footprintListTTL()); err != nil {
+			logger.Error("Failed to restore pending footprints", zap.Uint64("user_id", userID), zap.Error(err))
+		}
+	}
+}
+
+// requeue 落库失败时将用户放回待持久化集合
+func (s *footprintService) requeue(ctx context.Context, users []string) {
+	members := make([]interface{}, 0, len(users))
+	for _, user := range users {
+		members = append(members, user)
+	}
+	if err := cache.SAdd(ctx, footprintDirtyKey, members...); err != nil {
+		logger.Error("Failed to requeue footprint users", zap.Error(err))
+	}
+}
+
+// toUserFootprints 将Redis足迹转换为按天记录的模型
+func toUserFootprints(userID uint64, members []redis.Z) []*model.UserFootprint {
+	footprints := make([]*model.UserFootprint, 0, len(members))
+	for _, member := range members {
+		productID, err := strconv.ParseUint(member.Member.(string), 10, 64)
+		if err != nil {
+			continue
+		}
+		viewedAt := time.Unix(int64(member.Score), 0)
+		year, month, day := viewedAt.Date()
+		footprints = append(footprints, &model.UserFootprint{
+			UserID:    userID,
+			ProductID: productID,
+			ViewDate:  time.Date(year, month, day, 0, 0, 0, 0, viewedAt.Location()),
+			ViewedAt:  viewedAt,
+		})
+	}
+	return footprints
+}
+
+// footprintMaxItems 每个用户保留的足迹数
+func footprintMaxItems() int {
+	if max := config.GetConfig().Footprint.MaxItems; max > 0 {
+		return max
+	}
+	return 200
+}
+
+// footprintListTTL 足迹保留时长
+func footprintListTTL() time.Duration {
+	days := config.GetConfig().Footprint.RetentionDays
+	if days <= 0 {
+		days = 90
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+	"mall/internal/repository"
+	"mall/pkg/config"
+)
+
+// newTestFootprintService 创建不启动异步协程的足迹服务，每个用户最多保留3条足迹
+func newTestFootprintService(t *testing.T) (*footprintService, *gorm.DB) {
+	t.Helper()
+
+	useMiniredis(t)
+	cfg := &config.Config{}
+	cfg.Footprint.MaxItems = 3
+	cfg.Footprint.RetentionDays = 30
+	useConfig(t, cfg)
+
+	db := newTestDB(t, &model.Product{}, &model.ProductImage{}, &model.UserFootprint{})
+	for i := 1; i <= 5; i++ {
+		product := &model.Product{CategoryID: 1, Name: "p", Price: float64(i), Stock: 1, Status: 1}
+		if err := db.Create(product).Error; err != nil {
+			t.Fatalf("create product: %v", err)
+		}
+	}
+	s := &footprintService{
+		footprintRepo: repository.NewFootprintRepository(db),
+		productRepo:   repository.NewProductRepository(db),
+	}
+	return s, db
+}
+
+// viewProduct 同步记录一次浏览
+func viewProduct(s *footprintService, userID, productID uint64, viewedAt time.Time) {
+	s.record(footprintEvent{userID: userID, productID: productID, viewedAt: viewedAt})
+}
+
+// footprintProductIDs 按列表顺序返回足迹中的商品ID
+func footprintProductIDs(resp *FootprintListResponse) []uint64 {
+	var ids []uint64
+	for _, group := range resp.Groups {
+		for _, item := range group.Items {
+			ids = append(ids, item.ProductID)
+		}
+	}
+	return ids
+}
+
+func TestListFootprints(t *testing.T) {
+	s, db := newTestFootprintService(t)
+	today := time.Now().Truncate(time.Second)
+	yesterday := today.AddDate(0, 0, -1)
+
+	viewProduct(s, 1, 1, yesterday)
+	viewProduct(s, 1, 2, today.Add(-time.Minute))
+	viewProduct(s, 1, 1, today)
+	viewProduct(s, 1, 1, today.Add(time.Second)) // 当日重复浏览不更新
+	db.Model(&model.Product{}).Where("id = ?", 2).Update("status", 0)
+
+	resp, err := s.ListFootprints(1, &FootprintListRequest{})
+	if err != nil {
+		t.Fatalf("ListFootprints: %v", err)
+	}
+	if resp.Total != 2 || len(resp.Groups) != 1 || resp.Groups[0].Date != today.Format("2006-01-02") {
+		t.Fatalf("groups = %+v", resp.Groups)
+	}
+	items := resp.Groups[0].Items
+	if items[0].ProductID != 1 || items[0].ViewedAt != today.Format("2006-01-02 15:04:05") || !items[0].Available {
+		t.Errorf("first item = %+v", items[0])
+	}
+	if items[1].ProductID != 2 || items[1].Available {
+		t.Errorf("off shelf item = %+v", items[1])
+	}
+
+	recent, _ := s.GetRecentProductIDs(1, 1)
+	if len(recent) != 1 || recent[0] != 1 {
+		t.Errorf("recent = %v, want [1]", recent)
+	}
+}
+
+func TestPersistFootprints(t *testing.T) {
+	s, db := newTestFootprintService(t)
+	now := time.Now().Truncate(time.Second)
+	for i := uint64(1); i <= 4; i++ {
+		viewProduct(s, 1, i, now.Add(time.Duration(i)*time.Minute))
+	}
+	viewProduct(s, 2, 5, now)
+
+	if err := s.PersistPending(); err != nil {
+		t.Fatalf("PersistPending: %v", err)
+	}
+	cases := []struct {
+		userID uint64
+		want   int64
+	}{
+		{1, 3}, // 超出上限的最早足迹被裁剪
+		{2, 1},
+	}
+	for _, tc := range cases {
+		var count int64
+		db.Model(&model.UserFootprint{}).Where("user_id = ?", tc.userID).Count(&count)
+		if count != tc.want {
+			t.Errorf("user %d footprints = %d, want %d", tc.userID, count, tc.want)
+		}
+	}
+
+	// 重复落库不产生重复记录
+	if err := s.PersistPending(); err != nil {
+		t.Fatalf("PersistPending: %v", err)
+	}
+	var total int64
+	db.Model(&model.UserFootprint{}).Count(&total)
+	if total != 4 {
+		t.Errorf("footprints = %d, want 4", total)
+	}
+}
+
+func TestFootprintsLoadedFromDatabase(t *testing.T) {
+	s, db := newTestFootprintService(t)
+	now := time.Now().Truncate(time.Second)
+	for _, fp := range []*model.UserFootprint{
+		{UserID: 1, ProductID: 1, ViewDate: now.AddDate(0, 0, -2), ViewedAt: now.AddDate(0, 0, -2)},
+		{UserID: 1, ProductID: 2, ViewDate: now.AddDate(0, 0, -1), ViewedAt: now.AddDate(0, 0, -1)},
+	} {
+		if err := db.Create(fp).Error; err != nil {
+			t.Fatalf("create footprint: %v", err)
+		}
+	}
+	// Redis中尚未加载历史足迹时已有新浏览，合并后保留较新的浏览时间
+	viewProduct(s, 1, 1, now)
+
+	resp, err := s.ListFootprints(1, &FootprintListRequest{})
+	if err != nil {
+		t.Fatalf("ListFootprints: %v", err)
+	}
+	ids := footprintProductIDs(resp)
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 2 || len(resp.Groups) != 2 {
+		t.Errorf("footprints = %v in %d groups", ids, len(resp.Groups))
+	}
+}
+
+func TestDeleteFootprints(t *testing.T) {
+	s, db := newTestFootprintService(t)
+	now := time.Now().Truncate(time.Second)
+	for i := uint64(1); i <= 3; i++ {
+		viewProduct(s, 1, i, now.Add(time.Duration(i)*time.Second))
+	}
+	if err := s.PersistPending(); err != nil {
+		t.Fatalf("PersistPending: %v", err)
+	}
+
+	steps := []struct {
+		name    string
+		req     *DeleteFootprintsRequest
+		wantErr bool
+		want    int
+	}{
+		{"empty request", &DeleteFootprintsRequest{}, true, 3},
+		{"selected products", &DeleteFootprintsRequest{ProductIDs: []uint64{1, 2}}, false, 1},
+		{"clear all", &DeleteFootprintsRequest{All: true}, false, 0},
+	}
+	for _, step := range steps {
+		if err := s.DeleteFootprints(1, step.req); (err != nil) != step.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", step.name, err, step.wantErr)
+		}
+		resp, err := s.ListFootprints(1, &FootprintListRequest{})
+		if err != nil {
+			t.Fatalf("%s: ListFootprints: %v", step.name, err)
+		}
+		var stored int64
+		db.Model(&model.UserFootprint{}).Where("user_id = ?", 1).Count(&stored)
+		if len(footprintProductIDs(resp)) != step.want || stored != int64(step.want) {
+			t.Errorf("%s: listed %v, stored %d, want %d", step.name, footprintProductIDs(resp), stored, step.want)
+		}
+	}
+
+	// 删除后当天再次浏览重新记录
+	viewProduct(s, 1, 1, now.Add(time.Minute))
+	resp, _ := s.ListFootprints(1, &FootprintListRequest{})
+	if ids := footprintProductIDs(resp); len(ids) != 1 || ids[0] != 1 {
+		t.Errorf("footprints after viewing again = %v", ids)
+	}
+}
+
+func TestFootprintRecordAfterClose(t *testing.T) {
+	useMiniredis(t)
+	useConfig(t, &config.Config{})
+	db := newTestDB(t, &model.Product{}, &model.ProductImage{}, &model.UserFootprint{})
+	s := NewFootprintService(repository.NewFootprintRepository(db), repository.NewProductRepository(db))
+
+	s.Record(1, 1)
+	s.Close()
+	s.Record(1, 2)
+	s.Close()
+
+	// 关闭前入队的事件写完后才退出，关闭后的事件丢弃
+	ids, err := s.GetRecentProductIDs(1, 10)
+	if err != nil {
+		t.Fatalf("GetRecentProductIDs: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != 1 {
+		t.Errorf("recent = %v, want [1]", ids)
+	}
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+	"mall/internal/repository"
+)
+
+// 库存变动原因
+const (
+	InventoryReasonOrder  = "order"
+	InventoryReasonCancel = "cancel"
+	InventoryReasonRefund = "refund"
+	InventoryReasonAdjust = "adjust"
+	InventoryReasonImport = "import"
+)
+
+// 库存操作人类型
+const (
+	InventoryOperatorUser   = "user"
+	InventoryOperatorAdmin  = "admin"
+	InventoryOperatorSystem = "system"
+)
+
+// InventoryService 库存服务接口，商品与SKU库存的所有变动都经由此服务并记录流水
+type InventoryService interface {
+	DeductForOrder(tx *gorm.DB, order *model.Order, items []*model.OrderItem) error
+	RestockOrder(tx *gorm.DB, order *model.Order, items []*model.OrderItem, reason, refID string) error
+	SetStocks(tx *gorm.DB, levels []StockLevel, reason, refID string, operatorID uint64) error
+	ImportStocks(req *ImportStockRequest, operatorID uint64) error
+	GetLedger(req *InventoryLedgerRequest) (*InventoryLedgerResponse, error)
+}
+
+// StockLevel 商品或SKU的目标库存，SKUID为0表示商品库存
+type StockLevel struct {
+	ProductID uint64 `json:"product_id" binding:"required"`
+	SKUID     uint64 `json:"sku_id"`
+	Stock     int    `json:"stock" binding:"gte=0"`
+}
+
+// ImportStockRequest 批量导入库存请求，按盘点结果设置库存
+type ImportStockRequest struct {
+	RefID  string       `json:"ref_id" binding:"max=64"` // 导入批次号
+	Remark string       `json:"remark" binding:"max=255"`
+	Items  []StockLevel `json:"items" binding:"required,min=1,max=500,dive"`
+}
+
+// InventoryLedgerRequest 库存流水查询请求
+type InventoryLedgerRequest struct {
+	Page      int    `json:"page" form:"page"`
+	PageSize  int    `json:"page_size" form:"page_size"`
+	ProductID uint64 `json:"product_id" form:"product_id"`
+	SKUID     uint64 `json:"sku_id" form:"sku_id"`
+	Reason    string `json:"reason" form:"reason" binding:"omitempty,oneof=order cancel refund adjust import"`
+	RefID     string `json:"ref_id" form:"ref_id"`
+}
+
+// InventoryLedgerItem 库存流水条目
+type InventoryLedgerItem struct {
+	ID           uint64 `json:"id"`
+	ProductID    uint64 `json:"product_id"`
+	SKUID        uint64 `json:"sku_id"`
+	Delta        int    `json:"delta"`
+	Balance      int    `json:"balance"`
+	Reason       string `json:"reason"`
+	RefID        string `json:"ref_id"`
+	OperatorType string `json:"operator_type"`
+	OperatorID   uint64 `json:"operator_id"`
+	Remark       string `json:"remark"`
+	CreatedAt    string `json:"created_at"`
+}
+
+// InventoryLedgerResponse 库存流水列表响应
+type InventoryLedgerResponse struct {
+	Items      []*InventoryLedgerItem `json:"items"`
+	Total      int64                  `json:"total"`
+	Page       int                    `json:"page"`
+	PageSize   int                    `json:"page_size"`
+	TotalPages int                    `json:"total_pages"`
+}
+
+// inventoryService 库存服务实现
+type inventoryService struct {
+	inventoryRepo repository.InventoryRepository
+	orderItemRepo repository.OrderItemRepository
+}
+
+// NewInventoryService 创建库存服务
+func NewInventoryService(inventoryRepo repository.InventoryRepository, orderItemRepo repository.OrderItemRepository) InventoryService {
+	return &inventoryService{
+		inventoryRepo: inventoryRepo,
+		orderItemRepo: orderItemRepo,
+	}
+}
+
+// DeductForOrder 在下单事务tx中扣减库存，全部成功或全部失败，同一订单重复扣减会被忽略
+func (s *inventoryService) DeductForOrder(tx *gorm.DB, order *model.Order, items []*model.OrderItem) error {
+	changes := s.orderChanges(order, items, InventoryReasonOrder, order.OrderNo, -1)
+	if err := s.inventoryRepo.Apply(tx, changes); err != nil {
+		if errors.Is(err, repository.ErrInsufficientStock) {
+			return errors.New("insufficient stock")
+		}
+		return err
+	}
+	return nil
+}
+
+// RestockOrder 订单取消或退款时回补库存，refID为取消的订单号或退款单号；
+// items为空时按订单读取，tx为nil时单独开启事务，每个订单只回补一次
+func (s *inventoryService) RestockOrder(tx *gorm.DB, order *model.Order, items []*model.OrderItem, reason, refID string) error {
+	if items == nil {
+		var err error
+		items, err = s.orderItemRepo.GetByOrderID(order.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return s.inventoryRepo.Apply(tx, s.orderChanges(order, items, reason, refID, 1))
+}
+
+// SetStocks 将商品或SKU库存设为指定值，按差额记录流水，tx为空时单独开启事务
+func (s *inventoryService) SetStocks(tx *gorm.DB, levels []StockLevel, reason, refID string, operatorID uint64) error {
+	return s.setStocks(tx, levels, reason, refID, "", operatorID)
+}
+
+// ImportStocks 批量导入库存
+func (s *inventoryService) ImportStocks(req *ImportStockRequest, operatorID uint64) error {
+	seen := make(map[[2]uint64]bool, len(req.Items))
+	for _, item := range req.Items {
+		key := [2]uint64{item.ProductID, item.SKUID}
+		if seen[key] {
+			return fmt.Errorf("duplicate item for product %d sku %d", item.ProductID, item.SKUID)
+		}
+		seen[key] = true
+	}
+
+	if err := s.setStocks(nil, req.Items, InventoryReasonImport, req.RefID, req.Remark, operatorID); err != nil {
+		return errors.New("failed to import stock: " + err.Error())
+	}
+	return nil
+}
+
+// GetLedger 分页查询库存流水
+func (s *inventoryService) GetLedger(req *InventoryLedgerRequest) (*InventoryLedgerResponse, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	query := &repository.InventoryQuery{
+		ProductID: req.ProductID,
+		SKUID:     req.SKUID,
+		Reason:    req.Reason,
+		RefID:     req.RefID,
+	}
+	entries, total, err := s.inventoryRepo.List(query, req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*InventoryLedgerItem, 0, len(entries))
+	for _, entry := range entries {
+		items = append(items, &InventoryLedgerItem{
+			ID:           entry.ID,
+			ProductID:    entry.ProductID,
+			SKUID:        entry.SKUID,
+			Delta:        entry.Delta,
+			Balance:      entry.Balance,
+			Reason:       entry.Reason,
+			RefID:        entry.RefID,
+			OperatorType: entry.OperatorType,
+			OperatorID:   entry.OperatorID,
+			Remark:       entry.Remark,
+			CreatedAt:    entry.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+
+	totalPages := int((total + int64(req.PageSize) - 1) / int64(req.PageSize))
+
+	return &InventoryLedgerResponse{
+		Items:      items,
+		Total:      total,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// setStocks 按目标库存生成变动并在同一事务中应用
+func (s *inventoryService) setStocks(tx *gorm.DB, levels []StockLevel, reason, refID, remark string, operatorID uint64) error {
+	operatorType := InventoryOperatorAdmin
+	if operatorID == 0 {
+		operatorType = InventoryOperatorSystem
+	}
+
+	changes := make([]*repository.InventoryChange, 0, len(levels))
+	for _, level := range levels {
+		if level.Stock < 0 {
+			return errors.New("stock cannot be negative")
+		}
+		stock := level.Stock
+		changes = append(changes, &repository.InventoryChange{
+			Entry: &model.InventoryLedger{
+				ProductID:    level.ProductID,
+				SKUID:        level.SKUID,
+				Reason:       reason,
+				RefID:        refID,
+				OperatorType: operatorType,
+				OperatorID:   operatorID,
+				Remark:       remark,
+			},
+			SetTo: &stock,
+		})
+	}
+	return s.inventoryRepo.Apply(tx, changes)
+}
+
+// orderChanges 将订单项按商品与SKU合并为库存变动，幂等键区分扣减与回补
+func (s *inventoryService) orderChanges(order *model.Order, items []*model.OrderItem, reason, refID string, sign int) []*repository.InventoryChange {
+	action := "deduct"
+	if sign > 0 {
+		action = "restock"
+	}
+
+	changes := make([]*repository.InventoryChange, 0, len(items))
+	index := make(map[[2]uint64]*model.InventoryLedger, len(items))
+	for _, item := range items {
+		key := [2]uint64{item.ProductID, item.SKUID}
+		if entry, ok := index[key]; ok {
+			entry.Delta += sign * item.Quantity
+			continue
+		}
+
+		bizKey := fmt.Sprintf("%s:%s:%d:%d", action, order.OrderNo, item.ProductID, item.SKUID)
+		entry := &model.InventoryLedger{
+			ProductID:    item.ProductID,
+			SKUID:        item.SKUID,
+			Delta:        sign * item.Quantity,
+			Reason:       reason,
+			RefID:        refID,
+			OperatorType: InventoryOperatorUser,
+			OperatorID:   order.UserID,
+			BizKey:       &bizKey,
+		}
+		// 回补由取消、退款流程触发，记为系统操作
+		if sign > 0 {
+			entry.OperatorType = InventoryOperatorSystem
+			entry.OperatorID = 0
+		}
+		index[key] = entry
+		changes = append(changes, &repository.InventoryChange{Entry: entry})
+	}
+	return changes
+}
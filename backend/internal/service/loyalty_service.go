@@ -0,0 +1,497 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+	"mall/internal/repository"
+	"mall/pkg/config"
+	"mall/pkg/logger"
+)
+
+const (
+	// 每批处理的积分过期用户数
+	pointsExpireBatchSize = 200
+	// 每批补发积分的订单数
+	pointsReconcileBatchSize = 200
+	// 补发积分时回溯的订单完成时间范围
+	pointsReconcileWindow = 7 * 24 * time.Hour
+)
+
+// LoyaltyService 会员积分服务接口
+type LoyaltyService interface {
+	EarnForOrder(order *model.Order) error
+	RevokeForRefund(tx *gorm.DB, order *model.Order, refundNo string, refundAmount float64, fullRefund bool) error
+	ReconcileOrderPoints() error
+	GetSummary(userID uint64) (*PointsSummaryResponse, error)
+	GetLedger(userID uint64, req *PointsLedgerRequest) (*PointsLedgerResponse, error)
+	ProcessExpirations() error
+
+	// 管理员接口
+	ListLevels() ([]*MemberLevelResponse, error)
+	CreateLevel(req *MemberLevelRequest) (*MemberLevelResponse, error)
+	UpdateLevel(id uint64, req *MemberLevelRequest) (*MemberLevelResponse, error)
+	DeleteLevel(id uint64) error
+}
+
+// MemberLevelRequest 会员等级请求
+type MemberLevelRequest struct {
+	Name        string  `json:"name" binding:"required,max=50"`
+	Level       int     `json:"level" binding:"required,gt=0"`
+	MinSpend    float64 `json:"min_spend" binding:"gte=0"`
+	PointsRatio float64 `json:"points_ratio" binding:"gte=0,lte=100"`
+	Status      int8    `json:"status" binding:"oneof=0 1"`
+}
+
+// MemberLevelResponse 会员等级响应
+type MemberLevelResponse struct {
+	ID          uint64  `json:"id"`
+	Name        string  `json:"name"`
+	Level       int     `json:"level"`
+	MinSpend    float64 `json:"min_spend"`
+	PointsRatio float64 `json:"points_ratio"`
+	Status      int8    `json:"status"`
+}
+
+// PointsSummaryResponse 积分概览响应
+type PointsSummaryResponse struct {
+	Balance        int64                `json:"balance"`
+	TotalEarned    int64                `json:"total_earned"`
+	TotalSpend     float64              `json:"total_spend"`
+	Level          *MemberLevelResponse `json:"level"`
+	NextLevel      *MemberLevelResponse `json:"next_level"`
+	SpendToNext    float64              `json:"spend_to_next"`
+	ExpiringPoints int64                `json:"expiring_points"`
+	ExpiringBefore string               `json:"expiring_before"`
+}
+
+// PointsLedgerRequest 积分流水请求
+type PointsLedgerRequest struct {
+	Page     int `json:"page" form:"page"`
+	PageSize int `json:"page_size" form:"page_size"`
+}
+
+// PointsLedgerItem 积分流水条目
+type PointsLedgerItem struct {
+	ID           uint64 `json:"id"`
+	Type         string `json:"type"`
+	Points       int64  `json:"points"`
+	BalanceAfter int64  `json:"balance_after"`
+	OrderID      uint64 `json:"order_id"`
+	ExpiresAt    string `json:"expires_at"`
+	Remark       string `json:"remark"`
+	CreatedAt    string `json:"created_at"`
+}
+
+// PointsLedgerResponse 积分流水列表响应
+type PointsLedgerResponse struct {
+	Items      []*PointsLedgerItem `json:"items"`
+	Total      int64               `json:"total"`
+	Page       int                 `json:"page"`
+	PageSize   int                 `json:"page_size"`
+	TotalPages int                 `json:"total_pages"`
+}
+
+// loyaltyService 会员积分服务实现
+type loyaltyService struct {
+	pointsRepo repository.PointsRepository
+	levelRepo  repository.MemberLevelRepository
+}
+
+// NewLoyaltyService 创建会员积分服务
+func NewLoyaltyService(
+	pointsRepo repository.PointsRepository,
+	levelRepo repository.MemberLevelRepository,
+) LoyaltyService {
+	return &loyaltyService{
+		pointsRepo: pointsRepo,
+		levelRepo:  levelRepo,
+	}
+}
+
+// EarnForOrder 订单完成后按当前等级的积分比例发放积分，并计入累计消费；完成前已退款的金额不计
+func (s *loyaltyService) EarnForOrder(order *model.Order) error {
+	refunded, err := s.pointsRepo.GetOrderRefundedAmount(order.ID)
+	if err != nil {
+		return err
+	}
+	amount := math.Round((order.PayAmount-refunded)*100) / 100
+	if amount <= 0 {
+		return nil
+	}
+
+	ratio, err := s.currentRatio(order.UserID)
+	if err != nil {
+		return err
+	}
+
+	bizKey := fmt.Sprintf("earn:order:%d", order.ID)
+	entry := &model.PointsLedger{
+		UserID:  order.UserID,
+		Type:    "earn",
+		Points:  int64(math.Floor(amount * ratio)),
+		OrderID: order.ID,
+		BizKey:  &bizKey,
+		Remark:  fmt.Sprintf("订单%s完成", order.OrderNo),
+	}
+	if days := config.GetConfig().Loyalty.PointsExpireDays; days > 0 {
+		expiresAt := time.Now().AddDate(0, 0, days)
+		entry.ExpiresAt = &expiresAt
+	}
+
+	account, err := s.pointsRepo.AddEntry(nil, entry, amount)
+	if errors.Is(err, repository.ErrPointsEntryExists) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Order points earned",
+		zap.Uint64("user_id", order.UserID),
+		zap.Uint64("order_id", order.ID),
+		zap.Int64("points", entry.Points),
+		zap.Uint64("level_id", account.LevelID),
+	)
+	return nil
+}
+
+// RevokeForRefund 在退款事务tx中按退款比例扣回订单已发放的积分，并扣减累计消费；同一退款单号只扣回一次
+func (s *loyaltyService) RevokeForRefund(tx *gorm.DB, order *model.Order, refundNo string, refundAmount float64, fullRefund bool) error {
+	entries, err := s.pointsRepo.GetOrderEntries(tx, order.ID)
+	if err != nil {
+		return err
+	}
+
+	var earned, remaining int64
+	var expiresAt *time.Time
+	counted := false
+	for _, entry := range entries {
+		if entry.Type == "earn" {
+			earned += entry.Points
+			expiresAt = entry.ExpiresAt
+			counted = true
+		}
+		remaining += entry.Points
+	}
+	// 订单尚未完成，未发放积分也未计入累计消费
+	if !counted {
+		return nil
+	}
+
+	points := remaining
+	if !fullRefund && order.PayAmount > 0 {
+		points = int64(math.Round(float64(earned) * refundAmount / order.PayAmount))
+		if points > remaining {
+			points = remaining
+		}
+	}
+	if points < 0 {
+		points = 0
+	}
+
+	// 扣回的积分沿用获得时的过期时间，过期计算时与原获得积分相抵
+	bizKey := fmt.Sprintf("refund:%s", refundNo)
+	entry := &model.PointsLedger{
+		UserID:    order.UserID,
+		Type:      "refund",
+		Points:    -points,
+		OrderID:   order.ID,
+		BizKey:    &bizKey,
+		ExpiresAt: expiresAt,
+		Remark:    fmt.Sprintf("订单%s退款", order.OrderNo),
+	}
+	_, err = s.pointsRepo.AddEntry(tx, entry, -refundAmount)
+	if errors.Is(err, repository.ErrPointsEntryExists) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Order points revoked",
+		zap.Uint64("user_id", order.UserID),
+		zap.Uint64("order_id", order.ID),
+		zap.Int64("points", -entry.Points),
+	)
+	return nil
+}
+
+// GetSummary 获取用户积分与会员等级概览
+func (s *loyaltyService) GetSummary(userID uint64) (*PointsSummaryResponse, error) {
+	account, err := s.pointsRepo.GetAccount(userID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		account = &model.UserPoints{UserID: userID}
+	}
+
+	levels, err := s.levelRepo.List()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PointsSummaryResponse{
+		Balance:     account.Balance,
+		TotalEarned: account.TotalEarned,
+		TotalSpend:  account.TotalSpend,
+	}
+	for _, level := range levels {
+		if level.ID == account.LevelID {
+			response.Level = newMemberLevelResponse(level)
+		}
+		if level.Status != 1 || level.MinSpend <= account.TotalSpend {
+			continue
+		}
+		if response.NextLevel == nil || level.MinSpend < response.NextLevel.MinSpend {
+			response.NextLevel = newMemberLevelResponse(level)
+			response.SpendToNext = math.Round((level.MinSpend-account.TotalSpend)*100) / 100
+		}
+	}
+
+	noticeDays := config.GetConfig().Loyalty.ExpireNoticeDays
+	if noticeDays <= 0 {
+		noticeDays = 30
+	}
+	before := time.Now().AddDate(0, 0, noticeDays)
+	expiring, err := s.pointsRepo.GetExpirablePoints(userID, before)
+	if err != nil {
+		return nil, err
+	}
+	if expiring > account.Balance {
+		expiring = account.Balance
+	}
+	response.ExpiringPoints = expiring
+	response.ExpiringBefore = before.Format("2006-01-02 15:04:05")
+
+	return response, nil
+}
+
+// GetLedger 分页获取用户积分流水
+func (s *loyaltyService) GetLedger(userID uint64, req *PointsLedgerRequest) (*PointsLedgerResponse, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	entries, total, err := s.pointsRepo.GetLedger(userID, req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*PointsLedgerItem, 0, len(entries))
+	for _, entry := range entries {
+		item := &PointsLedgerItem{
+			ID:           entry.ID,
+			Type:         entry.Type,
+			Points:       entry.Points,
+			BalanceAfter: entry.BalanceAfter,
+			OrderID:      entry.OrderID,
+			Remark:       entry.Remark,
+			CreatedAt:    entry.CreatedAt.Format("2006-01-02 15:04:05"),
+		}
+		if entry.ExpiresAt != nil {
+			item.ExpiresAt = entry.ExpiresAt.Format("2006-01-02 15:04:05")
+		}
+		items = append(items, item)
+	}
+
+	totalPages := int((total + int64(req.PageSize) - 1) / int64(req.PageSize))
+
+	return &PointsLedgerResponse{
+		Items:      items,
+		Total:      total,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// ProcessExpirations 扣除已到期的积分
+func (s *loyaltyService) ProcessExpirations() error {
+	now := time.Now()
+	userIDs, err := s.pointsRepo.GetExpirableUsers(now, pointsExpireBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		expired, err := s.pointsRepo.Expire(userID, now)
+		if err != nil {
+			logger.Error("Failed to expire points", zap.Uint64("user_id", userID), zap.Error(err))
+			continue
+		}
+		if expired > 0 {
+			logger.Info("Points expired", zap.Uint64("user_id", userID), zap.Int64("points", expired))
+		}
+	}
+	return nil
+}
+
+// ReconcileOrderPoints 补发已完成但积分发放失败的订单积分，发放带幂等键可重复执行
+func (s *loyaltyService) ReconcileOrderPoints() error {
+	orders, err := s.pointsRepo.GetUnearnedOrders(time.Now().Add(-pointsReconcileWindow), pointsReconcileBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, order := range orders {
+		if err := s.EarnForOrder(order); err != nil {
+			logger.Error("Failed to reconcile order points", zap.Uint64("order_id", order.ID), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// ListLevels 获取会员等级列表
+func (s *loyaltyService) ListLevels() ([]*MemberLevelResponse, error) {
+	levels, err := s.levelRepo.List()
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*MemberLevelResponse, 0, len(levels))
+	for _, level := range levels {
+		items = append(items, newMemberLevelResponse(level))
+	}
+	return items, nil
+}
+
+// CreateLevel 创建会员等级
+func (s *loyaltyService) CreateLevel(req *MemberLevelRequest) (*MemberLevelResponse, error) {
+	if err := s.checkLevelUnique(0, req.Level); err != nil {
+		return nil, err
+	}
+
+	level := &model.MemberLevel{}
+	applyMemberLevelRequest(level, req)
+	if err := s.levelRepo.Create(level); err != nil {
+		return nil, err
+	}
+
+	s.refreshLevels()
+	return newMemberLevelResponse(level), nil
+}
+
+// UpdateLevel 更新会员等级，门槛或状态变化后重新计算用户等级
+func (s *loyaltyService) UpdateLevel(id uint64, req *MemberLevelRequest) (*MemberLevelResponse, error) {
+	level, err := s.levelRepo.GetByID(id)
+	if err != nil {
+		return nil, errors.New("member level not found")
+	}
+	if err := s.checkLevelUnique(id, req.Level); err != nil {
+		return nil, err
+	}
+
+	applyMemberLevelRequest(level, req)
+	if err := s.levelRepo.Update(level); err != nil {
+		return nil, err
+	}
+
+	s.refreshLevels()
+	return newMemberLevelResponse(level), nil
+}
+
+// DeleteLevel 删除会员等级，原等级用户重新归入其他等级
+func (s *loyaltyService) DeleteLevel(id uint64) error {
+	if _, err := s.levelRepo.GetByID(id); err != nil {
+		return errors.New("member level not found")
+	}
+
+	if err := s.levelRepo.Delete(id); err != nil {
+		return err
+	}
+
+	s.refreshLevels()
+	return nil
+}
+
+// currentRatio 获取用户当前等级的积分比例，未匹配等级时按1倍计算
+func (s *loyaltyService) currentRatio(userID uint64) (float64, error) {
+	account, err := s.pointsRepo.GetAccount(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return s.baseRatio()
+	}
+	if err != nil {
+		return 0, err
+	}
+	if account.LevelID == 0 {
+		return s.baseRatio()
+	}
+
+	level, err := s.levelRepo.GetByID(account.LevelID)
+	if err != nil || level.Status != 1 {
+		return s.baseRatio()
+	}
+	return level.PointsRatio, nil
+}
+
+// baseRatio 获取新用户适用的积分比例，即门槛最低的启用等级
+func (s *loyaltyService) baseRatio() (float64, error) {
+	levels, err := s.levelRepo.List()
+	if err != nil {
+		return 0, err
+	}
+
+	var base *model.MemberLevel
+	for _, level := range levels {
+		if level.Status == 1 && level.MinSpend <= 0 && (base == nil || level.Level > base.Level) {
+			base = level
+		}
+	}
+	if base == nil {
+		return 1, nil
+	}
+	return base.PointsRatio, nil
+}
+
+// checkLevelUnique 检查等级序号是否重复
+func (s *loyaltyService) checkLevelUnique(id uint64, levelNo int) error {
+	levels, err := s.levelRepo.List()
+	if err != nil {
+		return err
+	}
+	for _, level := range levels {
+		if level.Level == levelNo && level.ID != id {
+			return errors.New("member level already exists")
+		}
+	}
+	return nil
+}
+
+// refreshLevels 重新计算用户等级，失败只记录日志
+func (s *loyaltyService) refreshLevels() {
+	if err := s.pointsRepo.RefreshLevels(); err != nil {
+		logger.Error("Failed to refresh member levels", zap.Error(err))
+	}
+}
+
+// applyMemberLevelRequest 将请求字段写入会员等级
+func applyMemberLevelRequest(level *model.MemberLevel, req *MemberLevelRequest) {
+	level.Name = req.Name
+	level.Level = req.Level
+	level.MinSpend = req.MinSpend
+	level.PointsRatio = req.PointsRatio
+	level.Status = req.Status
+}
+
+// newMemberLevelResponse 转换会员等级响应
+func newMemberLevelResponse(level *model.MemberLevel) *MemberLevelResponse {
+	return &MemberLevelResponse{
+		ID:          level.ID,
+		Name:        level.Name,
+		Level:       level.Level,
+		MinSpend:    level.MinSpend,
+		PointsRatio: level.PointsRatio,
+		Status:      level.Status,
+	}
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+	"mall/internal/repository"
+	"mall/pkg/config"
+)
+
+// newTestLoyaltyService 创建积分服务，普通会员1倍积分，累计消费满100升级为2倍积分的金卡
+func newTestLoyaltyService(t *testing.T) (*loyaltyService, *gorm.DB) {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.Loyalty.PointsExpireDays = 365
+	cfg.Loyalty.ExpireNoticeDays = 30
+	useConfig(t, cfg)
+
+	db := newTestDB(t, &model.Order{}, &model.OrderRefund{}, &model.UserPoints{}, &model.PointsLedger{}, &model.MemberLevel{})
+	for _, level := range []*model.MemberLevel{
+		{Name: "normal", Level: 1, MinSpend: 0, PointsRatio: 1, Status: 1},
+		{Name: "gold", Level: 2, MinSpend: 100, PointsRatio: 2, Status: 1},
+	} {
+		if err := db.Create(level).Error; err != nil {
+			t.Fatalf("create level: %v", err)
+		}
+	}
+
+	s := NewLoyaltyService(repository.NewPointsRepository(db), repository.NewMemberLevelRepository(db)).(*loyaltyService)
+	return s, db
+}
+
+// createTestOrder 创建已完成订单
+func createTestOrder(t *testing.T, db *gorm.DB, userID uint64, orderNo string, payAmount float64) *model.Order {
+	t.Helper()
+
+	order := &model.Order{OrderNo: orderNo, UserID: userID, TotalAmount: payAmount, PayAmount: payAmount, Status: 4}
+	if err := db.Create(order).Error; err != nil {
+		t.Fatalf("create order: %v", err)
+	}
+	return order
+}
+
+// pointsAccount 读取用户积分账户
+func pointsAccount(t *testing.T, db *gorm.DB, userID uint64) model.UserPoints {
+	t.Helper()
+
+	var account model.UserPoints
+	db.Where("user_id = ?", userID).First(&account)
+	return account
+}
+
+func TestEarnForOrder(t *testing.T) {
+	s, db := newTestLoyaltyService(t)
+	first := createTestOrder(t, db, 1, "ORD1", 120.5)
+	second := createTestOrder(t, db, 1, "ORD2", 50)
+	partlyRefunded := createTestOrder(t, db, 1, "ORD3", 40)
+	fullyRefunded := createTestOrder(t, db, 1, "ORD4", 10)
+	db.Create(&model.OrderRefund{OrderID: partlyRefunded.ID, PaymentID: 1, RefundNo: "R1", Amount: 15})
+	db.Create(&model.OrderRefund{OrderID: fullyRefunded.ID, PaymentID: 2, RefundNo: "R2", Amount: 10})
+
+	steps := []struct {
+		name        string
+		order       *model.Order
+		wantBalance int64
+		wantSpend   float64
+		wantLevel   string
+	}{
+		{"base ratio rounds down", first, 120, 120.5, "gold"},
+		{"upgraded level doubles points", second, 220, 170.5, "gold"},
+		{"repeated completion is ignored", second, 220, 170.5, "gold"},
+		{"refunds before completion not counted", partlyRefunded, 270, 195.5, "gold"},
+		{"fully refunded order earns nothing", fullyRefunded, 270, 195.5, "gold"},
+	}
+	for _, step := range steps {
+		if err := s.EarnForOrder(step.order); err != nil {
+			t.Fatalf("%s: EarnForOrder: %v", step.name, err)
+		}
+		account := pointsAccount(t, db, 1)
+		var level model.MemberLevel
+		db.First(&level, account.LevelID)
+		if account.Balance != step.wantBalance || account.TotalSpend != step.wantSpend || level.Name != step.wantLevel {
+			t.Errorf("%s: balance = %d, spend = %.2f, level = %s", step.name, account.Balance, account.TotalSpend, level.Name)
+		}
+	}
+
+	var earn model.PointsLedger
+	db.Where("order_id = ? AND type = ?", first.ID, "earn").First(&earn)
+	if earn.ExpiresAt == nil || earn.ExpiresAt.Before(time.Now().AddDate(0, 0, 364)) {
+		t.Errorf("earned points expire at %v, want in 365 days", earn.ExpiresAt)
+	}
+
+	// 漏发的订单由对账任务补发，已发放的订单不重复发放
+	createTestOrder(t, db, 2, "ORD5", 30)
+	if err := s.ReconcileOrderPoints(); err != nil {
+		t.Fatalf("ReconcileOrderPoints: %v", err)
+	}
+	if account := pointsAccount(t, db, 2); account.Balance != 30 {
+		t.Errorf("reconciled balance = %d, want 30", account.Balance)
+	}
+	if account := pointsAccount(t, db, 1); account.Balance != 270 {
+		t.Errorf("balance after reconcile = %d, want 270", account.Balance)
+	}
+}
+
+func TestRevokeForRefund(t *testing.T) {
+	s, db := newTestLoyaltyService(t)
+	order := createTestOrder(t, db, 1, "ORD1", 100)
+	if err := s.EarnForOrder(order); err != nil {
+		t.Fatalf("EarnForOrder: %v", err)
+	}
+	unfinished := createTestOrder(t, db, 1, "ORD2", 20)
+
+	steps := []struct {
+		name        string
+		order       *model.Order
+		refundNo    string
+		amount      float64
+		full        bool
+		wantBalance int64
+		wantSpend   float64
+	}{
+		{"partial refund revokes proportionally", order, "R1", 30, false, 70, 70},
+		{"same refund applied once", order, "R1", 30, false, 70, 70},
+		{"unfinished order has nothing to revoke", unfinished, "R2", 20, true, 70, 70},
+		{"full refund revokes the rest", order, "R3", 70, true, 0, 0},
+	}
+	for _, step := range steps {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return s.RevokeForRefund(tx, step.order, step.refundNo, step.amount, step.full)
+		})
+		if err != nil {
+			t.Fatalf("%s: RevokeForRefund: %v", step.name, err)
+		}
+		account := pointsAccount(t, db, 1)
+		if account.Balance != step.wantBalance || account.TotalSpend != step.wantSpend {
+			t.Errorf("%s: balance = %d, spend = %.2f", step.name, account.Balance, account.TotalSpend)
+		}
+	}
+
+	// 回滚的退款事务不扣回积分
+	s2, db2 := newTestLoyaltyService(t)
+	order2 := createTestOrder(t, db2, 1, "ORD1", 100)
+	s2.EarnForOrder(order2)
+	db2.Transaction(func(tx *gorm.DB) error {
+		if err := s2.RevokeForRefund(tx, order2, "R1", 100, true); err != nil {
+			return err
+		}
+		return gorm.ErrInvalidTransaction
+	})
+	if account := pointsAccount(t, db2, 1); account.Balance != 100 {
+		t.Errorf("balance after rolled back refund = %d, want 100", account.Balance)
+	}
+}
+
+func TestProcessExpirations(t *testing.T) {
+	expired := time.Now().Add(-time.Hour)
+	later := time.Now().AddDate(0, 0, 10)
+	distant := time.Now().AddDate(0, 0, 200)
+
+	cases := []struct {
+		name         string
+		entries      []model.PointsLedger
+		wantExpiring int64
+		wantExpired  int64
+	}{
+		{
+			name: "spent points consume the oldest first",
+			entries: []model.PointsLedger{
+				{Type: "earn", Points: 100, ExpiresAt: &expired},
+				{Type: "earn", Points: 50, ExpiresAt: &distant},
+				{Type: "adjust", Points: -30},
+			},
+			wantExpiring: 70,
+			wantExpired:  70,
+		},
+		{
+			name: "refund offsets the points it revoked",
+			entries: []model.PointsLedger{
+				{Type: "earn", Points: 100, ExpiresAt: &expired},
+				{Type: "refund", Points: -40, ExpiresAt: &expired},
+			},
+			wantExpiring: 60,
+			wantExpired:  60,
+		},
+		{
+			name: "points expiring within the notice period",
+			entries: []model.PointsLedger{
+				{Type: "earn", Points: 80, ExpiresAt: &later},
+				{Type: "earn", Points: 20, ExpiresAt: &distant},
+			},
+			wantExpiring: 80,
+			wantExpired:  0,
+		},
+		{
+			name: "fully spent points do not expire",
+			entries: []model.PointsLedger{
+				{Type: "earn", Points: 50, ExpiresAt: &expired},
+				{Type: "adjust", Points: -50},
+			},
+			wantExpiring: 0,
+			wantExpired:  0,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, db := newTestLoyaltyService(t)
+			var balance int64
+			for i := range tc.entries {
+				entry := tc.entries[i]
+				entry.UserID = 1
+				balance += entry.Points
+				entry.BalanceAfter = balance
+				if err := db.Create(&entry).Error; err != nil {
+					t.Fatalf("create entry: %v", err)
+				}
+			}
+			if err := db.Create(&model.UserPoints{UserID: 1, Balance: balance}).Error; err != nil {
+				t.Fatalf("create account: %v", err)
+			}
+
+			summary, err := s.GetSummary(1)
+			if err != nil {
+				t.Fatalf("GetSummary: %v", err)
+			}
+			if summary.ExpiringPoints != tc.wantExpiring {
+				t.Errorf("expiring = %d, want %d", summary.ExpiringPoints, tc.wantExpiring)
+			}
+
+			if err := s.ProcessExpirations(); err != nil {
+				t.Fatalf("ProcessExpirations: %v", err)
+			}
+			if err := s.ProcessExpirations(); err != nil {
+				t.Fatalf("ProcessExpirations: %v", err)
+			}
+			if account := pointsAccount(t, db, 1); account.Balance != balance-tc.wantExpired {
+				t.Errorf("balance = %d, want %d", account.Balance, balance-tc.wantExpired)
+			}
+		})
+	}
+}
+
+func TestMemberLevels(t *testing.T) {
+	s, db := newTestLoyaltyService(t)
+	s.EarnForOrder(createTestOrder(t, db, 1, "ORD1", 150))
+	s.EarnForOrder(createTestOrder(t, db, 2, "ORD2", 60))
+
+	if _, err := s.CreateLevel(&MemberLevelRequest{Name: "dup", Level: 2, Status: 1}); err == nil {
+		t.Error("duplicate level number should be rejected")
+	}
+	platinum, err := s.CreateLevel(&MemberLevelRequest{Name: "platinum", Level: 3, MinSpend: 120, PointsRatio: 3, Status: 1})
+	if err != nil {
+		t.Fatalf("CreateLevel: %v", err)
+	}
+	if account := pointsAccount(t, db, 1); account.LevelID != platinum.ID {
+		t.Errorf("level = %d, want platinum after refresh", account.LevelID)
+	}
+
+	summary, err := s.GetSummary(2)
+	if err != nil {
+		t.Fatalf("GetSummary: %v", err)
+	}
+	if summary.Level == nil || summary.Level.Name != "normal" || summary.NextLevel == nil ||
+		summary.NextLevel.Name != "gold" || summary.SpendToNext != 40 {
+		t.Errorf("summary = %+v", summary)
+	}
+
+	var gold model.MemberLevel
+	db.Where("name = ?", "gold").First(&gold)
+	if err := s.DeleteLevel(platinum.ID); err != nil {
+		t.Fatalf("DeleteLevel: %v", err)
+	}
+	if account := pointsAccount(t, db, 1); account.LevelID != gold.ID {
+		t.Errorf("level = %d, want gold after deleting platinum", account.LevelID)
+	}
+}
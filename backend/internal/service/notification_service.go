@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"mall/internal/model"
+	"mall/internal/repository"
+	"mall/pkg/cache"
+	"mall/pkg/logger"
+)
+
+// 通知分类
+const (
+	NotificationCategoryOrder   = "order"
+	NotificationCategoryPayment = "payment"
+	NotificationCategoryAccount = "account"
+)
+
+const (
+	notificationUnreadKey = "notification:unread:%d"
+	notificationUnreadTTL = 24 * time.Hour
+)
+
+// NotificationService 站内通知服务接口
+type NotificationService interface {
+	Notify(userID uint64, message *NotificationMessage)
+	ListNotifications(userID uint64, req *NotificationListRequest) (*NotificationListResponse, error)
+	GetUnreadCount(userID uint64) (int64, error)
+	MarkRead(userID uint64, req *MarkNotificationsReadRequest) error
+}
+
+// NotificationMessage 待发送的通知内容
+type NotificationMessage struct {
+	Category string
+	Type     string
+	Title    string
+	Content  string
+	BizType  string
+	BizID    uint64
+}
+
+// NotificationListRequest 通知列表请求
+type NotificationListRequest struct {
+	Page       int    `json:"page" form:"page"`
+	PageSize   int    `json:"page_size" form:"page_size"`
+	Category   string `json:"category" form:"category" binding:"omitempty,oneof=order payment account"`
+	UnreadOnly bool   `json:"unread_only" form:"unread_only"`
+}
+
+// MarkNotificationsReadRequest 标记已读请求，不传ids时按分类全部标记
+type MarkNotificationsReadRequest struct {
+	IDs      []uint64 `json:"ids"`
+	Category string   `json:"category" binding:"omitempty,oneof=order payment account"`
+}
+
+// NotificationResponse 通知响应
+type NotificationResponse struct {
+	ID        uint64 `json:"id"`
+	Category  string `json:"category"`
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Content   string `json:"content"`
+	BizType   string `json:"biz_type"`
+	BizID     uint64 `json:"biz_id"`
+	IsRead    bool   `json:"is_read"`
+	ReadAt    string `json:"read_at"`
+	CreatedAt string `json:"created_at"`
+}
+
+// NotificationListResponse 通知列表响应
+type NotificationListResponse struct {
+	Items       []*NotificationResponse `json:"items"`
+	Total       int64                   `json:"total"`
+	UnreadCount int64                   `json:"unread_count"`
+	Page        int                     `json:"page"`
+	PageSize    int                     `json:"page_size"`
+	TotalPages  int                     `json:"total_pages"`
+}
+
+// notificationService 站内通知服务实现
+type notificationService struct {
+	notificationRepo repository.NotificationRepository
+}
+
+// NewNotificationService 创建站内通知服务
+func NewNotificationService(notificationRepo repository.NotificationRepository) NotificationService {
+	return &notificationService{
+		notificationRepo: notificationRepo,
+	}
+}
+
+// Notify 发送通知，失败只记录日志，不影响业务流程
+func (s *notificationService) Notify(userID uint64, message *NotificationMessage) {
+	notification := &model.Notification{
+		UserID:   userID,
+		Category: message.Category,
+		Type:     message.Type,
+		Title:    message.Title,
+		Content:  message.Content,
+		BizType:  message.BizType,
+		BizID:    message.BizID,
+	}
+	if err := s.notificationRepo.Create(notification); err != nil {
+		logger.Error("Failed to create notification",
+			zap.Uint64("user_id", userID),
+			zap.String("type", message.Type),
+			zap.Error(err),
+		)
+		return
+	}
+
+	s.invalidateUnread(userID)
+}
+
+// ListNotifications 分页获取通知
+func (s *notificationService) ListNotifications(userID uint64, req *NotificationListRequest) (*NotificationListResponse, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	query := &repository.NotificationQuery{
+		UserID:     userID,
+		Category:   req.Category,
+		UnreadOnly: req.UnreadOnly,
+	}
+	notifications, total, err := s.notificationRepo.List(query, req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	unread, err := s.GetUnreadCount(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*NotificationResponse, 0, len(notifications))
+	for _, notification := range notifications {
+		item := &NotificationResponse{
+			ID:        notification.ID,
+			Category:  notification.Category,
+			Type:      notification.Type,
+			Title:     notification.Title,
+			Content:   notification.Content,
+			BizType:   notification.BizType,
+			BizID:     notification.BizID,
+			IsRead:    notification.IsRead,
+			CreatedAt: notification.CreatedAt.Format("2006-01-02 15:04:05"),
+		}
+		if notification.ReadAt != nil {
+			item.ReadAt = notification.ReadAt.Format("2006-01-02 15:04:05")
+		}
+		items = append(items, item)
+	}
+
+	totalPages := int((total + int64(req.PageSize) - 1) / int64(req.PageSize))
+
+	return &NotificationListResponse{
+		Items:       items,
+		Total:       total,
+		UnreadCount: unread,
+		Page:        req.Page,
+		PageSize:    req.PageSize,
+		TotalPages:  totalPages,
+	}, nil
+}
+
+// GetUnreadCount 获取未读数，优先读取Redis缓存
+func (s *notificationService) GetUnreadCount(userID uint64) (int64, error) {
+	ctx := context.Background()
+	key := fmt.Sprintf(notificationUnreadKey, userID)
+	if val, err := cache.Get(ctx, key); err == nil {
+		if count, err := strconv.ParseInt(val, 10, 64); err == nil {
+			return count, nil
+		}
+	}
+
+	count, err := s.notificationRepo.CountUnread(userID)
+	if err != nil {
+		return 0, err
+	}
+	if err := cache.Set(ctx, key, count, notificationUnreadTTL); err != nil {
+		logger.Warn("Failed to cache unread notification count", zap.Uint64("user_id", userID), zap.Error(err))
+	}
+	return count, nil
+}
+
+// MarkRead 标记通知已读
+func (s *notificationService) MarkRead(userID uint64, req *MarkNotificationsReadRequest) error {
+	var updated int64
+	var err error
+	if len(req.IDs) > 0 {
+		if len(req.IDs) > 100 {
+			return errors.New("too many notifications")
+		}
+		updated, err = s.notificationRepo.MarkRead(userID, req.IDs)
+	} else {
+		updated, err = s.notificationRepo.MarkAllRead(userID, req.Category)
+	}
+	if err != nil {
+		return err
+	}
+
+	if updated > 0 {
+		s.invalidateUnread(userID)
+	}
+	return nil
+}
+
+// invalidateUnread 未读数变化后删除缓存，下次读取时重新统计
+func (s *notificationService) invalidateUnread(userID uint64) {
+	if err := cache.Del(context.Background(), fmt.Sprintf(notificationUnreadKey, userID)); err != nil {
+		logger.Warn("Failed to invalidate unread notification count", zap.Uint64("user_id", userID), zap.Error(err))
+	}
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+	"mall/internal/repository"
+)
+
+// newTestNotificationService 创建站内通知服务
+func newTestNotificationService(t *testing.T) (*notificationService, *miniredis.Miniredis, *gorm.DB) {
+	t.Helper()
+
+	mr := useMiniredis(t)
+	db := newTestDB(t, &model.Notification{})
+	s := NewNotificationService(repository.NewNotificationRepository(db)).(*notificationService)
+	return s, mr, db
+}
+
+// notifyAll 依次发送通知
+func notifyAll(s *notificationService, userID uint64, categories ...string) {
+	for _, category := range categories {
+		s.Notify(userID, &NotificationMessage{Category: category, Type: category + "_test", Title: "title", Content: "content"})
+	}
+}
+
+func TestNotificationUnreadCount(t *testing.T) {
+	s, mr, _ := newTestNotificationService(t)
+	notifyAll(s, 1, NotificationCategoryOrder, NotificationCategoryOrder, NotificationCategoryPayment)
+	notifyAll(s, 2, NotificationCategoryAccount)
+
+	list, err := s.ListNotifications(1, &NotificationListRequest{})
+	if err != nil {
+		t.Fatalf("ListNotifications: %v", err)
+	}
+	orderIDs := []uint64{list.Items[1].ID, list.Items[2].ID}
+	otherUser, _ := s.ListNotifications(2, &NotificationListRequest{})
+
+	steps := []struct {
+		name    string
+		req     *MarkNotificationsReadRequest
+		wantErr bool
+		want    int64
+	}{
+		{"another user's notification", &MarkNotificationsReadRequest{IDs: []uint64{otherUser.Items[0].ID}}, false, 3},
+		{"too many ids", &MarkNotificationsReadRequest{IDs: make([]uint64, 101)}, true, 3},
+		{"selected ids", &MarkNotificationsReadRequest{IDs: orderIDs[:1]}, false, 2},
+		{"already read", &MarkNotificationsReadRequest{IDs: orderIDs[:1]}, false, 2},
+		{"by category", &MarkNotificationsReadRequest{Category: NotificationCategoryOrder}, false, 1},
+		{"all", &MarkNotificationsReadRequest{}, false, 0},
+	}
+	for _, step := range steps {
+		if err := s.MarkRead(1, step.req); (err != nil) != step.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", step.name, err, step.wantErr)
+		}
+		count, err := s.GetUnreadCount(1)
+		if err != nil {
+			t.Fatalf("%s: GetUnreadCount: %v", step.name, err)
+		}
+		if count != step.want {
+			t.Errorf("%s: unread = %d, want %d", step.name, count, step.want)
+		}
+		if !mr.Exists("notification:unread:1") {
+			t.Errorf("%s: unread count should be cached", step.name)
+		}
+	}
+	if count, _ := s.GetUnreadCount(2); count != 1 {
+		t.Errorf("other user unread = %d, want 1", count)
+	}
+
+	// 新通知使缓存失效
+	notifyAll(s, 1, NotificationCategoryAccount)
+	if mr.Exists("notification:unread:1") {
+		t.Error("new notification should invalidate the cached count")
+	}
+	if count, _ := s.GetUnreadCount(1); count != 1 {
+		t.Errorf("unread after new notification = %d, want 1", count)
+	}
+}
+
+func TestListNotifications(t *testing.T) {
+	s, _, _ := newTestNotificationService(t)
+	notifyAll(s, 1, NotificationCategoryOrder, NotificationCategoryPayment, NotificationCategoryOrder, NotificationCategoryAccount)
+	if err := s.MarkRead(1, &MarkNotificationsReadRequest{Category: NotificationCategoryAccount}); err != nil {
+		t.Fatalf("MarkRead: %v", err)
+	}
+
+	cases := []struct {
+		name      string
+		req       *NotificationListRequest
+		wantTotal int64
+		wantItems int
+	}{
+		{"all", &NotificationListRequest{}, 4, 4},
+		{"by category", &NotificationListRequest{Category: NotificationCategoryOrder}, 2, 2},
+		{"unread only", &NotificationListRequest{UnreadOnly: true}, 3, 3},
+		{"paged", &NotificationListRequest{Page: 2, PageSize: 3}, 4, 1},
+	}
+	for _, tc := range cases {
+		resp, err := s.ListNotifications(1, tc.req)
+		if err != nil {
+			t.Fatalf("%s: ListNotifications: %v", tc.name, err)
+		}
+		if resp.Total != tc.wantTotal || len(resp.Items) != tc.wantItems || resp.UnreadCount != 3 {
+			t.Errorf("%s: total = %d, items = %d, unread = %d", tc.name, resp.Total, len(resp.Items), resp.UnreadCount)
+		}
+	}
+
+	resp, _ := s.ListNotifications(1, &NotificationListRequest{})
+	if first := resp.Items[0]; first.Category != NotificationCategoryAccount || !first.IsRead || first.ReadAt == "" {
+		t.Errorf("latest notification = %+v", first)
+	}
+	if last := resp.Items[3]; last.IsRead || last.ReadAt != "" {
+		t.Errorf("oldest notification = %+v", last)
+	}
+}
@@ -3,12 +3,21 @@ package service
 import (
 	"errors"
 	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
 
 	"mall/internal/model"
 	"mall/internal/repository"
+	"mall/pkg/config"
+	"mall/pkg/logger"
 	"mall/pkg/utils"
 )
 
+// 每批自动取消的订单数
+const orderAutoCancelBatchSize = 100
+
 // OrderService 订单服务接口
 type OrderService interface {
 	CreateOrder(userID uint64, req *CreateOrderRequest) (*CreateOrderResponse, error)
@@ -20,15 +29,19 @@ type OrderService interface {
 	// 管理员接口
 	GetOrders(req *AdminOrderListRequest) (*OrderListResponse, error)
 	UpdateOrderStatus(orderID uint64, status int8) error
-	SearchOrders(keyword string, page, pageSize int) (*OrderListResponse, error)
+	SearchOrders(keyword string, page, pageSize int, reveal bool) (*OrderListResponse, error)
+
+	// 后台任务
+	CancelExpiredOrders() error
 }
 
 // CreateOrderRequest 创建订单请求
 type CreateOrderRequest struct {
 	Items         []OrderItemRequest `json:"items" binding:"required"`
-	ReceiverName  string             `json:"receiver_name" binding:"required"`
-	ReceiverPhone string             `json:"receiver_phone" binding:"required"`
-	ReceiverAddress string           `json:"receiver_address" binding:"required"`
+	AddressID     uint64             `json:"address_id"` // 指定地址簿地址时忽略下方收货信息
+	ReceiverName  string             `json:"receiver_name" binding:"required_without=AddressID"`
+	ReceiverPhone string             `json:"receiver_phone" binding:"required_without=AddressID"`
+	ReceiverAddress string           `json:"receiver_address" binding:"required_without=AddressID"`
 	BuyerMessage  string             `json:"buyer_message"`
 }
 
@@ -59,6 +72,7 @@ type AdminOrderListRequest struct {
 	Page     int  `json:"page" form:"page"`
 	PageSize int  `json:"page_size" form:"page_size"`
 	Status   int8 `json:"status" form:"status"`
+	Reveal   bool `json:"-" form:"-"` // 是否展示完整收货信息
 }
 
 // OrderListResponse 订单列表响应
@@ -129,6 +143,10 @@ type orderService struct {
 	skuRepo       repository.ProductSKURepository
 	cartRepo      repository.CartRepository
 	userRepo      repository.UserRepository
+	addressRepo   repository.UserAddressRepository
+	loyalty       LoyaltyService
+	notifier      NotificationService
+	inventory     InventoryService
 }
 
 // NewOrderService 创建订单服务
@@ -139,6 +157,10 @@ func NewOrderService(
 	skuRepo repository.ProductSKURepository,
 	cartRepo repository.CartRepository,
 	userRepo repository.UserRepository,
+	addressRepo repository.UserAddressRepository,
+	loyalty LoyaltyService,
+	notifier NotificationService,
+	inventory InventoryService,
 ) OrderService {
 	return &orderService{
 		orderRepo:     orderRepo,
@@ -147,6 +169,10 @@ func NewOrderService(
 		skuRepo:       skuRepo,
 		cartRepo:      cartRepo,
 		userRepo:      userRepo,
+		addressRepo:   addressRepo,
+		loyalty:       loyalty,
+		notifier:      notifier,
+		inventory:     inventory,
 	}
 }
 
@@ -158,6 +184,18 @@ func (s *orderService) CreateOrder(userID uint64, req *CreateOrderRequest) (*Cre
 		return nil, errors.New("user not found")
 	}
 
+	// 使用地址簿地址时，将地址快照到订单
+	receiverName, receiverPhone, receiverAddress := req.ReceiverName, req.ReceiverPhone, req.ReceiverAddress
+	if req.AddressID > 0 {
+		address, err := s.addressRepo.GetByID(req.AddressID)
+		if err != nil || address.UserID != userID {
+			return nil, errors.New("address not found")
+		}
+		receiverName = address.Name
+		receiverPhone = address.Phone
+		receiverAddress = FormatFullAddress(address)
+	}
+
 	// 验证商品和计算总金额
 	var totalAmount float64
 	var orderItems []*model.OrderItem
@@ -244,9 +282,9 @@ func (s *orderService) CreateOrder(userID uint64, req *CreateOrderRequest) (*Cre
 		PaymentStatus:   0, // 未付款
 		DeliveryStatus:  0, // 未发货
 		BuyerMessage:    req.BuyerMessage,
-		ReceiverName:    req.ReceiverName,
-		ReceiverPhone:   req.ReceiverPhone,
-		ReceiverAddress: req.ReceiverAddress,
+		ReceiverName:    receiverName,
+		ReceiverPhone:   receiverPhone,
+		ReceiverAddress: receiverAddress,
 	}
 
 	// 使用事务创建订单
@@ -263,42 +301,18 @@ func (s *orderService) CreateOrder(userID uint64, req *CreateOrderRequest) (*Cre
 	}, nil
 }
 
-// createOrderWithTransaction 使用事务创建订单
+// createOrderWithTransaction 在同一事务中创建订单、订单项并扣减库存，任一失败全部回滚
 func (s *orderService) createOrderWithTransaction(order *model.Order, orderItems []*model.OrderItem) error {
-	// TODO: 实现数据库事务
-	// 这里暂时简化处理，实际项目中需要使用数据库事务
-
-	// 创建订单
-	if err := s.orderRepo.Create(order); err != nil {
-		return err
-	}
+	return s.orderRepo.CreateWithItems(order, orderItems, func(tx *gorm.DB) error {
+		return s.inventory.DeductForOrder(tx, order, orderItems)
+	})
+}
 
-	// 创建订单项
-	for _, item := range orderItems {
-		item.OrderID = order.ID
+// restockOrder 订单取消后回补库存，失败只记录日志，回补带幂等键可重试
+func (s *orderService) restockOrder(order *model.Order, reason string) {
+	if err := s.inventory.RestockOrder(nil, order, nil, reason, order.OrderNo); err != nil {
+		logger.Error("Failed to restock cancelled order", zap.Uint64("order_id", order.ID), zap.Error(err))
 	}
-	if err := s.orderItemRepo.BatchCreate(orderItems); err != nil {
-		return err
-	}
-
-	// 扣减库存
-	for _, item := range orderItems {
-		if item.SKUID > 0 {
-			// 扣减SKU库存
-			sku, _ := s.skuRepo.GetByID(item.SKUID)
-			if sku != nil {
-				s.skuRepo.UpdateStock(item.SKUID, sku.Stock-item.Quantity)
-			}
-		} else {
-			// 扣减商品库存
-			product, _ := s.productRepo.GetByID(item.ProductID)
-			if product != nil {
-				s.productRepo.UpdateStock(item.ProductID, product.Stock-item.Quantity)
-			}
-		}
-	}
-
-	return nil
 }
 
 // GetOrderDetail 获取订单详情
@@ -364,8 +378,17 @@ func (s *orderService) CancelOrder(userID uint64, orderID uint64) error {
 		return errors.New("order cannot be cancelled")
 	}
 
-	// 更新订单状态为已取消
-	return s.orderRepo.UpdateStatus(orderID, 5)
+	// 条件更新为已取消，避免与支付回调并发时取消已付款订单
+	cancelled, err := s.orderRepo.UpdateStatusFrom(orderID, 1, 5)
+	if err != nil {
+		return err
+	}
+	if !cancelled {
+		return errors.New("order cannot be cancelled")
+	}
+
+	s.restockOrder(order, InventoryReasonCancel)
+	return nil
 }
 
 // ConfirmOrder 确认收货
@@ -384,7 +407,15 @@ func (s *orderService) ConfirmOrder(userID uint64, orderID uint64) error {
 	}
 
 	// 更新订单状态为已完成
-	return s.orderRepo.UpdateStatus(orderID, 4)
+	if err := s.orderRepo.UpdateStatus(orderID, 4); err != nil {
+		return err
+	}
+
+	// 积分发放失败不影响确认收货，由定时任务按幂等键补发
+	if err := s.loyalty.EarnForOrder(order); err != nil {
+		logger.Error("Failed to earn order points", zap.Uint64("order_id", orderID), zap.Error(err))
+	}
+	return nil
 }
 
 // GetOrders 获取订单列表（管理员）
@@ -408,6 +439,9 @@ func (s *orderService) GetOrders(req *AdminOrderListRequest) (*OrderListResponse
 		if order.User.ID > 0 {
 			response.Username = order.User.Username
 		}
+		if !req.Reveal {
+			s.maskOrderResponse(response)
+		}
 		items = append(items, response)
 	}
 
@@ -425,13 +459,91 @@ func (s *orderService) GetOrders(req *AdminOrderListRequest) (*OrderListResponse
 	}, nil
 }
 
-// UpdateOrderStatus 更新订单状态
+// UpdateOrderStatus 更新订单状态，发货和取消时通知用户
 func (s *orderService) UpdateOrderStatus(orderID uint64, status int8) error {
-	return s.orderRepo.UpdateStatus(orderID, status)
+	order, err := s.orderRepo.GetByID(orderID)
+	if err != nil {
+		return errors.New("order not found")
+	}
+
+	if order.Status == status {
+		return nil
+	}
+
+	// 条件更新，订单状态在读取后被支付回调或用户操作改变时拒绝覆盖
+	updated, err := s.orderRepo.UpdateStatusFrom(orderID, order.Status, status)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return errors.New("order status has changed, please refresh and retry")
+	}
+
+	switch status {
+	case 3:
+		s.notifier.Notify(order.UserID, &NotificationMessage{
+			Category: NotificationCategoryOrder,
+			Type:     "order_shipped",
+			Title:    "订单已发货",
+			Content:  fmt.Sprintf("您的订单%s已发货，请留意物流信息", order.OrderNo),
+			BizType:  "order",
+			BizID:    order.ID,
+		})
+	case 5:
+		// 未发货的订单取消后商品回到库存
+		if order.Status == 1 || order.Status == 2 {
+			s.restockOrder(order, InventoryReasonCancel)
+		}
+		s.notifier.Notify(order.UserID, &NotificationMessage{
+			Category: NotificationCategoryOrder,
+			Type:     "order_cancelled",
+			Title:    "订单已取消",
+			Content:  fmt.Sprintf("您的订单%s已被商家取消", order.OrderNo),
+			BizType:  "order",
+			BizID:    order.ID,
+		})
+	}
+	return nil
+}
+
+// CancelExpiredOrders 自动取消超时未付款的订单
+func (s *orderService) CancelExpiredOrders() error {
+	minutes := config.GetConfig().Order.AutoCancelMinutes
+	if minutes <= 0 {
+		return nil
+	}
+
+	orders, err := s.orderRepo.GetUnpaidBefore(time.Now().Add(-time.Duration(minutes)*time.Minute), orderAutoCancelBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, order := range orders {
+		// 条件更新，避免与支付回调并发时取消已付款订单
+		cancelled, err := s.orderRepo.UpdateStatusFrom(order.ID, 1, 5)
+		if err != nil {
+			logger.Error("Failed to auto cancel order", zap.Uint64("order_id", order.ID), zap.Error(err))
+			continue
+		}
+		if !cancelled {
+			continue
+		}
+
+		s.restockOrder(order, InventoryReasonCancel)
+		s.notifier.Notify(order.UserID, &NotificationMessage{
+			Category: NotificationCategoryOrder,
+			Type:     "order_auto_cancelled",
+			Title:    "订单已自动取消",
+			Content:  fmt.Sprintf("您的订单%s超过%d分钟未付款，已自动取消", order.OrderNo, minutes),
+			BizType:  "order",
+			BizID:    order.ID,
+		})
+	}
+	return nil
 }
 
 // SearchOrders 搜索订单
-func (s *orderService) SearchOrders(keyword string, page, pageSize int) (*OrderListResponse, error) {
+func (s *orderService) SearchOrders(keyword string, page, pageSize int, reveal bool) (*OrderListResponse, error) {
 	if page <= 0 {
 		page = 1
 	}
@@ -450,6 +562,9 @@ func (s *orderService) SearchOrders(keyword string, page, pageSize int) (*OrderL
 		if order.User.ID > 0 {
 			response.Username = order.User.Username
 		}
+		if !reveal {
+			s.maskOrderResponse(response)
+		}
 		items = append(items, response)
 	}
 
@@ -467,6 +582,12 @@ func (s *orderService) SearchOrders(keyword string, page, pageSize int) (*OrderL
 	}, nil
 }
 
+// maskOrderResponse 收货手机号、地址脱敏
+func (s *orderService) maskOrderResponse(response *OrderResponse) {
+	response.ReceiverPhone = logger.MaskPhone(response.ReceiverPhone)
+	response.ReceiverAddress = logger.MaskAddress(response.ReceiverAddress)
+}
+
 // toOrderResponse 转换为订单响应
 func (s *orderService) toOrderResponse(order *model.Order) *OrderResponse {
 	response := &OrderResponse{
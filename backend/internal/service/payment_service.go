@@ -9,9 +9,13 @@ import (
 	"strings"
 	"time"
 
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
 	"mall/internal/model"
 	"mall/internal/repository"
 	"mall/pkg/config"
+	"mall/pkg/logger"
 	"mall/pkg/utils"
 )
 
@@ -22,6 +26,7 @@ type PaymentService interface {
 	ProcessAlipayCallback(req *AlipayCallbackRequest) error
 	GetPaymentStatus(paymentNo string) (*PaymentStatusResponse, error)
 	CancelPayment(paymentNo string) error
+	RefundPayment(paymentNo string, req *RefundPaymentRequest, operatorID uint64) (*RefundPaymentResponse, error)
 }
 
 // CreatePaymentRequest 创建支付请求
@@ -66,20 +71,47 @@ type PaymentStatusResponse struct {
 	PayTime       string  `json:"pay_time"`
 }
 
+// RefundPaymentRequest 退款请求
+type RefundPaymentRequest struct {
+	RefundAmount float64 `json:"refund_amount" binding:"required,gt=0"`
+	RefundReason string  `json:"refund_reason" binding:"required,max=255"`
+}
+
+// RefundPaymentResponse 退款响应
+type RefundPaymentResponse struct {
+	RefundNo      string  `json:"refund_no"`
+	PaymentNo     string  `json:"payment_no"`
+	RefundAmount  float64 `json:"refund_amount"`
+	TotalRefunded float64 `json:"total_refunded"`
+	Status        int8    `json:"status"`
+}
+
 // paymentService 支付服务实现
 type paymentService struct {
-	paymentRepo repository.OrderPaymentRepository
-	orderRepo   repository.OrderRepository
+	paymentRepo   repository.OrderPaymentRepository
+	orderRepo     repository.OrderRepository
+	orderItemRepo repository.OrderItemRepository
+	loyalty       LoyaltyService
+	notifier      NotificationService
+	inventory     InventoryService
 }
 
 // NewPaymentService 创建支付服务
 func NewPaymentService(
 	paymentRepo repository.OrderPaymentRepository,
 	orderRepo repository.OrderRepository,
+	orderItemRepo repository.OrderItemRepository,
+	loyalty LoyaltyService,
+	notifier NotificationService,
+	inventory InventoryService,
 ) PaymentService {
 	return &paymentService{
-		paymentRepo: paymentRepo,
-		orderRepo:   orderRepo,
+		paymentRepo:   paymentRepo,
+		orderRepo:     orderRepo,
+		orderItemRepo: orderItemRepo,
+		loyalty:       loyalty,
+		notifier:      notifier,
+		inventory:     inventory,
 	}
 }
 
@@ -236,12 +268,7 @@ func (s *paymentService) ProcessWechatCallback(req *WechatCallbackRequest) error
 	// 验证支付结果
 	if req.ReturnCode == "SUCCESS" && req.ResultCode == "SUCCESS" {
 		// 支付成功
-		if err := s.paymentRepo.UpdateStatus(payment.ID, 1, req.TransactionID); err != nil {
-			return err
-		}
-
-		// 更新订单状态
-		return s.orderRepo.UpdateStatus(payment.OrderID, 2) // 待发货
+		return s.handlePaid(payment, req.TransactionID)
 	} else {
 		// 支付失败
 		return s.paymentRepo.UpdateStatus(payment.ID, 2, "")
@@ -259,12 +286,7 @@ func (s *paymentService) ProcessAlipayCallback(req *AlipayCallbackRequest) error
 	// 验证支付结果
 	if req.TradeStatus == "TRADE_SUCCESS" || req.TradeStatus == "TRADE_FINISHED" {
 		// 支付成功
-		if err := s.paymentRepo.UpdateStatus(payment.ID, 1, req.TradeNo); err != nil {
-			return err
-		}
-
-		// 更新订单状态
-		return s.orderRepo.UpdateStatus(payment.OrderID, 2) // 待发货
+		return s.handlePaid(payment, req.TradeNo)
 	} else {
 		// 支付失败
 		return s.paymentRepo.UpdateStatus(payment.ID, 2, "")
@@ -304,4 +326,162 @@ func (s *paymentService) CancelPayment(paymentNo string) error {
 
 	// 更新支付状态为已取消
 	return s.paymentRepo.UpdateStatus(payment.ID, 3, "")
-}
\ No newline at end of file
+}
+
+// RefundPayment 退款，退款记录、累计退款金额、积分扣回与库存回补在同一事务中完成，渠道退款失败时全部回滚
+func (s *paymentService) RefundPayment(paymentNo string, req *RefundPaymentRequest, operatorID uint64) (*RefundPaymentResponse, error) {
+	payment, err := s.paymentRepo.GetByPaymentNo(paymentNo)
+	if err != nil {
+		return nil, errors.New("payment not found")
+	}
+	order := &payment.Order
+
+	// 未发货订单退款可能需要回补库存，订单项在事务外预先读取
+	var items []*model.OrderItem
+	if payment.Status == 1 && order.Status == 2 {
+		if items, err = s.orderItemRepo.GetByOrderID(order.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	refund := &model.OrderRefund{
+		PaymentID:  payment.ID,
+		RefundNo:   utils.GenerateRefundNo(),
+		Amount:     req.RefundAmount,
+		Reason:     req.RefundReason,
+		OperatorID: operatorID,
+	}
+	updated, err := s.paymentRepo.AddRefund(refund, func(tx *gorm.DB, locked *model.OrderPayment, fullRefund bool) error {
+		// 待退款的支付款项未用于订单，不涉及积分
+		if locked.Status == 1 {
+			if err := s.loyalty.RevokeForRefund(tx, order, refund.RefundNo, refund.Amount, fullRefund); err != nil {
+				return err
+			}
+			// 未发货订单全额退款时回补库存，已发货订单的退货入库通过库存调整完成
+			if fullRefund && items != nil {
+				if err := s.inventory.RestockOrder(tx, order, items, InventoryReasonRefund, refund.RefundNo); err != nil {
+					return err
+				}
+			}
+		}
+		return s.requestGatewayRefund(locked, refund)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrPaymentNotRefundable):
+			return nil, errors.New("payment is not refundable")
+		case errors.Is(err, repository.ErrRefundExceedsPaid):
+			return nil, errors.New("refund amount exceeds refundable amount")
+		}
+		return nil, err
+	}
+
+	logger.Info("Payment refunded",
+		zap.Uint64("order_id", payment.OrderID),
+		zap.String("payment_no", payment.PaymentNo),
+		zap.String("refund_no", refund.RefundNo),
+		zap.Float64("amount", refund.Amount),
+		zap.Uint64("operator_id", operatorID),
+	)
+	s.notifyRefunded(order, refund)
+
+	return &RefundPaymentResponse{
+		RefundNo:      refund.RefundNo,
+		PaymentNo:     payment.PaymentNo,
+		RefundAmount:  refund.Amount,
+		TotalRefunded: updated.RefundAmount,
+		Status:        updated.Status,
+	}, nil
+}
+
+// requestGatewayRefund 向支付渠道发起原路退款，以退款单号作为渠道侧幂等键
+func (s *paymentService) requestGatewayRefund(payment *model.OrderPayment, refund *model.OrderRefund) error {
+	switch payment.PaymentMethod {
+	case "wechat", "alipay":
+		// 这里应该调用微信或支付宝退款API，与下单一样简化处理，视为渠道受理成功
+		return nil
+	default:
+		return errors.New("unsupported payment method")
+	}
+}
+
+// handlePaid 处理支付成功回调，重复回调直接忽略；订单已不是待付款状态时转入退款处理
+func (s *paymentService) handlePaid(payment *model.OrderPayment, tradeNo string) error {
+	paid, err := s.paymentRepo.MarkPaid(payment.ID, tradeNo)
+	if err != nil {
+		return err
+	}
+	if !paid {
+		return nil
+	}
+
+	// 条件更新为待发货，避免与取消订单并发时把已取消订单改回待发货
+	updated, err := s.orderRepo.UpdateStatusFrom(payment.OrderID, 1, 2)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return s.markRefundPending(payment)
+	}
+
+	s.notifyPaid(payment)
+	return nil
+}
+
+// markRefundPending 订单已取消或已由其他支付单付款时，本次支付款项登记为待退款
+func (s *paymentService) markRefundPending(payment *model.OrderPayment) error {
+	if err := s.paymentRepo.UpdateStatus(payment.ID, 4, ""); err != nil {
+		return err
+	}
+
+	logger.Error("Payment received for order not pending payment, refund required",
+		zap.Uint64("order_id", payment.OrderID),
+		zap.String("payment_no", payment.PaymentNo),
+		zap.Float64("amount", payment.Amount),
+	)
+
+	order, err := s.orderRepo.GetByID(payment.OrderID)
+	if err != nil {
+		logger.Warn("Failed to load order for refund notification", zap.Uint64("order_id", payment.OrderID), zap.Error(err))
+		return nil
+	}
+	s.notifier.Notify(order.UserID, &NotificationMessage{
+		Category: NotificationCategoryPayment,
+		Type:     "payment_refund_pending",
+		Title:    "支付款项将退回",
+		Content:  fmt.Sprintf("订单%s已关闭，您支付的%.2f元将原路退回", order.OrderNo, payment.Amount),
+		BizType:  "order",
+		BizID:    order.ID,
+	})
+	return nil
+}
+
+// notifyRefunded 退款完成后通知用户
+func (s *paymentService) notifyRefunded(order *model.Order, refund *model.OrderRefund) {
+	s.notifier.Notify(order.UserID, &NotificationMessage{
+		Category: NotificationCategoryPayment,
+		Type:     "order_refunded",
+		Title:    "退款成功",
+		Content:  fmt.Sprintf("订单%s已退款%.2f元，款项将原路退回", order.OrderNo, refund.Amount),
+		BizType:  "order",
+		BizID:    order.ID,
+	})
+}
+
+// notifyPaid 支付成功后通知用户
+func (s *paymentService) notifyPaid(payment *model.OrderPayment) {
+	order, err := s.orderRepo.GetByID(payment.OrderID)
+	if err != nil {
+		logger.Warn("Failed to load order for payment notification", zap.Uint64("order_id", payment.OrderID), zap.Error(err))
+		return
+	}
+
+	s.notifier.Notify(order.UserID, &NotificationMessage{
+		Category: NotificationCategoryPayment,
+		Type:     "order_paid",
+		Title:    "订单支付成功",
+		Content:  fmt.Sprintf("订单%s已支付%.2f元，我们将尽快为您发货", order.OrderNo, payment.Amount),
+		BizType:  "order",
+		BizID:    order.ID,
+	})
+}
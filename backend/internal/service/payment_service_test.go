@@ -0,0 +1,172 @@
+package service
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+	"mall/internal/repository"
+	"mall/pkg/config"
+)
+
+// newTestPaymentService 创建支付服务，退款时扣回积分、回补库存并通知用户
+func newTestPaymentService(t *testing.T) (*paymentService, *gorm.DB) {
+	t.Helper()
+
+	useMiniredis(t)
+	useConfig(t, &config.Config{})
+
+	db := newTestDB(t,
+		&model.Product{},
+		&model.ProductSKU{},
+		&model.InventoryLedger{},
+		&model.Order{},
+		&model.OrderItem{},
+		&model.OrderPayment{},
+		&model.OrderRefund{},
+		&model.UserPoints{},
+		&model.PointsLedger{},
+		&model.MemberLevel{},
+		&model.Notification{},
+	)
+
+	orderItemRepo := repository.NewOrderItemRepository(db)
+	s := NewPaymentService(
+		repository.NewOrderPaymentRepository(db),
+		repository.NewOrderRepository(db),
+		orderItemRepo,
+		NewLoyaltyService(repository.NewPointsRepository(db), repository.NewMemberLevelRepository(db)),
+		NewNotificationService(repository.NewNotificationRepository(db)),
+		NewInventoryService(repository.NewInventoryRepository(db), orderItemRepo),
+	).(*paymentService)
+	return s, db
+}
+
+// createPaidOrder 创建已支付订单，包含一件库存为5的商品
+func createPaidOrder(t *testing.T, db *gorm.DB, orderNo string, status int8, amount float64) (*model.Order, *model.OrderPayment, *model.Product) {
+	t.Helper()
+
+	product := &model.Product{CategoryID: 1, Name: "p-" + orderNo, Price: amount, Stock: 5, Status: 1}
+	if err := db.Create(product).Error; err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	order := &model.Order{OrderNo: orderNo, UserID: 1, TotalAmount: amount, PayAmount: amount, Status: status}
+	if err := db.Create(order).Error; err != nil {
+		t.Fatalf("create order: %v", err)
+	}
+	item := &model.OrderItem{OrderID: order.ID, ProductID: product.ID, ProductName: product.Name, Price: amount, Quantity: 2, TotalAmount: amount}
+	payment := &model.OrderPayment{OrderID: order.ID, PaymentNo: "PAY-" + orderNo, PaymentMethod: "wechat", Amount: amount, Status: 1}
+	for _, value := range []interface{}{item, payment} {
+		if err := db.Create(value).Error; err != nil {
+			t.Fatalf("create %T: %v", value, err)
+		}
+	}
+	return order, payment, product
+}
+
+func TestRefundPaymentCompletedOrder(t *testing.T) {
+	s, db := newTestPaymentService(t)
+	order, payment, product := createPaidOrder(t, db, "ORD1", 4, 100)
+	if err := s.loyalty.EarnForOrder(order); err != nil {
+		t.Fatalf("EarnForOrder: %v", err)
+	}
+
+	steps := []struct {
+		name          string
+		amount        float64
+		wantErr       bool
+		wantRefunded  float64
+		wantPoints    int64
+		wantPayStatus int8
+		wantOrder     int8
+	}{
+		{"partial refund", 30, false, 30, 70, 1, 4},
+		{"refund over the paid amount", 80, true, 30, 70, 1, 4},
+		{"refund the rest", 70, false, 100, 0, 5, 6},
+		{"fully refunded payment", 1, true, 100, 0, 5, 6},
+	}
+	for _, step := range steps {
+		_, err := s.RefundPayment(payment.PaymentNo, &RefundPaymentRequest{RefundAmount: step.amount, RefundReason: "test"}, 9)
+		if (err != nil) != step.wantErr {
+			t.Fatalf("%s: err = %v, wantErr %v", step.name, err, step.wantErr)
+		}
+
+		var gotPayment model.OrderPayment
+		var gotOrder model.Order
+		db.First(&gotPayment, payment.ID)
+		db.First(&gotOrder, order.ID)
+		account := pointsAccount(t, db, 1)
+		if gotPayment.RefundAmount != step.wantRefunded || gotPayment.Status != step.wantPayStatus ||
+			gotOrder.Status != step.wantOrder || account.Balance != step.wantPoints {
+			t.Errorf("%s: refunded = %.2f, payment status = %d, order status = %d, points = %d",
+				step.name, gotPayment.RefundAmount, gotPayment.Status, gotOrder.Status, account.Balance)
+		}
+	}
+
+	var notifications, refunds int64
+	db.Model(&model.Notification{}).Where("type = ?", "order_refunded").Count(&notifications)
+	db.Model(&model.OrderRefund{}).Where("operator_id = ?", 9).Count(&refunds)
+	if notifications != 2 || refunds != 2 {
+		t.Errorf("notifications = %d, refunds = %d, want 2 and 2", notifications, refunds)
+	}
+	// 已发货订单退款不自动回补库存
+	if got := loadTestProduct(t, db, product.ID); got.Stock != 5 {
+		t.Errorf("stock = %d, want 5", got.Stock)
+	}
+}
+
+func TestRefundPaymentUnshippedOrderRestocks(t *testing.T) {
+	s, db := newTestPaymentService(t)
+	order, payment, product := createPaidOrder(t, db, "ORD1", 2, 50)
+
+	resp, err := s.RefundPayment(payment.PaymentNo, &RefundPaymentRequest{RefundAmount: 50, RefundReason: "cancel"}, 9)
+	if err != nil {
+		t.Fatalf("RefundPayment: %v", err)
+	}
+	if got := loadTestProduct(t, db, product.ID); got.Stock != 7 {
+		t.Errorf("stock = %d, want 7", got.Stock)
+	}
+	var ledger model.InventoryLedger
+	db.Where("product_id = ?", product.ID).First(&ledger)
+	if ledger.Reason != InventoryReasonRefund || ledger.RefID != resp.RefundNo || ledger.Delta != 2 {
+		t.Errorf("ledger = %+v", ledger)
+	}
+	// 未完成订单未发放积分，退款不产生积分流水
+	var entries int64
+	db.Model(&model.PointsLedger{}).Where("order_id = ?", order.ID).Count(&entries)
+	if entries != 0 {
+		t.Errorf("points entries = %d, want 0", entries)
+	}
+}
+
+func TestRefundPaymentRollsBackOnFailure(t *testing.T) {
+	s, db := newTestPaymentService(t)
+	order, payment, product := createPaidOrder(t, db, "ORD1", 2, 50)
+	if err := db.Model(payment).Update("payment_method", "cash").Error; err != nil {
+		t.Fatalf("update payment: %v", err)
+	}
+
+	// 渠道退款失败时退款记录、库存回补全部回滚
+	if _, err := s.RefundPayment(payment.PaymentNo, &RefundPaymentRequest{RefundAmount: 50, RefundReason: "cancel"}, 9); err == nil {
+		t.Fatal("unsupported payment method should fail")
+	}
+	var refunds int64
+	db.Model(&model.OrderRefund{}).Count(&refunds)
+	var gotOrder model.Order
+	db.First(&gotOrder, order.ID)
+	if refunds != 0 || gotOrder.Status != 2 || loadTestProduct(t, db, product.ID).Stock != 5 {
+		t.Errorf("refunds = %d, order status = %d, stock = %d", refunds, gotOrder.Status, loadTestProduct(t, db, product.ID).Stock)
+	}
+}
+
+// loadTestProduct 重新读取商品
+func loadTestProduct(t *testing.T, db *gorm.DB, id uint64) *model.Product {
+	t.Helper()
+
+	var product model.Product
+	if err := db.First(&product, id).Error; err != nil {
+		t.Fatalf("load product: %v", err)
+	}
+	return &product
+}
@@ -0,0 +1,303 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+	"mall/internal/repository"
+	"mall/pkg/logger"
+)
+
+// priceScheduleBatchSize 每轮处理的到期调价计划数
+const priceScheduleBatchSize = 200
+
+// PriceService 价格服务接口，负责定时调价与价格历史
+type PriceService interface {
+	CreateSchedule(productID uint64, req *CreatePriceScheduleRequest, operatorID uint64) (*PriceScheduleResponse, error)
+	CancelSchedule(productID, scheduleID uint64, operatorID uint64) error
+	ListSchedules(productID uint64) ([]*PriceScheduleResponse, error)
+	GetPriceHistory(productID uint64, req *PriceHistoryRequest) (*PriceHistoryResponse, error)
+	RecordManualChanges(tx *gorm.DB, changes []PriceChange, operatorID uint64) error
+	ProcessSchedules() error
+}
+
+// CreatePriceScheduleRequest 创建调价计划请求
+type CreatePriceScheduleRequest struct {
+	SKUID   uint64     `json:"sku_id"` // 为0时调整商品价格
+	Price   float64    `json:"price" binding:"required,gt=0"`
+	StartAt time.Time  `json:"start_at" binding:"required"`
+	EndAt   *time.Time `json:"end_at"` // 为空表示永久调价，不自动恢复
+	Remark  string     `json:"remark" binding:"max=255"`
+}
+
+// PriceScheduleResponse 调价计划响应
+type PriceScheduleResponse struct {
+	ID         uint64  `json:"id"`
+	ProductID  uint64  `json:"product_id"`
+	SKUID      uint64  `json:"sku_id"`
+	Price      float64 `json:"price"`
+	PrevPrice  float64 `json:"prev_price"`
+	StartAt    string  `json:"start_at"`
+	EndAt      string  `json:"end_at"`
+	Status     int8    `json:"status"`
+	AppliedAt  string  `json:"applied_at"`
+	EndedAt    string  `json:"ended_at"`
+	OperatorID uint64  `json:"operator_id"`
+	Remark     string  `json:"remark"`
+	CreatedAt  string  `json:"created_at"`
+}
+
+// PriceChange 一次手动改价
+type PriceChange struct {
+	ProductID uint64
+	SKUID     uint64
+	OldPrice  float64
+	NewPrice  float64
+}
+
+// PriceHistoryRequest 价格历史查询请求
+type PriceHistoryRequest struct {
+	Page     int    `json:"page" form:"page"`
+	PageSize int    `json:"page_size" form:"page_size"`
+	SKUID    uint64 `json:"sku_id" form:"sku_id"`
+}
+
+// PriceHistoryItem 价格历史条目
+type PriceHistoryItem struct {
+	ID           uint64  `json:"id"`
+	ProductID    uint64  `json:"product_id"`
+	SKUID        uint64  `json:"sku_id"`
+	OldPrice     float64 `json:"old_price"`
+	NewPrice     float64 `json:"new_price"`
+	Source       string  `json:"source"`
+	ScheduleID   uint64  `json:"schedule_id"`
+	OperatorType string  `json:"operator_type"`
+	OperatorID   uint64  `json:"operator_id"`
+	Remark       string  `json:"remark"`
+	CreatedAt    string  `json:"created_at"`
+}
+
+// PriceHistoryResponse 价格历史响应
+type PriceHistoryResponse struct {
+	Items      []*PriceHistoryItem `json:"items"`
+	Total      int64               `json:"total"`
+	Page       int                 `json:"page"`
+	PageSize   int                 `json:"page_size"`
+	TotalPages int                 `json:"total_pages"`
+}
+
+// priceService 价格服务实现
+type priceService struct {
+	priceRepo      repository.PriceRepository
+	productRepo    repository.ProductRepository
+	productSKURepo repository.ProductSKURepository
+	search         SearchService
+}
+
+// NewPriceService 创建价格服务
+func NewPriceService(
+	priceRepo repository.PriceRepository,
+	productRepo repository.ProductRepository,
+	productSKURepo repository.ProductSKURepository,
+	search SearchService,
+) PriceService {
+	return &priceService{
+		priceRepo:      priceRepo,
+		productRepo:    productRepo,
+		productSKURepo: productSKURepo,
+		search:         search,
+	}
+}
+
+// CreateSchedule 创建调价计划
+func (s *priceService) CreateSchedule(productID uint64, req *CreatePriceScheduleRequest, operatorID uint64) (*PriceScheduleResponse, error) {
+	if _, err := s.productRepo.GetByID(productID); err != nil {
+		return nil, errors.New("product not found")
+	}
+	if req.SKUID > 0 {
+		sku, err := s.productSKURepo.GetByID(req.SKUID)
+		if err != nil || sku.ProductID != productID {
+			return nil, errors.New("sku not found in product")
+		}
+	}
+
+	if !req.StartAt.After(time.Now()) {
+		return nil, errors.New("start_at must be in the future")
+	}
+	if req.EndAt != nil && !req.EndAt.After(req.StartAt) {
+		return nil, errors.New("end_at must be after start_at")
+	}
+
+	schedule := &model.PriceSchedule{
+		ProductID:  productID,
+		SKUID:      req.SKUID,
+		Price:      req.Price,
+		StartAt:    req.StartAt,
+		EndAt:      req.EndAt,
+		Status:     repository.PriceScheduleStatusPending,
+		OperatorID: operatorID,
+		Remark:     req.Remark,
+	}
+	if err := s.priceRepo.CreateSchedule(schedule); err != nil {
+		if errors.Is(err, repository.ErrPriceScheduleOverlap) {
+			return nil, errors.New("price schedule overlaps an existing schedule")
+		}
+		return nil, err
+	}
+
+	return toPriceScheduleResponse(schedule), nil
+}
+
+// CancelSchedule 取消调价计划，生效中的计划立即恢复原价
+func (s *priceService) CancelSchedule(productID, scheduleID uint64, operatorID uint64) error {
+	schedule, err := s.priceRepo.GetSchedule(scheduleID)
+	if err != nil || schedule.ProductID != productID {
+		return errors.New("price schedule not found")
+	}
+
+	if err := s.priceRepo.CloseSchedule(scheduleID, repository.PriceScheduleStatusCancelled, operatorID, time.Now()); err != nil {
+		if errors.Is(err, repository.ErrPriceScheduleClosed) {
+			return errors.New("price schedule already closed")
+		}
+		return err
+	}
+
+	if schedule.Status == repository.PriceScheduleStatusActive {
+		reindexProduct(s.search, s.productRepo, productID)
+	}
+	return nil
+}
+
+// ListSchedules 获取商品的调价计划
+func (s *priceService) ListSchedules(productID uint64) ([]*PriceScheduleResponse, error) {
+	schedules, err := s.priceRepo.ListSchedules(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*PriceScheduleResponse, 0, len(schedules))
+	for _, schedule := range schedules {
+		responses = append(responses, toPriceScheduleResponse(schedule))
+	}
+	return responses, nil
+}
+
+// GetPriceHistory 分页查询商品价格历史
+func (s *priceService) GetPriceHistory(productID uint64, req *PriceHistoryRequest) (*PriceHistoryResponse, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	entries, total, err := s.priceRepo.ListHistory(productID, req.SKUID, req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*PriceHistoryItem, 0, len(entries))
+	for _, entry := range entries {
+		items = append(items, &PriceHistoryItem{
+			ID:           entry.ID,
+			ProductID:    entry.ProductID,
+			SKUID:        entry.SKUID,
+			OldPrice:     entry.OldPrice,
+			NewPrice:     entry.NewPrice,
+			Source:       entry.Source,
+			ScheduleID:   entry.ScheduleID,
+			OperatorType: entry.OperatorType,
+			OperatorID:   entry.OperatorID,
+			Remark:       entry.Remark,
+			CreatedAt:    entry.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+
+	totalPages := int((total + int64(req.PageSize) - 1) / int64(req.PageSize))
+
+	return &PriceHistoryResponse{
+		Items:      items,
+		Total:      total,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// RecordManualChanges 在改价事务tx中记录后台手动改价，价格未变化的条目忽略
+func (s *priceService) RecordManualChanges(tx *gorm.DB, changes []PriceChange, operatorID uint64) error {
+	entries := make([]*model.PriceHistory, 0, len(changes))
+	for _, change := range changes {
+		if change.OldPrice == change.NewPrice {
+			continue
+		}
+		entries = append(entries, &model.PriceHistory{
+			ProductID:    change.ProductID,
+			SKUID:        change.SKUID,
+			OldPrice:     change.OldPrice,
+			NewPrice:     change.NewPrice,
+			Source:       repository.PriceSourceManual,
+			OperatorType: repository.PriceOperatorAdmin,
+			OperatorID:   operatorID,
+		})
+	}
+
+	return s.priceRepo.AddHistory(tx, entries)
+}
+
+// ProcessSchedules 应用到期的调价计划并恢复已结束计划的原价，由定时任务调用
+func (s *priceService) ProcessSchedules() error {
+	now := time.Now()
+	schedules, err := s.priceRepo.FindDueSchedules(now, priceScheduleBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, schedule := range schedules {
+		if schedule.Status == repository.PriceScheduleStatusPending {
+			err = s.priceRepo.ApplySchedule(schedule.ID, now)
+		} else {
+			err = s.priceRepo.CloseSchedule(schedule.ID, repository.PriceScheduleStatusFinished, 0, now)
+		}
+		if err != nil && !errors.Is(err, repository.ErrPriceScheduleClosed) {
+			logger.Error("Failed to process price schedule",
+				zap.Uint64("schedule_id", schedule.ID),
+				zap.Uint64("product_id", schedule.ProductID),
+				zap.Error(err),
+			)
+			continue
+		}
+		reindexProduct(s.search, s.productRepo, schedule.ProductID)
+	}
+	return nil
+}
+
+// toPriceScheduleResponse 转换调价计划响应
+func toPriceScheduleResponse(schedule *model.PriceSchedule) *PriceScheduleResponse {
+	response := &PriceScheduleResponse{
+		ID:         schedule.ID,
+		ProductID:  schedule.ProductID,
+		SKUID:      schedule.SKUID,
+		Price:      schedule.Price,
+		PrevPrice:  schedule.PrevPrice,
+		StartAt:    schedule.StartAt.Format("2006-01-02 15:04:05"),
+		Status:     schedule.Status,
+		OperatorID: schedule.OperatorID,
+		Remark:     schedule.Remark,
+		CreatedAt:  schedule.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if schedule.EndAt != nil {
+		response.EndAt = schedule.EndAt.Format("2006-01-02 15:04:05")
+	}
+	if schedule.AppliedAt != nil {
+		response.AppliedAt = schedule.AppliedAt.Format("2006-01-02 15:04:05")
+	}
+	if schedule.EndedAt != nil {
+		response.EndedAt = schedule.EndedAt.Format("2006-01-02 15:04:05")
+	}
+	return response
+}
@@ -0,0 +1,492 @@
+package service
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+	"mall/internal/repository"
+	"mall/pkg/config"
+	"mall/pkg/logger"
+	"mall/pkg/sms"
+	"mall/pkg/utils"
+)
+
+// PrivacyService 用户隐私服务接口（数据导出、账号注销）
+type PrivacyService interface {
+	RequestExport(userID uint64) (*PrivacyRequestResponse, error)
+	GetExportFile(userID, requestID uint64) (string, error)
+	SendDeletionCode(userID uint64, ip string) error
+	RequestDeletion(userID uint64, req *DeletionRequest) (*PrivacyRequestResponse, error)
+	CancelDeletion(userID uint64) error
+	GetRequests(userID uint64) ([]*PrivacyRequestResponse, error)
+	ProcessExports() error
+	ProcessDeletions() error
+	CleanupExpiredExports() error
+}
+
+// 隐私请求类型
+const (
+	PrivacyRequestExport = "export"
+	PrivacyRequestDelete = "delete"
+)
+
+// privacyBatchSize 定时任务单次处理数量
+const privacyBatchSize = 20
+
+// DeletionRequest 注销账号请求，已绑定手机号的用户需要短信验证
+type DeletionRequest struct {
+	Code string `json:"code"`
+}
+
+// PrivacyRequestResponse 隐私请求响应
+type PrivacyRequestResponse struct {
+	ID           uint64 `json:"id"`
+	Type         string `json:"type"`
+	Status       int8   `json:"status"`
+	ScheduledAt  string `json:"scheduled_at"`
+	CompletedAt  string `json:"completed_at,omitempty"`
+	ExpiresAt    string `json:"expires_at,omitempty"`
+	Downloadable bool   `json:"downloadable"`
+	FailReason   string `json:"fail_reason,omitempty"`
+	CreatedAt    string `json:"created_at"`
+}
+
+// privacyService 用户隐私服务实现
+type privacyService struct {
+	privacyRepo    repository.PrivacyRequestRepository
+	userRepo       repository.UserRepository
+	smsService     SMSService
+	sessionService SessionService
+}
+
+// NewPrivacyService 创建用户隐私服务
+func NewPrivacyService(
+	privacyRepo repository.PrivacyRequestRepository,
+	userRepo repository.UserRepository,
+	smsService SMSService,
+	sessionService SessionService,
+) PrivacyService {
+	return &privacyService{
+		privacyRepo:    privacyRepo,
+		userRepo:       userRepo,
+		smsService:     smsService,
+		sessionService: sessionService,
+	}
+}
+
+// RequestExport 申请导出个人数据，由后台任务异步生成
+func (s *privacyService) RequestExport(userID uint64) (*PrivacyRequestResponse, error) {
+	if existing, err := s.privacyRepo.GetActiveByUserAndType(userID, PrivacyRequestExport); err == nil {
+		return s.toPrivacyRequestResponse(existing), nil
+	}
+
+	req := &model.PrivacyRequest{
+		UserID:      userID,
+		Type:        PrivacyRequestExport,
+		Status:      repository.PrivacyStatusPending,
+		ScheduledAt: time.Now(),
+	}
+	if err := s.privacyRepo.Create(req); err != nil {
+		return nil, errors.New("failed to create export request")
+	}
+
+	return s.toPrivacyRequestResponse(req), nil
+}
+
+// GetExportFile 获取可下载的导出文件路径
+func (s *privacyService) GetExportFile(userID, requestID uint64) (string, error) {
+	req, err := s.privacyRepo.GetByID(requestID)
+	if err != nil || req.Type != PrivacyRequestExport {
+		return "", errors.New("export request not found")
+	}
+
+	if req.UserID != userID {
+		return "", errors.New("access denied")
+	}
+
+	if !s.isDownloadable(req) {
+		return "", errors.New("export file is not available")
+	}
+
+	return req.FilePath, nil
+}
+
+// SendDeletionCode 向账号绑定的手机号发送注销验证码
+func (s *privacyService) SendDeletionCode(userID uint64, ip string) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if user.Phone == "" {
+		return errors.New("no phone number bound to this account")
+	}
+	return s.smsService.SendCode(sms.SceneDelete, user.Phone, ip)
+}
+
+// RequestDeletion 申请注销账号，冷静期结束后执行
+func (s *privacyService) RequestDeletion(userID uint64, req *DeletionRequest) (*PrivacyRequestResponse, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if user.Phone != "" && !s.smsService.VerifyCode(sms.SceneDelete, user.Phone, req.Code) {
+		return nil, errors.New("invalid verification code")
+	}
+
+	if existing, err := s.privacyRepo.GetActiveByUserAndType(userID, PrivacyRequestDelete); err == nil {
+		return s.toPrivacyRequestResponse(existing), nil
+	}
+
+	deletion := &model.PrivacyRequest{
+		UserID:      userID,
+		Type:        PrivacyRequestDelete,
+		Status:      repository.PrivacyStatusPending,
+		ScheduledAt: time.Now().Add(s.coolingPeriod()),
+	}
+	if err := s.privacyRepo.Create(deletion); err != nil {
+		return nil, errors.New("failed to create deletion request")
+	}
+
+	logger.Info("Account deletion requested", zap.Uint64("user_id", userID), zap.Time("scheduled_at", deletion.ScheduledAt))
+	return s.toPrivacyRequestResponse(deletion), nil
+}
+
+// CancelDeletion 冷静期内撤销注销申请
+func (s *privacyService) CancelDeletion(userID uint64) error {
+	req, err := s.privacyRepo.GetActiveByUserAndType(userID, PrivacyRequestDelete)
+	if err != nil {
+		return errors.New("no pending deletion request")
+	}
+
+	if req.Status != repository.PrivacyStatusPending {
+		return errors.New("deletion is already in progress")
+	}
+
+	return s.privacyRepo.UpdateFields(req.ID, map[string]interface{}{
+		"status": repository.PrivacyStatusCancelled,
+	})
+}
+
+// GetRequests 获取用户的隐私请求记录
+func (s *privacyService) GetRequests(userID uint64) ([]*PrivacyRequestResponse, error) {
+	reqs, err := s.privacyRepo.GetUserRequests(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*PrivacyRequestResponse
+	for _, req := range reqs {
+		result = append(result, s.toPrivacyRequestResponse(req))
+	}
+	return result, nil
+}
+
+// ProcessExports 生成待处理的数据导出文件
+func (s *privacyService) ProcessExports() error {
+	reqs, err := s.privacyRepo.GetDue(PrivacyRequestExport, time.Now(), privacyBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, req := range reqs {
+		claimed, err := s.privacyRepo.Claim(req.ID)
+		if err != nil || !claimed {
+			continue
+		}
+
+		filePath, err := s.buildExportArchive(req)
+		if err != nil {
+			logger.Error("Failed to export user data", zap.Uint64("request_id", req.ID), zap.Error(err))
+			s.privacyRepo.UpdateFields(req.ID, map[string]interface{}{
+				"status":      repository.PrivacyStatusFailed,
+				"fail_reason": err.Error(),
+			})
+			continue
+		}
+
+		now := time.Now()
+		expiresAt := now.Add(s.exportExpiration())
+		s.privacyRepo.UpdateFields(req.ID, map[string]interface{}{
+			"status":       repository.PrivacyStatusCompleted,
+			"file_path":    filePath,
+			"completed_at": now,
+			"expires_at":   expiresAt,
+		})
+	}
+
+	return nil
+}
+
+// ProcessDeletions 执行冷静期已结束的注销申请
+func (s *privacyService) ProcessDeletions() error {
+	reqs, err := s.privacyRepo.GetDue(PrivacyRequestDelete, time.Now(), privacyBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, req := range reqs {
+		claimed, err := s.privacyRepo.Claim(req.ID)
+		if err != nil || !claimed {
+			continue
+		}
+
+		if err := s.privacyRepo.AnonymizeUser(req.UserID); err != nil {
+			logger.Error("Failed to delete user account", zap.Uint64("user_id", req.UserID), zap.Error(err))
+			s.privacyRepo.UpdateFields(req.ID, map[string]interface{}{
+				"status":      repository.PrivacyStatusFailed,
+				"fail_reason": err.Error(),
+			})
+			continue
+		}
+
+		// 注销后所有登录态立即失效
+		if err := s.sessionService.RevokeAllSessions(req.UserID); err != nil {
+			logger.Error("Failed to revoke sessions", zap.Uint64("user_id", req.UserID), zap.Error(err))
+		}
+		utils.RevokeUserTokens(req.UserID)
+
+		s.privacyRepo.UpdateFields(req.ID, map[string]interface{}{
+			"status":       repository.PrivacyStatusCompleted,
+			"completed_at": time.Now(),
+		})
+		logger.Info("User account deleted", zap.Uint64("user_id", req.UserID))
+	}
+
+	return nil
+}
+
+// CleanupExpiredExports 删除过期的导出文件
+func (s *privacyService) CleanupExpiredExports() error {
+	reqs, err := s.privacyRepo.GetExpiredExports(time.Now(), privacyBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, req := range reqs {
+		if err := os.Remove(req.FilePath); err != nil && !os.IsNotExist(err) {
+			logger.Warn("Failed to remove export file", zap.String("file", req.FilePath), zap.Error(err))
+			continue
+		}
+		s.privacyRepo.UpdateFields(req.ID, map[string]interface{}{"file_path": ""})
+	}
+
+	return nil
+}
+
+// buildExportArchive 将用户数据按类别写入ZIP归档
+func (s *privacyService) buildExportArchive(req *model.PrivacyRequest) (string, error) {
+	user, err := s.privacyRepo.LoadUserData(req.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", errors.New("user not found")
+		}
+		return "", err
+	}
+
+	dir := config.GetConfig().Privacy.ExportDir
+	if dir == "" {
+		dir = "data/exports"
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	filePath := filepath.Join(dir, fmt.Sprintf("user_%d_%d_%s.zip", req.UserID, req.ID, utils.GenerateRandomString(8)))
+	f, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", err
+	}
+
+	err = writeExportArchive(f, s.collectExportSections(user))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(filePath)
+		return "", err
+	}
+
+	return filePath, nil
+}
+
+// writeExportArchive 每个数据类别写入一个JSON文件
+func writeExportArchive(w io.Writer, sections map[string]interface{}) error {
+	archive := zip.NewWriter(w)
+	for name, data := range sections {
+		entry, err := archive.Create(name + ".json")
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(entry)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(data); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// collectExportSections 整理导出数据，去除密码等敏感凭证
+func (s *privacyService) collectExportSections(user *model.User) map[string]interface{} {
+	const layout = "2006-01-02 15:04:05"
+
+	account := map[string]interface{}{
+		"id":            user.ID,
+		"username":      user.Username,
+		"phone":         user.Phone,
+		"email":         user.Email,
+		"wechat_openid": user.WechatOpenID,
+		"status":        user.Status,
+		"created_at":    user.CreatedAt.Format(layout),
+	}
+
+	var profile map[string]interface{}
+	if user.Profile != nil {
+		profile = map[string]interface{}{
+			"nickname": user.Profile.Nickname,
+			"avatar":   user.Profile.Avatar,
+			"gender":   user.Profile.Gender,
+			"birthday": user.Profile.Birthday.Format("2006-01-02"),
+		}
+	}
+
+	addresses := make([]map[string]interface{}, 0, len(user.Addresses))
+	for _, addr := range user.Addresses {
+		addresses = append(addresses, map[string]interface{}{
+			"name":       addr.Name,
+			"phone":      addr.Phone,
+			"province":   addr.Province,
+			"city":       addr.City,
+			"district":   addr.District,
+			"address":    addr.Address,
+			"is_default": addr.IsDefault,
+		})
+	}
+
+	auths := make([]map[string]interface{}, 0, len(user.AuthInfos))
+	for _, auth := range user.AuthInfos {
+		auths = append(auths, map[string]interface{}{
+			"auth_type":  auth.AuthType,
+			"auth_key":   auth.AuthKey,
+			"created_at": auth.CreatedAt.Format(layout),
+		})
+	}
+
+	orders := make([]map[string]interface{}, 0, len(user.Orders))
+	payments := make([]map[string]interface{}, 0)
+	for _, order := range user.Orders {
+		items := make([]map[string]interface{}, 0, len(order.Items))
+		for _, item := range order.Items {
+			items = append(items, map[string]interface{}{
+				"product_id":   item.ProductID,
+				"sku_id":       item.SKUID,
+				"product_name": item.ProductName,
+				"sku_name":     item.SKUName,
+				"price":        item.Price,
+				"quantity":     item.Quantity,
+				"total_amount": item.TotalAmount,
+			})
+		}
+		orders = append(orders, map[string]interface{}{
+			"order_no":         order.OrderNo,
+			"total_amount":     order.TotalAmount,
+			"pay_amount":       order.PayAmount,
+			"freight_amount":   order.FreightAmount,
+			"discount_amount":  order.DiscountAmount,
+			"status":           order.Status,
+			"buyer_message":    order.BuyerMessage,
+			"receiver_name":    order.ReceiverName,
+			"receiver_phone":   order.ReceiverPhone,
+			"receiver_address": order.ReceiverAddress,
+			"created_at":       order.CreatedAt.Format(layout),
+			"items":            items,
+		})
+		for _, payment := range order.Payments {
+			payments = append(payments, map[string]interface{}{
+				"order_no":       order.OrderNo,
+				"payment_no":     payment.PaymentNo,
+				"payment_method": payment.PaymentMethod,
+				"amount":         payment.Amount,
+				"status":         payment.Status,
+				"pay_time":       payment.PayTime.Format(layout),
+			})
+		}
+	}
+
+	cart := make([]map[string]interface{}, 0, len(user.CartItems))
+	for _, item := range user.CartItems {
+		cart = append(cart, map[string]interface{}{
+			"product_id": item.ProductID,
+			"sku_id":     item.SKUID,
+			"quantity":   item.Quantity,
+			"created_at": item.CreatedAt.Format(layout),
+		})
+	}
+
+	return map[string]interface{}{
+		"account":       account,
+		"profile":       profile,
+		"addresses":     addresses,
+		"auth_bindings": auths,
+		"orders":        orders,
+		"payments":      payments,
+		"cart":          cart,
+	}
+}
+
+// isDownloadable 导出文件是否可下载
+func (s *privacyService) isDownloadable(req *model.PrivacyRequest) bool {
+	return req.Type == PrivacyRequestExport &&
+		req.Status == repository.PrivacyStatusCompleted &&
+		req.FilePath != "" &&
+		req.ExpiresAt != nil && req.ExpiresAt.After(time.Now())
+}
+
+// coolingPeriod 注销冷静期
+func (s *privacyService) coolingPeriod() time.Duration {
+	days := config.GetConfig().Privacy.DeletionCoolingDays
+	if days < 0 {
+		days = 0
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// exportExpiration 导出文件保留时长
+func (s *privacyService) exportExpiration() time.Duration {
+	hours := config.GetConfig().Privacy.ExportExpireHours
+	if hours <= 0 {
+		hours = 72
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// toPrivacyRequestResponse 转换为隐私请求响应
+func (s *privacyService) toPrivacyRequestResponse(req *model.PrivacyRequest) *PrivacyRequestResponse {
+	resp := &PrivacyRequestResponse{
+		ID:           req.ID,
+		Type:         req.Type,
+		Status:       req.Status,
+		ScheduledAt:  req.ScheduledAt.Format("2006-01-02 15:04:05"),
+		Downloadable: s.isDownloadable(req),
+		FailReason:   req.FailReason,
+		CreatedAt:    req.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if req.CompletedAt != nil {
+		resp.CompletedAt = req.CompletedAt.Format("2006-01-02 15:04:05")
+	}
+	if req.ExpiresAt != nil {
+		resp.ExpiresAt = req.ExpiresAt.Format("2006-01-02 15:04:05")
+	}
+	return resp
+}
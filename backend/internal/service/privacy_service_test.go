@@ -0,0 +1,228 @@
+package service
+
+import (
+	"archive/zip"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+	"mall/internal/repository"
+	"mall/pkg/config"
+	"mall/pkg/sms"
+	"mall/pkg/utils"
+)
+
+// newTestPrivacyService 创建隐私服务，注销冷静期7天，导出文件写入临时目录
+func newTestPrivacyService(t *testing.T) (*privacyService, *fakeSMSSender, *miniredis.Miniredis, *gorm.DB) {
+	t.Helper()
+
+	mr := useMiniredis(t)
+	cfg := &config.Config{}
+	cfg.JWT.ExpireHours = 2
+	cfg.Privacy.DeletionCoolingDays = 7
+	cfg.Privacy.ExportDir = t.TempDir()
+	useConfig(t, cfg)
+
+	db := newTestDB(t,
+		&model.User{},
+		&model.UserProfile{},
+		&model.UserAuth{},
+		&model.UserAddress{},
+		&model.Order{},
+		&model.OrderItem{},
+		&model.OrderPayment{},
+		&model.CartItem{},
+		&model.Product{},
+		&model.ProductFavorite{},
+		&model.UserFootprint{},
+		&model.Notification{},
+		&model.LoginLog{},
+		&model.UserSession{},
+		&model.PrivacyRequest{},
+	)
+
+	sender := newFakeSMSSender()
+	s := &privacyService{
+		privacyRepo:    repository.NewPrivacyRequestRepository(db),
+		userRepo:       repository.NewUserRepository(db),
+		smsService:     &smsService{sender: sender},
+		sessionService: NewSessionService(repository.NewUserSessionRepository(db)),
+	}
+	return s, sender, mr, db
+}
+
+func TestRequestDeletion(t *testing.T) {
+	s, sender, _, db := newTestPrivacyService(t)
+	user := createTestUser(t, db, "alice", "13800000001", "secret123")
+	noPhone := &model.User{Username: "wechat-only", WechatOpenID: "openid-wechat-only", Status: 1}
+	if err := db.Create(noPhone).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	if _, err := s.RequestDeletion(user.ID, &DeletionRequest{Code: "000000"}); err == nil {
+		t.Error("deletion without a valid code should fail")
+	}
+	if err := s.SendDeletionCode(noPhone.ID, "10.0.0.1"); err == nil {
+		t.Error("users without a phone should not receive a deletion code")
+	}
+	if err := s.SendDeletionCode(user.ID, "10.0.0.1"); err != nil {
+		t.Fatalf("SendDeletionCode: %v", err)
+	}
+
+	resp, err := s.RequestDeletion(user.ID, &DeletionRequest{Code: sender.codes[user.Phone]})
+	if err != nil {
+		t.Fatalf("RequestDeletion: %v", err)
+	}
+	var req model.PrivacyRequest
+	db.First(&req, resp.ID)
+	if d := time.Until(req.ScheduledAt); d < 7*24*time.Hour-time.Minute || d > 7*24*time.Hour {
+		t.Errorf("scheduled in %v, want the 7 day cooling period", d)
+	}
+
+	// 重复申请返回已有请求
+	again, err := s.RequestDeletion(noPhone.ID, &DeletionRequest{})
+	if err != nil {
+		t.Fatalf("RequestDeletion without phone: %v", err)
+	}
+	if dup, _ := s.RequestDeletion(noPhone.ID, &DeletionRequest{}); dup == nil || dup.ID != again.ID {
+		t.Errorf("duplicate request = %+v, want %d", dup, again.ID)
+	}
+
+	if err := s.CancelDeletion(user.ID); err != nil {
+		t.Fatalf("CancelDeletion: %v", err)
+	}
+	if err := s.CancelDeletion(user.ID); err == nil {
+		t.Error("cancelling twice should fail")
+	}
+	db.First(&req, resp.ID)
+	if req.Status != repository.PrivacyStatusCancelled {
+		t.Errorf("status = %d, want cancelled", req.Status)
+	}
+}
+
+func TestProcessDeletions(t *testing.T) {
+	s, _, _, db := newTestPrivacyService(t)
+	due := createTestUser(t, db, "alice", "13800000001", "secret123")
+	cooling := createTestUser(t, db, "bob", "13800000002", "secret123")
+
+	now := time.Now()
+	dueReq := &model.PrivacyRequest{UserID: due.ID, Type: PrivacyRequestDelete, ScheduledAt: now.Add(-time.Minute)}
+	coolingReq := &model.PrivacyRequest{UserID: cooling.ID, Type: PrivacyRequestDelete, ScheduledAt: now.Add(time.Hour)}
+	for _, req := range []*model.PrivacyRequest{dueReq, coolingReq} {
+		if err := db.Create(req).Error; err != nil {
+			t.Fatalf("create request: %v", err)
+		}
+	}
+	session, err := s.sessionService.CreateSession(due.ID, "web", nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	token := &utils.Claims{UserID: int64(due.ID), Role: "user", RegisteredClaims: jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(now)}}
+
+	if err := s.ProcessDeletions(); err != nil {
+		t.Fatalf("ProcessDeletions: %v", err)
+	}
+
+	cases := []struct {
+		name       string
+		req        *model.PrivacyRequest
+		user       *model.User
+		wantStatus int8
+		wantGone   bool
+	}{
+		{"due request anonymizes user", dueReq, due, repository.PrivacyStatusCompleted, true},
+		{"cooling period not over", coolingReq, cooling, repository.PrivacyStatusPending, false},
+	}
+	for _, tc := range cases {
+		var req model.PrivacyRequest
+		db.First(&req, tc.req.ID)
+		if req.Status != tc.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tc.name, req.Status, tc.wantStatus)
+		}
+		_, err := s.userRepo.GetByPhone(tc.user.Phone)
+		if gone := err != nil; gone != tc.wantGone {
+			t.Errorf("%s: user gone = %v, want %v", tc.name, gone, tc.wantGone)
+		}
+	}
+
+	if sessionValid(due.ID, session.SessionID) {
+		t.Error("sessions should be revoked after deletion")
+	}
+	if !utils.IsTokenRevoked(token) {
+		t.Error("tokens issued before deletion should be revoked")
+	}
+}
+
+func TestProcessExports(t *testing.T) {
+	s, _, _, db := newTestPrivacyService(t)
+	user := createTestUser(t, db, "alice", "13800000001", "secret123")
+	order := &model.Order{OrderNo: "ORD1", UserID: user.ID, PayAmount: 18, ReceiverName: "Alice"}
+	if err := db.Create(order).Error; err != nil {
+		t.Fatalf("create order: %v", err)
+	}
+
+	resp, err := s.RequestExport(user.ID)
+	if err != nil {
+		t.Fatalf("RequestExport: %v", err)
+	}
+	if _, err := s.GetExportFile(user.ID, resp.ID); err == nil {
+		t.Error("export should not be downloadable before it is generated")
+	}
+	if err := s.ProcessExports(); err != nil {
+		t.Fatalf("ProcessExports: %v", err)
+	}
+
+	if _, err := s.GetExportFile(user.ID+1, resp.ID); err == nil {
+		t.Error("other users should not download the export")
+	}
+	path, err := s.GetExportFile(user.ID, resp.ID)
+	if err != nil {
+		t.Fatalf("GetExportFile: %v", err)
+	}
+	if !strings.HasPrefix(path, config.GetConfig().Privacy.ExportDir) {
+		t.Errorf("export written to %s", path)
+	}
+
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("open archive: %v", err)
+	}
+	defer archive.Close()
+
+	entries := make(map[string]string)
+	for _, f := range archive.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		entries[f.Name] = string(data)
+	}
+	if !strings.Contains(entries["account.json"], user.Phone) || !strings.Contains(entries["orders.json"], "ORD1") {
+		t.Errorf("archive missing user data: %v", entries)
+	}
+	for name, data := range entries {
+		if strings.Contains(data, "password_hash") || strings.Contains(data, "$2a$") {
+			t.Errorf("%s leaks password hash", name)
+		}
+	}
+}
+
+func TestDeletionCodeScene(t *testing.T) {
+	s, sender, _, db := newTestPrivacyService(t)
+	user := createTestUser(t, db, "alice", "13800000001", "secret123")
+
+	if err := s.smsService.SendCode(sms.SceneLogin, user.Phone, "10.0.0.1"); err != nil {
+		t.Fatalf("SendCode: %v", err)
+	}
+	if _, err := s.RequestDeletion(user.ID, &DeletionRequest{Code: sender.codes[user.Phone]}); err == nil {
+		t.Error("login code should not authorize deletion")
+	}
+}
@@ -1,27 +1,52 @@
 package service
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
 
 	"mall/internal/model"
 	"mall/internal/repository"
+	"mall/pkg/logger"
 )
 
 // ProductService 商品服务接口
 type ProductService interface {
-	CreateProduct(req *CreateProductRequest) error
-	UpdateProduct(id uint64, req *UpdateProductRequest) error
+	CreateProduct(req *CreateProductRequest, operatorID uint64) error
+	UpdateProduct(id uint64, req *UpdateProductRequest, operatorID uint64) error
 	DeleteProduct(id uint64) error
 	GetProduct(id uint64) (*ProductResponse, error)
-	GetProductDetail(id uint64) (*ProductDetailResponse, error)
+	GetProductDetail(id, userID uint64) (*ProductDetailResponse, error)
 	GetProductList(req *ProductListRequest) (*ProductListResponse, error)
 	GetProductsByCategory(categoryID uint64, req *ProductListRequest) (*ProductListResponse, error)
 	SearchProducts(req *SearchProductRequest) (*ProductListResponse, error)
 	GetHotProducts(limit int) ([]*ProductResponse, error)
-	UpdateProductStock(id uint64, stock int) error
+	UpdateProductStock(id uint64, stock int, operatorID uint64) error
+	ListProductImages(productID uint64) ([]ProductImageResponse, error)
+	AddProductImage(productID uint64, req *ProductImageRequest) (*ProductImageResponse, error)
+	RemoveProductImage(productID, imageID uint64) error
+	ReorderProductImages(productID uint64, req *ReorderProductImagesRequest) error
+	SetMainProductImage(productID, imageID uint64) error
+	GetProductSpecs(productID uint64) ([]ProductSpecResponse, error)
+	SaveProductSpecs(productID uint64, req *SaveProductSpecsRequest) ([]ProductSpecResponse, error)
+	GenerateSKUs(productID uint64, req *GenerateSKUsRequest, operatorID uint64) ([]ProductSKUResponse, error)
+	SetListingSchedule(id uint64, req *ListingScheduleRequest) error
+	GetUpcomingLaunches(req *UpcomingLaunchRequest) (*ProductListResponse, error)
+	ProcessListingSchedules() error
 }
 
+// maxSpecCombinations 单个商品允许生成的规格组合上限
+const maxSpecCombinations = 500
+
+// listingBatchSize 每轮处理的定时上下架商品数
+const listingBatchSize = 200
+
 // CreateProductRequest 创建商品请求
 type CreateProductRequest struct {
 	CategoryID    uint64                `json:"category_id" binding:"required"`
@@ -30,10 +55,24 @@ type CreateProductRequest struct {
 	Description   string                `json:"description"`
 	Price         float64               `json:"price" binding:"required"`
 	OriginalPrice float64               `json:"original_price"`
-	Stock         int                   `json:"stock"`
+	Stock         int                   `json:"stock" binding:"gte=0"`
 	SortOrder     int                   `json:"sort_order"`
-	Images        []ProductImageRequest `json:"images"`
-	SKUs          []ProductSKURequest   `json:"skus"`
+	Images        []ProductImageRequest `json:"images" binding:"omitempty,dive"`
+	SKUs          []ProductSKURequest   `json:"skus" binding:"omitempty,dive"`
+	PublishAt     *time.Time            `json:"publish_at"` // 设置后商品以下架状态创建，到期自动上架
+	UnpublishAt   *time.Time            `json:"unpublish_at"`
+}
+
+// ListingScheduleRequest 定时上下架请求，字段为空表示清除对应时间
+type ListingScheduleRequest struct {
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+}
+
+// UpcomingLaunchRequest 待上架商品查询请求
+type UpcomingLaunchRequest struct {
+	Page     int `json:"page" form:"page"`
+	PageSize int `json:"page_size" form:"page_size"`
 }
 
 // UpdateProductRequest 更新商品请求
@@ -44,9 +83,11 @@ type UpdateProductRequest struct {
 	Description   string  `json:"description"`
 	Price         float64 `json:"price"`
 	OriginalPrice float64 `json:"original_price"`
-	Stock         int     `json:"stock"`
-	Status        int8    `json:"status"`
+	Stock         *int    `json:"stock" binding:"omitempty,gte=0"`      // 不传时不修改库存
+	Status        *int8   `json:"status" binding:"omitempty,oneof=0 1"` // 不传时不修改状态
 	SortOrder     int     `json:"sort_order"`
+	// SKUs 商品的完整SKU列表，不传时不修改SKU，传空数组时删除全部SKU
+	SKUs []ProductSKURequest `json:"skus" binding:"omitempty,dive"`
 }
 
 // ProductImageRequest 商品图片请求
@@ -56,14 +97,47 @@ type ProductImageRequest struct {
 	IsMain    int8   `json:"is_main"`
 }
 
-// ProductSKURequest 商品SKU请求
+// ReorderProductImagesRequest 图片排序请求，按顺序传入商品全部图片ID
+type ReorderProductImagesRequest struct {
+	ImageIDs []uint64 `json:"image_ids" binding:"required,min=1"`
+}
+
+// ProductSpecRequest 商品规格请求
+type ProductSpecRequest struct {
+	ID     uint64                    `json:"id"` // 已有规格ID，传入时原地更新（可改名）
+	Name   string                    `json:"name" binding:"required,max=50"`
+	Values []ProductSpecValueRequest `json:"values" binding:"required,min=1,max=50,dive"`
+}
+
+// ProductSpecValueRequest 商品规格值请求
+type ProductSpecValueRequest struct {
+	ID    uint64 `json:"id"` // 已有规格值ID，传入时原地更新（可改名），关联SKU保持不变
+	Value string `json:"value" binding:"required,max=100"`
+	Image string `json:"image" binding:"max=500"`
+}
+
+// SaveProductSpecsRequest 保存商品规格请求，传入商品的全部规格
+type SaveProductSpecsRequest struct {
+	Specs []ProductSpecRequest `json:"specs" binding:"max=5,dive"`
+}
+
+// GenerateSKUsRequest 生成SKU矩阵请求，价格与库存只用于新建的SKU
+type GenerateSKUsRequest struct {
+	Price         float64 `json:"price" binding:"omitempty,gt=0"` // 默认使用商品价格
+	Stock         int     `json:"stock" binding:"gte=0"`
+	SKUCodePrefix string  `json:"sku_code_prefix" binding:"max=50"` // 默认为P加商品ID
+}
+
+// ProductSKURequest 商品SKU请求，更新商品时带ID按ID更新，否则按编码匹配
 type ProductSKURequest struct {
-	SKUCode    string  `json:"sku_code" binding:"required"`
+	ID         uint64  `json:"id"`
+	SKUCode    string  `json:"sku_code" binding:"required,max=100"`
 	Name       string  `json:"name"`
-	Price      float64 `json:"price" binding:"required"`
-	Stock      int     `json:"stock"`
+	Price      float64 `json:"price" binding:"required,gt=0"`
+	Stock      int     `json:"stock" binding:"gte=0"`
 	AttrValues string  `json:"attr_values"`
 	Image      string  `json:"image"`
+	Status     *int8   `json:"status" binding:"omitempty,oneof=0 1"` // 默认上架
 }
 
 // ProductListRequest 商品列表请求
@@ -93,24 +167,45 @@ type ProductResponse struct {
 	Stock         int     `json:"stock"`
 	Sales         int     `json:"sales"`
 	Status        int8    `json:"status"`
+	FavoriteCount int     `json:"favorite_count"`
 	MainImage     string  `json:"main_image"`
+	PublishAt     string  `json:"publish_at,omitempty"`
+	UnpublishAt   string  `json:"unpublish_at,omitempty"`
 }
 
 // ProductDetailResponse 商品详情响应
 type ProductDetailResponse struct {
-	ID            uint64                  `json:"id"`
-	CategoryID    uint64                  `json:"category_id"`
-	CategoryName  string                  `json:"category_name"`
-	Name          string                  `json:"name"`
-	Subtitle      string                  `json:"subtitle"`
-	Description   string                  `json:"description"`
-	Price         float64                 `json:"price"`
-	OriginalPrice float64                 `json:"original_price"`
-	Stock         int                     `json:"stock"`
-	Sales         int                     `json:"sales"`
-	Status        int8                    `json:"status"`
-	Images        []ProductImageResponse  `json:"images"`
-	SKUs          []ProductSKUResponse    `json:"skus"`
+	ID            uint64                 `json:"id"`
+	CategoryID    uint64                 `json:"category_id"`
+	CategoryName  string                 `json:"category_name"`
+	Name          string                 `json:"name"`
+	Subtitle      string                 `json:"subtitle"`
+	Description   string                 `json:"description"`
+	Price         float64                `json:"price"`
+	OriginalPrice float64                `json:"original_price"`
+	Stock         int                    `json:"stock"`
+	Sales         int                    `json:"sales"`
+	Status        int8                   `json:"status"`
+	FavoriteCount int                    `json:"favorite_count"`
+	IsFavorited   bool                   `json:"is_favorited"`
+	Images        []ProductImageResponse `json:"images"`
+	SKUs          []ProductSKUResponse   `json:"skus"`
+	Specs         []ProductSpecResponse  `json:"specs"`
+	SKUMap        map[string]uint64      `json:"sku_map"` // 规格值ID升序逗号拼接 -> 可售SKU ID
+}
+
+// ProductSpecResponse 商品规格响应
+type ProductSpecResponse struct {
+	ID     uint64                     `json:"id"`
+	Name   string                     `json:"name"`
+	Values []ProductSpecValueResponse `json:"values"`
+}
+
+// ProductSpecValueResponse 商品规格值响应
+type ProductSpecValueResponse struct {
+	ID    uint64 `json:"id"`
+	Value string `json:"value"`
+	Image string `json:"image"`
 }
 
 // ProductImageResponse 商品图片响应
@@ -123,14 +218,15 @@ type ProductImageResponse struct {
 
 // ProductSKUResponse 商品SKU响应
 type ProductSKUResponse struct {
-	ID         uint64  `json:"id"`
-	SKUCode    string  `json:"sku_code"`
-	Name       string  `json:"name"`
-	Price      float64 `json:"price"`
-	Stock      int     `json:"stock"`
-	AttrValues string  `json:"attr_values"`
-	Image      string  `json:"image"`
-	Status     int8    `json:"status"`
+	ID           uint64   `json:"id"`
+	SKUCode      string   `json:"sku_code"`
+	Name         string   `json:"name"`
+	Price        float64  `json:"price"`
+	Stock        int      `json:"stock"`
+	AttrValues   string   `json:"attr_values"`
+	SpecValueIDs []uint64 `json:"spec_value_ids"`
+	Image        string   `json:"image"`
+	Status       int8     `json:"status"`
 }
 
 // ProductListResponse 商品列表响应
@@ -147,6 +243,12 @@ type productService struct {
 	productRepo    repository.ProductRepository
 	categoryRepo   repository.CategoryRepository
 	productSKURepo repository.ProductSKURepository
+	favoriteRepo   repository.ProductFavoriteRepository
+	imageRepo      repository.ProductImageRepository
+	specRepo       repository.ProductSpecRepository
+	inventory      InventoryService
+	pricing        PriceService
+	search         SearchService
 }
 
 // NewProductService 创建商品服务
@@ -154,23 +256,44 @@ func NewProductService(
 	productRepo repository.ProductRepository,
 	categoryRepo repository.CategoryRepository,
 	productSKURepo repository.ProductSKURepository,
+	favoriteRepo repository.ProductFavoriteRepository,
+	imageRepo repository.ProductImageRepository,
+	specRepo repository.ProductSpecRepository,
+	inventory InventoryService,
+	pricing PriceService,
+	search SearchService,
 ) ProductService {
 	return &productService{
 		productRepo:    productRepo,
 		categoryRepo:   categoryRepo,
 		productSKURepo: productSKURepo,
+		favoriteRepo:   favoriteRepo,
+		imageRepo:      imageRepo,
+		specRepo:       specRepo,
+		inventory:      inventory,
+		pricing:        pricing,
+		search:         search,
 	}
 }
 
-// CreateProduct 创建商品
-func (s *productService) CreateProduct(req *CreateProductRequest) error {
+// CreateProduct 创建商品，初始库存在商品创建后通过库存服务写入
+func (s *productService) CreateProduct(req *CreateProductRequest, operatorID uint64) error {
 	// 验证分类是否存在
 	_, err := s.categoryRepo.GetByID(req.CategoryID)
 	if err != nil {
 		return errors.New("category not found")
 	}
 
-	// 创建商品
+	if err := validateListingSchedule(req.PublishAt, req.UnpublishAt); err != nil {
+		return err
+	}
+
+	skus, err := s.buildProductSKUs(0, req.SKUs)
+	if err != nil {
+		return err
+	}
+
+	// 创建商品，定时上架的商品先以下架状态创建
 	product := &model.Product{
 		CategoryID:    req.CategoryID,
 		Name:          req.Name,
@@ -178,54 +301,40 @@ func (s *productService) CreateProduct(req *CreateProductRequest) error {
 		Description:   req.Description,
 		Price:         req.Price,
 		OriginalPrice: req.OriginalPrice,
-		Stock:         req.Stock,
 		Sales:         0,
 		Status:        1,
 		SortOrder:     req.SortOrder,
+		Images:        buildProductImages(req.Images),
+		SKUs:          skus,
+		PublishAt:     req.PublishAt,
+		UnpublishAt:   req.UnpublishAt,
 	}
-
-	if err := s.productRepo.Create(product); err != nil {
-		return err
+	if req.PublishAt != nil {
+		product.Status = 0
 	}
 
-	// 创建商品图片
-	for _, imgReq := range req.Images {
-		image := &model.ProductImage{
-			ProductID: product.ID,
-			ImageURL:  imgReq.ImageURL,
-			SortOrder: imgReq.SortOrder,
-			IsMain:    imgReq.IsMain,
-		}
-		// TODO: 保存商品图片
-		_ = image
-	}
-
-	// 创建商品SKU
-	for _, skuReq := range req.SKUs {
-		sku := &model.ProductSKU{
-			ProductID:  product.ID,
-			SKUCode:    skuReq.SKUCode,
-			Name:       skuReq.Name,
-			Price:      skuReq.Price,
-			Stock:      skuReq.Stock,
-			AttrValues: skuReq.AttrValues,
-			Image:      skuReq.Image,
-			Status:     1,
-		}
-		if err := s.productSKURepo.Create(sku); err != nil {
-			return fmt.Errorf("failed to create SKU: %v", err)
-		}
+	// 商品图片与SKU作为关联与商品在同一事务中创建，库存先置0
+	skuStocks := make([]int, len(product.SKUs))
+	for i := range product.SKUs {
+		skuStocks[i] = product.SKUs[i].Stock
+		product.SKUs[i].Stock = 0
 	}
-
-	return nil
+	return s.productRepo.Create(product, func(tx *gorm.DB) error {
+		levels := []StockLevel{{ProductID: product.ID, Stock: req.Stock}}
+		for i := range product.SKUs {
+			levels = append(levels, StockLevel{ProductID: product.ID, SKUID: product.SKUs[i].ID, Stock: skuStocks[i]})
+		}
+		return s.inventory.SetStocks(tx, levels, InventoryReasonAdjust, strconv.FormatUint(product.ID, 10), operatorID)
+	})
 }
 
-// UpdateProduct 更新商品
-func (s *productService) UpdateProduct(id uint64, req *UpdateProductRequest) error {
+// UpdateProduct 更新商品，库存变化通过库存服务记录，改价写入价格历史
+func (s *productService) UpdateProduct(id uint64, req *UpdateProductRequest, operatorID uint64) error {
 	product, err := s.productRepo.GetByID(id)
 	if err != nil {
 		return errors.New("product not found")
 	}
+	priceChanges := []PriceChange{{ProductID: id, OldPrice: product.Price}}
 
 	// 更新字段
 	if req.CategoryID > 0 {
@@ -251,17 +360,149 @@ func (s *productService) UpdateProduct(id uint64, req *UpdateProductRequest) err
 	if req.OriginalPrice > 0 {
 		product.OriginalPrice = req.OriginalPrice
 	}
-	if req.Stock >= 0 {
-		product.Stock = req.Stock
-	}
-	if req.Status >= 0 {
-		product.Status = req.Status
+	// 手动修改状态后之前设置的定时上下架不再执行
+	statusChanged := req.Status != nil && *req.Status != product.Status
+	if statusChanged {
+		product.Status = *req.Status
 	}
 	if req.SortOrder >= 0 {
 		product.SortOrder = req.SortOrder
 	}
 
-	return s.productRepo.Update(product)
+	priceChanges[0].NewPrice = product.Price
+
+	var levels []StockLevel
+	if req.Stock != nil {
+		levels = append(levels, StockLevel{ProductID: id, Stock: *req.Stock})
+	}
+
+	// 库存、价格历史与定时上下架的清除在商品更新的同一事务中写入
+	inTx := func(tx *gorm.DB) error {
+		if statusChanged {
+			if err := s.productRepo.ClearListingSchedule(tx, id); err != nil {
+				return err
+			}
+		}
+		if len(levels) > 0 {
+			if err := s.inventory.SetStocks(tx, levels, InventoryReasonAdjust, strconv.FormatUint(id, 10), operatorID); err != nil {
+				return err
+			}
+		}
+		return s.pricing.RecordManualChanges(tx, priceChanges, operatorID)
+	}
+
+	if req.SKUs == nil {
+		if err := s.productRepo.Update(product, inTx); err != nil {
+			return err
+		}
+	} else {
+		skus, err := s.buildProductSKUs(id, req.SKUs)
+		if err != nil {
+			return err
+		}
+		skuStocks := make([]int, len(skus))
+		skuPtrs := make([]*model.ProductSKU, 0, len(skus))
+		for i := range skus {
+			skuStocks[i] = skus[i].Stock
+			skuPtrs = append(skuPtrs, &skus[i])
+		}
+
+		oldPrices := make(map[uint64]float64)
+		if existing, err := s.productSKURepo.GetByProductID(id); err == nil {
+			for _, sku := range existing {
+				oldPrices[sku.ID] = sku.Price
+			}
+		}
+
+		// SKU写入后才有ID，在同一事务中按ID写入库存与改价记录
+		err = s.productRepo.UpdateWithSKUs(product, skuPtrs, func(tx *gorm.DB) error {
+			for i := range skus {
+				levels = append(levels, StockLevel{ProductID: id, SKUID: skus[i].ID, Stock: skuStocks[i]})
+				if oldPrice, ok := oldPrices[skus[i].ID]; ok {
+					priceChanges = append(priceChanges, PriceChange{ProductID: id, SKUID: skus[i].ID, OldPrice: oldPrice, NewPrice: skus[i].Price})
+				}
+			}
+			return inTx(tx)
+		})
+		if err != nil {
+			if errors.Is(err, repository.ErrSKUNotInProduct) {
+				return errors.New("sku not found in product")
+			}
+			return err
+		}
+	}
+
+	// 状态与价格变化需要同步到搜索索引
+	reindexProduct(s.search, s.productRepo, id)
+	return nil
+}
+
+// buildProductSKUs 校验并构建SKU，编码在写入前统一检查：请求内不能重复，也不能被其他商品的SKU占用
+func (s *productService) buildProductSKUs(productID uint64, reqs []ProductSKURequest) ([]model.ProductSKU, error) {
+	codes := make([]string, 0, len(reqs))
+	seenCodes := make(map[string]bool, len(reqs))
+	seenIDs := make(map[uint64]bool, len(reqs))
+	skus := make([]model.ProductSKU, 0, len(reqs))
+	for _, req := range reqs {
+		code := strings.TrimSpace(req.SKUCode)
+		if code == "" {
+			return nil, errors.New("sku code is required")
+		}
+		if seenCodes[code] {
+			return nil, fmt.Errorf("duplicate sku code %s", code)
+		}
+		seenCodes[code] = true
+		codes = append(codes, code)
+
+		if req.ID > 0 {
+			if productID == 0 {
+				return nil, errors.New("sku id is not allowed when creating product")
+			}
+			if seenIDs[req.ID] {
+				return nil, fmt.Errorf("duplicate sku id %d", req.ID)
+			}
+			seenIDs[req.ID] = true
+		}
+
+		attrValues := req.AttrValues
+		if attrValues == "" {
+			attrValues = "{}"
+		} else if !json.Valid([]byte(attrValues)) {
+			return nil, fmt.Errorf("attr_values of sku %s is not valid JSON", code)
+		}
+
+		status := int8(1)
+		if req.Status != nil {
+			status = *req.Status
+		}
+
+		skus = append(skus, model.ProductSKU{
+			BaseModel:  model.BaseModel{ID: req.ID},
+			SKUCode:    code,
+			Name:       req.Name,
+			Price:      req.Price,
+			Stock:      req.Stock,
+			AttrValues: attrValues,
+			Image:      req.Image,
+			Status:     status,
+		})
+	}
+
+	used, err := s.productSKURepo.FindByCodes(codes)
+	if err != nil {
+		return nil, err
+	}
+	var conflicts []string
+	for _, sku := range used {
+		if productID == 0 || sku.ProductID != productID {
+			conflicts = append(conflicts, sku.SKUCode)
+		}
+	}
+	if len(conflicts) > 0 {
+		return nil, fmt.Errorf("sku code already in use: %s", strings.Join(conflicts, ", "))
+	}
+
+	return skus, nil
 }
 
 // DeleteProduct 删除商品
@@ -280,14 +521,36 @@ func (s *productService) GetProduct(id uint64) (*ProductResponse, error) {
 	return s.toProductResponse(product), nil
 }
 
-// GetProductDetail 获取商品详情
-func (s *productService) GetProductDetail(id uint64) (*ProductDetailResponse, error) {
+// GetProductDetail 获取商品详情，userID为0表示未登录
+func (s *productService) GetProductDetail(id, userID uint64) (*ProductDetailResponse, error) {
 	product, err := s.productRepo.GetWithDetails(id)
 	if err != nil {
 		return nil, errors.New("product not found")
 	}
 
-	return s.toProductDetailResponse(product), nil
+	response := s.toProductDetailResponse(product)
+
+	specs, err := s.specRepo.GetByProductID(id)
+	if err != nil {
+		return nil, err
+	}
+	response.Specs = toProductSpecResponses(specs)
+	response.SKUMap = make(map[string]uint64)
+	for _, sku := range product.SKUs {
+		if sku.Status == 1 && repository.SpecKeyMatches(sku.SpecKey, specs) {
+			response.SKUMap[sku.SpecKey] = sku.ID
+		}
+	}
+
+	if userID > 0 {
+		favorited, err := s.favoriteRepo.Exists(userID, id)
+		if err != nil {
+			return nil, err
+		}
+		response.IsFavorited = favorited
+	}
+
+	return response, nil
 }
 
 // GetProductList 获取商品列表
@@ -377,9 +640,263 @@ func (s *productService) GetHotProducts(limit int) ([]*ProductResponse, error) {
 	return result, nil
 }
 
-// UpdateProductStock 更新商品库存
-func (s *productService) UpdateProductStock(id uint64, stock int) error {
-	return s.productRepo.UpdateStock(id, stock)
+// UpdateProductStock 更新商品库存，按差额记录库存流水
+func (s *productService) UpdateProductStock(id uint64, stock int, operatorID uint64) error {
+	if _, err := s.productRepo.GetByID(id); err != nil {
+		return errors.New("product not found")
+	}
+	return s.inventory.SetStocks(nil, []StockLevel{{ProductID: id, Stock: stock}}, InventoryReasonAdjust, strconv.FormatUint(id, 10), operatorID)
+}
+
+// ListProductImages 获取商品图片
+func (s *productService) ListProductImages(productID uint64) ([]ProductImageResponse, error) {
+	if _, err := s.productRepo.GetByID(productID); err != nil {
+		return nil, errors.New("product not found")
+	}
+
+	images, err := s.imageRepo.GetByProductID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ProductImageResponse, 0, len(images))
+	for _, image := range images {
+		result = append(result, toProductImageResponse(image))
+	}
+	return result, nil
+}
+
+// AddProductImage 添加商品图片
+func (s *productService) AddProductImage(productID uint64, req *ProductImageRequest) (*ProductImageResponse, error) {
+	if _, err := s.productRepo.GetByID(productID); err != nil {
+		return nil, errors.New("product not found")
+	}
+
+	image := &model.ProductImage{
+		ProductID: productID,
+		ImageURL:  req.ImageURL,
+		SortOrder: req.SortOrder,
+		IsMain:    req.IsMain,
+	}
+	if err := s.imageRepo.Add(image); err != nil {
+		return nil, err
+	}
+
+	// 首张图片会被自动设为主图，重新读取以返回实际状态
+	images, err := s.imageRepo.GetByProductID(productID)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range images {
+		if item.ID == image.ID {
+			image = item
+			break
+		}
+	}
+
+	response := toProductImageResponse(image)
+	return &response, nil
+}
+
+// RemoveProductImage 删除商品图片
+func (s *productService) RemoveProductImage(productID, imageID uint64) error {
+	removed, err := s.imageRepo.Remove(productID, imageID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return errors.New("image not found")
+	}
+	return nil
+}
+
+// ReorderProductImages 调整商品图片顺序
+func (s *productService) ReorderProductImages(productID uint64, req *ReorderProductImagesRequest) error {
+	if _, err := s.productRepo.GetByID(productID); err != nil {
+		return errors.New("product not found")
+	}
+
+	if err := s.imageRepo.Reorder(productID, req.ImageIDs); err != nil {
+		if errors.Is(err, repository.ErrImageOrderMismatch) {
+			return errors.New("image ids must include every image of the product exactly once")
+		}
+		return err
+	}
+	return nil
+}
+
+// SetMainProductImage 设置商品主图
+func (s *productService) SetMainProductImage(productID, imageID uint64) error {
+	found, err := s.imageRepo.SetMain(productID, imageID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.New("image not found")
+	}
+	return nil
+}
+
+// GetProductSpecs 获取商品规格
+func (s *productService) GetProductSpecs(productID uint64) ([]ProductSpecResponse, error) {
+	if _, err := s.productRepo.GetByID(productID); err != nil {
+		return nil, errors.New("product not found")
+	}
+
+	specs, err := s.specRepo.GetByProductID(productID)
+	if err != nil {
+		return nil, err
+	}
+	return toProductSpecResponses(specs), nil
+}
+
+// SaveProductSpecs 保存商品规格，规格组合失效的SKU会被停用
+func (s *productService) SaveProductSpecs(productID uint64, req *SaveProductSpecsRequest) ([]ProductSpecResponse, error) {
+	if _, err := s.productRepo.GetByID(productID); err != nil {
+		return nil, errors.New("product not found")
+	}
+
+	combinations := 1
+	specNames := make(map[string]bool, len(req.Specs))
+	specs := make([]*model.ProductSpec, 0, len(req.Specs))
+	for _, specReq := range req.Specs {
+		name := strings.TrimSpace(specReq.Name)
+		if name == "" || specNames[name] {
+			return nil, fmt.Errorf("spec name %q is empty or duplicated", specReq.Name)
+		}
+		specNames[name] = true
+
+		spec := &model.ProductSpec{BaseModel: model.BaseModel{ID: specReq.ID}, Name: name}
+		values := make(map[string]bool, len(specReq.Values))
+		for _, valueReq := range specReq.Values {
+			value := strings.TrimSpace(valueReq.Value)
+			if value == "" || values[value] {
+				return nil, fmt.Errorf("value %q of spec %s is empty or duplicated", valueReq.Value, name)
+			}
+			values[value] = true
+			spec.Values = append(spec.Values, model.ProductSpecValue{
+				BaseModel: model.BaseModel{ID: valueReq.ID},
+				Value:     value,
+				Image:     valueReq.Image,
+			})
+		}
+		specs = append(specs, spec)
+
+		combinations *= len(spec.Values)
+		if combinations > maxSpecCombinations {
+			return nil, fmt.Errorf("spec combinations exceed %d", maxSpecCombinations)
+		}
+	}
+
+	if err := s.specRepo.Save(productID, specs); err != nil {
+		if errors.Is(err, repository.ErrSpecNotFound) {
+			return nil, errors.New("spec or spec value id is invalid or duplicated")
+		}
+		return nil, err
+	}
+	return toProductSpecResponses(specs), nil
+}
+
+// GenerateSKUs 按规格生成笛卡尔积SKU矩阵，已有组合保留原价格与库存
+func (s *productService) GenerateSKUs(productID uint64, req *GenerateSKUsRequest, operatorID uint64) ([]ProductSKUResponse, error) {
+	product, err := s.productRepo.GetByID(productID)
+	if err != nil {
+		return nil, errors.New("product not found")
+	}
+
+	specs, err := s.specRepo.GetByProductID(productID)
+	if err != nil {
+		return nil, err
+	}
+	if len(specs) == 0 {
+		return nil, errors.New("product has no specs")
+	}
+
+	price := req.Price
+	if price <= 0 {
+		price = product.Price
+	}
+	prefix := req.SKUCodePrefix
+	if prefix == "" {
+		prefix = fmt.Sprintf("P%d", productID)
+	}
+
+	// 笛卡尔积，组合内规格值按规格顺序排列
+	combinations := [][]model.ProductSpecValue{{}}
+	for _, spec := range specs {
+		if len(spec.Values) == 0 {
+			return nil, fmt.Errorf("spec %s has no values", spec.Name)
+		}
+		next := make([][]model.ProductSpecValue, 0, len(combinations)*len(spec.Values))
+		for _, combination := range combinations {
+			for _, value := range spec.Values {
+				item := make([]model.ProductSpecValue, len(combination), len(combination)+1)
+				copy(item, combination)
+				next = append(next, append(item, value))
+			}
+		}
+		combinations = next
+	}
+
+	skus := make([]*model.ProductSKU, 0, len(combinations))
+	for _, combination := range combinations {
+		ids := make([]uint64, 0, len(combination))
+		names := make([]string, 0, len(combination))
+		attrs := make(map[string]string, len(combination))
+		for i, value := range combination {
+			ids = append(ids, value.ID)
+			names = append(names, value.Value)
+			attrs[specs[i].Name] = value.Value
+		}
+		attrValues, _ := json.Marshal(attrs)
+		specKey := repository.BuildSpecKey(ids)
+
+		skus = append(skus, &model.ProductSKU{
+			SKUCode:    prefix + "-" + strings.ReplaceAll(specKey, ",", "-"),
+			Name:       strings.Join(names, " "),
+			Price:      price,
+			Stock:      req.Stock,
+			AttrValues: string(attrValues),
+			SpecKey:    specKey,
+			Status:     1,
+		})
+	}
+
+	// 新建的SKU以0库存创建，再在同一事务中通过库存服务写入初始库存
+	created, err := s.productSKURepo.SyncSpecMatrix(productID, skus, func(tx *gorm.DB, created []uint64) error {
+		if len(created) == 0 || req.Stock <= 0 {
+			return nil
+		}
+		levels := make([]StockLevel, 0, len(created))
+		for _, skuID := range created {
+			levels = append(levels, StockLevel{ProductID: productID, SKUID: skuID, Stock: req.Stock})
+		}
+		return s.inventory.SetStocks(tx, levels, InventoryReasonAdjust, strconv.FormatUint(productID, 10), operatorID)
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrSKUCodeExists) {
+			return nil, errors.New("generated sku code already exists, use another sku_code_prefix")
+		}
+		return nil, err
+	}
+
+	if len(created) > 0 && req.Stock > 0 {
+		createdSet := make(map[uint64]bool, len(created))
+		for _, skuID := range created {
+			createdSet[skuID] = true
+		}
+		for _, sku := range skus {
+			if createdSet[sku.ID] {
+				sku.Stock = req.Stock
+			}
+		}
+	}
+
+	result := make([]ProductSKUResponse, 0, len(skus))
+	for _, sku := range skus {
+		result = append(result, toProductSKUResponse(sku))
+	}
+	return result, nil
 }
 
 // GetProductsByCategory 按分类获取商品
@@ -408,6 +925,83 @@ func (s *productService) GetProductsByCategory(categoryID uint64, req *ProductLi
 	}, nil
 }
 
+// SetListingSchedule 设置商品定时上下架时间
+func (s *productService) SetListingSchedule(id uint64, req *ListingScheduleRequest) error {
+	if _, err := s.productRepo.GetByID(id); err != nil {
+		return errors.New("product not found")
+	}
+	if err := validateListingSchedule(req.PublishAt, req.UnpublishAt); err != nil {
+		return err
+	}
+	return s.productRepo.SetListingSchedule(id, req.PublishAt, req.UnpublishAt)
+}
+
+// GetUpcomingLaunches 分页获取待定时上架的商品
+func (s *productService) GetUpcomingLaunches(req *UpcomingLaunchRequest) (*ProductListResponse, error) {
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	products, total, err := s.productRepo.ListUpcomingLaunches(req.Page, req.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*ProductResponse, 0, len(products))
+	for _, product := range products {
+		items = append(items, s.toProductResponse(product))
+	}
+
+	totalPages := int((total + int64(req.PageSize) - 1) / int64(req.PageSize))
+
+	return &ProductListResponse{
+		Items:      items,
+		Total:      total,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// ProcessListingSchedules 执行到期的定时上下架，状态变化后更新搜索索引，由定时任务调用
+func (s *productService) ProcessListingSchedules() error {
+	now := time.Now()
+	products, err := s.productRepo.FindDueListings(now, listingBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, product := range products {
+		changed, err := s.productRepo.ApplyListingSchedule(product.ID, now)
+		if err != nil {
+			logger.Error("Failed to apply listing schedule", zap.Uint64("product_id", product.ID), zap.Error(err))
+			continue
+		}
+		if changed {
+			reindexProduct(s.search, s.productRepo, product.ID)
+		}
+	}
+	return nil
+}
+
+// validateListingSchedule 校验定时上下架时间：必须晚于当前时间，同时设置时下架须晚于上架
+func validateListingSchedule(publishAt, unpublishAt *time.Time) error {
+	now := time.Now()
+	if publishAt != nil && !publishAt.After(now) {
+		return errors.New("publish_at must be in the future")
+	}
+	if unpublishAt != nil && !unpublishAt.After(now) {
+		return errors.New("unpublish_at must be in the future")
+	}
+	if publishAt != nil && unpublishAt != nil && !unpublishAt.After(*publishAt) {
+		return errors.New("unpublish_at must be after publish_at")
+	}
+	return nil
+}
+
 // toProductResponse 转换为商品响应
 func (s *productService) toProductResponse(product *model.Product) *ProductResponse {
 	response := &ProductResponse{
@@ -420,6 +1014,13 @@ func (s *productService) toProductResponse(product *model.Product) *ProductRespo
 		Stock:         product.Stock,
 		Sales:         product.Sales,
 		Status:        product.Status,
+		FavoriteCount: product.FavoriteCount,
+	}
+	if product.PublishAt != nil {
+		response.PublishAt = product.PublishAt.Format("2006-01-02 15:04:05")
+	}
+	if product.UnpublishAt != nil {
+		response.UnpublishAt = product.UnpublishAt.Format("2006-01-02 15:04:05")
 	}
 
 	// 设置分类名称
@@ -451,6 +1052,7 @@ func (s *productService) toProductDetailResponse(product *model.Product) *Produc
 		Stock:         product.Stock,
 		Sales:         product.Sales,
 		Status:        product.Status,
+		FavoriteCount: product.FavoriteCount,
 	}
 
 	// 设置分类名称
@@ -459,28 +1061,86 @@ func (s *productService) toProductDetailResponse(product *model.Product) *Produc
 	}
 
 	// 设置图片
-	for _, image := range product.Images {
-		response.Images = append(response.Images, ProductImageResponse{
-			ID:        image.ID,
-			ImageURL:  image.ImageURL,
-			SortOrder: image.SortOrder,
-			IsMain:    image.IsMain,
-		})
+	for i := range product.Images {
+		response.Images = append(response.Images, toProductImageResponse(&product.Images[i]))
 	}
 
 	// 设置SKU
-	for _, sku := range product.SKUs {
-		response.SKUs = append(response.SKUs, ProductSKUResponse{
-			ID:         sku.ID,
-			SKUCode:    sku.SKUCode,
-			Name:       sku.Name,
-			Price:      sku.Price,
-			Stock:      sku.Stock,
-			AttrValues: sku.AttrValues,
-			Image:      sku.Image,
-			Status:     sku.Status,
-		})
+	for i := range product.SKUs {
+		response.SKUs = append(response.SKUs, toProductSKUResponse(&product.SKUs[i]))
 	}
 
 	return response
+}
+
+// toProductImageResponse 转换为商品图片响应
+func toProductImageResponse(image *model.ProductImage) ProductImageResponse {
+	return ProductImageResponse{
+		ID:        image.ID,
+		ImageURL:  image.ImageURL,
+		SortOrder: image.SortOrder,
+		IsMain:    image.IsMain,
+	}
+}
+
+// buildProductImages 构建商品图片，保证有且仅有一张主图：未指定时取排序最靠前的图片，指定多张时只保留第一张
+func buildProductImages(reqs []ProductImageRequest) []model.ProductImage {
+	images := make([]model.ProductImage, 0, len(reqs))
+	mainIndex := -1
+	for i, req := range reqs {
+		images = append(images, model.ProductImage{
+			ImageURL:  req.ImageURL,
+			SortOrder: req.SortOrder,
+		})
+		if req.IsMain == 1 && mainIndex < 0 {
+			mainIndex = i
+		}
+	}
+	if mainIndex < 0 {
+		for i := range images {
+			if mainIndex < 0 || images[i].SortOrder < images[mainIndex].SortOrder {
+				mainIndex = i
+			}
+		}
+	}
+	if mainIndex >= 0 {
+		images[mainIndex].IsMain = 1
+	}
+	return images
+}
+
+// toProductSKUResponse 转换为商品SKU响应
+func toProductSKUResponse(sku *model.ProductSKU) ProductSKUResponse {
+	return ProductSKUResponse{
+		ID:           sku.ID,
+		SKUCode:      sku.SKUCode,
+		Name:         sku.Name,
+		Price:        sku.Price,
+		Stock:        sku.Stock,
+		AttrValues:   sku.AttrValues,
+		SpecValueIDs: repository.ParseSpecKey(sku.SpecKey),
+		Image:        sku.Image,
+		Status:       sku.Status,
+	}
+}
+
+// toProductSpecResponses 转换为商品规格树
+func toProductSpecResponses(specs []*model.ProductSpec) []ProductSpecResponse {
+	result := make([]ProductSpecResponse, 0, len(specs))
+	for _, spec := range specs {
+		item := ProductSpecResponse{
+			ID:     spec.ID,
+			Name:   spec.Name,
+			Values: make([]ProductSpecValueResponse, 0, len(spec.Values)),
+		}
+		for _, value := range spec.Values {
+			item.Values = append(item.Values, ProductSpecValueResponse{
+				ID:    value.ID,
+				Value: value.Value,
+				Image: value.Image,
+			})
+		}
+		result = append(result, item)
+	}
+	return result
 }
\ No newline at end of file
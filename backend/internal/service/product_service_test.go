@@ -0,0 +1,189 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+	"mall/internal/repository"
+)
+
+// newTestProductService 基于内存SQLite创建商品服务
+func newTestProductService(t *testing.T) (*productService, *gorm.DB) {
+	t.Helper()
+
+	db := newTestDB(t,
+		&model.Product{},
+		&model.ProductSKU{},
+		&model.ProductSpec{},
+		&model.ProductSpecValue{},
+		&model.InventoryLedger{},
+		&model.PriceSchedule{},
+		&model.PriceHistory{},
+	)
+
+	productRepo := repository.NewProductRepository(db)
+	productSKURepo := repository.NewProductSKURepository(db)
+	return &productService{
+		productRepo:    productRepo,
+		productSKURepo: productSKURepo,
+		specRepo:       repository.NewProductSpecRepository(db),
+		inventory:      NewInventoryService(repository.NewInventoryRepository(db), nil),
+		pricing:        NewPriceService(repository.NewPriceRepository(db), productRepo, productSKURepo, nil),
+	}, db
+}
+
+func TestGenerateSKUs(t *testing.T) {
+	s, db := newTestProductService(t)
+	product := &model.Product{CategoryID: 1, Name: "测试商品", Price: 99, Status: 1}
+	if err := db.Create(product).Error; err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+
+	specs := []*model.ProductSpec{
+		{Name: "颜色", Values: []model.ProductSpecValue{{Value: "红"}, {Value: "蓝"}}},
+		{Name: "尺码", Values: []model.ProductSpecValue{{Value: "S"}, {Value: "M"}, {Value: "L"}}},
+	}
+	if err := s.specRepo.Save(product.ID, specs); err != nil {
+		t.Fatalf("save specs: %v", err)
+	}
+
+	skus, err := s.GenerateSKUs(product.ID, &GenerateSKUsRequest{Stock: 5}, 1)
+	if err != nil {
+		t.Fatalf("GenerateSKUs: %v", err)
+	}
+	if len(skus) != 6 {
+		t.Fatalf("generated %d skus, want 6", len(skus))
+	}
+
+	// 组合按规格顺序命名，默认使用商品价格与P加商品ID的编码前缀
+	first := skus[0]
+	red, small := specs[0].Values[0].ID, specs[1].Values[0].ID
+	wantKey := repository.BuildSpecKey([]uint64{red, small})
+	if first.Name != "红 S" || repository.BuildSpecKey(first.SpecValueIDs) != wantKey || first.Price != 99 || first.Stock != 5 {
+		t.Errorf("first sku = %+v", first)
+	}
+	if first.AttrValues != `{"尺码":"S","颜色":"红"}` {
+		t.Errorf("attr values = %s", first.AttrValues)
+	}
+	if want := fmt.Sprintf("P%d-%d-%d", product.ID, red, small); first.SKUCode != want {
+		t.Errorf("sku code = %s, want %s", first.SKUCode, want)
+	}
+	seen := make(map[string]bool)
+	for _, sku := range skus {
+		key := repository.BuildSpecKey(sku.SpecValueIDs)
+		if seen[key] {
+			t.Errorf("duplicate spec key %s", key)
+		}
+		seen[key] = true
+	}
+
+	// 初始库存通过库存流水写入
+	var ledger int64
+	db.Model(&model.InventoryLedger{}).Count(&ledger)
+	if ledger != 6 {
+		t.Errorf("ledger entries = %d, want 6", ledger)
+	}
+
+	// 再次生成保留已有组合的价格与库存
+	if err := db.Model(&model.ProductSKU{}).Where("id = ?", first.ID).Update("price", 120).Error; err != nil {
+		t.Fatalf("update price: %v", err)
+	}
+	again, err := s.GenerateSKUs(product.ID, &GenerateSKUsRequest{Price: 10, Stock: 1}, 1)
+	if err != nil {
+		t.Fatalf("GenerateSKUs: %v", err)
+	}
+	if len(again) != 6 || again[0].ID != first.ID || again[0].Price != 120 || again[0].Stock != 5 {
+		t.Errorf("regenerated first sku = %+v", again[0])
+	}
+	var count int64
+	db.Model(&model.ProductSKU{}).Count(&count)
+	if count != 6 {
+		t.Errorf("sku rows = %d, want 6", count)
+	}
+}
+
+func TestGenerateSKUsRequiresSpecValues(t *testing.T) {
+	s, db := newTestProductService(t)
+	product := &model.Product{CategoryID: 1, Name: "测试商品", Price: 99, Status: 1}
+	if err := db.Create(product).Error; err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+
+	if _, err := s.GenerateSKUs(product.ID, &GenerateSKUsRequest{}, 1); err == nil {
+		t.Error("product without specs should be rejected")
+	}
+	if err := s.specRepo.Save(product.ID, []*model.ProductSpec{{Name: "颜色"}}); err != nil {
+		t.Fatalf("save specs: %v", err)
+	}
+	if _, err := s.GenerateSKUs(product.ID, &GenerateSKUsRequest{}, 1); err == nil {
+		t.Error("spec without values should be rejected")
+	}
+}
+
+func TestUpdateProductKeepsListingSchedule(t *testing.T) {
+	s, db := newTestProductService(t)
+	publishAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	unpublishAt := publishAt.Add(24 * time.Hour)
+	product := &model.Product{CategoryID: 1, Name: "测试商品", Price: 99, Status: 1, PublishAt: &publishAt, UnpublishAt: &unpublishAt}
+	if err := db.Create(product).Error; err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+
+	listed, delisted := int8(1), int8(0)
+	cases := []struct {
+		name         string
+		req          *UpdateProductRequest
+		wantStatus   int8
+		wantSchedule bool
+	}{
+		{"partial update without status", &UpdateProductRequest{Name: "新名称"}, 1, true},
+		{"unchanged status", &UpdateProductRequest{Status: &listed}, 1, true},
+		{"manual delist", &UpdateProductRequest{Status: &delisted}, 0, false},
+	}
+	for _, tc := range cases {
+		if err := s.UpdateProduct(product.ID, tc.req, 1); err != nil {
+			t.Fatalf("%s: UpdateProduct: %v", tc.name, err)
+		}
+		var got model.Product
+		db.First(&got, product.ID)
+		if got.Status != tc.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tc.name, got.Status, tc.wantStatus)
+		}
+		if hasSchedule := got.PublishAt != nil && got.UnpublishAt != nil; hasSchedule != tc.wantSchedule {
+			t.Errorf("%s: schedule kept = %v, want %v", tc.name, hasSchedule, tc.wantSchedule)
+		}
+	}
+}
+
+func TestBuildProductImages(t *testing.T) {
+	cases := []struct {
+		name     string
+		reqs     []ProductImageRequest
+		wantMain int
+	}{
+		{"no images", nil, -1},
+		{"main not set uses lowest sort order", []ProductImageRequest{{ImageURL: "a", SortOrder: 2}, {ImageURL: "b", SortOrder: 1}}, 1},
+		{"ties keep the first image", []ProductImageRequest{{ImageURL: "a"}, {ImageURL: "b"}}, 0},
+		{"main set", []ProductImageRequest{{ImageURL: "a"}, {ImageURL: "b", SortOrder: 1, IsMain: 1}}, 1},
+		{"several mains keep the first", []ProductImageRequest{{ImageURL: "a", IsMain: 1}, {ImageURL: "b", IsMain: 1}}, 0},
+	}
+	for _, tc := range cases {
+		images := buildProductImages(tc.reqs)
+		if len(images) != len(tc.reqs) {
+			t.Fatalf("%s: images = %d, want %d", tc.name, len(images), len(tc.reqs))
+		}
+		for i, image := range images {
+			wantMain := int8(0)
+			if i == tc.wantMain {
+				wantMain = 1
+			}
+			if image.IsMain != wantMain || image.ImageURL != tc.reqs[i].ImageURL || image.SortOrder != tc.reqs[i].SortOrder {
+				t.Errorf("%s: image %d = %+v", tc.name, i, image)
+			}
+		}
+	}
+}
@@ -9,9 +9,11 @@ import (
 
 	"mall/internal/model"
 	"mall/internal/repository"
+	"mall/pkg/logger"
 
 	"github.com/elastic/go-elasticsearch/v8"
 	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"go.uber.org/zap"
 )
 
 // SearchService 搜索服务接口
@@ -428,4 +430,20 @@ func (s *searchService) BulkIndexProducts() error {
 	}
 
 	return nil
+}
+
+// reindexProduct 商品状态或价格变化后重建搜索索引，搜索未启用时跳过，失败只记录日志
+func reindexProduct(search SearchService, productRepo repository.ProductRepository, productID uint64) {
+	if search == nil {
+		return
+	}
+
+	product, err := productRepo.GetWithDetails(productID)
+	if err != nil {
+		logger.Error("Failed to load product for reindex", zap.Uint64("product_id", productID), zap.Error(err))
+		return
+	}
+	if err := search.IndexProduct(product); err != nil {
+		logger.Error("Failed to reindex product", zap.Uint64("product_id", productID), zap.Error(err))
+	}
 }
\ No newline at end of file
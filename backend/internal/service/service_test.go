@@ -0,0 +1,81 @@
+package service
+
+import (
+	"encoding/base64"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"mall/pkg/cache"
+	"mall/pkg/config"
+	"mall/pkg/logger"
+	"mall/pkg/utils"
+)
+
+func TestMain(m *testing.M) {
+	logger.Logger = zap.NewNop()
+
+	// 加密字段的密钥只加载一次，测试开始前使用固定密钥完成加载
+	cfg := &config.Config{}
+	cfg.Encryption.ActiveVersion = 1
+	cfg.Encryption.Keys = map[string]string{"1": base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32)))}
+	cfg.Encryption.BlindIndexKey = "blind-index-test-key"
+	config.GlobalConfig = cfg
+	if err := utils.InitFieldCrypto(); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+// newTestDB 创建内存SQLite数据库并迁移指定的表
+func newTestDB(t *testing.T, models ...interface{}) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	// 内存库按连接隔离，只保留一个连接
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("get sql db: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// useMiniredis 使用内存Redis替换全局客户端
+func useMiniredis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	prev := cache.RDB
+	cache.RDB = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		cache.RDB.Close()
+		cache.RDB = prev
+	})
+	return mr
+}
+
+// useConfig 替换全局配置，测试结束后恢复
+func useConfig(t *testing.T, cfg *config.Config) {
+	t.Helper()
+
+	prev := config.GlobalConfig
+	config.GlobalConfig = cfg
+	t.Cleanup(func() { config.GlobalConfig = prev })
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"mall/internal/model"
+	"mall/internal/repository"
+	"mall/pkg/config"
+	"mall/pkg/logger"
+	"mall/pkg/utils"
+)
+
+// SessionService 用户会话服务接口
+type SessionService interface {
+	CreateSession(userID uint64, platform string, client *ClientInfo) (*model.UserSession, error)
+	ExtendSession(sessionID string) error
+	ListSessions(userID uint64, currentSessionID string) ([]*SessionResponse, error)
+	RevokeSession(userID uint64, id uint64) error
+	RevokeBySessionID(userID uint64, sessionID string) error
+	RevokeAllSessions(userID uint64) error
+	ActiveSessionIDs(userID uint64) ([]string, error)
+}
+
+// SessionResponse 会话响应
+type SessionResponse struct {
+	ID         uint64 `json:"id"`
+	Platform   string `json:"platform"`
+	Device     string `json:"device"`
+	IP         string `json:"ip"`
+	LastSeenAt string `json:"last_seen_at"`
+	CreatedAt  string `json:"created_at"`
+	ExpiresAt  string `json:"expires_at"`
+	Current    bool   `json:"current"`
+}
+
+// sessionService 用户会话服务实现
+type sessionService struct {
+	sessionRepo repository.UserSessionRepository
+}
+
+// NewSessionService 创建用户会话服务
+func NewSessionService(sessionRepo repository.UserSessionRepository) SessionService {
+	return &sessionService{
+		sessionRepo: sessionRepo,
+	}
+}
+
+// CreateSession 登录时创建会话，超出平台并发上限时踢出最早的会话
+func (s *sessionService) CreateSession(userID uint64, platform string, client *ClientInfo) (*model.UserSession, error) {
+	if err := s.enforcePlatformLimit(userID, platform); err != nil {
+		logger.Error("Failed to enforce session limit", zap.Uint64("user_id", userID), zap.Error(err))
+	}
+
+	now := time.Now()
+	ttl := s.sessionTTL()
+	session := &model.UserSession{
+		UserID:     userID,
+		SessionID:  utils.GenerateRandomString(32),
+		Platform:   platform,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(ttl),
+		Status:     1,
+	}
+	if client != nil {
+		session.IP = client.IP
+		session.UserAgent = client.UserAgent
+		session.Device = client.Device
+		if session.Device == "" {
+			session.Device = parseDevice(client.UserAgent)
+		}
+	}
+
+	if err := s.sessionRepo.Create(session); err != nil {
+		return nil, errors.New("failed to create session")
+	}
+
+	if err := utils.StoreSession(session.SessionID, userID, ttl); err != nil {
+		return nil, errors.New("failed to create session")
+	}
+
+	return session, nil
+}
+
+// ExtendSession 刷新token时延长会话有效期
+func (s *sessionService) ExtendSession(sessionID string) error {
+	if sessionID == "" {
+		return nil
+	}
+
+	session, err := s.sessionRepo.GetBySessionID(sessionID)
+	if err != nil || session.Status != 1 {
+		return errors.New("session not found")
+	}
+
+	ttl := s.sessionTTL()
+	if err := s.sessionRepo.UpdateExpiresAt(sessionID, time.Now().Add(ttl)); err != nil {
+		return err
+	}
+	return utils.StoreSession(sessionID, session.UserID, ttl)
+}
+
+// ListSessions 获取用户在线会话列表
+func (s *sessionService) ListSessions(userID uint64, currentSessionID string) ([]*SessionResponse, error) {
+	sessions, err := s.sessionRepo.GetActiveByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*SessionResponse
+	for _, session := range sessions {
+		// 最近活跃时间以缓存为准，并回写数据库
+		if lastSeen, ok := utils.GetSessionLastSeen(session.SessionID); ok && lastSeen.After(session.LastSeenAt) {
+			session.LastSeenAt = lastSeen
+			s.sessionRepo.UpdateLastSeen(session.SessionID, lastSeen)
+		}
+
+		result = append(result, &SessionResponse{
+			ID:         session.ID,
+			Platform:   session.Platform,
+			Device:     session.Device,
+			IP:         session.IP,
+			LastSeenAt: session.LastSeenAt.Format("2006-01-02 15:04:05"),
+			CreatedAt:  session.CreatedAt.Format("2006-01-02 15:04:05"),
+			ExpiresAt:  session.ExpiresAt.Format("2006-01-02 15:04:05"),
+			Current:    session.SessionID == currentSessionID,
+		})
+	}
+
+	return result, nil
+}
+
+// RevokeSession 注销指定会话
+func (s *sessionService) RevokeSession(userID uint64, id uint64) error {
+	session, err := s.sessionRepo.GetByID(id)
+	if err != nil {
+		return errors.New("session not found")
+	}
+
+	if session.UserID != userID {
+		return errors.New("access denied")
+	}
+
+	return s.revoke([]*model.UserSession{session})
+}
+
+// RevokeBySessionID 根据会话标识注销会话（登出）
+func (s *sessionService) RevokeBySessionID(userID uint64, sessionID string) error {
+	session, err := s.sessionRepo.GetBySessionID(sessionID)
+	if err != nil {
+		return errors.New("session not found")
+	}
+
+	if session.UserID != userID {
+		return errors.New("access denied")
+	}
+
+	return s.revoke([]*model.UserSession{session})
+}
+
+// RevokeAllSessions 注销用户所有会话
+func (s *sessionService) RevokeAllSessions(userID uint64) error {
+	sessions, err := s.sessionRepo.GetActiveByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	return s.revoke(sessions)
+}
+
+// ActiveSessionIDs 获取用户有效会话的会话ID
+func (s *sessionService) ActiveSessionIDs(userID uint64) ([]string, error) {
+	sessions, err := s.sessionRepo.GetActiveByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionIDs := make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		sessionIDs = append(sessionIDs, session.SessionID)
+	}
+	return sessionIDs, nil
+}
+
+// enforcePlatformLimit 执行平台并发会话上限策略
+func (s *sessionService) enforcePlatformLimit(userID uint64, platform string) error {
+	limit := config.GetConfig().Session.MaxPerPlatform[platform]
+	if limit <= 0 {
+		return nil
+	}
+
+	sessions, err := s.sessionRepo.GetActiveByPlatform(userID, platform)
+	if err != nil {
+		return err
+	}
+
+	// 为新会话预留一个名额
+	overflow := len(sessions) - limit + 1
+	if overflow <= 0 {
+		return nil
+	}
+
+	return s.revoke(sessions[:overflow])
+}
+
+// revoke 注销会话并清除缓存
+func (s *sessionService) revoke(sessions []*model.UserSession) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+
+	ids := make([]uint64, 0, len(sessions))
+	sessionIDs := make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		ids = append(ids, session.ID)
+		sessionIDs = append(sessionIDs, session.SessionID)
+	}
+
+	if err := s.sessionRepo.Revoke(ids); err != nil {
+		return err
+	}
+	return utils.RemoveSessions(sessionIDs...)
+}
+
+// sessionTTL 会话有效期，与token有效期一致
+func (s *sessionService) sessionTTL() time.Duration {
+	hours := config.GetConfig().JWT.ExpireHours
+	if hours <= 0 {
+		hours = 168
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+// parseDevice 根据User-Agent粗略识别设备类型
+func parseDevice(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	switch {
+	case strings.Contains(ua, "miniprogram") || strings.Contains(ua, "micromessenger"):
+		return "WeChat"
+	case strings.Contains(ua, "iphone"):
+		return "iPhone"
+	case strings.Contains(ua, "ipad"):
+		return "iPad"
+	case strings.Contains(ua, "android"):
+		return "Android"
+	case strings.Contains(ua, "windows"):
+		return "Windows"
+	case strings.Contains(ua, "mac os"):
+		return "Mac"
+	case strings.Contains(ua, "linux"):
+		return "Linux"
+	default:
+		return "Unknown"
+	}
+}
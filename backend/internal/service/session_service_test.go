@@ -0,0 +1,118 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+
+	"mall/internal/model"
+	"mall/internal/repository"
+	"mall/pkg/config"
+	"mall/pkg/utils"
+)
+
+// newTestSessionService 创建会话服务，web端最多2个并发会话
+func newTestSessionService(t *testing.T) (*sessionService, *gorm.DB) {
+	t.Helper()
+
+	useMiniredis(t)
+	cfg := &config.Config{}
+	cfg.JWT.ExpireHours = 2
+	cfg.Session.MaxPerPlatform = map[string]int{"web": 2}
+	useConfig(t, cfg)
+
+	db := newTestDB(t, &model.UserSession{})
+	return &sessionService{sessionRepo: repository.NewUserSessionRepository(db)}, db
+}
+
+// sessionValid 会话缓存是否仍然有效
+func sessionValid(userID uint64, sessionID string) bool {
+	return utils.ValidateSession(&utils.Claims{UserID: int64(userID), Role: "user", RegisteredClaims: jwt.RegisteredClaims{ID: sessionID}})
+}
+
+func TestCreateSessionPlatformLimit(t *testing.T) {
+	s, _ := newTestSessionService(t)
+
+	var web []*model.UserSession
+	for i := 0; i < 3; i++ {
+		session, err := s.CreateSession(1, "web", &ClientInfo{IP: "10.0.0.1", UserAgent: "Mozilla/5.0 (iPhone)"})
+		if err != nil {
+			t.Fatalf("CreateSession: %v", err)
+		}
+		web = append(web, session)
+	}
+	mini, err := s.CreateSession(1, "miniprogram", nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	other, err := s.CreateSession(2, "web", nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+
+	cases := []struct {
+		name    string
+		userID  uint64
+		session *model.UserSession
+		want    bool
+	}{
+		{"oldest web session kicked", 1, web[0], false},
+		{"newer web sessions kept", 1, web[1], true},
+		{"newest web session kept", 1, web[2], true},
+		{"other platform unaffected", 1, mini, true},
+		{"other user unaffected", 2, other, true},
+		{"session bound to its user", 2, web[2], false},
+	}
+	for _, tc := range cases {
+		if got := sessionValid(tc.userID, tc.session.SessionID); got != tc.want {
+			t.Errorf("%s: valid = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	sessions, err := s.ListSessions(1, web[2].SessionID)
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	if len(sessions) != 3 {
+		t.Fatalf("listed %d sessions, want 3", len(sessions))
+	}
+	for _, session := range sessions {
+		if session.Current != (session.ID == web[2].ID) {
+			t.Errorf("session %d current = %v", session.ID, session.Current)
+		}
+		if session.ID == web[1].ID && session.Device != "iPhone" {
+			t.Errorf("device = %s, want iPhone", session.Device)
+		}
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	s, db := newTestSessionService(t)
+	session, err := s.CreateSession(1, "web", nil)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+
+	if err := s.RevokeSession(2, session.ID); err == nil {
+		t.Error("other users should not revoke the session")
+	}
+	if !sessionValid(1, session.SessionID) {
+		t.Fatal("session should still be valid")
+	}
+	if err := s.RevokeSession(1, session.ID); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	if sessionValid(1, session.SessionID) {
+		t.Error("revoked session should be invalid")
+	}
+
+	var stored model.UserSession
+	db.First(&stored, session.ID)
+	if stored.Status != 0 {
+		t.Errorf("status = %d, want 0", stored.Status)
+	}
+	if err := s.ExtendSession(session.SessionID); err == nil {
+		t.Error("revoked session should not be extended")
+	}
+}
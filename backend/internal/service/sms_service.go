@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"mall/pkg/cache"
+	"mall/pkg/config"
+	"mall/pkg/logger"
+	"mall/pkg/sms"
+	"mall/pkg/utils"
+)
+
+// SMSService 短信验证码服务接口
+type SMSService interface {
+	SendCode(scene, phone, ip string) error
+	VerifyCode(scene, phone, code string) bool
+}
+
+// 短信相关缓存key
+const (
+	smsCodeKey       = "sms_code:%s:%s"
+	smsAttemptsKey   = "sms_attempts:%s:%s"
+	smsPhoneDailyKey = "sms_daily:phone:%s:%s"
+	smsIPDailyKey    = "sms_daily:ip:%s:%s"
+)
+
+// smsService 短信验证码服务实现
+type smsService struct {
+	sender sms.SMSSender
+}
+
+// NewSMSService 创建短信验证码服务
+func NewSMSService(sender sms.SMSSender) SMSService {
+	return &smsService{
+		sender: sender,
+	}
+}
+
+// SendCode 发送验证码
+func (s *smsService) SendCode(scene, phone, ip string) error {
+	// 验证手机号格式
+	if !utils.IsValidPhone(phone) {
+		return errors.New("invalid phone number format")
+	}
+
+	tpl, err := sms.GetTemplate(scene)
+	if err != nil {
+		return errors.New("unsupported sms scene")
+	}
+
+	cfg := config.GetConfig()
+	ctx := context.Background()
+	today := time.Now().Format("20060102")
+
+	// 预占每日发送配额，发送失败时释放，只有成功发送的短信计入配额
+	var reserved []string
+	release := func() {
+		for _, key := range reserved {
+			cache.Decr(ctx, key)
+		}
+	}
+	phoneKey := fmt.Sprintf(smsPhoneDailyKey, phone, today)
+	if err := s.reserveDailyQuota(ctx, phoneKey, cfg.SMS.PhoneDailyLimit); err != nil {
+		return errors.New("daily sms limit reached for this phone")
+	}
+	reserved = append(reserved, phoneKey)
+	if ip != "" {
+		ipKey := fmt.Sprintf(smsIPDailyKey, ip, today)
+		if err := s.reserveDailyQuota(ctx, ipKey, cfg.SMS.IPDailyLimit); err != nil {
+			release()
+			return errors.New("daily sms limit reached for this ip")
+		}
+		reserved = append(reserved, ipKey)
+	}
+
+	// 生成验证码
+	code := utils.GenerateVerifyCode()
+	expire := s.codeExpiration()
+	codeKey := fmt.Sprintf(smsCodeKey, scene, phone)
+
+	// 存储验证码并重置校验次数
+	if err := cache.Set(ctx, codeKey, code, expire); err != nil {
+		release()
+		return errors.New("failed to store verification code")
+	}
+	cache.Del(ctx, fmt.Sprintf(smsAttemptsKey, scene, phone))
+
+	params := map[string]string{
+		"code":   code,
+		"expire": strconv.Itoa(int(expire.Minutes())),
+	}
+	if err := s.sender.Send(ctx, phone, tpl, params); err != nil {
+		logger.Error("Failed to send sms", zap.String("scene", scene), zap.Error(err))
+		cache.Del(ctx, codeKey)
+		release()
+		return errors.New("failed to send verification code")
+	}
+
+	return nil
+}
+
+// VerifyCode 校验验证码，超过最大校验次数后验证码作废
+func (s *smsService) VerifyCode(scene, phone, code string) bool {
+	ctx := context.Background()
+	codeKey := fmt.Sprintf(smsCodeKey, scene, phone)
+	attemptsKey := fmt.Sprintf(smsAttemptsKey, scene, phone)
+
+	storedCode, err := cache.Get(ctx, codeKey)
+	if err != nil {
+		return false
+	}
+
+	attempts, err := cache.Incr(ctx, attemptsKey)
+	if err != nil {
+		return false
+	}
+	if attempts == 1 {
+		cache.Expire(ctx, attemptsKey, s.codeExpiration())
+	}
+
+	maxAttempts := config.GetConfig().SMS.MaxVerifyAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	if attempts > int64(maxAttempts) {
+		cache.Del(ctx, codeKey, attemptsKey)
+		return false
+	}
+
+	// 验证成功后删除验证码
+	if storedCode == code {
+		cache.Del(ctx, codeKey, attemptsKey)
+		return true
+	}
+
+	return false
+}
+
+// reserveDailyQuota 预占一次每日配额，超出上限时立即归还；未设上限时也计数以便发送失败时统一释放
+func (s *smsService) reserveDailyQuota(ctx context.Context, key string, limit int) error {
+	count, err := cache.Incr(ctx, key)
+	if err != nil {
+		return err
+	}
+	if count == 1 {
+		cache.Expire(ctx, key, 24*time.Hour)
+	}
+	if limit > 0 && count > int64(limit) {
+		cache.Decr(ctx, key)
+		return errors.New("quota exceeded")
+	}
+	return nil
+}
+
+// codeExpiration 验证码有效期
+func (s *smsService) codeExpiration() time.Duration {
+	minutes := config.GetConfig().SMS.CodeExpireMinutes
+	if minutes <= 0 {
+		minutes = 5
+	}
+	return time.Duration(minutes) * time.Minute
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"mall/pkg/config"
+	"mall/pkg/sms"
+)
+
+// fakeSMSSender 记录发送内容的短信发送器
+type fakeSMSSender struct {
+	codes map[string]string
+	fail  bool
+}
+
+func newFakeSMSSender() *fakeSMSSender {
+	return &fakeSMSSender{codes: make(map[string]string)}
+}
+
+func (f *fakeSMSSender) Send(ctx context.Context, phone string, tpl *sms.Template, params map[string]string) error {
+	if f.fail {
+		return errors.New("gateway unavailable")
+	}
+	f.codes[phone] = params["code"]
+	return nil
+}
+
+func newTestSMSService(t *testing.T) (*smsService, *fakeSMSSender) {
+	t.Helper()
+
+	useMiniredis(t)
+	cfg := &config.Config{}
+	cfg.SMS.PhoneDailyLimit = 2
+	cfg.SMS.IPDailyLimit = 3
+	cfg.SMS.MaxVerifyAttempts = 3
+	useConfig(t, cfg)
+
+	sender := newFakeSMSSender()
+	return &smsService{sender: sender}, sender
+}
+
+func TestSMSSendCodeDailyQuota(t *testing.T) {
+	s, sender := newTestSMSService(t)
+
+	steps := []struct {
+		name    string
+		phone   string
+		ip      string
+		fail    bool
+		wantErr bool
+	}{
+		{"first send", "13800000001", "10.0.0.1", false, false},
+		{"failed send is not counted", "13800000001", "10.0.0.1", true, true},
+		{"second send", "13800000001", "10.0.0.1", false, false},
+		{"phone limit reached", "13800000001", "10.0.0.2", false, true},
+		{"other phone same ip", "13800000002", "10.0.0.1", false, false},
+		{"ip limit reached", "13800000003", "10.0.0.1", false, true},
+		{"other ip", "13800000003", "10.0.0.3", false, false},
+		{"invalid phone", "12345", "10.0.0.4", false, true},
+	}
+	for _, step := range steps {
+		sender.fail = step.fail
+		err := s.SendCode(sms.SceneLogin, step.phone, step.ip)
+		if (err != nil) != step.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", step.name, err, step.wantErr)
+		}
+	}
+}
+
+func TestSMSSendCodeUnknownScene(t *testing.T) {
+	s, _ := newTestSMSService(t)
+	if err := s.SendCode("unknown", "13800000001", ""); err == nil {
+		t.Error("unknown scene should be rejected")
+	}
+}
+
+func TestSMSVerifyCodeAttempts(t *testing.T) {
+	const phone = "13800000001"
+	cases := []struct {
+		name        string
+		wrong       int
+		wantOK      bool
+		scene       string
+		verifyScene string
+	}{
+		{"correct code", 0, true, sms.SceneLogin, sms.SceneLogin},
+		{"correct after wrong attempts", 2, true, sms.SceneLogin, sms.SceneLogin},
+		{"attempt cap reached", 3, false, sms.SceneLogin, sms.SceneLogin},
+		{"code bound to scene", 0, false, sms.SceneLogin, sms.SceneReset},
+	}
+	for _, tc := range cases {
+		s, sender := newTestSMSService(t)
+		if err := s.SendCode(tc.scene, phone, ""); err != nil {
+			t.Fatalf("%s: SendCode: %v", tc.name, err)
+		}
+		for i := 0; i < tc.wrong; i++ {
+			if s.VerifyCode(tc.verifyScene, phone, "000000x") {
+				t.Fatalf("%s: wrong code accepted", tc.name)
+			}
+		}
+		if got := s.VerifyCode(tc.verifyScene, phone, sender.codes[phone]); got != tc.wantOK {
+			t.Errorf("%s: VerifyCode = %v, want %v", tc.name, got, tc.wantOK)
+		}
+	}
+}
+
+func TestSMSVerifyCodeSingleUse(t *testing.T) {
+	const phone = "13800000001"
+	s, sender := newTestSMSService(t)
+	if err := s.SendCode(sms.SceneBind, phone, ""); err != nil {
+		t.Fatalf("SendCode: %v", err)
+	}
+	code := sender.codes[phone]
+	if !s.VerifyCode(sms.SceneBind, phone, code) {
+		t.Fatal("first verification should succeed")
+	}
+	if s.VerifyCode(sms.SceneBind, phone, code) {
+		t.Error("code should not be reusable")
+	}
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"mall/pkg/config"
+	"mall/pkg/logger"
+	"mall/pkg/storage"
+	"mall/pkg/utils"
+)
+
+// 上传场景，决定存储目录
+const (
+	UploadSceneAvatar  = "avatar"
+	UploadSceneProduct = "product"
+)
+
+// uploadExtensions 按内容识别出的类型对应的扩展名
+var uploadExtensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+	"image/webp": "webp",
+}
+
+// UploadService 文件上传服务接口
+type UploadService interface {
+	UploadImage(scene string, file *multipart.FileHeader) (*UploadResponse, error)
+}
+
+// UploadResponse 上传响应
+type UploadResponse struct {
+	URL         string `json:"url"`
+	Key         string `json:"key"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type"`
+}
+
+// uploadService 文件上传服务实现
+type uploadService struct {
+	storage storage.Storage
+}
+
+// NewUploadService 创建文件上传服务
+func NewUploadService(storage storage.Storage) UploadService {
+	return &uploadService{
+		storage: storage,
+	}
+}
+
+// UploadImage 上传图片，按内容识别类型，以内容哈希命名，相同文件只存一份
+func (s *uploadService) UploadImage(scene string, file *multipart.FileHeader) (*UploadResponse, error) {
+	cfg := config.GetConfig().Upload
+	maxSize := int64(cfg.MaxSize) << 20
+	if maxSize <= 0 {
+		maxSize = 10 << 20
+	}
+	if file.Size > maxSize {
+		return nil, fmt.Errorf("file size exceeds %dMB", maxSize>>20)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, errors.New("failed to read file")
+	}
+	defer src.Close()
+
+	// 多读一个字节，防止声明大小与实际内容不符
+	data, err := io.ReadAll(io.LimitReader(src, maxSize+1))
+	if err != nil {
+		return nil, errors.New("failed to read file")
+	}
+	if len(data) == 0 {
+		return nil, errors.New("file is empty")
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("file size exceeds %dMB", maxSize>>20)
+	}
+
+	// 忽略客户端声明的Content-Type与扩展名，以文件内容为准
+	contentType := http.DetectContentType(data)
+	ext, ok := uploadExtensions[contentType]
+	if !ok || !utils.IsAllowedFileType(contentType, cfg.AllowedTypes) {
+		return nil, errors.New("file type not allowed")
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	key := fmt.Sprintf("%s/%s/%s.%s", scene, hash[:2], hash, ext)
+
+	ctx := context.Background()
+	exists, err := s.storage.Exists(ctx, key)
+	if err != nil {
+		logger.Warn("Failed to check uploaded file", zap.String("key", key), zap.Error(err))
+	}
+	if !exists {
+		if err := s.storage.Put(ctx, key, data, contentType); err != nil {
+			logger.Error("Failed to store uploaded file", zap.String("key", key), zap.Error(err))
+			return nil, errors.New("failed to store file")
+		}
+	}
+
+	return &UploadResponse{
+		URL:         s.storage.URL(key),
+		Key:         key,
+		Size:        int64(len(data)),
+		ContentType: contentType,
+	}, nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mall/pkg/config"
+	"mall/pkg/storage"
+)
+
+// newTestUploadService 创建使用临时目录本地存储的上传服务，单个文件最大1MB
+func newTestUploadService(t *testing.T) (*uploadService, string) {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.Upload.MaxSize = 1
+	cfg.Upload.AllowedTypes = []string{"image/png", "image/jpeg"}
+	useConfig(t, cfg)
+
+	root := t.TempDir()
+	return NewUploadService(storage.NewLocalStorage(root, "/uploads")).(*uploadService), root
+}
+
+// newFileHeader 构造multipart上传文件
+func newFileHeader(t *testing.T, filename string, data []byte) *multipart.FileHeader {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatalf("create form file: %v", err)
+	}
+	part.Write(data)
+	writer.Close()
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(4 << 20)
+	if err != nil {
+		t.Fatalf("read form: %v", err)
+	}
+	t.Cleanup(func() { form.RemoveAll() })
+	return form.File["file"][0]
+}
+
+// testPNG 生成PNG图片
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestUploadImage(t *testing.T) {
+	s, root := newTestUploadService(t)
+	pngData := testPNG(t)
+	gif := []byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;")
+
+	cases := []struct {
+		name     string
+		filename string
+		data     []byte
+		wantErr  string
+		wantType string
+	}{
+		{"png", "a.png", pngData, "", "image/png"},
+		{"extension ignored", "photo.jpg", pngData, "", "image/png"},
+		{"type not allowed", "a.gif", gif, "file type not allowed", ""},
+		{"not an image", "a.png", []byte("<html></html>"), "file type not allowed", ""},
+		{"empty file", "a.png", nil, "file is empty", ""},
+		{"too large", "a.png", append(pngData, make([]byte, 1<<20)...), "file size exceeds 1MB", ""},
+	}
+	for _, tc := range cases {
+		resp, err := s.UploadImage(UploadSceneProduct, newFileHeader(t, tc.filename, tc.data))
+		if tc.wantErr != "" {
+			if err == nil || err.Error() != tc.wantErr {
+				t.Errorf("%s: err = %v, want %s", tc.name, err, tc.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: UploadImage: %v", tc.name, err)
+		}
+		if resp.ContentType != tc.wantType || !strings.HasPrefix(resp.Key, "product/") ||
+			!strings.HasSuffix(resp.Key, ".png") || resp.URL != "/uploads/"+resp.Key || resp.Size != int64(len(tc.data)) {
+			t.Errorf("%s: resp = %+v", tc.name, resp)
+		}
+	}
+
+	// 相同内容只存一份
+	files, _ := filepath.Glob(filepath.Join(root, "product", "*", "*"))
+	if len(files) != 1 {
+		t.Errorf("stored files = %v, want 1", files)
+	}
+	avatar, err := s.UploadImage(UploadSceneAvatar, newFileHeader(t, "a.png", pngData))
+	if err != nil {
+		t.Fatalf("UploadImage: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, avatar.Key)); err != nil || !bytes.Equal(data, pngData) {
+		t.Errorf("avatar file = %d bytes, %v", len(data), err)
+	}
+}
@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// footprintScript 记录浏览足迹，当日已记录过的商品直接跳过
+// KEYS[1] 当日已浏览集合；KEYS[2] 足迹有序集合；KEYS[3] 待持久化用户集合；KEYS[4] 用户待落库足迹
+// ARGV[1] 商品ID；ARGV[2] 浏览时间戳；ARGV[3] 足迹上限；ARGV[4] 当日集合过期秒数；ARGV[5] 足迹过期秒数；ARGV[6] 用户ID
+// 返回 1 表示已记录，0 表示当日重复浏览
+var footprintScript = redis.NewScript(`
+if redis.call('SADD', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call('EXPIRE', KEYS[1], tonumber(ARGV[4]))
+
+redis.call('ZADD', KEYS[2], tonumber(ARGV[2]), ARGV[1])
+local max = tonumber(ARGV[3])
+if max > 0 then
+	redis.call('ZREMRANGEBYRANK', KEYS[2], 0, -(max + 1))
+end
+redis.call('EXPIRE', KEYS[2], tonumber(ARGV[5]))
+
+redis.call('ZADD', KEYS[4], tonumber(ARGV[2]), ARGV[1])
+redis.call('EXPIRE', KEYS[4], tonumber(ARGV[5]))
+redis.call('SADD', KEYS[3], ARGV[6])
+return 1
+`)
+
+// footprintTakeScript 取出并清空用户待落库足迹
+// KEYS[1] 用户待落库足迹；返回 {商品ID, 浏览时间戳, ...}
+var footprintTakeScript = redis.NewScript(`
+local entries = redis.call('ZRANGE', KEYS[1], 0, -1, 'WITHSCORES')
+redis.call('DEL', KEYS[1])
+return entries
+`)
+
+// footprintMergeScript 将足迹合并进有序集合，已有更新的浏览时间时保留较新的值
+// KEYS[1] 足迹有序集合；ARGV[1] 足迹上限，0表示不限；ARGV[2] 过期秒数；ARGV[3...] 浏览时间戳与商品ID交替
+var footprintMergeScript = redis.NewScript(`
+for i = 3, #ARGV, 2 do
+	local score = tonumber(ARGV[i])
+	local current = redis.call('ZSCORE', KEYS[1], ARGV[i + 1])
+	if not current or tonumber(current) < score then
+		redis.call('ZADD', KEYS[1], score, ARGV[i + 1])
+	end
+end
+
+local max = tonumber(ARGV[1])
+if max > 0 then
+	redis.call('ZREMRANGEBYRANK', KEYS[1], 0, -(max + 1))
+end
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	redis.call('EXPIRE', KEYS[1], tonumber(ARGV[2]))
+end
+return 1
+`)
+
+// FootprintKeys 足迹相关的Redis key
+type FootprintKeys struct {
+	Seen    string // 当日已浏览集合
+	List    string // 足迹有序集合
+	Dirty   string // 待持久化用户集合
+	Pending string // 用户待落库足迹
+}
+
+// RecordFootprint 原子地记录一次浏览，返回是否为当日首次浏览
+func RecordFootprint(ctx context.Context, keys FootprintKeys, userID, productID uint64, viewedAt time.Time,
+	maxItems int, seenTTL, listTTL time.Duration) (bool, error) {
+	recorded, err := footprintScript.Run(ctx, RDB, []string{keys.Seen, keys.List, keys.Dirty, keys.Pending},
+		productID, viewedAt.Unix(), maxItems, int64(seenTTL.Seconds()), int64(listTTL.Seconds()), userID).Int()
+	if err != nil {
+		return false, err
+	}
+	return recorded == 1, nil
+}
+
+// TakePendingFootprints 原子地取出用户自上次落库后新增的足迹
+func TakePendingFootprints(ctx context.Context, key string) ([]redis.Z, error) {
+	values, err := footprintTakeScript.Run(ctx, RDB, []string{key}).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]redis.Z, 0, len(values)/2)
+	for i := 0; i+1 < len(values); i += 2 {
+		score, err := strconv.ParseFloat(values[i+1], 64)
+		if err != nil {
+			continue
+		}
+		members = append(members, redis.Z{Score: score, Member: values[i]})
+	}
+	return members, nil
+}
+
+// MergeFootprints 将足迹合并进有序集合，同一商品保留较新的浏览时间，maxItems为0时不裁剪
+func MergeFootprints(ctx context.Context, key string, members []redis.Z, maxItems int, ttl time.Duration) error {
+	args := make([]interface{}, 0, 2+len(members)*2)
+	args = append(args, maxItems, int64(ttl.Seconds()))
+	for _, member := range members {
+		args = append(args, member.Score, member.Member)
+	}
+	return footprintMergeScript.Run(ctx, RDB, []string{key}, args...).Err()
+}
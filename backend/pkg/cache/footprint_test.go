@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRecordFootprint(t *testing.T) {
+	mr := useMiniredis(t)
+	ctx := context.Background()
+	keys := FootprintKeys{Seen: "fp:seen", List: "fp:list", Dirty: "fp:dirty", Pending: "fp:pending"}
+	now := time.Unix(1700000000, 0)
+
+	steps := []struct {
+		name      string
+		productID uint64
+		viewedAt  time.Time
+		want      bool
+		wantList  []string
+	}{
+		{"first view", 1, now, true, []string{"1"}},
+		{"repeat view same day skipped", 1, now.Add(time.Minute), false, []string{"1"}},
+		{"second product", 2, now.Add(2 * time.Minute), true, []string{"1", "2"}},
+		{"oldest trimmed over limit", 3, now.Add(3 * time.Minute), true, []string{"2", "3"}},
+	}
+	for _, step := range steps {
+		recorded, err := RecordFootprint(ctx, keys, 7, step.productID, step.viewedAt, 2, time.Hour, 24*time.Hour)
+		if err != nil {
+			t.Fatalf("%s: RecordFootprint: %v", step.name, err)
+		}
+		if recorded != step.want {
+			t.Errorf("%s: recorded = %v, want %v", step.name, recorded, step.want)
+		}
+		members, _ := mr.ZMembers(keys.List)
+		if strings.Join(members, ",") != strings.Join(step.wantList, ",") {
+			t.Errorf("%s: list = %v, want %v", step.name, members, step.wantList)
+		}
+	}
+	if dirty, _ := mr.SMembers(keys.Dirty); len(dirty) != 1 || dirty[0] != "7" {
+		t.Errorf("dirty users = %v", dirty)
+	}
+
+	// 待落库足迹取出后清空，未受上限裁剪
+	pending, err := TakePendingFootprints(ctx, keys.Pending)
+	if err != nil {
+		t.Fatalf("TakePendingFootprints: %v", err)
+	}
+	if len(pending) != 3 || pending[0].Member != "1" || pending[0].Score != float64(now.Unix()) {
+		t.Errorf("pending = %v", pending)
+	}
+	if mr.Exists(keys.Pending) {
+		t.Error("pending footprints should be cleared")
+	}
+}
+
+func TestMergeFootprints(t *testing.T) {
+	mr := useMiniredis(t)
+	ctx := context.Background()
+	mr.ZAdd("fp:list", 200, "1")
+	mr.ZAdd("fp:list", 100, "2")
+
+	cases := []struct {
+		name     string
+		members  []redis.Z
+		maxItems int
+		want     map[string]float64
+	}{
+		{"newer view kept", []redis.Z{{Score: 150, Member: "1"}, {Score: 120, Member: "2"}}, 0,
+			map[string]float64{"1": 200, "2": 120}},
+		{"trimmed to limit", []redis.Z{{Score: 300, Member: "3"}}, 2,
+			map[string]float64{"1": 200, "3": 300}},
+	}
+	for _, tc := range cases {
+		if err := MergeFootprints(ctx, "fp:list", tc.members, tc.maxItems, time.Hour); err != nil {
+			t.Fatalf("%s: MergeFootprints: %v", tc.name, err)
+		}
+		members, _ := mr.ZMembers("fp:list")
+		if len(members) != len(tc.want) {
+			t.Errorf("%s: members = %v", tc.name, members)
+		}
+		for member, score := range tc.want {
+			if got, _ := mr.ZScore("fp:list", member); got != score {
+				t.Errorf("%s: score of %s = %v, want %v", tc.name, member, got, score)
+			}
+		}
+	}
+	if ttl := mr.TTL("fp:list"); ttl != time.Hour {
+		t.Errorf("ttl = %v, want 1h", ttl)
+	}
+
+	// 合并空足迹不会创建key
+	if err := MergeFootprints(ctx, "fp:empty", nil, 0, time.Hour); err != nil {
+		t.Fatalf("MergeFootprints: %v", err)
+	}
+	if mr.Exists("fp:empty") {
+		t.Error("merging nothing should not create the key")
+	}
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindowScript 滑动窗口限流脚本，以Redis服务器时间为准，计数与写入在同一脚本内原子完成
+// KEYS[1] 限流key；ARGV[1] 窗口毫秒数；ARGV[2] 窗口内最大请求数；ARGV[3] 本次请求唯一标识
+// 返回 {是否放行, 剩余次数, 距窗口内最早请求过期的毫秒数}
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, now .. ':' .. ARGV[3])
+	redis.call('PEXPIRE', key, window)
+	count = count + 1
+	allowed = 1
+end
+
+local reset = window
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+
+local remaining = limit - count
+if remaining < 0 then
+	remaining = 0
+end
+return {allowed, remaining, reset}
+`)
+
+// RateLimitResult 限流结果
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	ResetIn   time.Duration
+}
+
+// SlidingWindowAllow 按滑动窗口判断请求是否放行
+func SlidingWindowAllow(ctx context.Context, key string, limit int, window time.Duration, requestID string) (*RateLimitResult, error) {
+	values, err := slidingWindowScript.Run(ctx, RDB, []string{key}, window.Milliseconds(), limit, requestID).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	return &RateLimitResult{
+		Allowed:   values[0] == 1,
+		Limit:     limit,
+		Remaining: int(values[1]),
+		ResetIn:   time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// useMiniredis 使用内存Redis替换全局客户端
+func useMiniredis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	prev := RDB
+	RDB = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		RDB.Close()
+		RDB = prev
+	})
+	return mr
+}
+
+func TestSlidingWindowAllow(t *testing.T) {
+	mr := useMiniredis(t)
+	ctx := context.Background()
+	start := time.Unix(1700000000, 0)
+	mr.SetTime(start)
+
+	for i := 0; i < 3; i++ {
+		result, err := SlidingWindowAllow(ctx, "rl:test", 3, time.Minute, fmt.Sprintf("req-%d", i))
+		if err != nil {
+			t.Fatalf("SlidingWindowAllow: %v", err)
+		}
+		if !result.Allowed {
+			t.Fatalf("request %d should be allowed", i)
+		}
+		if result.Remaining != 2-i {
+			t.Errorf("request %d remaining = %d, want %d", i, result.Remaining, 2-i)
+		}
+	}
+
+	result, err := SlidingWindowAllow(ctx, "rl:test", 3, time.Minute, "req-3")
+	if err != nil {
+		t.Fatalf("SlidingWindowAllow: %v", err)
+	}
+	if result.Allowed || result.Remaining != 0 {
+		t.Errorf("fourth request = %+v, want rejected with 0 remaining", result)
+	}
+	if result.ResetIn != time.Minute {
+		t.Errorf("ResetIn = %v, want %v", result.ResetIn, time.Minute)
+	}
+
+	// 被拒绝的请求不计入窗口
+	if members, _ := mr.ZMembers("rl:test"); len(members) != 3 {
+		t.Errorf("window holds %d requests, want 3", len(members))
+	}
+
+	// 其他key互不影响
+	if other, _ := SlidingWindowAllow(ctx, "rl:other", 3, time.Minute, "req-0"); !other.Allowed {
+		t.Error("different key should have its own window")
+	}
+}
+
+func TestSlidingWindowAllowSlides(t *testing.T) {
+	mr := useMiniredis(t)
+	ctx := context.Background()
+	start := time.Unix(1700000000, 0)
+
+	mr.SetTime(start)
+	SlidingWindowAllow(ctx, "rl:slide", 2, time.Minute, "a")
+	mr.SetTime(start.Add(30 * time.Second))
+	SlidingWindowAllow(ctx, "rl:slide", 2, time.Minute, "b")
+
+	result, _ := SlidingWindowAllow(ctx, "rl:slide", 2, time.Minute, "c")
+	if result.Allowed {
+		t.Fatal("window is full, request should be rejected")
+	}
+	if result.ResetIn != 30*time.Second {
+		t.Errorf("ResetIn = %v, want 30s until the oldest request expires", result.ResetIn)
+	}
+
+	// 最早的请求滑出窗口后放行一个
+	mr.SetTime(start.Add(61 * time.Second))
+	result, _ = SlidingWindowAllow(ctx, "rl:slide", 2, time.Minute, "d")
+	if !result.Allowed || result.Remaining != 0 {
+		t.Errorf("after the oldest request expires = %+v, want allowed with 0 remaining", result)
+	}
+	result, _ = SlidingWindowAllow(ctx, "rl:slide", 2, time.Minute, "e")
+	if result.Allowed {
+		t.Error("window is full again, request should be rejected")
+	}
+}
+
+func TestSlidingWindowAllowSameMillisecond(t *testing.T) {
+	mr := useMiniredis(t)
+	ctx := context.Background()
+	mr.SetTime(time.Unix(1700000000, 0))
+
+	// 同一毫秒内的请求以请求ID区分，不会互相覆盖
+	for _, id := range []string{"a", "b"} {
+		if result, _ := SlidingWindowAllow(ctx, "rl:burst", 5, time.Minute, id); !result.Allowed {
+			t.Fatalf("request %s should be allowed", id)
+		}
+	}
+	if members, _ := mr.ZMembers("rl:burst"); len(members) != 2 {
+		t.Errorf("window holds %d requests, want 2", len(members))
+	}
+	if ttl := mr.TTL("rl:burst"); ttl != time.Minute {
+		t.Errorf("key ttl = %v, want %v", ttl, time.Minute)
+	}
+}
@@ -82,6 +82,11 @@ func Get(ctx context.Context, key string) (string, error) {
 	return RDB.Get(ctx, key).Result()
 }
 
+// GetDel 获取缓存并删除
+func GetDel(ctx context.Context, key string) (string, error) {
+	return RDB.GetDel(ctx, key).Result()
+}
+
 // Del 删除缓存
 func Del(ctx context.Context, keys ...string) error {
 	return RDB.Del(ctx, keys...).Err()
@@ -97,6 +102,26 @@ func Expire(ctx context.Context, key string, expiration time.Duration) error {
 	return RDB.Expire(ctx, key, expiration).Err()
 }
 
+// Incr 自增计数
+func Incr(ctx context.Context, key string) (int64, error) {
+	return RDB.Incr(ctx, key).Result()
+}
+
+// Decr 自减计数
+func Decr(ctx context.Context, key string) (int64, error) {
+	return RDB.Decr(ctx, key).Result()
+}
+
+// TTL 获取剩余过期时间
+func TTL(ctx context.Context, key string) (time.Duration, error) {
+	return RDB.TTL(ctx, key).Result()
+}
+
+// SetNX 不存在时设置缓存
+func SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	return RDB.SetNX(ctx, key, value, expiration).Result()
+}
+
 // HSet 设置hash字段
 func HSet(ctx context.Context, key string, values ...interface{}) error {
 	return RDB.HSet(ctx, key, values...).Err()
@@ -147,6 +172,31 @@ func ZRange(ctx context.Context, key string, start, stop int64) ([]string, error
 	return RDB.ZRange(ctx, key, start, stop).Result()
 }
 
+// ZRevRangeWithScores 按分数从高到低获取有序集合成员及分数
+func ZRevRangeWithScores(ctx context.Context, key string, start, stop int64) ([]redis.Z, error) {
+	return RDB.ZRevRangeWithScores(ctx, key, start, stop).Result()
+}
+
+// ZCard 获取有序集合成员数
+func ZCard(ctx context.Context, key string) (int64, error) {
+	return RDB.ZCard(ctx, key).Result()
+}
+
+// ZRem 删除有序集合成员
+func ZRem(ctx context.Context, key string, members ...interface{}) error {
+	return RDB.ZRem(ctx, key, members...).Err()
+}
+
+// SRem 删除集合成员
+func SRem(ctx context.Context, key string, members ...interface{}) error {
+	return RDB.SRem(ctx, key, members...).Err()
+}
+
+// SPopN 随机弹出多个集合成员
+func SPopN(ctx context.Context, key string, count int64) ([]string, error) {
+	return RDB.SPopN(ctx, key, count).Result()
+}
+
 // CloseRedis 关闭Redis连接
 func CloseRedis() {
 	if RDB != nil {
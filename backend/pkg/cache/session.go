@@ -0,0 +1,28 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// touchSessionScript 校验会话归属并刷新最近活跃时间，一次往返完成
+// KEYS[1] 会话hash；ARGV[1] 用户ID；ARGV[2] 当前时间戳
+// 返回 1 表示会话有效，0 表示会话不存在或不属于该用户
+var touchSessionScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'user_id') ~= ARGV[1] then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'last_seen', ARGV[2])
+return 1
+`)
+
+// TouchSession 会话属于该用户时刷新最近活跃时间，返回会话是否有效
+func TouchSession(ctx context.Context, key string, userID int64, now time.Time) (bool, error) {
+	ok, err := touchSessionScript.Run(ctx, RDB, []string{key}, userID, now.Unix()).Int()
+	if err != nil {
+		return false, err
+	}
+	return ok == 1, nil
+}
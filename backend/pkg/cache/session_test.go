@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTouchSession(t *testing.T) {
+	mr := useMiniredis(t)
+	ctx := context.Background()
+	if err := HSet(ctx, "session:s1", "user_id", 7, "last_seen", 100); err != nil {
+		t.Fatalf("HSet: %v", err)
+	}
+
+	now := time.Unix(1700000000, 0)
+	cases := []struct {
+		name     string
+		key      string
+		userID   int64
+		want     bool
+		lastSeen string
+	}{
+		{"other user", "session:s1", 8, false, "100"},
+		{"missing session", "session:s2", 7, false, ""},
+		{"owner refreshes last seen", "session:s1", 7, true, "1700000000"},
+	}
+	for _, tc := range cases {
+		ok, err := TouchSession(ctx, tc.key, tc.userID, now)
+		if err != nil {
+			t.Fatalf("%s: TouchSession: %v", tc.name, err)
+		}
+		if ok != tc.want {
+			t.Errorf("%s: TouchSession = %v, want %v", tc.name, ok, tc.want)
+		}
+		if got := mr.HGet(tc.key, "last_seen"); got != tc.lastSeen {
+			t.Errorf("%s: last_seen = %q, want %q", tc.name, got, tc.lastSeen)
+		}
+	}
+
+	// 不存在的会话不会被脚本创建
+	if mr.Exists("session:s2") {
+		t.Error("touching a missing session should not create it")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/spf13/viper"
 )
@@ -44,8 +45,11 @@ type Config struct {
 	} `mapstructure:"elasticsearch"`
 
 	JWT struct {
-		Secret      string `mapstructure:"secret"`
-		ExpireHours int    `mapstructure:"expire_hours"`
+		Secret        string `mapstructure:"secret"`
+		ExpireHours   int    `mapstructure:"expire_hours"`
+		Algorithm     string `mapstructure:"algorithm"` // HS256, RS256, EdDSA
+		KeysDir       string `mapstructure:"keys_dir"`
+		RotationHours int    `mapstructure:"rotation_hours"`
 	} `mapstructure:"jwt"`
 
 	Log struct {
@@ -57,9 +61,20 @@ type Config struct {
 	} `mapstructure:"log"`
 
 	Upload struct {
+		Driver       string   `mapstructure:"driver"` // local, s3
 		Path         string   `mapstructure:"path"`
+		BaseURL      string   `mapstructure:"base_url"` // 本地存储对外访问前缀
 		MaxSize      int      `mapstructure:"max_size"`
 		AllowedTypes []string `mapstructure:"allowed_types"`
+		S3           struct {
+			Endpoint  string `mapstructure:"endpoint"`
+			Region    string `mapstructure:"region"`
+			Bucket    string `mapstructure:"bucket"`
+			AccessKey string `mapstructure:"access_key"`
+			SecretKey string `mapstructure:"secret_key"`
+			PublicURL string `mapstructure:"public_url"` // CDN或存储桶对外访问前缀
+			PathStyle bool   `mapstructure:"path_style"`
+		} `mapstructure:"s3"`
 	} `mapstructure:"upload"`
 
 	Wechat struct {
@@ -74,6 +89,80 @@ type Config struct {
 		PrivateKey      string `mapstructure:"private_key"`
 		AlipayPublicKey string `mapstructure:"alipay_public_key"`
 	} `mapstructure:"alipay"`
+
+	SMS struct {
+		Provider          string            `mapstructure:"provider"`
+		FilePath          string            `mapstructure:"file_path"`
+		SignName          string            `mapstructure:"sign_name"`
+		CodeExpireMinutes int               `mapstructure:"code_expire_minutes"`
+		PhoneDailyLimit   int               `mapstructure:"phone_daily_limit"`
+		IPDailyLimit      int               `mapstructure:"ip_daily_limit"`
+		MaxVerifyAttempts int               `mapstructure:"max_verify_attempts"`
+		Templates         map[string]string `mapstructure:"templates"`
+		Aliyun            struct {
+			AccessKeyID     string `mapstructure:"access_key_id"`
+			AccessKeySecret string `mapstructure:"access_key_secret"`
+			Endpoint        string `mapstructure:"endpoint"`
+			RegionID        string `mapstructure:"region_id"`
+		} `mapstructure:"aliyun"`
+	} `mapstructure:"sms"`
+
+	Login struct {
+		MaxFailedAttempts   int   `mapstructure:"max_failed_attempts"`
+		FailedWindowMinutes int   `mapstructure:"failed_window_minutes"`
+		LockoutMinutes      []int `mapstructure:"lockout_minutes"`
+	} `mapstructure:"login"`
+
+	Session struct {
+		MaxPerPlatform map[string]int `mapstructure:"max_per_platform"`
+	} `mapstructure:"session"`
+
+	Privacy struct {
+		ExportDir           string   `mapstructure:"export_dir"`
+		ExportExpireHours   int      `mapstructure:"export_expire_hours"`
+		DeletionCoolingDays int      `mapstructure:"deletion_cooling_days"`
+		RevealRoles         []string `mapstructure:"reveal_roles"` // 可查看完整手机号、地址的管理员角色
+	} `mapstructure:"privacy"`
+
+	Encryption struct {
+		ActiveVersion int               `mapstructure:"active_version"`
+		Keys          map[string]string `mapstructure:"keys"` // 版本号 -> base64编码的32字节密钥
+		BlindIndexKey string            `mapstructure:"blind_index_key"`
+	} `mapstructure:"encryption"`
+
+	RateLimit struct {
+		Enabled  bool                       `mapstructure:"enabled"`
+		Policies map[string]RateLimitPolicy `mapstructure:"policies"`
+	} `mapstructure:"rate_limit"`
+
+	Signature struct {
+		Enabled              bool              `mapstructure:"enabled"`
+		TimestampSkewSeconds int               `mapstructure:"timestamp_skew_seconds"`
+		EnforcePlatforms     []string          `mapstructure:"enforce_platforms"`
+		Apps                 map[string]string `mapstructure:"apps"` // app_id -> secret
+	} `mapstructure:"signature"`
+
+	Order struct {
+		AutoCancelMinutes int `mapstructure:"auto_cancel_minutes"` // 未付款订单自动取消时间
+	} `mapstructure:"order"`
+
+	Loyalty struct {
+		PointsExpireDays int `mapstructure:"points_expire_days"` // 积分有效期，0表示永不过期
+		ExpireNoticeDays int `mapstructure:"expire_notice_days"` // 提示即将过期积分的天数
+	} `mapstructure:"loyalty"`
+
+	Footprint struct {
+		MaxItems               int `mapstructure:"max_items"`                // 每个用户保留的足迹数
+		RetentionDays          int `mapstructure:"retention_days"`           // MySQL中足迹保留天数
+		PersistIntervalSeconds int `mapstructure:"persist_interval_seconds"` // Redis足迹落库间隔
+	} `mapstructure:"footprint"`
+}
+
+// RateLimitPolicy 限流策略
+type RateLimitPolicy struct {
+	Limit         int    `mapstructure:"limit"`
+	WindowSeconds int    `mapstructure:"window_seconds"`
+	KeyBy         string `mapstructure:"key_by"` // ip, user, phone
 }
 
 var GlobalConfig *Config
@@ -97,6 +186,7 @@ func LoadConfig() *Config {
 	if err := viper.ReadInConfig(); err != nil {
 		log.Fatalf("Error reading config file: %v", err)
 	}
+	expandEnvValues()
 
 	var config Config
 	if err := viper.Unmarshal(&config); err != nil {
@@ -107,6 +197,15 @@ func LoadConfig() *Config {
 	return &config
 }
 
+// expandEnvValues 展开配置值中的${VAR}环境变量占位符，密钥等敏感配置不写入配置文件
+func expandEnvValues() {
+	for _, key := range viper.AllKeys() {
+		if value, ok := viper.Get(key).(string); ok && strings.Contains(value, "${") {
+			viper.Set(key, os.ExpandEnv(value))
+		}
+	}
+}
+
 // GetConfig 获取全局配置
 func GetConfig() *Config {
 	if GlobalConfig == nil {
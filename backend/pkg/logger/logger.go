@@ -65,8 +65,8 @@ func InitLogger(level, filename string, maxSize, maxAge, maxBackups int) {
 		logLevel,
 	)
 
-	// 合并core
-	core := zapcore.NewTee(fileCore, consoleCore)
+	// 合并core，写入前统一脱敏
+	core := newMaskCore(zapcore.NewTee(fileCore, consoleCore))
 
 	// 创建logger
 	Logger = zap.New(core, zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
@@ -0,0 +1,202 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// phonePattern 日志中的手机号
+var phonePattern = regexp.MustCompile(`1[3-9]\d{9}`)
+
+// maskCore 日志脱敏core，写入前对手机号、地址做脱敏
+type maskCore struct {
+	zapcore.Core
+}
+
+// newMaskCore 包装core增加脱敏
+func newMaskCore(core zapcore.Core) zapcore.Core {
+	return &maskCore{Core: core}
+}
+
+// With 添加字段时同样脱敏
+func (c *maskCore) With(fields []zapcore.Field) zapcore.Core {
+	return &maskCore{Core: c.Core.With(maskFields(fields))}
+}
+
+// Check 检查日志级别
+func (c *maskCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+// Write 脱敏后写入
+func (c *maskCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = MaskText(entry.Message)
+	return c.Core.Write(entry, maskFields(fields))
+}
+
+// maskFields 按字段名或内容脱敏字段
+func maskFields(fields []zapcore.Field) []zapcore.Field {
+	masked := make([]zapcore.Field, len(fields))
+	for i, field := range fields {
+		masked[i] = maskField(field)
+	}
+	return masked
+}
+
+// maskField 脱敏单个字段，结构体、Stringer、error等非字符串字段先编码再按字段名递归脱敏
+func maskField(field zapcore.Field) zapcore.Field {
+	key := strings.ToLower(field.Key)
+	switch field.Type {
+	case zapcore.StringType:
+		field.String = maskString(key, field.String)
+		return field
+	case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type,
+		zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type:
+		if !isPhoneKey(key) {
+			return field
+		}
+	case zapcore.ByteStringType, zapcore.StringerType, zapcore.ErrorType, zapcore.ReflectType,
+		zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType:
+	default:
+		return field
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	field.AddTo(enc)
+	value, ok := enc.Fields[field.Key]
+	if !ok {
+		return field
+	}
+
+	switch v := maskValue(key, value).(type) {
+	case string:
+		return zapcore.Field{Key: field.Key, Type: zapcore.StringType, String: v}
+	default:
+		return zapcore.Field{Key: field.Key, Type: zapcore.ReflectType, Interface: v}
+	}
+}
+
+// maskValue 按字段名递归脱敏编码后的值
+func maskValue(key string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, bool:
+		return v
+	case string:
+		return maskString(key, v)
+	case []byte:
+		return maskString(key, string(v))
+	case map[string]interface{}:
+		masked := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			masked[k] = maskValue(strings.ToLower(k), item)
+		}
+		return masked
+	case []interface{}:
+		masked := make([]interface{}, len(v))
+		for i, item := range v {
+			masked[i] = maskValue(key, item)
+		}
+		return masked
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, json.Number:
+		if isPhoneKey(key) {
+			return MaskPhone(fmt.Sprint(v))
+		}
+		return v
+	}
+
+	// 其他类型（结构体、切片等）转为通用JSON结构后脱敏
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return value
+	}
+	return maskValue(key, generic)
+}
+
+// maskString 按字段名脱敏字符串，未命中字段名时脱敏其中的手机号
+func maskString(key, value string) string {
+	switch {
+	case isPhoneKey(key):
+		return MaskPhone(value)
+	case strings.Contains(key, "address"):
+		return MaskAddress(value)
+	default:
+		return MaskText(value)
+	}
+}
+
+// isPhoneKey 判断字段名是否表示手机号
+func isPhoneKey(key string) bool {
+	return strings.Contains(key, "phone") || strings.Contains(key, "mobile")
+}
+
+// MaskText 脱敏文本中出现的手机号
+func MaskText(text string) string {
+	indexes := phonePattern.FindAllStringIndex(text, -1)
+	if len(indexes) == 0 {
+		return text
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, idx := range indexes {
+		start, end := idx[0], idx[1]
+		// 更长数字串中的片段（如订单号）不处理
+		if (start > 0 && isDigit(text[start-1])) || (end < len(text) && isDigit(text[end])) {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(MaskPhone(text[start:end]))
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// MaskPhone 手机号脱敏，保留前3位和后4位
+func MaskPhone(phone string) string {
+	runes := []rune(phone)
+	if len(runes) < 7 {
+		return phone
+	}
+	return string(runes[:3]) + strings.Repeat("*", len(runes)-7) + string(runes[len(runes)-4:])
+}
+
+// MaskAddress 地址脱敏，保留前6个字符
+func MaskAddress(address string) string {
+	runes := []rune(address)
+	if len(runes) <= 6 {
+		return address
+	}
+	return string(runes[:6]) + "****"
+}
+
+// MaskAccount 账号脱敏，保留首尾各2个字符，过短时只保留首字符
+func MaskAccount(account string) string {
+	runes := []rune(account)
+	switch {
+	case len(runes) == 0:
+		return account
+	case len(runes) <= 4:
+		return string(runes[:1]) + "***"
+	default:
+		return string(runes[:2]) + "****" + string(runes[len(runes)-2:])
+	}
+}
+
+// isDigit 判断是否数字字符
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
@@ -0,0 +1,91 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"mall/pkg/logger"
+)
+
+// Job 定时任务
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context)
+}
+
+// Scheduler 后台定时任务调度器
+type Scheduler struct {
+	jobs   []*Job
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New 创建调度器
+func New() *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Every 注册按固定间隔执行的任务，需在Start之前调用
+func (s *Scheduler) Every(name string, interval time.Duration, fn func(ctx context.Context)) {
+	s.jobs = append(s.jobs, &Job{
+		Name:     name,
+		Interval: interval,
+		Run:      fn,
+	})
+}
+
+// Start 启动所有任务
+func (s *Scheduler) Start() {
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(job)
+	}
+	logger.Info(fmt.Sprintf("Scheduler started with %d jobs", len(s.jobs)))
+}
+
+// Stop 停止调度器并等待正在执行的任务结束
+func (s *Scheduler) Stop() {
+	s.cancel()
+	s.wg.Wait()
+	logger.Info("Scheduler stopped")
+}
+
+// loop 任务执行循环
+func (s *Scheduler) loop(job *Job) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.runJob(job)
+		}
+	}
+}
+
+// runJob 执行单次任务，防止panic导致调度器退出
+func (s *Scheduler) runJob(job *Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("Scheduled job panicked", zap.String("job", job.Name), zap.Any("panic", r))
+		}
+	}()
+
+	start := time.Now()
+	job.Run(s.ctx)
+	logger.Debug("Scheduled job finished", zap.String("job", job.Name), zap.Duration("duration", time.Since(start)))
+}
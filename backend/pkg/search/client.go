@@ -0,0 +1,20 @@
+package search
+
+import (
+	"fmt"
+
+	"github.com/elastic/go-elasticsearch/v8"
+
+	"mall/pkg/config"
+)
+
+// NewClient 根据配置创建Elasticsearch客户端，创建时不连接，请求失败时由调用方处理
+func NewClient() (*elasticsearch.Client, error) {
+	cfg := config.GetConfig().Elasticsearch
+
+	return elasticsearch.NewClient(elasticsearch.Config{
+		Addresses: []string{fmt.Sprintf("http://%s:%d", cfg.Host, cfg.Port)},
+		Username:  cfg.Username,
+		Password:  cfg.Password,
+	})
+}
@@ -0,0 +1,170 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"mall/pkg/config"
+	"mall/pkg/logger"
+)
+
+// 短信场景
+const (
+	SceneLogin    = "login"
+	SceneRegister = "register"
+	SceneBind     = "bind"
+	SceneReset    = "reset"
+	SceneDelete   = "delete"
+)
+
+// Template 短信模板
+type Template struct {
+	Scene   string // 业务场景
+	Code    string // 服务商模板编号
+	Content string // 模板内容，${key} 为占位符
+}
+
+// Render 渲染模板内容
+func (t *Template) Render(params map[string]string) string {
+	content := t.Content
+	for key, value := range params {
+		content = strings.ReplaceAll(content, "${"+key+"}", value)
+	}
+	return content
+}
+
+// SMSSender 短信发送接口
+type SMSSender interface {
+	Send(ctx context.Context, phone string, tpl *Template, params map[string]string) error
+}
+
+var (
+	templates   = make(map[string]*Template)
+	templatesMu sync.RWMutex
+)
+
+func init() {
+	RegisterTemplate(&Template{Scene: SceneLogin, Code: "SMS_LOGIN", Content: "您的登录验证码为${code}，${expire}分钟内有效，请勿泄露给他人。"})
+	RegisterTemplate(&Template{Scene: SceneRegister, Code: "SMS_REGISTER", Content: "您正在注册账号，验证码为${code}，${expire}分钟内有效。"})
+	RegisterTemplate(&Template{Scene: SceneBind, Code: "SMS_BIND", Content: "您正在绑定手机号，验证码为${code}，${expire}分钟内有效。"})
+	RegisterTemplate(&Template{Scene: SceneReset, Code: "SMS_RESET", Content: "您正在重置密码，验证码为${code}，${expire}分钟内有效，如非本人操作请忽略。"})
+	RegisterTemplate(&Template{Scene: SceneDelete, Code: "SMS_DELETE", Content: "您正在申请注销账号，验证码为${code}，${expire}分钟内有效，如非本人操作请立即修改密码。"})
+}
+
+// RegisterTemplate 注册短信模板
+func RegisterTemplate(tpl *Template) {
+	templatesMu.Lock()
+	defer templatesMu.Unlock()
+	templates[tpl.Scene] = tpl
+}
+
+// GetTemplate 根据场景获取短信模板
+func GetTemplate(scene string) (*Template, error) {
+	templatesMu.RLock()
+	defer templatesMu.RUnlock()
+
+	tpl, ok := templates[scene]
+	if !ok {
+		return nil, fmt.Errorf("sms template for scene %s not found", scene)
+	}
+	return tpl, nil
+}
+
+// NewSender 根据配置创建短信发送器，生产模式下不允许使用日志或文件发送器
+func NewSender() (SMSSender, error) {
+	cfg := config.GetConfig()
+
+	// 配置中的模板编号覆盖默认值
+	for scene, code := range cfg.SMS.Templates {
+		if tpl, err := GetTemplate(scene); err == nil {
+			RegisterTemplate(&Template{Scene: scene, Code: code, Content: tpl.Content})
+		}
+	}
+
+	switch cfg.SMS.Provider {
+	case "aliyun":
+		return NewAliyunSender(AliyunOptions{
+			AccessKeyID:     cfg.SMS.Aliyun.AccessKeyID,
+			AccessKeySecret: cfg.SMS.Aliyun.AccessKeySecret,
+			Endpoint:        cfg.SMS.Aliyun.Endpoint,
+			RegionID:        cfg.SMS.Aliyun.RegionID,
+			SignName:        cfg.SMS.SignName,
+		})
+	case "file", "log", "":
+		if cfg.Server.Mode == "release" {
+			return nil, fmt.Errorf("sms provider %q is for development only", cfg.SMS.Provider)
+		}
+		if cfg.SMS.Provider == "file" {
+			return NewFileSender(cfg.SMS.FilePath, cfg.SMS.SignName), nil
+		}
+		return NewLogSender(cfg.SMS.SignName), nil
+	default:
+		return nil, fmt.Errorf("unsupported sms provider %q", cfg.SMS.Provider)
+	}
+}
+
+// logSender 日志短信发送器（开发环境）
+type logSender struct {
+	signName string
+}
+
+// NewLogSender 创建日志短信发送器
+func NewLogSender(signName string) SMSSender {
+	return &logSender{signName: signName}
+}
+
+// Send 将短信内容写入应用日志
+func (s *logSender) Send(ctx context.Context, phone string, tpl *Template, params map[string]string) error {
+	logger.Info("SMS sent",
+		zap.String("phone", phone),
+		zap.String("template", tpl.Code),
+		zap.String("content", fmt.Sprintf("【%s】%s", s.signName, tpl.Render(params))),
+	)
+	return nil
+}
+
+// fileSender 文件短信发送器（开发环境）
+type fileSender struct {
+	path     string
+	signName string
+	mu       sync.Mutex
+}
+
+// NewFileSender 创建文件短信发送器
+func NewFileSender(path, signName string) SMSSender {
+	if path == "" {
+		path = "logs/sms.log"
+	}
+	return &fileSender{path: path, signName: signName}
+}
+
+// Send 将短信内容追加写入文件
+func (s *fileSender) Send(ctx context.Context, phone string, tpl *Template, params map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	line := fmt.Sprintf("%s\t%s\t%s\t【%s】%s\n",
+		time.Now().Format(time.RFC3339), logger.MaskText(phone), tpl.Code, s.signName, tpl.Render(params))
+	if _, err := f.WriteString(line); err != nil {
+		return errors.New("failed to write sms file")
+	}
+	return nil
+}
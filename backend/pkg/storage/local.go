@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// localStorage 本地磁盘存储
+type localStorage struct {
+	root    string
+	baseURL string
+}
+
+// NewLocalStorage 创建本地磁盘存储，baseURL为对外访问前缀
+func NewLocalStorage(root, baseURL string) Storage {
+	if baseURL == "" {
+		baseURL = "/uploads"
+	}
+	return &localStorage{root: root, baseURL: baseURL}
+}
+
+// Put 先写临时文件再重命名，避免读到写了一半的文件
+func (s *localStorage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Exists 判断文件是否已存在
+func (s *localStorage) Exists(ctx context.Context, key string) (bool, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return false, err
+	}
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// URL 获取文件访问地址
+func (s *localStorage) URL(key string) string {
+	return joinURL(s.baseURL, key)
+}
+
+// path 将存储路径转换为磁盘路径，拒绝跳出根目录的路径
+func (s *localStorage) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if strings.Contains(key, "..") || clean == "/" {
+		return "", errors.New("invalid storage key")
+	}
+	return filepath.Join(s.root, clean), nil
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"context"
+	"strings"
+
+	"mall/pkg/config"
+)
+
+// Storage 文件存储接口，key为存储路径（如 avatar/ab/abcdef.jpg）
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Exists(ctx context.Context, key string) (bool, error)
+	URL(key string) string
+}
+
+// NewStorage 根据配置创建文件存储
+func NewStorage() Storage {
+	cfg := config.GetConfig().Upload
+
+	switch cfg.Driver {
+	case "s3":
+		return NewS3Storage(&S3Options{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+			PublicURL: cfg.S3.PublicURL,
+			PathStyle: cfg.S3.PathStyle,
+		})
+	default:
+		return NewLocalStorage(cfg.Path, cfg.BaseURL)
+	}
+}
+
+// joinURL 拼接访问前缀与存储路径
+func joinURL(base, key string) string {
+	return strings.TrimRight(base, "/") + "/" + strings.TrimLeft(key, "/")
+}
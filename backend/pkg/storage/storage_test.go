@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLocalStorage(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	s := NewLocalStorage(root, "")
+
+	cases := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{"nested key", "avatar/ab/abc.png", false},
+		{"leading slash", "/product/cd/cde.jpg", false},
+		{"parent directory", "../escape.png", true},
+		{"parent directory inside key", "avatar/../../escape.png", true},
+		{"empty key", "", true},
+	}
+	for _, tc := range cases {
+		err := s.Put(ctx, tc.key, []byte("data"), "image/png")
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: Put err = %v, wantErr %v", tc.name, err, tc.wantErr)
+			continue
+		}
+		exists, err := s.Exists(ctx, tc.key)
+		if (err != nil) != tc.wantErr || exists == tc.wantErr {
+			t.Errorf("%s: Exists = %v, %v", tc.name, exists, err)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, "avatar", "ab", "abc.png"))
+	if err != nil || string(data) != "data" {
+		t.Errorf("stored file = %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(root), "escape.png")); err == nil {
+		t.Error("file written outside the root")
+	}
+	if exists, _ := s.Exists(ctx, "avatar/ab/missing.png"); exists {
+		t.Error("missing file reported as existing")
+	}
+	if got := s.URL("avatar/ab/abc.png"); got != "/uploads/avatar/ab/abc.png" {
+		t.Errorf("URL = %s", got)
+	}
+}
+
+func TestS3Storage(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	objects := map[string][]byte{}
+	var lastAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		lastAuth = r.Header.Get("Authorization")
+		switch r.Method {
+		case http.MethodPut:
+			if r.Header.Get("Content-Type") == "" || r.Header.Get("X-Amz-Content-Sha256") == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if strings.Contains(r.URL.Path, "denied") {
+				w.WriteHeader(http.StatusForbidden)
+				io.WriteString(w, "AccessDenied")
+				return
+			}
+			data, _ := io.ReadAll(r.Body)
+			objects[r.URL.EscapedPath()] = data
+		case http.MethodHead:
+			if strings.Contains(r.URL.Path, "denied") {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			if _, ok := objects[r.URL.EscapedPath()]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	s := NewS3Storage(&S3Options{Endpoint: server.URL + "/", Bucket: "mall", AccessKey: "AK", SecretKey: "SK", PathStyle: true})
+
+	steps := []struct {
+		name       string
+		key        string
+		put        bool
+		wantErr    bool
+		wantExists bool
+	}{
+		{"missing object", "product/ab/abc.png", false, false, false},
+		{"uploaded object", "product/ab/abc.png", true, false, true},
+		{"key needing escape", "product/a b+c.png", true, false, true},
+		{"rejected upload", "denied/abc.png", true, true, false},
+		{"unexpected status", "denied/abc.png", false, true, false},
+	}
+	for _, step := range steps {
+		var err error
+		if step.put {
+			err = s.Put(ctx, step.key, []byte("data"), "image/png")
+		} else {
+			_, err = s.Exists(ctx, step.key)
+		}
+		if (err != nil) != step.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", step.name, err, step.wantErr)
+		}
+		if !strings.HasPrefix(lastAuth, "AWS4-HMAC-SHA256 Credential=AK/") ||
+			!strings.Contains(lastAuth, "/us-east-1/s3/aws4_request") ||
+			!strings.Contains(lastAuth, "SignedHeaders=") {
+			t.Errorf("%s: authorization = %s", step.name, lastAuth)
+		}
+		if strings.HasPrefix(step.key, "denied") {
+			continue
+		}
+		exists, err := s.Exists(ctx, step.key)
+		if err != nil || exists != step.wantExists {
+			t.Errorf("%s: Exists = %v, %v", step.name, exists, err)
+		}
+	}
+
+	if _, ok := objects["/mall/product/a%20b%2Bc.png"]; !ok {
+		t.Errorf("objects = %v, want escaped key under the bucket path", objects)
+	}
+}
+
+func TestS3StorageURL(t *testing.T) {
+	cases := []struct {
+		name string
+		opts S3Options
+		want string
+	}{
+		{"virtual hosted", S3Options{Bucket: "mall", Region: "ap-east-1"}, "https://mall.s3.ap-east-1.amazonaws.com/avatar/a.png"},
+		{"path style", S3Options{Endpoint: "http://minio:9000", Bucket: "mall", PathStyle: true}, "http://minio:9000/mall/avatar/a.png"},
+		{"public url", S3Options{Bucket: "mall", PublicURL: "https://cdn.example.com/"}, "https://cdn.example.com/avatar/a.png"},
+		{"endpoint without scheme", S3Options{Endpoint: "oss.example.com", Bucket: "mall"}, "https://mall.oss.example.com/avatar/a.png"},
+	}
+	for _, tc := range cases {
+		opts := tc.opts
+		if got := NewS3Storage(&opts).URL("/avatar/a.png"); got != tc.want {
+			t.Errorf("%s: URL = %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
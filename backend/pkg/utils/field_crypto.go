@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"mall/pkg/config"
+)
+
+// fieldKeyring 字段加密密钥环
+type fieldKeyring struct {
+	keys     map[int][]byte
+	active   int
+	blindKey []byte
+}
+
+var (
+	fieldKeysOnce sync.Once
+	fieldKeys     *fieldKeyring
+	fieldKeysErr  error
+)
+
+// InitFieldCrypto 加载字段加密密钥，配置错误时返回错误
+func InitFieldCrypto() error {
+	_, err := loadFieldKeys()
+	return err
+}
+
+// loadFieldKeys 从配置加载密钥，只加载一次
+func loadFieldKeys() (*fieldKeyring, error) {
+	fieldKeysOnce.Do(func() {
+		cfg := config.GetConfig().Encryption
+
+		ring := &fieldKeyring{keys: make(map[int][]byte), active: cfg.ActiveVersion}
+		for version, encoded := range cfg.Keys {
+			v, err := strconv.Atoi(version)
+			if err != nil || v <= 0 {
+				fieldKeysErr = fmt.Errorf("invalid encryption key version: %s", version)
+				return
+			}
+			key, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil || len(key) != 32 {
+				fieldKeysErr = fmt.Errorf("encryption key v%d must be 32 bytes base64", v)
+				return
+			}
+			ring.keys[v] = key
+		}
+		if _, ok := ring.keys[ring.active]; !ok {
+			fieldKeysErr = fmt.Errorf("active encryption key v%d not configured", ring.active)
+			return
+		}
+		if len(cfg.BlindIndexKey) < 16 {
+			fieldKeysErr = errors.New("blind index key must be at least 16 characters")
+			return
+		}
+		ring.blindKey = []byte(cfg.BlindIndexKey)
+
+		fieldKeys = ring
+	})
+	return fieldKeys, fieldKeysErr
+}
+
+// EncryptField 使用当前版本密钥加密字段，格式为 v<版本>:base64(nonce+密文)
+func EncryptField(plain string) (string, error) {
+	if plain == "" {
+		return "", nil
+	}
+
+	ring, err := loadFieldKeys()
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := newFieldGCM(ring.keys[ring.active])
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return fmt.Sprintf("v%d:%s", ring.active, base64.StdEncoding.EncodeToString(sealed)), nil
+}
+
+// DecryptField 按密文中的版本号选择密钥解密，未加密的历史数据原样返回
+func DecryptField(value string) (string, error) {
+	version, payload, ok := parseFieldCipher(value)
+	if !ok {
+		return value, nil
+	}
+
+	ring, err := loadFieldKeys()
+	if err != nil {
+		return "", err
+	}
+	key, exists := ring.keys[version]
+	if !exists {
+		return "", fmt.Errorf("encryption key v%d not configured", version)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newFieldGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("invalid encrypted field")
+	}
+
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// FieldKeyVersion 返回密文使用的密钥版本，未加密返回0
+func FieldKeyVersion(value string) int {
+	version, _, ok := parseFieldCipher(value)
+	if !ok {
+		return 0
+	}
+	return version
+}
+
+// BlindIndex 计算字段的盲索引，用于加密字段的等值查询
+func BlindIndex(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+
+	ring, err := loadFieldKeys()
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, ring.blindKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// parseFieldCipher 解析 v<版本>:<密文> 格式
+func parseFieldCipher(value string) (int, string, bool) {
+	if len(value) < 3 || value[0] != 'v' {
+		return 0, "", false
+	}
+	idx := strings.IndexByte(value, ':')
+	if idx < 2 {
+		return 0, "", false
+	}
+	version, err := strconv.Atoi(value[1:idx])
+	if err != nil || version <= 0 {
+		return 0, "", false
+	}
+	return version, value[idx+1:], true
+}
+
+// newFieldGCM 创建AES-256-GCM
+func newFieldGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
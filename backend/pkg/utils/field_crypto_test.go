@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"encoding/base64"
+	"strings"
+	"sync"
+	"testing"
+
+	"mall/pkg/config"
+)
+
+var (
+	testFieldKeyV1 = base64.StdEncoding.EncodeToString([]byte(strings.Repeat("1", 32)))
+	testFieldKeyV2 = base64.StdEncoding.EncodeToString([]byte(strings.Repeat("2", 32)))
+)
+
+// useFieldKeys 使用指定的密钥配置重新加载密钥环
+func useFieldKeys(t *testing.T, active int, keys map[string]string, blindKey string) {
+	t.Helper()
+
+	prev := config.GlobalConfig
+	cfg := &config.Config{}
+	cfg.Encryption.ActiveVersion = active
+	cfg.Encryption.Keys = keys
+	cfg.Encryption.BlindIndexKey = blindKey
+	config.GlobalConfig = cfg
+	resetFieldKeys()
+
+	t.Cleanup(func() {
+		config.GlobalConfig = prev
+		resetFieldKeys()
+	})
+}
+
+// resetFieldKeys 清除已加载的密钥环
+func resetFieldKeys() {
+	fieldKeysOnce = sync.Once{}
+	fieldKeys = nil
+	fieldKeysErr = nil
+}
+
+func TestEncryptDecryptField(t *testing.T) {
+	useFieldKeys(t, 1, map[string]string{"1": testFieldKeyV1}, "blind-index-test-key")
+
+	cipherText, err := EncryptField("13800138000")
+	if err != nil {
+		t.Fatalf("EncryptField: %v", err)
+	}
+	if !strings.HasPrefix(cipherText, "v1:") {
+		t.Errorf("cipher text %q should carry the key version", cipherText)
+	}
+	if FieldKeyVersion(cipherText) != 1 {
+		t.Errorf("FieldKeyVersion = %d, want 1", FieldKeyVersion(cipherText))
+	}
+
+	again, _ := EncryptField("13800138000")
+	if again == cipherText {
+		t.Error("encryption should use a random nonce")
+	}
+
+	plain, err := DecryptField(cipherText)
+	if err != nil {
+		t.Fatalf("DecryptField: %v", err)
+	}
+	if plain != "13800138000" {
+		t.Errorf("DecryptField = %q, want 13800138000", plain)
+	}
+
+	if empty, err := EncryptField(""); err != nil || empty != "" {
+		t.Errorf("EncryptField(\"\") = %q, %v; want empty", empty, err)
+	}
+}
+
+func TestDecryptFieldLegacyPlaintext(t *testing.T) {
+	useFieldKeys(t, 1, map[string]string{"1": testFieldKeyV1}, "blind-index-test-key")
+
+	for _, value := range []string{"13800138000", "北京市朝阳区", "v:abc", "vx:abc", ""} {
+		plain, err := DecryptField(value)
+		if err != nil || plain != value {
+			t.Errorf("DecryptField(%q) = %q, %v; want unchanged", value, plain, err)
+		}
+		if FieldKeyVersion(value) != 0 {
+			t.Errorf("FieldKeyVersion(%q) should be 0", value)
+		}
+	}
+}
+
+func TestDecryptFieldRejectsTamperedCipher(t *testing.T) {
+	useFieldKeys(t, 1, map[string]string{"1": testFieldKeyV1}, "blind-index-test-key")
+
+	cipherText, _ := EncryptField("secret")
+	sealed, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(cipherText, "v1:"))
+	sealed[len(sealed)-1] ^= 0xff
+	tampered := "v1:" + base64.StdEncoding.EncodeToString(sealed)
+
+	if _, err := DecryptField(tampered); err == nil {
+		t.Error("tampered cipher text should fail to decrypt")
+	}
+	if _, err := DecryptField("v3:" + strings.TrimPrefix(cipherText, "v1:")); err == nil {
+		t.Error("unknown key version should fail to decrypt")
+	}
+}
+
+func TestFieldKeyRotation(t *testing.T) {
+	useFieldKeys(t, 1, map[string]string{"1": testFieldKeyV1}, "blind-index-test-key")
+	oldCipher, _ := EncryptField("广东省深圳市")
+
+	// 轮换到v2后旧密文仍可用v1解密，新密文使用v2
+	useFieldKeys(t, 2, map[string]string{"1": testFieldKeyV1, "2": testFieldKeyV2}, "blind-index-test-key")
+	plain, err := DecryptField(oldCipher)
+	if err != nil || plain != "广东省深圳市" {
+		t.Errorf("DecryptField(old) = %q, %v", plain, err)
+	}
+
+	newCipher, _ := EncryptField("广东省深圳市")
+	if FieldKeyVersion(newCipher) != 2 {
+		t.Errorf("new cipher version = %d, want 2", FieldKeyVersion(newCipher))
+	}
+}
+
+func TestFieldCryptoConfigValidation(t *testing.T) {
+	cases := map[string]struct {
+		active   int
+		keys     map[string]string
+		blindKey string
+	}{
+		"missing active key":  {2, map[string]string{"1": testFieldKeyV1}, "blind-index-test-key"},
+		"short key":           {1, map[string]string{"1": base64.StdEncoding.EncodeToString([]byte("short"))}, "blind-index-test-key"},
+		"invalid version":     {1, map[string]string{"1": testFieldKeyV1, "x": testFieldKeyV2}, "blind-index-test-key"},
+		"short blind key":     {1, map[string]string{"1": testFieldKeyV1}, "short"},
+		"unset configuration": {0, nil, ""},
+	}
+	for name, tc := range cases {
+		useFieldKeys(t, tc.active, tc.keys, tc.blindKey)
+		if err := InitFieldCrypto(); err == nil {
+			t.Errorf("%s: InitFieldCrypto should fail", name)
+		}
+		if _, err := EncryptField("x"); err == nil {
+			t.Errorf("%s: EncryptField should fail", name)
+		}
+		if _, err := BlindIndex("x"); err == nil {
+			t.Errorf("%s: BlindIndex should fail", name)
+		}
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	useFieldKeys(t, 1, map[string]string{"1": testFieldKeyV1}, "blind-index-test-key")
+
+	a, err := BlindIndex("13800138000")
+	if err != nil {
+		t.Fatalf("BlindIndex: %v", err)
+	}
+	if len(a) != 64 {
+		t.Errorf("blind index length = %d, want 64", len(a))
+	}
+
+	// 首尾空格不影响索引，不同值索引不同
+	if b, _ := BlindIndex(" 13800138000 "); b != a {
+		t.Error("blind index should ignore surrounding spaces")
+	}
+	if c, _ := BlindIndex("13800138001"); c == a {
+		t.Error("different values should have different blind indexes")
+	}
+	if empty, err := BlindIndex(""); err != nil || empty != "" {
+		t.Errorf("BlindIndex(\"\") = %q, %v; want empty", empty, err)
+	}
+
+	// 索引只依赖盲索引密钥，与加密密钥轮换无关
+	useFieldKeys(t, 2, map[string]string{"1": testFieldKeyV1, "2": testFieldKeyV2}, "blind-index-test-key")
+	if d, _ := BlindIndex("13800138000"); d != a {
+		t.Error("blind index should not change with encryption key rotation")
+	}
+	useFieldKeys(t, 1, map[string]string{"1": testFieldKeyV1}, "another-blind-index-key")
+	if e, _ := BlindIndex("13800138000"); e == a {
+		t.Error("blind index should depend on the blind index key")
+	}
+}
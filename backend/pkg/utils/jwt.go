@@ -1,14 +1,21 @@
 package utils
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 
+	"mall/pkg/cache"
 	"mall/pkg/config"
 )
 
+// tokenRevokedBeforeKey 用户token撤销时间点
+const tokenRevokedBeforeKey = "token_revoked_before:%d"
+
 // Claims JWT声明结构体
 type Claims struct {
 	UserID   int64  `json:"user_id"`
@@ -20,6 +27,11 @@ type Claims struct {
 
 // GenerateToken 生成JWT token
 func GenerateToken(userID int64, username, role, platform string) (string, error) {
+	return GenerateSessionToken(userID, username, role, platform, "")
+}
+
+// GenerateSessionToken 生成绑定会话的JWT token，会话标识写入jti
+func GenerateSessionToken(userID int64, username, role, platform, sessionID string) (string, error) {
 	cfg := config.GetConfig()
 	
 	now := time.Now()
@@ -36,11 +48,11 @@ func GenerateToken(userID int64, username, role, platform string) (string, error
 			NotBefore: jwt.NewNumericDate(now),
 			Issuer:    "mall-system",
 			Subject:   username,
+			ID:        sessionID,
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(cfg.JWT.Secret))
+	tokenString, err := signToken(claims)
 	if err != nil {
 		return "", err
 	}
@@ -50,20 +62,16 @@ func GenerateToken(userID int64, username, role, platform string) (string, error
 
 // ParseToken 解析JWT token
 func ParseToken(tokenString string) (*Claims, error) {
-	cfg := config.GetConfig()
-
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(cfg.JWT.Secret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, verificationKey)
 
 	if err != nil {
 		return nil, err
 	}
 
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		if IsTokenRevoked(claims) {
+			return nil, errors.New("token has been revoked")
+		}
 		return claims, nil
 	}
 
@@ -79,7 +87,7 @@ func RefreshToken(tokenString string) (string, error) {
 
 	// 检查token是否还在有效期内（允许在过期前1小时刷新）
 	if time.Until(claims.ExpiresAt.Time) < time.Hour {
-		return GenerateToken(claims.UserID, claims.Username, claims.Role, claims.Platform)
+		return GenerateSessionToken(claims.UserID, claims.Username, claims.Role, claims.Platform, claims.ID)
 	}
 
 	return "", errors.New("token is not eligible for refresh")
@@ -89,4 +97,30 @@ func RefreshToken(tokenString string) (string, error) {
 func ValidateToken(tokenString string) bool {
 	_, err := ParseToken(tokenString)
 	return err == nil
-}
\ No newline at end of file
+}
+
+// RevokeUserTokens 使用户此前签发的所有token失效
+func RevokeUserTokens(userID uint64) error {
+	cfg := config.GetConfig()
+	key := fmt.Sprintf(tokenRevokedBeforeKey, userID)
+	return cache.Set(context.Background(), key, time.Now().Unix(), time.Duration(cfg.JWT.ExpireHours)*time.Hour)
+}
+
+// IsTokenRevoked 检查用户token是否已被撤销
+func IsTokenRevoked(claims *Claims) bool {
+	if claims.Role != "user" {
+		return false
+	}
+
+	val, err := cache.Get(context.Background(), fmt.Sprintf(tokenRevokedBeforeKey, claims.UserID))
+	if err != nil {
+		return false
+	}
+	revokedAt, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	// iat精度为秒，与撤销时刻同一秒签发的token也视为已撤销
+	return claims.IssuedAt == nil || claims.IssuedAt.Unix() <= revokedAt
+}
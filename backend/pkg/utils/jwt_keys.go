@@ -0,0 +1,407 @@
+package utils
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+
+	"mall/pkg/cache"
+	"mall/pkg/config"
+	"mall/pkg/logger"
+)
+
+// 签名算法
+const (
+	JWTAlgHS256 = "HS256"
+	JWTAlgRS256 = "RS256"
+	JWTAlgEdDSA = "EdDSA"
+)
+
+// jwtKeyRotationLockKey 密钥轮换分布式锁，避免多实例同时生成新密钥
+const jwtKeyRotationLockKey = "jwt_key_rotation_lock"
+
+// jwtKeyReloadInterval 遇到未知kid时重新加载密钥的最小间隔，避免伪造kid触发频繁读盘
+const jwtKeyReloadInterval = 10 * time.Second
+
+// jwtKeyTimeLayout 密钥kid中的时间格式
+const jwtKeyTimeLayout = "20060102150405"
+
+// jwtKey JWT签名密钥
+type jwtKey struct {
+	kid       string
+	method    jwt.SigningMethod
+	private   interface{}
+	public    interface{}
+	createdAt time.Time
+}
+
+// jwtKeyStore 已加载的密钥集合
+type jwtKeyStore struct {
+	mu      sync.RWMutex
+	keys    map[string]*jwtKey
+	current *jwtKey
+
+	reloadMu   sync.Mutex
+	lastReload time.Time
+}
+
+var jwtKeys = &jwtKeyStore{keys: make(map[string]*jwtKey)}
+
+// JWK 公钥的JSON Web Key表示
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet JSON Web Key集合
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// jwtAlgorithm 当前配置的签名算法
+func jwtAlgorithm() string {
+	switch alg := config.GetConfig().JWT.Algorithm; alg {
+	case JWTAlgRS256, JWTAlgEdDSA:
+		return alg
+	default:
+		return JWTAlgHS256
+	}
+}
+
+// usesSharedSecret 是否使用共享密钥签名
+func usesSharedSecret() bool {
+	return jwtAlgorithm() == JWTAlgHS256
+}
+
+// InitJWTKeys 加载签名密钥，不存在可用密钥时生成
+func InitJWTKeys() error {
+	if usesSharedSecret() {
+		return nil
+	}
+
+	if err := loadJWTKeys(); err != nil {
+		return err
+	}
+
+	if currentJWTKey() == nil {
+		return rotateJWTKey()
+	}
+	return nil
+}
+
+// RotateJWTKeysIfDue 定时任务：重新加载密钥，到期时轮换并清理已过保留期的旧密钥
+func RotateJWTKeysIfDue() error {
+	if usesSharedSecret() {
+		return nil
+	}
+
+	if err := loadJWTKeys(); err != nil {
+		return err
+	}
+
+	current := currentJWTKey()
+	if current == nil || time.Since(current.createdAt) >= jwtRotationInterval() {
+		ok, err := cache.SetNX(context.Background(), jwtKeyRotationLockKey, "1", time.Minute)
+		if err != nil {
+			return err
+		}
+		if ok {
+			if err := rotateJWTKey(); err != nil {
+				return err
+			}
+		}
+	}
+
+	pruneJWTKeys()
+	return nil
+}
+
+// GetJWKS 获取当前所有可用于验签的公钥
+func GetJWKS() *JWKSet {
+	jwtKeys.mu.RLock()
+	defer jwtKeys.mu.RUnlock()
+
+	set := &JWKSet{Keys: []JWK{}}
+	for _, key := range sortedJWTKeys(jwtKeys.keys) {
+		jwk := JWK{
+			Use: "sig",
+			Alg: key.method.Alg(),
+			Kid: key.kid,
+		}
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// signToken 使用当前配置的算法签名token
+func signToken(claims jwt.Claims) (string, error) {
+	if usesSharedSecret() {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(config.GetConfig().JWT.Secret))
+	}
+
+	key := currentJWTKey()
+	if key == nil {
+		return "", errors.New("no active signing key")
+	}
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.kid
+	return token.SignedString(key.private)
+}
+
+// verificationKey 根据token头部的kid选择验签公钥
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if !usesSharedSecret() {
+			return nil, errors.New("unexpected signing method")
+		}
+		return []byte(config.GetConfig().JWT.Secret), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("missing key id")
+	}
+
+	key := lookupJWTKey(kid)
+	if key == nil && reloadJWTKeysOnMiss() {
+		// 其他实例可能刚完成轮换，重新加载后再查找
+		key = lookupJWTKey(kid)
+	}
+	if key == nil {
+		return nil, errors.New("unknown key id")
+	}
+
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.public, nil
+}
+
+// currentJWTKey 当前签名密钥
+func currentJWTKey() *jwtKey {
+	jwtKeys.mu.RLock()
+	defer jwtKeys.mu.RUnlock()
+	return jwtKeys.current
+}
+
+// lookupJWTKey 根据kid查找密钥
+func lookupJWTKey(kid string) *jwtKey {
+	jwtKeys.mu.RLock()
+	defer jwtKeys.mu.RUnlock()
+	return jwtKeys.keys[kid]
+}
+
+// reloadJWTKeysOnMiss 未知kid时重新加载密钥，间隔内最多加载一次，返回是否完成了加载
+func reloadJWTKeysOnMiss() bool {
+	jwtKeys.reloadMu.Lock()
+	defer jwtKeys.reloadMu.Unlock()
+
+	if time.Since(jwtKeys.lastReload) < jwtKeyReloadInterval {
+		return false
+	}
+	jwtKeys.lastReload = time.Now()
+	return loadJWTKeys() == nil
+}
+
+// loadJWTKeys 从密钥目录加载全部密钥，最新的与配置算法一致的密钥用于签名
+func loadJWTKeys() error {
+	dir := jwtKeysDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]*jwtKey, len(files))
+	for _, file := range files {
+		key, err := readJWTKey(file)
+		if err != nil {
+			logger.Warn("Failed to load jwt key", zap.String("file", file), zap.Error(err))
+			continue
+		}
+		keys[key.kid] = key
+	}
+
+	alg := jwtAlgorithm()
+	var current *jwtKey
+	for _, key := range sortedJWTKeys(keys) {
+		if key.method.Alg() == alg {
+			current = key
+		}
+	}
+
+	jwtKeys.mu.Lock()
+	jwtKeys.keys = keys
+	jwtKeys.current = current
+	jwtKeys.mu.Unlock()
+	return nil
+}
+
+// rotateJWTKey 生成新的签名密钥并设为当前密钥
+func rotateJWTKey() error {
+	var (
+		private interface{}
+		err     error
+	)
+	switch jwtAlgorithm() {
+	case JWTAlgEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	}
+	if err != nil {
+		return err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	kid := fmt.Sprintf("%s-%s", now.Format(jwtKeyTimeLayout), GenerateRandomString(8))
+	file := filepath.Join(jwtKeysDir(), kid+".pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(file, data, 0600); err != nil {
+		return err
+	}
+
+	logger.Info("JWT signing key rotated", zap.String("kid", kid), zap.String("alg", jwtAlgorithm()))
+	return loadJWTKeys()
+}
+
+// pruneJWTKeys 删除已被替换且其签发的token均已过期的旧密钥
+func pruneJWTKeys() {
+	jwtKeys.mu.RLock()
+	keys := sortedJWTKeys(jwtKeys.keys)
+	jwtKeys.mu.RUnlock()
+
+	retention := time.Duration(config.GetConfig().JWT.ExpireHours) * time.Hour
+	removed := false
+	for i := 0; i < len(keys)-1; i++ {
+		// 旧密钥自下一把密钥生成起停止签发，再保留一个token有效期用于验签
+		if time.Since(keys[i+1].createdAt) <= retention+time.Hour {
+			continue
+		}
+		file := filepath.Join(jwtKeysDir(), keys[i].kid+".pem")
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			logger.Warn("Failed to remove expired jwt key", zap.String("kid", keys[i].kid), zap.Error(err))
+			continue
+		}
+		removed = true
+	}
+
+	if removed {
+		loadJWTKeys()
+	}
+}
+
+// readJWTKey 读取PKCS8格式的私钥文件
+func readJWTKey(file string) (*jwtKey, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid pem data")
+	}
+
+	private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+	createdAt, err := time.ParseInLocation(jwtKeyTimeLayout, strings.SplitN(kid, "-", 2)[0], time.Local)
+	if err != nil {
+		return nil, errors.New("invalid key id")
+	}
+
+	key := &jwtKey{
+		kid:       kid,
+		private:   private,
+		createdAt: createdAt,
+	}
+	switch pk := private.(type) {
+	case *rsa.PrivateKey:
+		key.method = jwt.SigningMethodRS256
+		key.public = &pk.PublicKey
+	case ed25519.PrivateKey:
+		key.method = jwt.SigningMethodEdDSA
+		key.public = pk.Public()
+	default:
+		return nil, errors.New("unsupported key type")
+	}
+	return key, nil
+}
+
+// sortedJWTKeys 按生成时间升序排列密钥
+func sortedJWTKeys(keys map[string]*jwtKey) []*jwtKey {
+	list := make([]*jwtKey, 0, len(keys))
+	for _, key := range keys {
+		list = append(list, key)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].createdAt.Before(list[j].createdAt)
+	})
+	return list
+}
+
+// jwtKeysDir 密钥存储目录，多实例部署时需使用共享存储
+func jwtKeysDir() string {
+	dir := config.GetConfig().JWT.KeysDir
+	if dir == "" {
+		dir = "keys/jwt"
+	}
+	return dir
+}
+
+// jwtRotationInterval 密钥轮换周期
+func jwtRotationInterval() time.Duration {
+	hours := config.GetConfig().JWT.RotationHours
+	if hours <= 0 {
+		hours = 720
+	}
+	return time.Duration(hours) * time.Hour
+}
@@ -0,0 +1,240 @@
+package utils
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"mall/pkg/cache"
+	"mall/pkg/config"
+	"mall/pkg/logger"
+)
+
+// useMiniredis 使用内存Redis替换全局客户端
+func useMiniredis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	prev := cache.RDB
+	cache.RDB = redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() {
+		cache.RDB.Close()
+		cache.RDB = prev
+	})
+	return mr
+}
+
+// useJWTConfig 使用临时密钥目录和指定算法，并清空已加载的密钥
+func useJWTConfig(t *testing.T, alg string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	prev := config.GlobalConfig
+	cfg := &config.Config{}
+	cfg.JWT.Secret = "test-secret"
+	cfg.JWT.ExpireHours = 2
+	cfg.JWT.Algorithm = alg
+	cfg.JWT.KeysDir = dir
+	cfg.JWT.RotationHours = 24
+	config.GlobalConfig = cfg
+
+	prevKeys, prevLogger := jwtKeys, logger.Logger
+	jwtKeys = &jwtKeyStore{keys: make(map[string]*jwtKey)}
+	logger.Logger = zap.NewNop()
+	t.Cleanup(func() {
+		config.GlobalConfig = prev
+		jwtKeys = prevKeys
+		logger.Logger = prevLogger
+	})
+	return dir
+}
+
+// writeJWTKey 在密钥目录写入指定生成时间的密钥，返回kid
+func writeJWTKey(t *testing.T, dir, alg string, createdAt time.Time) string {
+	t.Helper()
+
+	var (
+		private interface{}
+		err     error
+	)
+	if alg == JWTAlgEdDSA {
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	} else {
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	}
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+
+	kid := fmt.Sprintf("%s-%s", createdAt.Format(jwtKeyTimeLayout), GenerateRandomString(8))
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0600); err != nil {
+		t.Fatalf("write key: %v", err)
+	}
+	return kid
+}
+
+// tokenKid 读取token头部的kid
+func tokenKid(t *testing.T, tokenString string) string {
+	t.Helper()
+
+	token, _, err := jwt.NewParser().ParseUnverified(tokenString, &Claims{})
+	if err != nil {
+		t.Fatalf("parse token: %v", err)
+	}
+	kid, _ := token.Header["kid"].(string)
+	return kid
+}
+
+func TestJWTKeyRotation(t *testing.T) {
+	for _, tc := range []struct {
+		alg string
+		kty string
+	}{
+		{JWTAlgRS256, "RSA"},
+		{JWTAlgEdDSA, "OKP"},
+	} {
+		t.Run(tc.alg, func(t *testing.T) {
+			useMiniredis(t)
+			dir := useJWTConfig(t, tc.alg)
+			oldKid := writeJWTKey(t, dir, tc.alg, time.Now().Add(-30*time.Hour))
+
+			if err := InitJWTKeys(); err != nil {
+				t.Fatalf("InitJWTKeys: %v", err)
+			}
+			oldToken, err := GenerateToken(1, "alice", "admin", "admin")
+			if err != nil {
+				t.Fatalf("GenerateToken: %v", err)
+			}
+			if kid := tokenKid(t, oldToken); kid != oldKid {
+				t.Fatalf("token kid = %s, want %s", kid, oldKid)
+			}
+
+			// 当前密钥已超过轮换周期，轮换后新token使用新密钥，旧token仍可验签
+			if err := RotateJWTKeysIfDue(); err != nil {
+				t.Fatalf("RotateJWTKeysIfDue: %v", err)
+			}
+			newToken, err := GenerateToken(1, "alice", "admin", "admin")
+			if err != nil {
+				t.Fatalf("GenerateToken: %v", err)
+			}
+			newKid := tokenKid(t, newToken)
+			if newKid == oldKid || newKid == "" {
+				t.Fatalf("token still signed with kid %s", newKid)
+			}
+			for _, token := range []string{oldToken, newToken} {
+				if _, err := ParseToken(token); err != nil {
+					t.Errorf("ParseToken: %v", err)
+				}
+			}
+
+			jwks := GetJWKS()
+			if len(jwks.Keys) != 2 {
+				t.Fatalf("jwks has %d keys, want 2", len(jwks.Keys))
+			}
+			for _, key := range jwks.Keys {
+				if key.Kty != tc.kty || key.Alg != tc.alg || key.Use != "sig" {
+					t.Errorf("jwk = %+v", key)
+				}
+			}
+		})
+	}
+}
+
+func TestPruneJWTKeys(t *testing.T) {
+	useMiniredis(t)
+	dir := useJWTConfig(t, JWTAlgEdDSA)
+	now := time.Now()
+	expired := writeJWTKey(t, dir, JWTAlgEdDSA, now.Add(-10*time.Hour))
+	retired := writeJWTKey(t, dir, JWTAlgEdDSA, now.Add(-5*time.Hour))
+	current := writeJWTKey(t, dir, JWTAlgEdDSA, now.Add(-2*time.Hour))
+
+	// token有效期2小时：被替换超过3小时的密钥删除，刚被替换的密钥保留用于验签
+	if err := RotateJWTKeysIfDue(); err != nil {
+		t.Fatalf("RotateJWTKeysIfDue: %v", err)
+	}
+	if lookupJWTKey(expired) != nil {
+		t.Error("expired key should be pruned")
+	}
+	if lookupJWTKey(retired) == nil || lookupJWTKey(current) == nil {
+		t.Error("retired and current keys should be kept")
+	}
+	if currentJWTKey().kid != current {
+		t.Errorf("current kid = %s, want %s", currentJWTKey().kid, current)
+	}
+}
+
+func TestParseTokenRejectsUnknownKeys(t *testing.T) {
+	useMiniredis(t)
+	useJWTConfig(t, JWTAlgHS256)
+	hsToken, err := GenerateToken(1, "alice", "admin", "admin")
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	dir := useJWTConfig(t, JWTAlgEdDSA)
+	writeJWTKey(t, dir, JWTAlgEdDSA, time.Now())
+	if err := InitJWTKeys(); err != nil {
+		t.Fatalf("InitJWTKeys: %v", err)
+	}
+	if _, err := ParseToken(hsToken); err == nil {
+		t.Error("HS256 token should be rejected once asymmetric signing is enabled")
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, &Claims{UserID: 1, Role: "admin"})
+	token.Header["kid"] = "20000101000000-unknown"
+	_, private, _ := ed25519.GenerateKey(rand.Reader)
+	forged, err := token.SignedString(private)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if _, err := ParseToken(forged); err == nil {
+		t.Error("token with an unknown kid should be rejected")
+	}
+}
+
+func TestIsTokenRevoked(t *testing.T) {
+	useMiniredis(t)
+	revokedAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+	if err := cache.Set(context.Background(), fmt.Sprintf(tokenRevokedBeforeKey, 1), revokedAt.Unix(), time.Hour); err != nil {
+		t.Fatalf("set revoke time: %v", err)
+	}
+
+	cases := []struct {
+		name     string
+		userID   int64
+		role     string
+		issuedAt *jwt.NumericDate
+		want     bool
+	}{
+		{"issued before revoke", 1, "user", jwt.NewNumericDate(revokedAt.Add(-time.Second)), true},
+		{"issued in the same second", 1, "user", jwt.NewNumericDate(revokedAt.Add(500 * time.Millisecond)), true},
+		{"issued after revoke", 1, "user", jwt.NewNumericDate(revokedAt.Add(time.Second)), false},
+		{"missing issued at", 1, "user", nil, true},
+		{"other user", 2, "user", jwt.NewNumericDate(revokedAt.Add(-time.Second)), false},
+		{"admin token", 1, "admin", jwt.NewNumericDate(revokedAt.Add(-time.Second)), false},
+	}
+	for _, tc := range cases {
+		claims := &Claims{UserID: tc.userID, Role: tc.role, RegisteredClaims: jwt.RegisteredClaims{IssuedAt: tc.issuedAt}}
+		if got := IsTokenRevoked(claims); got != tc.want {
+			t.Errorf("%s: IsTokenRevoked = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
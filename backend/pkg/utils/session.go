@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"mall/pkg/cache"
+)
+
+// sessionCacheKey 活跃会话缓存
+const sessionCacheKey = "session:%s"
+
+// StoreSession 缓存活跃会话，过期时间与token一致
+func StoreSession(sessionID string, userID uint64, expiration time.Duration) error {
+	ctx := context.Background()
+	key := fmt.Sprintf(sessionCacheKey, sessionID)
+	if err := cache.HSet(ctx, key, "user_id", userID, "last_seen", time.Now().Unix()); err != nil {
+		return err
+	}
+	return cache.Expire(ctx, key, expiration)
+}
+
+// ValidateSession 校验token绑定的会话是否仍然有效，有效时同时刷新最近活跃时间
+func ValidateSession(claims *Claims) bool {
+	// 管理员token不绑定会话，不做会话校验
+	if claims.Role != "user" {
+		return true
+	}
+	// 用户token必须绑定会话
+	if claims.ID == "" {
+		return false
+	}
+
+	ok, err := cache.TouchSession(context.Background(), fmt.Sprintf(sessionCacheKey, claims.ID), claims.UserID, time.Now())
+	return err == nil && ok
+}
+
+// GetSessionLastSeen 获取会话最近活跃时间
+func GetSessionLastSeen(sessionID string) (time.Time, bool) {
+	val, err := cache.HGet(context.Background(), fmt.Sprintf(sessionCacheKey, sessionID), "last_seen")
+	if err != nil {
+		return time.Time{}, false
+	}
+	ts, err := strconv.ParseInt(val, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(ts, 0), true
+}
+
+// RemoveSessions 删除会话缓存
+func RemoveSessions(sessionIDs ...string) error {
+	if len(sessionIDs) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(sessionIDs))
+	for _, id := range sessionIDs {
+		keys = append(keys, fmt.Sprintf(sessionCacheKey, id))
+	}
+	return cache.Del(context.Background(), keys...)
+}
@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// SignRequest 计算请求签名
+// 待签名串为 METHOD\nPATH\nSHA256(body)\nTIMESTAMP\nNONCE，使用应用密钥做HMAC-SHA256后取十六进制
+func SignRequest(secret, method, path string, body []byte, timestamp, nonce string) string {
+	bodyHash := sha256.Sum256(body)
+	payload := strings.Join([]string{
+		strings.ToUpper(method),
+		path,
+		hex.EncodeToString(bodyHash[:]),
+		timestamp,
+		nonce,
+	}, "\n")
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyRequestSignature 常量时间比较请求签名
+func VerifyRequestSignature(secret, method, path string, body []byte, timestamp, nonce, signature string) bool {
+	expected := SignRequest(secret, method, path, body, timestamp, nonce)
+	return hmac.Equal([]byte(expected), []byte(strings.ToLower(signature)))
+}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestSignRequestPayload(t *testing.T) {
+	body := []byte(`{"sku_id":1}`)
+	bodyHash := sha256.Sum256(body)
+	payload := "POST\n/api/v1/orders?from=app\n" + hex.EncodeToString(bodyHash[:]) + "\n1700000000\nnonce-123"
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(payload))
+	want := hex.EncodeToString(mac.Sum(nil))
+
+	// 方法名不区分大小写
+	got := SignRequest("secret", "post", "/api/v1/orders?from=app", body, "1700000000", "nonce-123")
+	if got != want {
+		t.Errorf("SignRequest = %s, want %s", got, want)
+	}
+}
+
+func TestVerifyRequestSignature(t *testing.T) {
+	body := []byte(`{"sku_id":1}`)
+	signature := SignRequest("secret", "POST", "/api/v1/orders", body, "1700000000", "nonce-123")
+
+	if !VerifyRequestSignature("secret", "POST", "/api/v1/orders", body, "1700000000", "nonce-123", signature) {
+		t.Error("valid signature should be accepted")
+	}
+	if !VerifyRequestSignature("secret", "POST", "/api/v1/orders", body, "1700000000", "nonce-123", strings.ToUpper(signature)) {
+		t.Error("uppercase hex signature should be accepted")
+	}
+
+	cases := map[string][]string{
+		"secret":    {"other", "POST", "/api/v1/orders", `{"sku_id":1}`, "1700000000", "nonce-123"},
+		"method":    {"secret", "PUT", "/api/v1/orders", `{"sku_id":1}`, "1700000000", "nonce-123"},
+		"path":      {"secret", "POST", "/api/v1/orders?x=1", `{"sku_id":1}`, "1700000000", "nonce-123"},
+		"body":      {"secret", "POST", "/api/v1/orders", `{"sku_id":2}`, "1700000000", "nonce-123"},
+		"timestamp": {"secret", "POST", "/api/v1/orders", `{"sku_id":1}`, "1700000001", "nonce-123"},
+		"nonce":     {"secret", "POST", "/api/v1/orders", `{"sku_id":1}`, "1700000000", "nonce-124"},
+	}
+	for field, args := range cases {
+		if VerifyRequestSignature(args[0], args[1], args[2], []byte(args[3]), args[4], args[5], signature) {
+			t.Errorf("signature should be rejected when %s changes", field)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 6238 附录B的SHA1测试密钥 "12345678901234567890"
+const rfcTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateTOTPRFCVectors(t *testing.T) {
+	// RFC给出的是8位口令，6位口令取其后6位
+	cases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tc := range cases {
+		at := time.Unix(tc.unix, 0)
+		step, ok := ValidateTOTP(rfcTOTPSecret, tc.code, at)
+		if !ok {
+			t.Errorf("ValidateTOTP(%d, %s) = false, want true", tc.unix, tc.code)
+			continue
+		}
+		if want := tc.unix / TOTPPeriod; step != want {
+			t.Errorf("ValidateTOTP(%d) step = %d, want %d", tc.unix, step, want)
+		}
+	}
+}
+
+func TestValidateTOTPSkew(t *testing.T) {
+	at := time.Unix(1111111109, 0)
+	code := "081804"
+
+	if _, ok := ValidateTOTP(rfcTOTPSecret, code, at.Add(TOTPPeriod*time.Second)); !ok {
+		t.Error("code from the previous step should be accepted")
+	}
+	if _, ok := ValidateTOTP(rfcTOTPSecret, code, at.Add(-TOTPPeriod*time.Second)); !ok {
+		t.Error("code from the next step should be accepted")
+	}
+	if _, ok := ValidateTOTP(rfcTOTPSecret, code, at.Add(2*TOTPPeriod*time.Second)); ok {
+		t.Error("code two steps old should be rejected")
+	}
+}
+
+func TestValidateTOTPRejectsMalformedInput(t *testing.T) {
+	at := time.Unix(1111111109, 0)
+
+	cases := map[string]struct {
+		secret string
+		code   string
+	}{
+		"short code":     {rfcTOTPSecret, "08180"},
+		"long code":      {rfcTOTPSecret, "0818040"},
+		"wrong code":     {rfcTOTPSecret, "081805"},
+		"invalid secret": {"not-base32!", "081804"},
+		"empty secret":   {"", "081804"},
+	}
+	for name, tc := range cases {
+		if _, ok := ValidateTOTP(tc.secret, tc.code, at); ok {
+			t.Errorf("%s: ValidateTOTP should fail", name)
+		}
+	}
+
+	// 密钥大小写与口令首尾空格不影响校验
+	if _, ok := ValidateTOTP(strings.ToLower(rfcTOTPSecret), " 081804 ", at); !ok {
+		t.Error("lowercase secret and padded code should be accepted")
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret := GenerateTOTPSecret()
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("secret is not valid base32: %v", err)
+	}
+	if len(key) != 20 {
+		t.Errorf("secret length = %d bytes, want 20", len(key))
+	}
+	if secret == GenerateTOTPSecret() {
+		t.Error("secrets should be random")
+	}
+
+	now := time.Now()
+	code := totpCode(key, now.Unix()/TOTPPeriod)
+	if _, ok := ValidateTOTP(secret, code, now); !ok {
+		t.Error("code generated from the secret should be accepted")
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := TOTPProvisioningURI("Mall Admin", "alice", "ABC")
+	if !strings.HasPrefix(uri, "otpauth://totp/Mall%20Admin:alice?") {
+		t.Errorf("unexpected uri prefix: %s", uri)
+	}
+	for _, param := range []string{"secret=ABC", "issuer=Mall+Admin", "digits=6", "period=30", "algorithm=SHA1"} {
+		if !strings.Contains(uri, param) {
+			t.Errorf("uri %s missing %s", uri, param)
+		}
+	}
+}
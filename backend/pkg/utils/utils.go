@@ -42,6 +42,14 @@ func GeneratePaymentNo() string {
 	return fmt.Sprintf("PAY%s%s", timestamp, random)
 }
 
+// GenerateRefundNo 生成退款单号
+func GenerateRefundNo() string {
+	now := time.Now()
+	timestamp := now.Format("20060102150405")
+	random := GenerateRandomString(6)
+	return fmt.Sprintf("REF%s%s", timestamp, random)
+}
+
 // HashPassword 密码加密
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)